@@ -0,0 +1,16 @@
+# Reboot a domain and wait until it reports an IP address again
+action "libvirt_domain_reboot" "web" {
+  config {
+    domain = libvirt_domain.web.uuid
+
+    wait = {
+      state   = "running"
+      timeout = 300
+      ip = {
+        source = "lease"
+      }
+    }
+  }
+}
+
+# Invoke with: terraform apply -invoke=action.libvirt_domain_reboot.web
@@ -0,0 +1,17 @@
+# Look up a domain that is not managed by Terraform
+data "libvirt_domain" "appliance" {
+  name = "firewall-appliance"
+}
+
+output "appliance_uuid" {
+  value = data.libvirt_domain.appliance.uuid
+}
+
+output "appliance_running" {
+  value = data.libvirt_domain.appliance.running
+}
+
+# MAC addresses of the appliance interfaces
+output "appliance_macs" {
+  value = [for iface in data.libvirt_domain.appliance.devices.interfaces : iface.mac.address]
+}
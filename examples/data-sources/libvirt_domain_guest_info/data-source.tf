@@ -0,0 +1,23 @@
+# Requires qemu-guest-agent running in the guest
+data "libvirt_domain_guest_info" "vm" {
+  domain = libvirt_domain.vm.id
+}
+
+output "guest_os" {
+  value = data.libvirt_domain_guest_info.vm.os.pretty_name
+}
+
+# Fail the run if the image booted something unexpected
+check "guest_os" {
+  assert {
+    condition     = data.libvirt_domain_guest_info.vm.os.id == "fedora"
+    error_message = "Expected a Fedora guest, got ${data.libvirt_domain_guest_info.vm.os.pretty_name}."
+  }
+}
+
+output "root_filesystem_usage" {
+  value = [
+    for fs in data.libvirt_domain_guest_info.vm.filesystems :
+    fs.used_bytes / fs.total_bytes if fs.mountpoint == "/"
+  ]
+}
@@ -0,0 +1,17 @@
+# Wait until the guest reports a routable IPv4 address through the guest agent
+data "libvirt_domain_interface_addresses" "example" {
+  domain = libvirt_domain.example.id
+  source = "agent"
+
+  address_family     = "ipv4"
+  exclude_link_local = true
+  exclude_loopback   = true
+
+  wait = {
+    timeout = 300 # seconds
+  }
+}
+
+output "ip" {
+  value = data.libvirt_domain_interface_addresses.example.interfaces[*].addrs[*].addr
+}
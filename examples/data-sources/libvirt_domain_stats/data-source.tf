@@ -0,0 +1,33 @@
+data "libvirt_domain_stats" "vm" {
+  domain = libvirt_domain.vm.name
+}
+
+locals {
+  vm_stats = data.libvirt_domain_stats.vm.domains[0]
+}
+
+# Warn before the guest runs out of memory or disk space
+check "vm_resources" {
+  assert {
+    condition     = try(local.vm_stats.balloon.usable > 262144, true)
+    error_message = "Less than 256 MiB of usable memory left in ${local.vm_stats.name}."
+  }
+
+  assert {
+    condition = alltrue([
+      for block in local.vm_stats.blocks :
+      try(block.allocation < block.capacity * 0.9, true)
+    ])
+    error_message = "A disk of ${local.vm_stats.name} is more than 90% allocated."
+  }
+}
+
+# Statistics for every domain on the host
+data "libvirt_domain_stats" "all" {}
+
+output "cpu_time_seconds" {
+  value = {
+    for vm in data.libvirt_domain_stats.all.domains :
+    vm.name => vm.cpu == null ? 0 : vm.cpu.time / 1e9
+  }
+}
@@ -0,0 +1,19 @@
+# All running web servers, tagged through their domain metadata:
+#   <metadata><app:role xmlns:app="https://example.com/app">web</app:role></metadata>
+data "libvirt_domains" "web" {
+  states     = ["running"]
+  name_regex = "^web-"
+
+  metadata_tags = {
+    role = "web"
+  }
+}
+
+# Ansible inventory in YAML format
+output "ansible_inventory" {
+  value = yamlencode({
+    web = {
+      hosts = { for d in data.libvirt_domains.web.domains : d.name => {} }
+    }
+  })
+}
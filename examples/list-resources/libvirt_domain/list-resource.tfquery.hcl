@@ -0,0 +1,8 @@
+list "libvirt_domain" "running_web" {
+  provider = libvirt
+
+  config {
+    name_prefix = "web-"
+    state       = "running"
+  }
+}
@@ -0,0 +1,5 @@
+# Import by UUID
+terraform import libvirt_domain.example 8f1c7e0a-54b4-4a4f-9a52-3c1d2b7e9f01
+
+# Import by name
+terraform import libvirt_domain.example my-vm
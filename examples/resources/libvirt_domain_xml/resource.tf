@@ -0,0 +1,11 @@
+# Domain managed from an existing XML definition, e.g. one used with virsh define
+resource "libvirt_domain_xml" "example" {
+  xml     = file("${path.module}/example-vm.xml")
+  running = true
+
+  destroy = {
+    shutdown = {
+      timeout = 60
+    }
+  }
+}
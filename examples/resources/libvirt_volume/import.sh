@@ -0,0 +1,5 @@
+# Import by key or absolute path
+terraform import libvirt_volume.example /var/lib/libvirt/images/disk.qcow2
+
+# Import by <pool>/<volume>
+terraform import libvirt_volume.example default/disk.qcow2
@@ -96,6 +96,10 @@ type FieldIR struct {
 	// IsXMLAttr indicates if this is an XML attribute vs element
 	IsXMLAttr bool
 
+	// IsInline indicates the field has no XML element of its own (xml:"-"), such as the
+	// members of a union like DomainDiskSource.File whose attributes live on <source>
+	IsInline bool
+
 	// IsPointer indicates if the Go type is a pointer
 	IsPointer bool
 
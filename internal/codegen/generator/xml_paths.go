@@ -0,0 +1,137 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"text/template"
+)
+
+// XMLPathResource describes the XML path table generated for one top-level resource.
+type XMLPathResource struct {
+	// Name is the Go name of the resource (e.g., "Domain", "StoragePool")
+	Name string
+
+	// XMLRoot is the name of the document element (e.g., "domain", "pool")
+	XMLRoot string
+
+	// Entries are the fields reachable from the resource, in traversal order
+	Entries []XMLPathEntry
+}
+
+// XMLPathEntry maps the libvirt XML path of a field to its Terraform path.
+type XMLPathEntry struct {
+	// XMLPath is the XML path of the field (e.g., "domain.devices.disk.target.@bus")
+	XMLPath string
+
+	// TFPath is the Terraform attribute path below the resource (e.g., "devices.disks.target.bus")
+	TFPath string
+
+	// IsList indicates the Terraform attribute is a list indexed by XML sibling position
+	IsList bool
+}
+
+// AssignPaths walks the fields reachable from root and records their Terraform and XML
+// paths. Structs shared between several locations keep the TFPath/XMLPath of the first
+// location reached; the returned entries contain every location. Inline fields (union
+// members) add a Terraform attribute but no XML element, so their children are reported
+// under the parent element.
+func AssignPaths(root *StructIR, xmlRoot string) []XMLPathEntry {
+	var entries []XMLPathEntry
+	seen := make(map[string]bool)
+
+	var walk func(s *StructIR, tfPath, xmlPath string)
+	walk = func(s *StructIR, tfPath, xmlPath string) {
+		for _, field := range s.Fields {
+			if field.IsExcluded || field.IsCycle {
+				continue
+			}
+
+			fieldTFPath := field.TFName
+			if tfPath != "" {
+				fieldTFPath = tfPath + "." + field.TFName
+			}
+			fieldXMLPath := fieldXMLPath(xmlPath, field)
+
+			if field.TFPath == "" {
+				field.TFPath = fieldTFPath
+				field.XMLPath = fieldXMLPath
+			}
+
+			// The first union member wins when several produce the same XML path
+			if !field.IsInline && !seen[fieldXMLPath] {
+				seen[fieldXMLPath] = true
+				entries = append(entries, XMLPathEntry{
+					XMLPath: fieldXMLPath,
+					TFPath:  fieldTFPath,
+					IsList:  field.IsList,
+				})
+			}
+
+			if field.IsNested && field.NestedStruct != nil {
+				walk(field.NestedStruct, fieldTFPath, fieldXMLPath)
+			}
+		}
+	}
+
+	walk(root, "", xmlRoot)
+	return entries
+}
+
+// fieldXMLPath returns the XML path of field below the element at parent.
+func fieldXMLPath(parent string, field *FieldIR) string {
+	if field.IsInline {
+		return parent
+	}
+
+	name := field.XMLName
+	if name == "" {
+		name = field.TFName
+	}
+
+	switch {
+	case field.IsFlattenedAttr:
+		return parent + "." + name + ".@" + field.FlattenedAttrName
+	case field.IsXMLAttr:
+		return parent + ".@" + name
+	default:
+		return parent + "." + name
+	}
+}
+
+// XMLPathsGenerator generates the XML path lookup tables.
+type XMLPathsGenerator struct {
+	template *template.Template
+}
+
+// NewXMLPathsGenerator creates a new XML paths generator.
+func NewXMLPathsGenerator(templatePath string) (*XMLPathsGenerator, error) {
+	tmpl, err := template.ParseFiles(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("parsing template: %w", err)
+	}
+
+	return &XMLPathsGenerator{
+		template: tmpl,
+	}, nil
+}
+
+// Generate generates the XML path tables for the given resources.
+func (g *XMLPathsGenerator) Generate(resources []XMLPathResource) (string, error) {
+	var buf bytes.Buffer
+
+	data := map[string]interface{}{
+		"Resources": resources,
+	}
+
+	if err := g.template.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("executing template: %w", err)
+	}
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("formatting generated code: %w", err)
+	}
+
+	return string(formatted), nil
+}
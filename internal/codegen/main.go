@@ -119,6 +119,20 @@ func run() error {
 		}
 	}
 
+	// Generate the XML path tables used to map XML errors back to Terraform attributes
+	xmlPathResources := make([]generator.XMLPathResource, 0, len(resourceIRs))
+	for _, res := range resourceIRs {
+		xmlRoot := xmlRootName(res.Name)
+		xmlPathResources = append(xmlPathResources, generator.XMLPathResource{
+			Name:    res.Root.Name,
+			XMLRoot: xmlRoot,
+			Entries: generator.AssignPaths(res.Root, xmlRoot),
+		})
+	}
+	if err := generateXMLPaths(xmlPathResources, outputDir); err != nil {
+		return fmt.Errorf("generating XML paths: %w", err)
+	}
+
 	fmt.Printf("Generated %d structs (%d top-level, %d total fields) in %s/\n", len(structs), topLevelCount, totalFields, outputDir)
 
 	return nil
@@ -184,6 +198,38 @@ func generateConvert(structs []*generator.StructIR, outputDir, resourceName stri
 	return nil
 }
 
+func generateXMLPaths(resources []generator.XMLPathResource, outputDir string) error {
+	templatePath := "internal/codegen/templates/xml_paths.go.tmpl"
+	gen, err := generator.NewXMLPathsGenerator(templatePath)
+	if err != nil {
+		return err
+	}
+
+	code, err := gen.Generate(resources)
+	if err != nil {
+		return err
+	}
+
+	outputPath := filepath.Join(outputDir, "xml_paths.gen.go")
+	if err := os.WriteFile(outputPath, []byte(code), 0644); err != nil {
+		return fmt.Errorf("writing XML paths file: %w", err)
+	}
+
+	return nil
+}
+
+// xmlRootName returns the document element name of a top-level resource.
+func xmlRootName(resourceName string) string {
+	switch resourceName {
+	case "storage_pool":
+		return "pool"
+	case "storage_volume":
+		return "volume"
+	default:
+		return resourceName
+	}
+}
+
 // collectAllStructs recursively collects all structs including nested ones.
 func collectAllStructs(root *generator.StructIR) []*generator.StructIR {
 	result := []*generator.StructIR{}
@@ -212,6 +212,7 @@ func (r *LibvirtXMLReflector) analyzeField(structName string, field reflect.Stru
 	fieldIR.XMLName = xmlElementName(parts[0])
 	if isDashTag {
 		fieldIR.XMLName = field.Name
+		fieldIR.IsInline = true
 	}
 
 	// Check for attributes and options
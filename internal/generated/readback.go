@@ -0,0 +1,130 @@
+package generated
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ReadbackPath is an attribute path whose value the FromXML conversions keep from the
+// plan instead of reading it from XML, such as "numa_tune" or "devices.disks[*].io_tune".
+type ReadbackPath []readbackStep
+
+// readbackStep is either an attribute name or, with an empty name, a list index. An index
+// of -1 matches every element.
+type readbackStep struct {
+	name  string
+	index int
+}
+
+var readbackSegment = regexp.MustCompile(`^([a-z0-9_]+)(?:\[(\*|\d+)\])?$`)
+
+// ParseReadbackPath parses a dot separated attribute path. List elements are selected with
+// [n] or [*]; a list attribute without an index applies to all of its elements.
+func ParseReadbackPath(s string) (ReadbackPath, error) {
+	if s == "" {
+		return nil, fmt.Errorf("path is empty")
+	}
+
+	segments := strings.Split(s, ".")
+	var p ReadbackPath
+	for i, segment := range segments {
+		m := readbackSegment.FindStringSubmatch(segment)
+		if m == nil {
+			return nil, fmt.Errorf("invalid path segment %q: expected an attribute name, optionally followed by [n] or [*]", segment)
+		}
+		p = append(p, readbackStep{name: m[1]})
+
+		switch m[2] {
+		case "":
+		case "*":
+			// A trailing [*] selects the whole list
+			if i < len(segments)-1 {
+				p = append(p, readbackStep{index: -1})
+			}
+		default:
+			if i == len(segments)-1 {
+				return nil, fmt.Errorf("path %q ends in a list index: select an attribute of the element instead", s)
+			}
+			index, err := strconv.Atoi(m[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid list index in %q: %w", segment, err)
+			}
+			p = append(p, readbackStep{index: index})
+		}
+	}
+	return p, nil
+}
+
+// Names returns the attribute names of the path, without list indexes.
+func (p ReadbackPath) Names() []string {
+	var names []string
+	for _, step := range p {
+		if step.name != "" {
+			names = append(names, step.name)
+		}
+	}
+	return names
+}
+
+type readbackKey struct{}
+
+// WithReadbackIgnore returns a context in which the FromXML conversions keep the planned
+// value of the given paths, relative to the top-level model, instead of the value in XML.
+// Values are only kept when a plan is passed to the conversion.
+func WithReadbackIgnore(ctx context.Context, paths []ReadbackPath) context.Context {
+	if len(paths) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, readbackKey{}, paths)
+}
+
+// readbackChild returns the context for converting the nested attribute name. Only the
+// paths continuing below name are kept.
+func readbackChild(ctx context.Context, name string) context.Context {
+	paths, _ := ctx.Value(readbackKey{}).([]ReadbackPath)
+	if len(paths) == 0 {
+		return ctx
+	}
+
+	var remaining []ReadbackPath
+	for _, p := range paths {
+		if len(p) > 1 && p[0].name == name {
+			remaining = append(remaining, p[1:])
+		}
+	}
+	return context.WithValue(ctx, readbackKey{}, remaining)
+}
+
+// readbackElement returns the context for converting element index of a list. Paths
+// without an index at this point apply to every element.
+func readbackElement(ctx context.Context, index int) context.Context {
+	paths, _ := ctx.Value(readbackKey{}).([]ReadbackPath)
+	if len(paths) == 0 {
+		return ctx
+	}
+
+	var remaining []ReadbackPath
+	for _, p := range paths {
+		switch {
+		case p[0].name != "":
+			remaining = append(remaining, p)
+		case p[0].index == -1 || p[0].index == index:
+			remaining = append(remaining, p[1:])
+		}
+	}
+	return context.WithValue(ctx, readbackKey{}, remaining)
+}
+
+// readbackIgnored reports whether the attribute name is one of the ignored paths.
+func readbackIgnored(ctx context.Context, name string) bool {
+	paths, _ := ctx.Value(readbackKey{}).([]ReadbackPath)
+	for _, p := range paths {
+		if len(p) == 1 && p[0].name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package generated
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"libvirt.org/go/libvirtxml"
+)
+
+func TestParseReadbackPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		expect  ReadbackPath
+		wantErr bool
+	}{
+		{path: "numa_tune", expect: ReadbackPath{{name: "numa_tune"}}},
+		{path: "devices.disks[*].io_tune", expect: ReadbackPath{{name: "devices"}, {name: "disks"}, {index: -1}, {name: "io_tune"}}},
+		{path: "devices.disks[2].io_tune", expect: ReadbackPath{{name: "devices"}, {name: "disks"}, {index: 2}, {name: "io_tune"}}},
+		{path: "devices.disks[*]", expect: ReadbackPath{{name: "devices"}, {name: "disks"}}},
+		{path: "devices.disks[0]", wantErr: true},
+		{path: "devices..disks", wantErr: true},
+		{path: "Devices", wantErr: true},
+		{path: "", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, err := ParseReadbackPath(tc.path)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%q: expected an error", tc.path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.expect) {
+			t.Errorf("%q: expected %+v, got %+v", tc.path, tc.expect, got)
+		}
+	}
+}
+
+func readbackTestDomain(title, description string, iotune ...uint64) *libvirtxml.Domain {
+	domain := &libvirtxml.Domain{
+		Type:        "kvm",
+		Name:        "test-readback",
+		Title:       title,
+		Description: description,
+		Devices:     &libvirtxml.DomainDeviceList{},
+	}
+	for i, bytes := range iotune {
+		domain.Devices.Disks = append(domain.Devices.Disks, libvirtxml.DomainDisk{
+			Device: "disk",
+			Target: &libvirtxml.DomainDiskTarget{Dev: "vd" + string(rune('a'+i)), Bus: "virtio"},
+			IOTune: &libvirtxml.DomainDiskIOTune{TotalBytesSec: bytes},
+		})
+	}
+	return domain
+}
+
+func TestDomainFromXMLReadbackIgnore(t *testing.T) {
+	tests := []struct {
+		name              string
+		paths             []string
+		expectDescription string
+		expectIOTune      []int64
+	}{
+		{name: "nothing ignored", expectDescription: "changed", expectIOTune: []int64{5000, 6000}},
+		{name: "scalar", paths: []string{"description"}, expectDescription: "planned", expectIOTune: []int64{5000, 6000}},
+		{name: "all list elements", paths: []string{"devices.disks.io_tune"}, expectDescription: "changed", expectIOTune: []int64{1000, 2000}},
+		{name: "one list element", paths: []string{"devices.disks[1].io_tune"}, expectDescription: "changed", expectIOTune: []int64{5000, 2000}},
+		{name: "whole list", paths: []string{"devices.disks[*]"}, expectDescription: "changed", expectIOTune: []int64{1000, 2000}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			plan, err := DomainFromXML(ctx, readbackTestDomain("planned", "planned", 1000, 2000), nil)
+			if err != nil {
+				t.Fatalf("DomainFromXML failed: %v", err)
+			}
+
+			var paths []ReadbackPath
+			for _, s := range tc.paths {
+				p, err := ParseReadbackPath(s)
+				if err != nil {
+					t.Fatal(err)
+				}
+				paths = append(paths, p)
+			}
+
+			model, err := DomainFromXML(WithReadbackIgnore(ctx, paths), readbackTestDomain("changed", "changed", 5000, 6000), plan)
+			if err != nil {
+				t.Fatalf("DomainFromXML failed: %v", err)
+			}
+
+			if model.Title.ValueString() != "changed" {
+				t.Errorf("expected title to be refreshed, got %v", model.Title)
+			}
+			if model.Description.ValueString() != tc.expectDescription {
+				t.Errorf("expected description %q, got %v", tc.expectDescription, model.Description)
+			}
+
+			var devices DomainDeviceListModel
+			if diags := model.Devices.As(ctx, &devices, basetypes.ObjectAsOptions{}); diags.HasError() {
+				t.Fatalf("failed to read devices: %v", diags)
+			}
+			var disks []DomainDiskModel
+			if diags := devices.Disks.ElementsAs(ctx, &disks, false); diags.HasError() {
+				t.Fatalf("failed to read disks: %v", diags)
+			}
+			if len(disks) != len(tc.expectIOTune) {
+				t.Fatalf("expected %d disks, got %d", len(tc.expectIOTune), len(disks))
+			}
+			for i, disk := range disks {
+				var iotune DomainDiskIOTuneModel
+				if diags := disk.IOTune.As(ctx, &iotune, basetypes.ObjectAsOptions{}); diags.HasError() {
+					t.Fatalf("failed to read io_tune: %v", diags)
+				}
+				if iotune.TotalBytesSec.ValueInt64() != tc.expectIOTune[i] {
+					t.Errorf("disk %d: expected total_bytes_sec %d, got %v", i, tc.expectIOTune[i], iotune.TotalBytesSec)
+				}
+			}
+		})
+	}
+}
@@ -6,11 +6,16 @@ import (
 	"encoding/hex"
 	"fmt"
 	"net/url"
+	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/digitalocean/go-libvirt"
 	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt/dialers"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/osinfo"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/relaxng"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"libvirt.org/go/libvirtxml"
 )
 
 // Client wraps the libvirt connection and provides helper methods
@@ -18,8 +23,22 @@ type Client struct {
 	conn       *libvirt.Libvirt
 	uri        string
 	libVersion uint64
+
+	domainCapsMu sync.Mutex
+	domainCaps   map[string]*libvirtxml.DomainCaps
+
+	schemaDir string
+	schemasMu sync.Mutex
+	schemas   map[string]*relaxng.Schema
+
+	osinfoMu   sync.Mutex
+	osinfoDirs []string
+	osinfoDB   *osinfo.DB
 }
 
+// DefaultSchemaDir is where libvirt installs the RelaxNG schemas of its XML formats.
+const DefaultSchemaDir = "/usr/share/libvirt/schemas"
+
 // NewClient creates a new libvirt client from a connection URI
 func NewClient(ctx context.Context, uri string) (*Client, error) {
 	parsedURI, err := url.Parse(uri)
@@ -113,6 +132,127 @@ func (c *Client) Ping(ctx context.Context) error {
 	return nil
 }
 
+// DomainCapabilities returns the domain capabilities for an architecture, machine type and
+// virtualization type, any of which may be empty to use the hypervisor default. Results are
+// cached for the lifetime of the client.
+func (c *Client) DomainCapabilities(arch, machine, virtType string) (*libvirtxml.DomainCaps, error) {
+	key := arch + "\x00" + machine + "\x00" + virtType
+
+	c.domainCapsMu.Lock()
+	defer c.domainCapsMu.Unlock()
+
+	if caps, ok := c.domainCaps[key]; ok {
+		return caps, nil
+	}
+
+	capsXML, err := c.conn.ConnectGetDomainCapabilities(optString(""), optString(arch), optString(machine), optString(virtType), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	caps := &libvirtxml.DomainCaps{}
+	if err := caps.Unmarshal(capsXML); err != nil {
+		return nil, fmt.Errorf("failed to parse domain capabilities: %w", err)
+	}
+
+	if c.domainCaps == nil {
+		c.domainCaps = map[string]*libvirtxml.DomainCaps{}
+	}
+	c.domainCaps[key] = caps
+	return caps, nil
+}
+
+// EnableSchemaValidation makes ValidateXML check documents against the RelaxNG schemas in
+// dir. An empty dir means DefaultSchemaDir.
+func (c *Client) EnableSchemaValidation(dir string) {
+	if dir == "" {
+		dir = DefaultSchemaDir
+	}
+
+	c.schemasMu.Lock()
+	defer c.schemasMu.Unlock()
+	c.schemaDir = dir
+	c.schemas = nil
+}
+
+// SchemaValidationEnabled reports whether documents are validated before they are sent to
+// libvirt.
+func (c *Client) SchemaValidationEnabled() bool {
+	c.schemasMu.Lock()
+	defer c.schemasMu.Unlock()
+	return c.schemaDir != ""
+}
+
+// ValidateXML checks doc against the libvirt schema of the given name, such as "domain"
+// or "storagepool". It returns nil when validation is disabled, a *relaxng.ValidationError
+// when the document does not match, and an error wrapping fs.ErrNotExist when the schema is
+// not installed. Compiled schemas are cached for the lifetime of the client.
+func (c *Client) ValidateXML(schema, doc string) error {
+	c.schemasMu.Lock()
+	if c.schemaDir == "" {
+		c.schemasMu.Unlock()
+		return nil
+	}
+	compiled, ok := c.schemas[schema]
+	if !ok {
+		var err error
+		compiled, err = relaxng.LoadFile(filepath.Join(c.schemaDir, schema+".rng"))
+		if err != nil {
+			c.schemasMu.Unlock()
+			return err
+		}
+		if c.schemas == nil {
+			c.schemas = map[string]*relaxng.Schema{}
+		}
+		c.schemas[schema] = compiled
+	}
+	c.schemasMu.Unlock()
+
+	return compiled.Validate([]byte(doc))
+}
+
+// SetOSInfoDir makes OSInfo read the osinfo database in dir instead of the directories
+// libosinfo reads. An empty dir restores the default.
+func (c *Client) SetOSInfoDir(dir string) {
+	c.osinfoMu.Lock()
+	defer c.osinfoMu.Unlock()
+	c.osinfoDirs = nil
+	if dir != "" {
+		c.osinfoDirs = []string{dir}
+	}
+	c.osinfoDB = nil
+}
+
+// OSInfo returns the osinfo database of the machine running the provider. It is loaded on
+// first use and cached for the lifetime of the client. The error wraps fs.ErrNotExist when
+// no database is installed.
+func (c *Client) OSInfo() (*osinfo.DB, error) {
+	c.osinfoMu.Lock()
+	defer c.osinfoMu.Unlock()
+
+	if c.osinfoDB != nil {
+		return c.osinfoDB, nil
+	}
+	dirs := c.osinfoDirs
+	if dirs == nil {
+		dirs = osinfo.DefaultDirs()
+	}
+	db, err := osinfo.Load(dirs)
+	if err != nil {
+		return nil, err
+	}
+	c.osinfoDB = db
+	return db, nil
+}
+
+// optString converts a string to libvirt's optional string, empty meaning unset.
+func optString(s string) libvirt.OptString {
+	if s == "" {
+		return nil
+	}
+	return libvirt.OptString{s}
+}
+
 // LookupDomainByUUID looks up a domain by its UUID string
 func (c *Client) LookupDomainByUUID(uuidStr string) (libvirt.Domain, error) {
 	uuid, err := parseUUID(uuidStr)
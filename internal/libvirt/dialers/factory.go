@@ -21,7 +21,7 @@ const (
 func NewDialerFromURI(uri *url.URL) (Dialer, error) {
 	// Parse the scheme to extract driver and transport
 	// Format: driver[+transport]://[host]/path
-	// Examples: qemu:///system, qemu+ssh://host/system, qemu+sshcmd://host/system
+	// Examples: qemu:///system, qemu+ssh://host/system, qemu+sshcmd://host/system, mock:///test
 	schemeParts := strings.Split(uri.Scheme, "+")
 	driver := schemeParts[0]
 	transport := ""
@@ -33,6 +33,12 @@ func NewDialerFromURI(uri *url.URL) (Dialer, error) {
 	switch driver {
 	case "qemu", "lxc", "xen", "vbox", "test":
 		// Valid drivers
+	case "mock":
+		// In-process fake daemon, only reachable locally
+		if transport != "" || uri.Host != "" {
+			return nil, fmt.Errorf("mock driver does not support transports or hosts")
+		}
+		return newMockDialer(uri)
 	default:
 		return nil, fmt.Errorf("unsupported libvirt driver: %s", driver)
 	}
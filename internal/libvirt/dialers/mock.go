@@ -0,0 +1,29 @@
+package dialers
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt/mock"
+)
+
+// newMockDialer creates a dialer for the in-process mock daemon.
+//
+// mock:///<name> uses an in-memory host shared by all connections with the same name
+// in the process (the name defaults to "default"). mock:///?file=/path/state.json
+// persists the host to a JSON file instead, so that state survives between runs.
+func newMockDialer(parsedURI *url.URL) (Dialer, error) {
+	if path := parsedURI.Query().Get("file"); path != "" {
+		store, err := mock.FileStore(path)
+		if err != nil {
+			return nil, err
+		}
+		return mock.NewServer(store), nil
+	}
+
+	name := strings.Trim(parsedURI.Path, "/")
+	if name == "" {
+		name = "default"
+	}
+	return mock.NewServer(mock.MemoryStore(name)), nil
+}
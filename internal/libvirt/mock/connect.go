@@ -0,0 +1,206 @@
+package mock
+
+import (
+	"fmt"
+
+	"github.com/digitalocean/go-libvirt"
+)
+
+const (
+	// libVersion is the libvirt version the mock reports (10.0.0).
+	libVersion = 10_000_000
+	// hypervisorVersion is the hypervisor version the mock reports (QEMU 8.2.0).
+	hypervisorVersion = 8_002_000
+	// hostMemoryKiB is the host memory the mock reports (16 GiB).
+	hostMemoryKiB = 16 * 1024 * 1024
+)
+
+const capabilitiesXML = `<capabilities>
+  <host>
+    <uuid>00000000-0000-4000-8000-000000000000</uuid>
+    <cpu>
+      <arch>x86_64</arch>
+      <topology sockets="1" dies="1" cores="4" threads="2"/>
+    </cpu>
+  </host>
+  <guest>
+    <os_type>hvm</os_type>
+    <arch name="x86_64">
+      <wordsize>64</wordsize>
+      <emulator>/usr/bin/qemu-system-x86_64</emulator>
+      <machine canonical="pc-q35-8.2">q35</machine>
+      <machine canonical="pc-i440fx-8.2">pc</machine>
+      <domain type="qemu"/>
+      <domain type="kvm"/>
+    </arch>
+  </guest>
+</capabilities>
+`
+
+// domainCapabilitiesXML is reported for x86_64 guests. %s is replaced by the domain type, %s
+// by the canonical machine type and %s by the values of the secure loader enum.
+const domainCapabilitiesXML = `<domainCapabilities>
+  <path>/usr/bin/qemu-system-x86_64</path>
+  <domain>%s</domain>
+  <machine>%s</machine>
+  <arch>x86_64</arch>
+  <vcpu max="255"/>
+  <os supported="yes">
+    <enum name="firmware">
+      <value>bios</value>
+      <value>efi</value>
+    </enum>
+    <loader supported="yes">
+      <value>/usr/share/OVMF/OVMF_CODE.fd</value>
+      <value>/usr/share/OVMF/OVMF_CODE.secboot.fd</value>
+      <enum name="type">
+        <value>rom</value>
+        <value>pflash</value>
+      </enum>
+      <enum name="secure">
+%s
+      </enum>
+    </loader>
+  </os>
+  <cpu>
+    <mode name="host-passthrough" supported="yes"/>
+    <mode name="maximum" supported="yes"/>
+    <mode name="host-model" supported="yes">
+      <model fallback="forbid">Skylake-Client-IBRS</model>
+    </mode>
+    <mode name="custom" supported="yes">
+      <model usable="yes">qemu64</model>
+      <model usable="yes">Skylake-Client</model>
+      <model usable="no">EPYC</model>
+    </mode>
+  </cpu>
+  <devices>
+    <disk supported="yes">
+      <enum name="diskDevice">
+        <value>disk</value>
+        <value>cdrom</value>
+        <value>floppy</value>
+        <value>lun</value>
+      </enum>
+      <enum name="bus">
+        <value>fdc</value>
+        <value>scsi</value>
+        <value>virtio</value>
+        <value>usb</value>
+        <value>sata</value>
+      </enum>
+    </disk>
+    <video supported="yes">
+      <enum name="modelType">
+        <value>vga</value>
+        <value>cirrus</value>
+        <value>virtio</value>
+        <value>none</value>
+        <value>bochs</value>
+        <value>ramfb</value>
+      </enum>
+    </video>
+    <tpm supported="yes">
+      <enum name="model">
+        <value>tpm-tis</value>
+        <value>tpm-crb</value>
+      </enum>
+    </tpm>
+  </devices>
+</domainCapabilities>
+`
+
+// machineTypes maps the machine types the mock supports to their canonical names.
+var machineTypes = map[string]string{
+	"q35":           "pc-q35-8.2",
+	"pc-q35-8.2":    "pc-q35-8.2",
+	"pc":            "pc-i440fx-8.2",
+	"pc-i440fx-8.2": "pc-i440fx-8.2",
+}
+
+func authList(*Store) (any, error) {
+	// REMOTE_AUTH_NONE
+	return &libvirt.AuthListRet{Types: []libvirt.AuthType{0}}, nil
+}
+
+func connectGetLibVersion(*Store) (any, error) {
+	return &libvirt.ConnectGetLibVersionRet{LibVer: libVersion}, nil
+}
+
+func connectGetVersion(*Store) (any, error) {
+	return &libvirt.ConnectGetVersionRet{HvVer: hypervisorVersion}, nil
+}
+
+func connectGetHostname(*Store) (any, error) {
+	return &libvirt.ConnectGetHostnameRet{Hostname: "mock"}, nil
+}
+
+func connectGetCapabilities(*Store) (any, error) {
+	return &libvirt.ConnectGetCapabilitiesRet{Capabilities: capabilitiesXML}, nil
+}
+
+func connectGetDomainCapabilities(_ *Store, args libvirt.ConnectGetDomainCapabilitiesArgs) (any, error) {
+	virtType, arch, machine := "kvm", "x86_64", "q35"
+	if len(args.Virttype) > 0 {
+		virtType = args.Virttype[0]
+	}
+	if len(args.Arch) > 0 {
+		arch = args.Arch[0]
+	}
+	if len(args.Machine) > 0 {
+		machine = args.Machine[0]
+	}
+
+	if virtType != "kvm" && virtType != "qemu" {
+		return nil, errorf(libvirt.ErrInvalidArg, "invalid argument: unknown virttype: %s", virtType)
+	}
+	if arch != "x86_64" {
+		return nil, errorf(libvirt.ErrInvalidArg, "invalid argument: unable to find any emulator to serve '%s' architecture", arch)
+	}
+	canonical, ok := machineTypes[machine]
+	if !ok {
+		return nil, errorf(libvirt.ErrInvalidArg, "invalid argument: the machine '%s' is not supported by emulator '/usr/bin/qemu-system-x86_64'", machine)
+	}
+
+	// Secure boot needs SMM, which only q35 provides
+	secure := `        <value>no</value>`
+	if canonical == machineTypes["q35"] {
+		secure = "        <value>yes</value>\n" + secure
+	}
+
+	return &libvirt.ConnectGetDomainCapabilitiesRet{
+		Capabilities: fmt.Sprintf(domainCapabilitiesXML, virtType, canonical, secure),
+	}, nil
+}
+
+func nodeGetInfo(*Store) (any, error) {
+	ret := &libvirt.NodeGetInfoRet{
+		Memory:  hostMemoryKiB,
+		Cpus:    8,
+		Mhz:     2400,
+		Nodes:   1,
+		Sockets: 1,
+		Cores:   4,
+		Threads: 2,
+	}
+	for i, c := range "x86_64" {
+		ret.Model[i] = int8(c)
+	}
+	return ret, nil
+}
+
+func nodeNumOfDevices(_ *Store, _ libvirt.NodeNumOfDevicesArgs) (any, error) {
+	return &libvirt.NodeNumOfDevicesRet{Num: 0}, nil
+}
+
+func nodeListDevices(_ *Store, _ libvirt.NodeListDevicesArgs) (any, error) {
+	return &libvirt.NodeListDevicesRet{Names: []string{}}, nil
+}
+
+// boolInt converts a boolean to libvirt's int flags.
+func boolInt(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,575 @@
+package mock
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/digitalocean/go-libvirt"
+	"libvirt.org/go/libvirtxml"
+)
+
+// domainRef returns the RPC reference of a domain record.
+func domainRef(uuid string, rec *DomainRecord) libvirt.Domain {
+	ref := libvirt.Domain{Name: rec.Name, ID: -1}
+	ref.UUID, _ = parseUUID(uuid)
+	if rec.active() {
+		ref.ID = rec.ID
+	}
+	return ref
+}
+
+func (rec *DomainRecord) active() bool {
+	return rec.State != int32(libvirt.DomainShutoff)
+}
+
+// domain returns the record referenced by dom. The caller must hold s.mu.
+func (s *Store) domain(dom libvirt.Domain) (string, *DomainRecord, error) {
+	uuid := uuidString(dom.UUID)
+	rec, ok := s.Domains[uuid]
+	if !ok {
+		return "", nil, errorf(libvirt.ErrNoDomain, "Domain not found: no domain with matching uuid '%s' (%s)", uuid, dom.Name)
+	}
+	return uuid, rec, nil
+}
+
+func (s *Store) domainByName(name string) (string, *DomainRecord) {
+	for _, uuid := range sortedKeys(s.Domains) {
+		if s.Domains[uuid].Name == name {
+			return uuid, s.Domains[uuid]
+		}
+	}
+	return "", nil
+}
+
+func (s *Store) listAllDomains(args libvirt.ConnectListAllDomainsArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	flags := args.Flags
+	ret := &libvirt.ConnectListAllDomainsRet{Domains: []libvirt.Domain{}}
+	for _, uuid := range sortedKeys(s.Domains) {
+		rec := s.Domains[uuid]
+		state := libvirt.DomainState(rec.State)
+
+		if !matchFlagPair(flags, libvirt.ConnectListDomainsActive, libvirt.ConnectListDomainsInactive, rec.active()) ||
+			!matchFlagPair(flags, libvirt.ConnectListDomainsPersistent, libvirt.ConnectListDomainsTransient, rec.Persistent) ||
+			!matchFlagPair(flags, libvirt.ConnectListDomainsAutostart, libvirt.ConnectListDomainsNoAutostart, rec.Autostart) {
+			continue
+		}
+
+		stateFlags := libvirt.ConnectListDomainsRunning | libvirt.ConnectListDomainsPaused |
+			libvirt.ConnectListDomainsShutoff | libvirt.ConnectListDomainsOther
+		if flags&stateFlags != 0 {
+			var flag libvirt.ConnectListAllDomainsFlags
+			switch state {
+			case libvirt.DomainRunning:
+				flag = libvirt.ConnectListDomainsRunning
+			case libvirt.DomainPaused:
+				flag = libvirt.ConnectListDomainsPaused
+			case libvirt.DomainShutoff:
+				flag = libvirt.ConnectListDomainsShutoff
+			default:
+				flag = libvirt.ConnectListDomainsOther
+			}
+			if flags&flag == 0 {
+				continue
+			}
+		}
+
+		ret.Domains = append(ret.Domains, domainRef(uuid, rec))
+	}
+	ret.Ret = uint32(len(ret.Domains))
+	return ret, nil
+}
+
+// matchFlagPair implements libvirt's filter semantics for a pair of mutually exclusive
+// flags: when neither or both are set every object matches.
+func matchFlagPair[F ~int32 | ~uint32](flags, yes, no F, value bool) bool {
+	if flags&yes != 0 && flags&no == 0 {
+		return value
+	}
+	if flags&no != 0 && flags&yes == 0 {
+		return !value
+	}
+	return true
+}
+
+func (s *Store) domainDefineXML(args libvirt.DomainDefineXMLArgs) (any, error) {
+	return s.domainDefineXMLFlags(libvirt.DomainDefineXMLFlagsArgs{XML: args.XML})
+}
+
+func (s *Store) domainDefineXMLFlags(args libvirt.DomainDefineXMLFlagsArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var def libvirtxml.Domain
+	if err := def.Unmarshal(args.XML); err != nil {
+		return nil, errorf(libvirt.ErrXMLError, "XML error: %s", err)
+	}
+	if def.Name == "" {
+		return nil, errorf(libvirt.ErrXMLError, "XML error: missing domain name")
+	}
+
+	existingUUID, existing := s.domainByName(def.Name)
+	if def.UUID == "" {
+		if existing != nil {
+			def.UUID = existingUUID
+		} else {
+			def.UUID = uuidString(newUUID())
+		}
+	}
+	uuid, err := parseUUID(def.UUID)
+	if err != nil {
+		return nil, errorf(libvirt.ErrXMLError, "XML error: %s", err)
+	}
+	def.UUID = uuidString(uuid)
+
+	if existing != nil && existingUUID != def.UUID {
+		return nil, errorf(libvirt.ErrOperationFailed, "operation failed: domain '%s' already exists with uuid %s", def.Name, existingUUID)
+	}
+	if rec, ok := s.Domains[def.UUID]; ok && rec.Name != def.Name {
+		return nil, errorf(libvirt.ErrOperationFailed, "operation failed: domain '%s' already exists with uuid %s", rec.Name, def.UUID)
+	}
+
+	// libvirt generates MAC addresses for interfaces defined without one
+	if def.Devices != nil {
+		for i := range def.Devices.Interfaces {
+			if def.Devices.Interfaces[i].MAC == nil || def.Devices.Interfaces[i].MAC.Address == "" {
+				def.Devices.Interfaces[i].MAC = &libvirtxml.DomainInterfaceMAC{Address: newMAC()}
+			}
+		}
+	}
+	def.ID = nil
+
+	xml, err := def.Marshal()
+	if err != nil {
+		return nil, errorf(libvirt.ErrXMLError, "XML error: %s", err)
+	}
+
+	rec, ok := s.Domains[def.UUID]
+	if !ok {
+		rec = &DomainRecord{Name: def.Name, ID: -1, State: int32(libvirt.DomainShutoff)}
+		s.Domains[def.UUID] = rec
+	}
+	rec.XML = xml
+	rec.Persistent = true
+
+	return &libvirt.DomainDefineXMLRet{Dom: domainRef(def.UUID, rec)}, s.save()
+}
+
+func (s *Store) domainLookupByID(args libvirt.DomainLookupByIDArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, uuid := range sortedKeys(s.Domains) {
+		if rec := s.Domains[uuid]; rec.active() && rec.ID == args.ID {
+			return &libvirt.DomainLookupByIDRet{Dom: domainRef(uuid, rec)}, nil
+		}
+	}
+	return nil, errorf(libvirt.ErrNoDomain, "Domain not found: no domain with matching id %d", args.ID)
+}
+
+func (s *Store) domainLookupByName(args libvirt.DomainLookupByNameArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uuid, rec := s.domainByName(args.Name)
+	if rec == nil {
+		return nil, errorf(libvirt.ErrNoDomain, "Domain not found: no domain with matching name '%s'", args.Name)
+	}
+	return &libvirt.DomainLookupByNameRet{Dom: domainRef(uuid, rec)}, nil
+}
+
+func (s *Store) domainLookupByUUID(args libvirt.DomainLookupByUUIDArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uuid := uuidString(args.UUID)
+	rec, ok := s.Domains[uuid]
+	if !ok {
+		return nil, errorf(libvirt.ErrNoDomain, "Domain not found: no domain with matching uuid '%s'", uuid)
+	}
+	return &libvirt.DomainLookupByUUIDRet{Dom: domainRef(uuid, rec)}, nil
+}
+
+func (s *Store) domainGetXMLDesc(args libvirt.DomainGetXMLDescArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.domain(args.Dom)
+	if err != nil {
+		return nil, err
+	}
+
+	var def libvirtxml.Domain
+	if err := def.Unmarshal(rec.XML); err != nil {
+		return nil, errorf(libvirt.ErrInternalError, "internal error: %s", err)
+	}
+	if rec.active() && args.Flags&libvirt.DomainXMLInactive == 0 {
+		id := int(rec.ID)
+		def.ID = &id
+	}
+
+	xml, err := def.Marshal()
+	if err != nil {
+		return nil, errorf(libvirt.ErrInternalError, "internal error: %s", err)
+	}
+	return &libvirt.DomainGetXMLDescRet{XML: xml}, nil
+}
+
+func (s *Store) domainGetInfo(args libvirt.DomainGetInfoArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.domain(args.Dom)
+	if err != nil {
+		return nil, err
+	}
+
+	var def libvirtxml.Domain
+	if err := def.Unmarshal(rec.XML); err != nil {
+		return nil, errorf(libvirt.ErrInternalError, "internal error: %s", err)
+	}
+
+	ret := &libvirt.DomainGetInfoRet{State: uint8(rec.State)}
+	if def.Memory != nil {
+		ret.MaxMem = memoryKiB(def.Memory.Value, def.Memory.Unit)
+		ret.Memory = ret.MaxMem
+	}
+	if def.CurrentMemory != nil {
+		ret.Memory = memoryKiB(def.CurrentMemory.Value, def.CurrentMemory.Unit)
+	}
+	if def.VCPU != nil {
+		ret.NrVirtCPU = uint16(def.VCPU.Value)
+	}
+	return ret, nil
+}
+
+func (s *Store) domainGetState(args libvirt.DomainGetStateArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.domain(args.Dom)
+	if err != nil {
+		return nil, err
+	}
+	return &libvirt.DomainGetStateRet{State: rec.State, Reason: 1}, nil
+}
+
+func (s *Store) domainIsActive(args libvirt.DomainIsActiveArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.domain(args.Dom)
+	if err != nil {
+		return nil, err
+	}
+	return &libvirt.DomainIsActiveRet{Active: boolInt(rec.active())}, nil
+}
+
+func (s *Store) domainIsPersistent(args libvirt.DomainIsPersistentArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.domain(args.Dom)
+	if err != nil {
+		return nil, err
+	}
+	return &libvirt.DomainIsPersistentRet{Persistent: boolInt(rec.Persistent)}, nil
+}
+
+func (s *Store) domainGetAutostart(args libvirt.DomainGetAutostartArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.domain(args.Dom)
+	if err != nil {
+		return nil, err
+	}
+	return &libvirt.DomainGetAutostartRet{Autostart: boolInt(rec.Autostart)}, nil
+}
+
+func (s *Store) domainSetAutostart(args libvirt.DomainSetAutostartArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.domain(args.Dom)
+	if err != nil {
+		return nil, err
+	}
+	if !rec.Persistent {
+		return nil, errorf(libvirt.ErrOperationInvalid, "Requested operation is not valid: cannot set autostart for transient domain")
+	}
+	rec.Autostart = args.Autostart != 0
+	return nil, s.save()
+}
+
+func (s *Store) domainCreate(args libvirt.DomainCreateArgs) (any, error) {
+	_, err := s.domainCreateWithFlags(libvirt.DomainCreateWithFlagsArgs{Dom: args.Dom})
+	return nil, err
+}
+
+func (s *Store) domainCreateWithFlags(args libvirt.DomainCreateWithFlagsArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uuid, rec, err := s.domain(args.Dom)
+	if err != nil {
+		return nil, err
+	}
+	if rec.active() {
+		return nil, errorf(libvirt.ErrOperationInvalid, "Requested operation is not valid: domain is already running")
+	}
+
+	rec.ID = s.NextDomainID
+	s.NextDomainID++
+	rec.State = int32(libvirt.DomainRunning)
+	if args.Flags&uint32(libvirt.DomainStartPaused) != 0 {
+		rec.State = int32(libvirt.DomainPaused)
+	}
+
+	return &libvirt.DomainCreateWithFlagsRet{Dom: domainRef(uuid, rec)}, s.save()
+}
+
+// stopDomain powers off a domain; transient domains disappear. The caller must hold s.mu.
+func (s *Store) stopDomain(dom libvirt.Domain) error {
+	uuid, rec, err := s.domain(dom)
+	if err != nil {
+		return err
+	}
+	if !rec.active() {
+		return errorf(libvirt.ErrOperationInvalid, "Requested operation is not valid: domain is not running")
+	}
+
+	rec.State = int32(libvirt.DomainShutoff)
+	rec.ID = -1
+	if !rec.Persistent {
+		delete(s.Domains, uuid)
+	}
+	return s.save()
+}
+
+func (s *Store) domainDestroy(args libvirt.DomainDestroyArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return nil, s.stopDomain(args.Dom)
+}
+
+func (s *Store) domainDestroyFlags(args libvirt.DomainDestroyFlagsArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return nil, s.stopDomain(args.Dom)
+}
+
+// Guests of the mock host honour shutdown requests immediately.
+func (s *Store) domainShutdown(args libvirt.DomainShutdownArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return nil, s.stopDomain(args.Dom)
+}
+
+// Shutdown modes are accepted like the QEMU driver does: initctl and signal are only
+// known to LXC, and the agent needs a guest agent channel unless another mode is allowed.
+func (s *Store) domainShutdownFlags(args libvirt.DomainShutdownFlagsArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if unsupported := args.Flags & (libvirt.DomainShutdownInitctl | libvirt.DomainShutdownSignal); unsupported != 0 {
+		return nil, errorf(libvirt.ErrInvalidArg, "invalid argument: unsupported flags (0x%x) in function qemuDomainShutdownFlags", unsupported)
+	}
+	if args.Flags == libvirt.DomainShutdownGuestAgent {
+		_, rec, err := s.domain(args.Dom)
+		if err != nil {
+			return nil, err
+		}
+		var def libvirtxml.Domain
+		if err := def.Unmarshal(rec.XML); err != nil {
+			return nil, errorf(libvirt.ErrInternalError, "internal error: %s", err)
+		}
+		if !hasGuestAgentChannel(&def) {
+			return nil, errorf(libvirt.ErrArgumentUnsupported, "argument unsupported: QEMU guest agent is not configured")
+		}
+	}
+	return nil, s.stopDomain(args.Dom)
+}
+
+func hasGuestAgentChannel(def *libvirtxml.Domain) bool {
+	if def.Devices == nil {
+		return false
+	}
+	for _, channel := range def.Devices.Channels {
+		if channel.Target != nil && channel.Target.VirtIO != nil && channel.Target.VirtIO.Name == "org.qemu.guest_agent.0" {
+			return true
+		}
+	}
+	return false
+}
+
+// requireActive returns an error unless the domain is running or paused. The caller
+// must hold s.mu.
+func (s *Store) requireActive(dom libvirt.Domain) (*DomainRecord, error) {
+	_, rec, err := s.domain(dom)
+	if err != nil {
+		return nil, err
+	}
+	if !rec.active() {
+		return nil, errorf(libvirt.ErrOperationInvalid, "Requested operation is not valid: domain is not running")
+	}
+	return rec, nil
+}
+
+func (s *Store) domainReboot(args libvirt.DomainRebootArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.requireActive(args.Dom)
+	return nil, err
+}
+
+func (s *Store) domainReset(args libvirt.DomainResetArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.requireActive(args.Dom)
+	return nil, err
+}
+
+func (s *Store) domainSuspend(args libvirt.DomainSuspendArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, err := s.requireActive(args.Dom)
+	if err != nil {
+		return nil, err
+	}
+	rec.State = int32(libvirt.DomainPaused)
+	return nil, s.save()
+}
+
+func (s *Store) domainResume(args libvirt.DomainResumeArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, err := s.requireActive(args.Dom)
+	if err != nil {
+		return nil, err
+	}
+	if rec.State != int32(libvirt.DomainPaused) {
+		return nil, errorf(libvirt.ErrOperationInvalid, "Requested operation is not valid: domain is not paused")
+	}
+	rec.State = int32(libvirt.DomainRunning)
+	return nil, s.save()
+}
+
+func (s *Store) domainUndefine(args libvirt.DomainUndefineArgs) (any, error) {
+	return s.domainUndefineFlags(libvirt.DomainUndefineFlagsArgs{Dom: args.Dom})
+}
+
+// domainUndefineFlags removes the definition; a running domain stays as a transient
+// domain until it is stopped.
+func (s *Store) domainUndefineFlags(args libvirt.DomainUndefineFlagsArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uuid, rec, err := s.domain(args.Dom)
+	if err != nil {
+		return nil, err
+	}
+	if !rec.Persistent {
+		return nil, errorf(libvirt.ErrOperationInvalid, "Requested operation is not valid: cannot undefine transient domain")
+	}
+
+	if rec.active() {
+		rec.Persistent = false
+		rec.Autostart = false
+	} else {
+		delete(s.Domains, uuid)
+	}
+	return nil, s.save()
+}
+
+// domainInterfaceAddresses reports one IPv4 address per interface, derived from its MAC
+// address, as if the guest had obtained a DHCP lease on the default network. Like a real
+// guest agent, the agent source also reports the loopback interface and an IPv6
+// link-local address per interface.
+func (s *Store) domainInterfaceAddresses(args libvirt.DomainInterfaceAddressesArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, err := s.requireActive(args.Dom)
+	if err != nil {
+		return nil, err
+	}
+
+	var def libvirtxml.Domain
+	if err := def.Unmarshal(rec.XML); err != nil {
+		return nil, errorf(libvirt.ErrInternalError, "internal error: %s", err)
+	}
+
+	agent := args.Source == uint32(libvirt.DomainInterfaceAddressesSrcAgent)
+
+	ret := &libvirt.DomainInterfaceAddressesRet{Ifaces: []libvirt.DomainInterface{}}
+	if agent {
+		ret.Ifaces = append(ret.Ifaces, libvirt.DomainInterface{
+			Name:   "lo",
+			Hwaddr: libvirt.OptString{"00:00:00:00:00:00"},
+			Addrs: []libvirt.DomainIPAddr{
+				{Type: int32(libvirt.IPAddrTypeIpv4), Addr: "127.0.0.1", Prefix: 8},
+				{Type: int32(libvirt.IPAddrTypeIpv6), Addr: "::1", Prefix: 128},
+			},
+		})
+	}
+	if def.Devices == nil {
+		return ret, nil
+	}
+
+	for i, iface := range def.Devices.Interfaces {
+		if iface.MAC == nil {
+			continue
+		}
+
+		name := fmt.Sprintf("vnet%d", i)
+		if agent {
+			name = fmt.Sprintf("eth%d", i)
+		}
+
+		var last byte
+		if _, err := fmt.Sscanf(iface.MAC.Address[len(iface.MAC.Address)-2:], "%02x", &last); err != nil {
+			continue
+		}
+
+		addrs := []libvirt.DomainIPAddr{{
+			Type:   int32(libvirt.IPAddrTypeIpv4),
+			Addr:   fmt.Sprintf("192.168.122.%d", 2+int(last)%250),
+			Prefix: 24,
+		}}
+		if args.Source == uint32(libvirt.DomainInterfaceAddressesSrcArp) {
+			// The ARP table carries no prefix length
+			addrs[0].Prefix = 0
+		}
+		if agent {
+			addrs = append(addrs, libvirt.DomainIPAddr{
+				Type:   int32(libvirt.IPAddrTypeIpv6),
+				Addr:   fmt.Sprintf("fe80::5054:ff:fe00:%x", last),
+				Prefix: 64,
+			})
+		}
+
+		ret.Ifaces = append(ret.Ifaces, libvirt.DomainInterface{
+			Name:   name,
+			Hwaddr: libvirt.OptString{iface.MAC.Address},
+			Addrs:  addrs,
+		})
+	}
+	return ret, nil
+}
+
+// memoryKiB converts a libvirt memory value to KiB.
+func memoryKiB(value uint, unit string) uint64 {
+	return sizeBytes(uint64(value), unit, "KiB") / 1024
+}
+
+// newMAC returns a random MAC address with the QEMU OUI, as libvirt generates them.
+func newMAC() string {
+	var b [3]byte
+	_, _ = rand.Read(b[:])
+	return fmt.Sprintf("52:54:00:%02x:%02x:%02x", b[0], b[1], b[2])
+}
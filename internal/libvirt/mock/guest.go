@@ -0,0 +1,120 @@
+package mock
+
+import (
+	"fmt"
+
+	"github.com/digitalocean/go-libvirt"
+	"libvirt.org/go/libvirtxml"
+)
+
+// domainGetGuestInfo answers as a Linux guest agent would. The hostname is the domain
+// name, every disk of the definition is seen by the guest and the first one holds the
+// root filesystem.
+func (s *Store) domainGetGuestInfo(args libvirt.DomainGetGuestInfoArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, err := s.requireActive(args.Dom)
+	if err != nil {
+		return nil, err
+	}
+
+	var def libvirtxml.Domain
+	if err := def.Unmarshal(rec.XML); err != nil {
+		return nil, errorf(libvirt.ErrInternalError, "internal error: %s", err)
+	}
+
+	var disks []string
+	if def.Devices != nil {
+		for _, disk := range def.Devices.Disks {
+			if disk.Device != "cdrom" && disk.Target != nil {
+				disks = append(disks, disk.Target.Dev)
+			}
+		}
+	}
+
+	want := func(t libvirt.DomainGuestInfoTypes) bool {
+		return args.Types == 0 || args.Types&uint32(t) != 0
+	}
+
+	var params typedParams
+	if want(libvirt.DomainGuestInfoUsers) {
+		params.uint("user.count", 1)
+		params.str("user.0.name", "root")
+		params.add("user.0.login-time", libvirt.NewTypedParamValueUllong(1700000000000))
+	}
+	if want(libvirt.DomainGuestInfoOs) {
+		params.str("os.id", "mock")
+		params.str("os.name", "Mock Linux")
+		params.str("os.pretty-name", "Mock Linux 1.0")
+		params.str("os.version", "1.0")
+		params.str("os.version-id", "1.0")
+		params.str("os.kernel-release", "6.8.0-mock")
+		params.str("os.kernel-version", "#1 SMP")
+		params.str("os.machine", "x86_64")
+	}
+	if want(libvirt.DomainGuestInfoTimezone) {
+		params.str("timezone.name", "UTC")
+		params.add("timezone.offset", libvirt.NewTypedParamValueInt(0))
+	}
+	if want(libvirt.DomainGuestInfoHostname) {
+		params.str("hostname", rec.Name)
+	}
+	if want(libvirt.DomainGuestInfoFilesystem) {
+		if len(disks) > 0 {
+			params.uint("fs.count", 1)
+			params.str("fs.0.mountpoint", "/")
+			params.str("fs.0.name", disks[0]+"1")
+			params.str("fs.0.fstype", "ext4")
+			params.add("fs.0.total-bytes", libvirt.NewTypedParamValueUllong(10<<30))
+			params.add("fs.0.used-bytes", libvirt.NewTypedParamValueUllong(2<<30))
+			params.uint("fs.0.disk.count", 1)
+			params.str("fs.0.disk.0.alias", disks[0])
+			params.str("fs.0.disk.0.device", "/dev/"+disks[0]+"1")
+		} else {
+			params.uint("fs.count", 0)
+		}
+	}
+	if want(libvirt.DomainGuestInfoDisks) {
+		params.uint("disk.count", uint32(len(disks)))
+		for i, dev := range disks {
+			prefix := fmt.Sprintf("disk.%d.", i)
+			params.str(prefix+"name", "/dev/"+dev)
+			params.add(prefix+"partition", libvirt.NewTypedParamValueBoolean(0))
+			params.str(prefix+"alias", dev)
+		}
+	}
+
+	return &libvirt.DomainGetGuestInfoRet{Params: params}, nil
+}
+
+// domainOpenConsole streams a short boot log ending in a login prompt and closes the
+// stream, as if the console had been disconnected.
+func domainOpenConsole(sess *session, payload []byte) (any, error) {
+	var args libvirt.DomainOpenConsoleArgs
+	if err := decodeXDR(payload, &args); err != nil {
+		return nil, errorf(libvirt.ErrRPC, "failed to decode arguments: %s", err)
+	}
+
+	s := sess.server.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, err := s.requireActive(args.Dom)
+	if err != nil {
+		return nil, err
+	}
+
+	var def libvirtxml.Domain
+	if err := def.Unmarshal(rec.XML); err != nil {
+		return nil, errorf(libvirt.ErrInternalError, "internal error: %s", err)
+	}
+	if def.Devices == nil || (len(def.Devices.Consoles) == 0 && len(def.Devices.Serials) == 0) {
+		return nil, errorf(libvirt.ErrOperationFailed, "operation failed: cannot find character device <null>")
+	}
+
+	sess.console = []byte(fmt.Sprintf("[    0.000000] Linux version 6.8.0-mock\r\n"+
+		"Welcome to Mock Linux 1.0!\r\n\r\n"+
+		"Mock Linux 1.0 %[1]s ttyS0\r\n\r\n%[1]s login: ", rec.Name))
+	return nil, nil
+}
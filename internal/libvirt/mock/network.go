@@ -0,0 +1,220 @@
+package mock
+
+import (
+	"github.com/digitalocean/go-libvirt"
+	"libvirt.org/go/libvirtxml"
+)
+
+func networkRef(uuid string, rec *NetworkRecord) libvirt.Network {
+	ref := libvirt.Network{Name: rec.Name}
+	ref.UUID, _ = parseUUID(uuid)
+	return ref
+}
+
+// network returns the record referenced by net. The caller must hold s.mu.
+func (s *Store) network(net libvirt.Network) (string, *NetworkRecord, error) {
+	uuid := uuidString(net.UUID)
+	rec, ok := s.Networks[uuid]
+	if !ok {
+		return "", nil, errorf(libvirt.ErrNoNetwork, "Network not found: no network with matching uuid '%s' (%s)", uuid, net.Name)
+	}
+	return uuid, rec, nil
+}
+
+func (s *Store) networkByName(name string) (string, *NetworkRecord) {
+	for _, uuid := range sortedKeys(s.Networks) {
+		if s.Networks[uuid].Name == name {
+			return uuid, s.Networks[uuid]
+		}
+	}
+	return "", nil
+}
+
+func (s *Store) listAllNetworks(args libvirt.ConnectListAllNetworksArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	flags := args.Flags
+	ret := &libvirt.ConnectListAllNetworksRet{Nets: []libvirt.Network{}}
+	for _, uuid := range sortedKeys(s.Networks) {
+		rec := s.Networks[uuid]
+		if !matchFlagPair(flags, libvirt.ConnectListNetworksActive, libvirt.ConnectListNetworksInactive, rec.Active) ||
+			!matchFlagPair(flags, libvirt.ConnectListNetworksPersistent, libvirt.ConnectListNetworksTransient, true) ||
+			!matchFlagPair(flags, libvirt.ConnectListNetworksAutostart, libvirt.ConnectListNetworksNoAutostart, rec.Autostart) {
+			continue
+		}
+		ret.Nets = append(ret.Nets, networkRef(uuid, rec))
+	}
+	ret.Ret = uint32(len(ret.Nets))
+	return ret, nil
+}
+
+func (s *Store) networkDefineXML(args libvirt.NetworkDefineXMLArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var def libvirtxml.Network
+	if err := def.Unmarshal(args.XML); err != nil {
+		return nil, errorf(libvirt.ErrXMLError, "XML error: %s", err)
+	}
+	if def.Name == "" {
+		return nil, errorf(libvirt.ErrXMLError, "XML error: missing network name")
+	}
+
+	existingUUID, existing := s.networkByName(def.Name)
+	if def.UUID == "" {
+		if existing != nil {
+			def.UUID = existingUUID
+		} else {
+			def.UUID = uuidString(newUUID())
+		}
+	}
+	uuid, err := parseUUID(def.UUID)
+	if err != nil {
+		return nil, errorf(libvirt.ErrXMLError, "XML error: %s", err)
+	}
+	def.UUID = uuidString(uuid)
+
+	if existing != nil && existingUUID != def.UUID {
+		return nil, errorf(libvirt.ErrOperationFailed, "operation failed: network '%s' already exists with uuid %s", def.Name, existingUUID)
+	}
+	if rec, ok := s.Networks[def.UUID]; ok && rec.Name != def.Name {
+		return nil, errorf(libvirt.ErrOperationFailed, "operation failed: network '%s' already exists with uuid %s", rec.Name, def.UUID)
+	}
+
+	xml, err := def.Marshal()
+	if err != nil {
+		return nil, errorf(libvirt.ErrXMLError, "XML error: %s", err)
+	}
+
+	rec, ok := s.Networks[def.UUID]
+	if !ok {
+		rec = &NetworkRecord{Name: def.Name}
+		s.Networks[def.UUID] = rec
+	}
+	rec.XML = xml
+
+	return &libvirt.NetworkDefineXMLRet{Net: networkRef(def.UUID, rec)}, s.save()
+}
+
+func (s *Store) networkLookupByName(args libvirt.NetworkLookupByNameArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uuid, rec := s.networkByName(args.Name)
+	if rec == nil {
+		return nil, errorf(libvirt.ErrNoNetwork, "Network not found: no network with matching name '%s'", args.Name)
+	}
+	return &libvirt.NetworkLookupByNameRet{Net: networkRef(uuid, rec)}, nil
+}
+
+func (s *Store) networkLookupByUUID(args libvirt.NetworkLookupByUUIDArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uuid := uuidString(args.UUID)
+	rec, ok := s.Networks[uuid]
+	if !ok {
+		return nil, errorf(libvirt.ErrNoNetwork, "Network not found: no network with matching uuid '%s'", uuid)
+	}
+	return &libvirt.NetworkLookupByUUIDRet{Net: networkRef(uuid, rec)}, nil
+}
+
+func (s *Store) networkGetXMLDesc(args libvirt.NetworkGetXMLDescArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.network(args.Net)
+	if err != nil {
+		return nil, err
+	}
+	return &libvirt.NetworkGetXMLDescRet{XML: rec.XML}, nil
+}
+
+func (s *Store) networkIsActive(args libvirt.NetworkIsActiveArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.network(args.Net)
+	if err != nil {
+		return nil, err
+	}
+	return &libvirt.NetworkIsActiveRet{Active: boolInt(rec.Active)}, nil
+}
+
+func (s *Store) networkIsPersistent(args libvirt.NetworkIsPersistentArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, _, err := s.network(args.Net); err != nil {
+		return nil, err
+	}
+	return &libvirt.NetworkIsPersistentRet{Persistent: 1}, nil
+}
+
+func (s *Store) networkGetAutostart(args libvirt.NetworkGetAutostartArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.network(args.Net)
+	if err != nil {
+		return nil, err
+	}
+	return &libvirt.NetworkGetAutostartRet{Autostart: boolInt(rec.Autostart)}, nil
+}
+
+func (s *Store) networkSetAutostart(args libvirt.NetworkSetAutostartArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.network(args.Net)
+	if err != nil {
+		return nil, err
+	}
+	rec.Autostart = args.Autostart != 0
+	return nil, s.save()
+}
+
+func (s *Store) networkCreate(args libvirt.NetworkCreateArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.network(args.Net)
+	if err != nil {
+		return nil, err
+	}
+	if rec.Active {
+		return nil, errorf(libvirt.ErrOperationInvalid, "Requested operation is not valid: network is already active")
+	}
+	rec.Active = true
+	return nil, s.save()
+}
+
+func (s *Store) networkDestroy(args libvirt.NetworkDestroyArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.network(args.Net)
+	if err != nil {
+		return nil, err
+	}
+	if !rec.Active {
+		return nil, errorf(libvirt.ErrOperationInvalid, "Requested operation is not valid: network is not active")
+	}
+	rec.Active = false
+	return nil, s.save()
+}
+
+// networkUndefine removes the network. Unlike domains the mock does not keep active
+// networks around as transient objects.
+func (s *Store) networkUndefine(args libvirt.NetworkUndefineArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uuid, _, err := s.network(args.Net)
+	if err != nil {
+		return nil, err
+	}
+	delete(s.Networks, uuid)
+	return nil, s.save()
+}
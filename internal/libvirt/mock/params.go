@@ -0,0 +1,20 @@
+package mock
+
+import (
+	"github.com/digitalocean/go-libvirt"
+)
+
+// typedParams builds the typed parameter list of a reply.
+type typedParams []libvirt.TypedParam
+
+func (p *typedParams) add(field string, value *libvirt.TypedParamValue) {
+	*p = append(*p, libvirt.TypedParam{Field: field, Value: *value})
+}
+
+func (p *typedParams) str(field, value string) {
+	p.add(field, libvirt.NewTypedParamValueString(value))
+}
+
+func (p *typedParams) uint(field string, value uint32) {
+	p.add(field, libvirt.NewTypedParamValueUint(value))
+}
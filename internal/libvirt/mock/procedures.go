@@ -0,0 +1,201 @@
+package mock
+
+import (
+	"github.com/digitalocean/go-libvirt"
+)
+
+// Remote procedure numbers from libvirt's remote_protocol.x. go-libvirt keeps its copy
+// in an internal package.
+const (
+	procConnectOpen                = 1
+	procConnectClose               = 2
+	procConnectGetVersion          = 4
+	procNodeGetInfo                = 6
+	procConnectGetCapabilities     = 7
+	procDomainCreate               = 9
+	procDomainDefineXML            = 11
+	procDomainDestroy              = 12
+	procDomainGetXMLDesc           = 14
+	procDomainGetAutostart         = 15
+	procDomainGetInfo              = 16
+	procDomainLookupByID           = 22
+	procDomainLookupByName         = 23
+	procDomainLookupByUUID         = 24
+	procDomainReboot               = 27
+	procDomainResume               = 28
+	procDomainSetAutostart         = 29
+	procDomainShutdown             = 33
+	procDomainSuspend              = 34
+	procDomainUndefine             = 35
+	procNetworkCreate              = 39
+	procNetworkDefineXML           = 41
+	procNetworkDestroy             = 42
+	procNetworkGetXMLDesc          = 43
+	procNetworkGetAutostart        = 44
+	procNetworkLookupByName        = 46
+	procNetworkLookupByUUID        = 47
+	procNetworkSetAutostart        = 48
+	procNetworkUndefine            = 49
+	procConnectGetHostname         = 59
+	procAuthList                   = 66
+	procStoragePoolDefineXML       = 77
+	procStoragePoolCreate          = 78
+	procStoragePoolBuild           = 79
+	procStoragePoolDestroy         = 80
+	procStoragePoolDelete          = 81
+	procStoragePoolUndefine        = 82
+	procStoragePoolRefresh         = 83
+	procStoragePoolLookupByName    = 84
+	procStoragePoolLookupByUUID    = 85
+	procStoragePoolLookupByVolume  = 86
+	procStoragePoolGetInfo         = 87
+	procStoragePoolGetXMLDesc      = 88
+	procStoragePoolGetAutostart    = 89
+	procStoragePoolSetAutostart    = 90
+	procStorageVolCreateXML        = 93
+	procStorageVolDelete           = 94
+	procStorageVolLookupByName     = 95
+	procStorageVolLookupByKey      = 96
+	procStorageVolLookupByPath     = 97
+	procStorageVolGetInfo          = 98
+	procStorageVolGetXMLDesc       = 99
+	procNodeNumOfDevices           = 111
+	procNodeListDevices            = 112
+	procStorageVolCreateXMLFrom    = 125
+	procDomainIsActive             = 150
+	procDomainIsPersistent         = 151
+	procNetworkIsActive            = 152
+	procNetworkIsPersistent        = 153
+	procStoragePoolIsActive        = 154
+	procConnectGetLibVersion       = 157
+	procStorageVolWipe             = 165
+	procDomainCreateWithFlags      = 196
+	procDomainOpenConsole          = 201
+	procStorageVolUpload           = 208
+	procDomainGetState             = 212
+	procDomainUndefineFlags        = 231
+	procDomainDestroyFlags         = 234
+	procDomainReset                = 245
+	procDomainShutdownFlags        = 258
+	procConnectListAllDomains      = 273
+	procConnectListAllStoragePools = 281
+	procStoragePoolListAllVolumes  = 282
+	procConnectListAllNetworks     = 283
+	procConnectGetDomainCaps       = 342
+	procConnectGetAllDomainStats   = 344
+	procDomainDefineXMLFlags       = 350
+	procDomainInterfaceAddresses   = 353
+	procDomainGetGuestInfo         = 418
+)
+
+// handler decodes the arguments of a call and returns the reply body, or nil for
+// procedures without a reply body.
+type handler func(sess *session, payload []byte) (any, error)
+
+// call adapts a typed procedure implementation to a handler.
+func call[A any](fn func(s *Store, args A) (any, error)) handler {
+	return func(sess *session, payload []byte) (any, error) {
+		var args A
+		if err := decodeXDR(payload, &args); err != nil {
+			return nil, errorf(libvirt.ErrRPC, "failed to decode arguments: %s", err)
+		}
+		return fn(sess.server.store, args)
+	}
+}
+
+// noArgs adapts a procedure implementation without arguments to a handler.
+func noArgs(fn func(s *Store) (any, error)) handler {
+	return func(sess *session, payload []byte) (any, error) {
+		return fn(sess.server.store)
+	}
+}
+
+var procedures map[uint32]handler
+
+func init() {
+	procedures = map[uint32]handler{
+		procAuthList:               noArgs(authList),
+		procConnectOpen:            noArgs(func(*Store) (any, error) { return nil, nil }),
+		procConnectClose:           noArgs(func(*Store) (any, error) { return nil, nil }),
+		procConnectGetLibVersion:   noArgs(connectGetLibVersion),
+		procConnectGetVersion:      noArgs(connectGetVersion),
+		procConnectGetHostname:     noArgs(connectGetHostname),
+		procConnectGetCapabilities: noArgs(connectGetCapabilities),
+		procConnectGetDomainCaps:   call(connectGetDomainCapabilities),
+		procNodeGetInfo:            noArgs(nodeGetInfo),
+		procNodeNumOfDevices:       call(nodeNumOfDevices),
+		procNodeListDevices:        call(nodeListDevices),
+
+		procConnectListAllDomains:    call((*Store).listAllDomains),
+		procDomainDefineXML:          call((*Store).domainDefineXML),
+		procDomainDefineXMLFlags:     call((*Store).domainDefineXMLFlags),
+		procDomainLookupByID:         call((*Store).domainLookupByID),
+		procDomainLookupByName:       call((*Store).domainLookupByName),
+		procDomainLookupByUUID:       call((*Store).domainLookupByUUID),
+		procDomainGetXMLDesc:         call((*Store).domainGetXMLDesc),
+		procDomainGetInfo:            call((*Store).domainGetInfo),
+		procDomainGetState:           call((*Store).domainGetState),
+		procDomainIsActive:           call((*Store).domainIsActive),
+		procDomainIsPersistent:       call((*Store).domainIsPersistent),
+		procDomainGetAutostart:       call((*Store).domainGetAutostart),
+		procDomainSetAutostart:       call((*Store).domainSetAutostart),
+		procDomainCreate:             call((*Store).domainCreate),
+		procDomainCreateWithFlags:    call((*Store).domainCreateWithFlags),
+		procDomainDestroy:            call((*Store).domainDestroy),
+		procDomainDestroyFlags:       call((*Store).domainDestroyFlags),
+		procDomainShutdown:           call((*Store).domainShutdown),
+		procDomainShutdownFlags:      call((*Store).domainShutdownFlags),
+		procDomainReboot:             call((*Store).domainReboot),
+		procDomainReset:              call((*Store).domainReset),
+		procDomainSuspend:            call((*Store).domainSuspend),
+		procDomainResume:             call((*Store).domainResume),
+		procDomainUndefine:           call((*Store).domainUndefine),
+		procDomainUndefineFlags:      call((*Store).domainUndefineFlags),
+		procDomainInterfaceAddresses: call((*Store).domainInterfaceAddresses),
+		procDomainGetGuestInfo:       call((*Store).domainGetGuestInfo),
+		procDomainOpenConsole:        domainOpenConsole,
+		procConnectGetAllDomainStats: call((*Store).connectGetAllDomainStats),
+
+		procConnectListAllNetworks: call((*Store).listAllNetworks),
+		procNetworkDefineXML:       call((*Store).networkDefineXML),
+		procNetworkLookupByName:    call((*Store).networkLookupByName),
+		procNetworkLookupByUUID:    call((*Store).networkLookupByUUID),
+		procNetworkGetXMLDesc:      call((*Store).networkGetXMLDesc),
+		procNetworkIsActive:        call((*Store).networkIsActive),
+		procNetworkIsPersistent:    call((*Store).networkIsPersistent),
+		procNetworkGetAutostart:    call((*Store).networkGetAutostart),
+		procNetworkSetAutostart:    call((*Store).networkSetAutostart),
+		procNetworkCreate:          call((*Store).networkCreate),
+		procNetworkDestroy:         call((*Store).networkDestroy),
+		procNetworkUndefine:        call((*Store).networkUndefine),
+
+		procConnectListAllStoragePools: call((*Store).listAllStoragePools),
+		procStoragePoolDefineXML:       call((*Store).storagePoolDefineXML),
+		procStoragePoolLookupByName:    call((*Store).storagePoolLookupByName),
+		procStoragePoolLookupByUUID:    call((*Store).storagePoolLookupByUUID),
+		procStoragePoolLookupByVolume:  call((*Store).storagePoolLookupByVolume),
+		procStoragePoolGetXMLDesc:      call((*Store).storagePoolGetXMLDesc),
+		procStoragePoolGetInfo:         call((*Store).storagePoolGetInfo),
+		procStoragePoolIsActive:        call((*Store).storagePoolIsActive),
+		procStoragePoolGetAutostart:    call((*Store).storagePoolGetAutostart),
+		procStoragePoolSetAutostart:    call((*Store).storagePoolSetAutostart),
+		procStoragePoolCreate:          call((*Store).storagePoolCreate),
+		procStoragePoolBuild:           call((*Store).storagePoolBuild),
+		procStoragePoolRefresh:         call((*Store).storagePoolRefresh),
+		procStoragePoolDestroy:         call((*Store).storagePoolDestroy),
+		procStoragePoolDelete:          call((*Store).storagePoolDelete),
+		procStoragePoolUndefine:        call((*Store).storagePoolUndefine),
+		procStoragePoolListAllVolumes:  call((*Store).storagePoolListAllVolumes),
+
+		procStorageVolCreateXML:     call((*Store).storageVolCreateXML),
+		procStorageVolCreateXMLFrom: call((*Store).storageVolCreateXMLFrom),
+		procStorageVolLookupByName:  call((*Store).storageVolLookupByName),
+		procStorageVolLookupByKey:   call((*Store).storageVolLookupByKey),
+		procStorageVolLookupByPath:  call((*Store).storageVolLookupByPath),
+		procStorageVolGetXMLDesc:    call((*Store).storageVolGetXMLDesc),
+		procStorageVolGetInfo:       call((*Store).storageVolGetInfo),
+		procStorageVolDelete:        call((*Store).storageVolDelete),
+		procStorageVolWipe:          call((*Store).storageVolWipe),
+		procStorageVolUpload:        storageVolUpload,
+	}
+}
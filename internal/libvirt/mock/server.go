@@ -0,0 +1,277 @@
+// Package mock implements an in-process fake libvirt daemon speaking the libvirt
+// remote RPC protocol.
+//
+// It covers the procedures the provider uses for domains, networks, storage pools and
+// volumes (define, lookup, XML description, lifecycle and volume uploads), so that
+// configurations and acceptance tests can run without libvirtd. Domains do not run
+// anything: lifecycle calls only change the recorded state, and the console of a
+// running domain replays a fixed boot log ending in a login prompt. Unknown procedures are
+// rejected the way libvirtd rejects them, which go-libvirt reports as ErrUnsupported.
+package mock
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/digitalocean/go-libvirt"
+)
+
+const (
+	program         = 0x20008086
+	protocolVersion = 1
+
+	packetHeaderSize = 28 // length + program + version + procedure + type + serial + status
+
+	packetCall   = 0
+	packetReply  = 1
+	packetStream = 3
+
+	statusOK       = 0
+	statusError    = 1
+	statusContinue = 2
+
+	// maxPacketSize mirrors libvirt's VIR_NET_MESSAGE_MAX plus header.
+	maxPacketSize = 32 * 1024 * 1024
+)
+
+// Server serves the remote protocol on top of a Store.
+type Server struct {
+	store *Store
+}
+
+// NewServer returns a server backed by store.
+func NewServer(store *Store) *Server {
+	return &Server{store: store}
+}
+
+// Dial returns the client end of an in-process connection served by s.
+func (s *Server) Dial() (net.Conn, error) {
+	client, server := net.Pipe()
+	go s.Serve(server)
+	return client, nil
+}
+
+// Serve handles requests on conn until it is closed.
+func (s *Server) Serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	sess := &session{
+		server:  s,
+		writer:  bufio.NewWriter(conn),
+		uploads: map[int32]*upload{},
+	}
+
+	reader := bufio.NewReader(conn)
+	for {
+		header, payload, err := readPacket(reader)
+		if err != nil {
+			return
+		}
+
+		switch header.Type {
+		case packetCall:
+			if closed := sess.call(header, payload); closed {
+				return
+			}
+		case packetStream:
+			sess.stream(header, payload)
+		}
+	}
+}
+
+type packetHeader struct {
+	Program   uint32
+	Version   uint32
+	Procedure uint32
+	Type      uint32
+	Serial    int32
+	Status    uint32
+}
+
+func readPacket(r io.Reader) (packetHeader, []byte, error) {
+	var length uint32
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return packetHeader{}, nil, err
+	}
+	if length < packetHeaderSize || length > maxPacketSize {
+		return packetHeader{}, nil, fmt.Errorf("invalid packet length %d", length)
+	}
+
+	var header packetHeader
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return packetHeader{}, nil, err
+	}
+
+	payload := make([]byte, length-packetHeaderSize)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return packetHeader{}, nil, err
+	}
+	return header, payload, nil
+}
+
+// session is the per-connection state.
+type session struct {
+	server *Server
+
+	mu     sync.Mutex
+	writer *bufio.Writer
+
+	uploads map[int32]*upload
+	// pending is set by a handler that accepts an upload stream for the current call.
+	pending *upload
+	// console is set by a handler that opens a console stream for the current call. It
+	// is sent to the client after the reply, followed by the end of the stream.
+	console []byte
+}
+
+// upload collects the data of a StorageVolUpload stream.
+type upload struct {
+	size   uint64
+	finish func(size uint64) error
+}
+
+// rpcError is an error reported to the client as a libvirt error.
+type rpcError struct {
+	code    libvirt.ErrorNumber
+	message string
+}
+
+func (e *rpcError) Error() string {
+	return e.message
+}
+
+func errorf(code libvirt.ErrorNumber, format string, args ...any) error {
+	return &rpcError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// remoteError is libvirt's remote_error.
+type remoteError struct {
+	Code    int32
+	Domain  int32
+	Message libvirt.OptString
+	Level   int32
+	Dom     libvirt.OptDomain
+	Str1    libvirt.OptString
+	Str2    libvirt.OptString
+	Str3    libvirt.OptString
+	Int1    int32
+	Int2    int32
+	Net     libvirt.OptNetwork
+}
+
+// errorDomainRemote is VIR_FROM_REMOTE, used for every error the mock reports.
+const errorDomainRemote = 13
+
+func (sess *session) call(header packetHeader, payload []byte) bool {
+	if header.Program != program {
+		sess.replyError(header, errorf(libvirt.ErrNoSupport, "unknown program: %#x", header.Program))
+		return false
+	}
+
+	handler, ok := procedures[header.Procedure]
+	if !ok {
+		sess.replyError(header, errorf(libvirt.ErrNoSupport, "unknown procedure: %d", header.Procedure))
+		return false
+	}
+
+	sess.pending = nil
+	sess.console = nil
+	ret, err := handler(sess, payload)
+	if err != nil {
+		sess.replyError(header, err)
+		return false
+	}
+
+	var body []byte
+	if ret != nil {
+		body, err = encodeXDR(ret)
+		if err != nil {
+			sess.replyError(header, errorf(libvirt.ErrInternalError, "failed to encode reply: %s", err))
+			return false
+		}
+	}
+
+	if sess.pending != nil {
+		sess.uploads[header.Serial] = sess.pending
+		sess.pending = nil
+	}
+
+	sess.send(header, packetReply, statusOK, body)
+
+	if sess.console != nil {
+		sess.send(header, packetStream, statusContinue, sess.console)
+		sess.send(header, packetStream, statusOK, nil)
+		sess.console = nil
+	}
+	return header.Procedure == procConnectClose
+}
+
+// stream consumes a packet of an upload stream. The final packet is confirmed with an
+// empty stream packet, which go-libvirt waits for before returning from the call.
+func (sess *session) stream(header packetHeader, payload []byte) {
+	up, ok := sess.uploads[header.Serial]
+	if !ok {
+		return
+	}
+
+	switch header.Status {
+	case statusContinue:
+		up.size += uint64(len(payload))
+	case statusOK:
+		delete(sess.uploads, header.Serial)
+		if err := up.finish(up.size); err != nil {
+			sess.replyError(header, err)
+			return
+		}
+		sess.send(header, packetStream, statusOK, nil)
+	default:
+		delete(sess.uploads, header.Serial)
+	}
+}
+
+func (sess *session) replyError(header packetHeader, err error) {
+	var rerr *rpcError
+	if !errors.As(err, &rerr) {
+		rerr = &rpcError{code: libvirt.ErrInternalError, message: err.Error()}
+	}
+
+	body, encErr := encodeXDR(&remoteError{
+		Code:    int32(rerr.code),
+		Domain:  errorDomainRemote,
+		Message: libvirt.OptString{rerr.message},
+		Level:   2,
+	})
+	if encErr != nil {
+		return
+	}
+
+	typ := uint32(packetReply)
+	if header.Type == packetStream {
+		typ = packetStream
+	}
+	sess.send(header, typ, statusError, body)
+}
+
+func (sess *session) send(header packetHeader, typ uint32, status uint32, body []byte) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	reply := packetHeader{
+		Program:   header.Program,
+		Version:   protocolVersion,
+		Procedure: header.Procedure,
+		Type:      typ,
+		Serial:    header.Serial,
+		Status:    status,
+	}
+
+	_ = binary.Write(sess.writer, binary.BigEndian, uint32(packetHeaderSize+len(body)))
+	_ = binary.Write(sess.writer, binary.BigEndian, reply)
+	_, _ = sess.writer.Write(body)
+	_ = sess.writer.Flush()
+}
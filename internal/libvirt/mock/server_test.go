@@ -0,0 +1,202 @@
+package mock
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/go-libvirt"
+	"libvirt.org/go/libvirtxml"
+)
+
+func connect(t *testing.T, store *Store) *libvirt.Libvirt {
+	t.Helper()
+
+	conn, err := NewServer(store).Dial()
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+
+	//nolint:staticcheck // the in-process pipe needs no dialer
+	l := libvirt.New(conn)
+	if err := l.ConnectToURI("mock:///test"); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Disconnect() })
+	return l
+}
+
+func TestDomainLifecycle(t *testing.T) {
+	l := connect(t, newSeededStore(""))
+
+	dom, err := l.DomainDefineXML(`<domain type="kvm"><name>vm</name><memory unit="MiB">512</memory>` +
+		`<vcpu>2</vcpu><devices><interface type="network"><source network="default"/></interface></devices></domain>`)
+	if err != nil {
+		t.Fatalf("define failed: %v", err)
+	}
+	if dom.ID != -1 {
+		t.Errorf("expected inactive domain ID -1, got %d", dom.ID)
+	}
+
+	byName, err := l.DomainLookupByName("vm")
+	if err != nil {
+		t.Fatalf("lookup by name failed: %v", err)
+	}
+	if byName.UUID != dom.UUID {
+		t.Errorf("lookup returned a different UUID")
+	}
+
+	xml, err := l.DomainGetXMLDesc(dom, 0)
+	if err != nil {
+		t.Fatalf("get xml failed: %v", err)
+	}
+	var def libvirtxml.Domain
+	if err := def.Unmarshal(xml); err != nil {
+		t.Fatalf("invalid domain XML: %v", err)
+	}
+	if def.UUID == "" || def.Devices.Interfaces[0].MAC == nil {
+		t.Errorf("expected generated UUID and MAC address, got %s", xml)
+	}
+
+	if _, err := l.DomainCreateWithFlags(dom, 0); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	state, _, err := l.DomainGetState(dom, 0)
+	if err != nil || libvirt.DomainState(state) != libvirt.DomainRunning {
+		t.Fatalf("expected running domain, got state %d (%v)", state, err)
+	}
+
+	_, maxMem, _, vcpus, _, err := l.DomainGetInfo(dom)
+	if err != nil {
+		t.Fatalf("get info failed: %v", err)
+	}
+	if maxMem != 512*1024 || vcpus != 2 {
+		t.Errorf("unexpected info: memory %d KiB, %d vcpus", maxMem, vcpus)
+	}
+
+	ifaces, err := l.DomainInterfaceAddresses(dom, 0, 0)
+	if err != nil || len(ifaces) != 1 || len(ifaces[0].Addrs) != 1 {
+		t.Fatalf("expected one interface address, got %v (%v)", ifaces, err)
+	}
+
+	active, _, err := l.ConnectListAllDomains(1, libvirt.ConnectListDomainsActive)
+	if err != nil || len(active) != 1 {
+		t.Fatalf("expected one active domain, got %v (%v)", active, err)
+	}
+	inactive, _, err := l.ConnectListAllDomains(1, libvirt.ConnectListDomainsInactive)
+	if err != nil || len(inactive) != 0 {
+		t.Fatalf("expected no inactive domains, got %v (%v)", inactive, err)
+	}
+
+	if _, err := l.DomainCreateWithFlags(dom, 0); err == nil {
+		t.Errorf("expected starting a running domain to fail")
+	}
+
+	if err := l.DomainDestroy(dom); err != nil {
+		t.Fatalf("destroy failed: %v", err)
+	}
+	if err := l.DomainUndefineFlags(dom, 0); err != nil {
+		t.Fatalf("undefine failed: %v", err)
+	}
+
+	_, err = l.DomainLookupByUUID(dom.UUID)
+	if !libvirt.IsNotFound(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestVolumeUpload(t *testing.T) {
+	l := connect(t, newSeededStore(""))
+
+	pool, err := l.StoragePoolLookupByName("default")
+	if err != nil {
+		t.Fatalf("lookup default pool failed: %v", err)
+	}
+
+	vol, err := l.StorageVolCreateXML(pool, `<volume><name>disk.img</name><capacity unit="MiB">1</capacity></volume>`, 0)
+	if err != nil {
+		t.Fatalf("create volume failed: %v", err)
+	}
+	if vol.Key != "/var/lib/libvirt/images/disk.img" {
+		t.Errorf("unexpected volume key %q", vol.Key)
+	}
+
+	data := strings.Repeat("x", 4096)
+	if err := l.StorageVolUpload(vol, strings.NewReader(data), 0, uint64(len(data)), 0); err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+
+	_, capacity, allocation, err := l.StorageVolGetInfo(vol)
+	if err != nil {
+		t.Fatalf("get info failed: %v", err)
+	}
+	if capacity != 1024*1024 || allocation != uint64(len(data)) {
+		t.Errorf("unexpected capacity %d and allocation %d", capacity, allocation)
+	}
+
+	byPath, err := l.StorageVolLookupByPath(vol.Key)
+	if err != nil || byPath.Name != "disk.img" {
+		t.Fatalf("lookup by path failed: %v (%v)", byPath, err)
+	}
+
+	if _, err := l.StorageVolCreateXML(pool, `<volume><name>disk.img</name><capacity>1</capacity></volume>`, 0); err == nil {
+		t.Errorf("expected duplicate volume creation to fail")
+	}
+}
+
+func TestUnknownProcedure(t *testing.T) {
+	l := connect(t, newSeededStore(""))
+
+	_, err := l.ConnectGetSysinfo(0)
+	if !errors.Is(err, libvirt.ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestNotFoundErrorCode(t *testing.T) {
+	l := connect(t, newSeededStore(""))
+
+	_, err := l.NetworkLookupByName("missing")
+	var lerr libvirt.Error
+	if !errors.As(err, &lerr) || lerr.Code != uint32(libvirt.ErrNoNetwork) {
+		t.Fatalf("expected ErrNoNetwork, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "Network not found") {
+		t.Errorf("unexpected message %q", err)
+	}
+}
+
+func TestFileStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	store, err := FileStore(path)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	l := connect(t, store)
+	if _, err := l.NetworkDefineXML(`<network><name>test</name></network>`); err != nil {
+		t.Fatalf("define network failed: %v", err)
+	}
+
+	// a fresh process reads the file again
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read state: %v", err)
+	}
+	reloaded := newStore(path)
+	if err := json.Unmarshal(data, reloaded); err != nil {
+		t.Fatalf("failed to parse state: %v", err)
+	}
+	if _, rec := reloaded.networkByName("test"); rec == nil {
+		t.Errorf("expected network to be persisted in %s", path)
+	}
+}
+
+func newSeededStore(path string) *Store {
+	store := newStore(path)
+	store.seed()
+	return store
+}
@@ -0,0 +1,134 @@
+package mock
+
+import (
+	"fmt"
+
+	"github.com/digitalocean/go-libvirt"
+	"libvirt.org/go/libvirtxml"
+)
+
+// connectGetAllDomainStats reports fixed counters derived from the definitions. Like
+// libvirt, only the state and balloon groups are reported for inactive domains.
+func (s *Store) connectGetAllDomainStats(args libvirt.ConnectGetAllDomainStatsArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var uuids []string
+	if len(args.Doms) > 0 {
+		for _, dom := range args.Doms {
+			uuid, _, err := s.domain(dom)
+			if err != nil {
+				return nil, err
+			}
+			uuids = append(uuids, uuid)
+		}
+	} else {
+		flags := libvirt.ConnectGetAllDomainStatsFlags(args.Flags)
+		for _, uuid := range sortedKeys(s.Domains) {
+			rec := s.Domains[uuid]
+			if !matchFlagPair(flags, libvirt.ConnectGetAllDomainsStatsActive, libvirt.ConnectGetAllDomainsStatsInactive, rec.active()) ||
+				!matchFlagPair(flags, libvirt.ConnectGetAllDomainsStatsPersistent, libvirt.ConnectGetAllDomainsStatsTransient, rec.Persistent) {
+				continue
+			}
+			uuids = append(uuids, uuid)
+		}
+	}
+
+	want := func(t libvirt.DomainStatsTypes) bool {
+		return args.Stats == 0 || args.Stats&uint32(t) != 0
+	}
+
+	ret := &libvirt.ConnectGetAllDomainStatsRet{RetStats: []libvirt.DomainStatsRecord{}}
+	for _, uuid := range uuids {
+		rec := s.Domains[uuid]
+
+		var def libvirtxml.Domain
+		if err := def.Unmarshal(rec.XML); err != nil {
+			return nil, errorf(libvirt.ErrInternalError, "internal error: %s", err)
+		}
+
+		var params typedParams
+		if want(libvirt.DomainStatsState) {
+			params.add("state.state", libvirt.NewTypedParamValueInt(rec.State))
+			params.add("state.reason", libvirt.NewTypedParamValueInt(1))
+		}
+		if want(libvirt.DomainStatsBalloon) && def.Memory != nil {
+			maximum := memoryKiB(def.Memory.Value, def.Memory.Unit)
+			current := maximum
+			if def.CurrentMemory != nil {
+				current = memoryKiB(def.CurrentMemory.Value, def.CurrentMemory.Unit)
+			}
+			params.add(libvirt.DomainStatsBalloonCurrent, libvirt.NewTypedParamValueUllong(current))
+			params.add(libvirt.DomainStatsBalloonMaximum, libvirt.NewTypedParamValueUllong(maximum))
+			if rec.active() {
+				params.add(libvirt.DomainStatsBalloonAvailable, libvirt.NewTypedParamValueUllong(current))
+				params.add(libvirt.DomainStatsBalloonUnused, libvirt.NewTypedParamValueUllong(current/2))
+				params.add(libvirt.DomainStatsBalloonUsable, libvirt.NewTypedParamValueUllong(current*3/4))
+				params.add(libvirt.DomainStatsBalloonRss, libvirt.NewTypedParamValueUllong(current/2))
+			}
+		}
+
+		if rec.active() {
+			addActiveDomainStats(&params, &def, want)
+		}
+
+		ret.RetStats = append(ret.RetStats, libvirt.DomainStatsRecord{Dom: domainRef(uuid, rec), Params: params})
+	}
+	return ret, nil
+}
+
+// addActiveDomainStats adds the groups only reported for running domains.
+func addActiveDomainStats(params *typedParams, def *libvirtxml.Domain, want func(libvirt.DomainStatsTypes) bool) {
+	if want(libvirt.DomainStatsCPUTotal) {
+		params.add("cpu.time", libvirt.NewTypedParamValueUllong(1_500_000_000))
+		params.add("cpu.user", libvirt.NewTypedParamValueUllong(1_000_000_000))
+		params.add("cpu.system", libvirt.NewTypedParamValueUllong(500_000_000))
+	}
+
+	if want(libvirt.DomainStatsVCPU) {
+		vcpus := uint32(1)
+		if def.VCPU != nil {
+			vcpus = uint32(def.VCPU.Value)
+		}
+		params.uint(libvirt.DomainStatsVCPUCurrent, vcpus)
+		params.uint(libvirt.DomainStatsVCPUMaximum, vcpus)
+		for i := uint32(0); i < vcpus; i++ {
+			prefix := fmt.Sprintf("vcpu.%d", i)
+			params.add(prefix+libvirt.DomainStatsVCPUSuffixState, libvirt.NewTypedParamValueInt(int32(libvirt.VCPURunning)))
+			params.add(prefix+libvirt.DomainStatsVCPUSuffixTime, libvirt.NewTypedParamValueUllong(1_000_000_000))
+			params.add(prefix+libvirt.DomainStatsVCPUSuffixWait, libvirt.NewTypedParamValueUllong(0))
+		}
+	}
+
+	if def.Devices == nil {
+		return
+	}
+
+	if want(libvirt.DomainStatsInterface) {
+		params.uint("net.count", uint32(len(def.Devices.Interfaces)))
+		for i := range def.Devices.Interfaces {
+			prefix := fmt.Sprintf("net.%d.", i)
+			params.str(prefix+"name", fmt.Sprintf("vnet%d", i))
+			for _, counter := range []string{"rx.bytes", "rx.pkts", "rx.errs", "rx.drop", "tx.bytes", "tx.pkts", "tx.errs", "tx.drop"} {
+				params.add(prefix+counter, libvirt.NewTypedParamValueUllong(0))
+			}
+		}
+	}
+
+	if want(libvirt.DomainStatsBlock) {
+		params.uint(libvirt.DomainStatsBlockCount, uint32(len(def.Devices.Disks)))
+		for i, disk := range def.Devices.Disks {
+			prefix := fmt.Sprintf("block.%d", i)
+			if disk.Target != nil {
+				params.str(prefix+libvirt.DomainStatsBlockSuffixName, disk.Target.Dev)
+			}
+			if disk.Source != nil && disk.Source.File != nil {
+				params.str(prefix+libvirt.DomainStatsBlockSuffixPath, disk.Source.File.File)
+			}
+			params.add(prefix+libvirt.DomainStatsBlockSuffixRdReqs, libvirt.NewTypedParamValueUllong(0))
+			params.add(prefix+libvirt.DomainStatsBlockSuffixRdBytes, libvirt.NewTypedParamValueUllong(0))
+			params.add(prefix+libvirt.DomainStatsBlockSuffixWrReqs, libvirt.NewTypedParamValueUllong(0))
+			params.add(prefix+libvirt.DomainStatsBlockSuffixWrBytes, libvirt.NewTypedParamValueUllong(0))
+		}
+	}
+}
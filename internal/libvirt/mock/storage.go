@@ -0,0 +1,602 @@
+package mock
+
+import (
+	"github.com/digitalocean/go-libvirt"
+	"libvirt.org/go/libvirtxml"
+)
+
+// poolCapacity is the capacity every mock storage pool reports (100 GiB).
+const poolCapacity = 100 * 1024 * 1024 * 1024
+
+func poolRef(uuid string, rec *PoolRecord) libvirt.StoragePool {
+	ref := libvirt.StoragePool{Name: rec.Name}
+	ref.UUID, _ = parseUUID(uuid)
+	return ref
+}
+
+// pool returns the record referenced by pool. The caller must hold s.mu.
+func (s *Store) pool(pool libvirt.StoragePool) (string, *PoolRecord, error) {
+	uuid := uuidString(pool.UUID)
+	rec, ok := s.Pools[uuid]
+	if !ok {
+		return "", nil, errorf(libvirt.ErrNoStoragePool, "Storage pool not found: no storage pool with matching uuid '%s' (%s)", uuid, pool.Name)
+	}
+	return uuid, rec, nil
+}
+
+// activePool is like pool but also requires the pool to be running.
+func (s *Store) activePool(pool libvirt.StoragePool) (string, *PoolRecord, error) {
+	uuid, rec, err := s.pool(pool)
+	if err != nil {
+		return "", nil, err
+	}
+	if !rec.Active {
+		return "", nil, errorf(libvirt.ErrOperationInvalid, "Requested operation is not valid: storage pool '%s' is not active", rec.Name)
+	}
+	return uuid, rec, nil
+}
+
+func (s *Store) poolByName(name string) (string, *PoolRecord) {
+	for _, uuid := range sortedKeys(s.Pools) {
+		if s.Pools[uuid].Name == name {
+			return uuid, s.Pools[uuid]
+		}
+	}
+	return "", nil
+}
+
+// poolPath returns the target path of a pool, where its volumes live.
+func poolPath(rec *PoolRecord) string {
+	var def libvirtxml.StoragePool
+	if err := def.Unmarshal(rec.XML); err != nil || def.Target == nil {
+		return ""
+	}
+	return def.Target.Path
+}
+
+func poolAllocation(rec *PoolRecord) uint64 {
+	var total uint64
+	for _, vol := range rec.Volumes {
+		total += vol.Allocation
+	}
+	return total
+}
+
+func (s *Store) listAllStoragePools(args libvirt.ConnectListAllStoragePoolsArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	flags := args.Flags
+	ret := &libvirt.ConnectListAllStoragePoolsRet{Pools: []libvirt.StoragePool{}}
+	for _, uuid := range sortedKeys(s.Pools) {
+		rec := s.Pools[uuid]
+		if !matchFlagPair(flags, libvirt.ConnectListStoragePoolsActive, libvirt.ConnectListStoragePoolsInactive, rec.Active) ||
+			!matchFlagPair(flags, libvirt.ConnectListStoragePoolsPersistent, libvirt.ConnectListStoragePoolsTransient, true) ||
+			!matchFlagPair(flags, libvirt.ConnectListStoragePoolsAutostart, libvirt.ConnectListStoragePoolsNoAutostart, rec.Autostart) {
+			continue
+		}
+		ret.Pools = append(ret.Pools, poolRef(uuid, rec))
+	}
+	ret.Ret = uint32(len(ret.Pools))
+	return ret, nil
+}
+
+func (s *Store) storagePoolDefineXML(args libvirt.StoragePoolDefineXMLArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var def libvirtxml.StoragePool
+	if err := def.Unmarshal(args.XML); err != nil {
+		return nil, errorf(libvirt.ErrXMLError, "XML error: %s", err)
+	}
+	if def.Name == "" {
+		return nil, errorf(libvirt.ErrXMLError, "XML error: missing pool source name element")
+	}
+
+	existingUUID, existing := s.poolByName(def.Name)
+	if def.UUID == "" {
+		if existing != nil {
+			def.UUID = existingUUID
+		} else {
+			def.UUID = uuidString(newUUID())
+		}
+	}
+	uuid, err := parseUUID(def.UUID)
+	if err != nil {
+		return nil, errorf(libvirt.ErrXMLError, "XML error: %s", err)
+	}
+	def.UUID = uuidString(uuid)
+
+	if existing != nil && existingUUID != def.UUID {
+		return nil, errorf(libvirt.ErrOperationFailed, "operation failed: pool '%s' already exists with uuid %s", def.Name, existingUUID)
+	}
+	if rec, ok := s.Pools[def.UUID]; ok && rec.Name != def.Name {
+		return nil, errorf(libvirt.ErrOperationFailed, "operation failed: pool '%s' already exists with uuid %s", rec.Name, def.UUID)
+	}
+
+	// Capacity figures are reported by the host, not stored in the definition.
+	def.Capacity = nil
+	def.Allocation = nil
+	def.Available = nil
+
+	xml, err := def.Marshal()
+	if err != nil {
+		return nil, errorf(libvirt.ErrXMLError, "XML error: %s", err)
+	}
+
+	rec, ok := s.Pools[def.UUID]
+	if !ok {
+		rec = &PoolRecord{Name: def.Name, Volumes: map[string]*VolumeRecord{}}
+		s.Pools[def.UUID] = rec
+	}
+	rec.XML = xml
+
+	return &libvirt.StoragePoolDefineXMLRet{Pool: poolRef(def.UUID, rec)}, s.save()
+}
+
+func (s *Store) storagePoolLookupByName(args libvirt.StoragePoolLookupByNameArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uuid, rec := s.poolByName(args.Name)
+	if rec == nil {
+		return nil, errorf(libvirt.ErrNoStoragePool, "Storage pool not found: no storage pool with matching name '%s'", args.Name)
+	}
+	return &libvirt.StoragePoolLookupByNameRet{Pool: poolRef(uuid, rec)}, nil
+}
+
+func (s *Store) storagePoolLookupByUUID(args libvirt.StoragePoolLookupByUUIDArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uuid := uuidString(args.UUID)
+	rec, ok := s.Pools[uuid]
+	if !ok {
+		return nil, errorf(libvirt.ErrNoStoragePool, "Storage pool not found: no storage pool with matching uuid '%s'", uuid)
+	}
+	return &libvirt.StoragePoolLookupByUUIDRet{Pool: poolRef(uuid, rec)}, nil
+}
+
+func (s *Store) storagePoolLookupByVolume(args libvirt.StoragePoolLookupByVolumeArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uuid, rec := s.poolByName(args.Vol.Pool)
+	if rec == nil {
+		return nil, errorf(libvirt.ErrNoStoragePool, "Storage pool not found: no storage pool with matching name '%s'", args.Vol.Pool)
+	}
+	return &libvirt.StoragePoolLookupByVolumeRet{Pool: poolRef(uuid, rec)}, nil
+}
+
+func (s *Store) storagePoolGetXMLDesc(args libvirt.StoragePoolGetXMLDescArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.pool(args.Pool)
+	if err != nil {
+		return nil, err
+	}
+
+	var def libvirtxml.StoragePool
+	if err := def.Unmarshal(rec.XML); err != nil {
+		return nil, errorf(libvirt.ErrInternalError, "internal error: %s", err)
+	}
+	if args.Flags&libvirt.StorageXMLInactive == 0 {
+		allocation := poolAllocation(rec)
+		def.Capacity = &libvirtxml.StoragePoolSize{Unit: "bytes", Value: poolCapacity}
+		def.Allocation = &libvirtxml.StoragePoolSize{Unit: "bytes", Value: allocation}
+		def.Available = &libvirtxml.StoragePoolSize{Unit: "bytes", Value: poolCapacity - allocation}
+	}
+
+	xml, err := def.Marshal()
+	if err != nil {
+		return nil, errorf(libvirt.ErrInternalError, "internal error: %s", err)
+	}
+	return &libvirt.StoragePoolGetXMLDescRet{XML: xml}, nil
+}
+
+func (s *Store) storagePoolGetInfo(args libvirt.StoragePoolGetInfoArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.pool(args.Pool)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &libvirt.StoragePoolGetInfoRet{State: uint8(libvirt.StoragePoolInactive)}
+	if rec.Active {
+		allocation := poolAllocation(rec)
+		ret.State = uint8(libvirt.StoragePoolRunning)
+		ret.Capacity = poolCapacity
+		ret.Allocation = allocation
+		ret.Available = poolCapacity - allocation
+	}
+	return ret, nil
+}
+
+func (s *Store) storagePoolIsActive(args libvirt.StoragePoolIsActiveArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.pool(args.Pool)
+	if err != nil {
+		return nil, err
+	}
+	return &libvirt.StoragePoolIsActiveRet{Active: boolInt(rec.Active)}, nil
+}
+
+func (s *Store) storagePoolGetAutostart(args libvirt.StoragePoolGetAutostartArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.pool(args.Pool)
+	if err != nil {
+		return nil, err
+	}
+	return &libvirt.StoragePoolGetAutostartRet{Autostart: boolInt(rec.Autostart)}, nil
+}
+
+func (s *Store) storagePoolSetAutostart(args libvirt.StoragePoolSetAutostartArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.pool(args.Pool)
+	if err != nil {
+		return nil, err
+	}
+	rec.Autostart = args.Autostart != 0
+	return nil, s.save()
+}
+
+func (s *Store) storagePoolCreate(args libvirt.StoragePoolCreateArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.pool(args.Pool)
+	if err != nil {
+		return nil, err
+	}
+	if rec.Active {
+		return nil, errorf(libvirt.ErrOperationInvalid, "Requested operation is not valid: storage pool '%s' is already active", rec.Name)
+	}
+	rec.Active = true
+	return nil, s.save()
+}
+
+// storagePoolBuild has nothing to prepare on the mock host.
+func (s *Store) storagePoolBuild(args libvirt.StoragePoolBuildArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, _, err := s.pool(args.Pool)
+	return nil, err
+}
+
+func (s *Store) storagePoolRefresh(args libvirt.StoragePoolRefreshArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, _, err := s.activePool(args.Pool)
+	return nil, err
+}
+
+func (s *Store) storagePoolDestroy(args libvirt.StoragePoolDestroyArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.activePool(args.Pool)
+	if err != nil {
+		return nil, err
+	}
+	rec.Active = false
+	return nil, s.save()
+}
+
+// storagePoolDelete removes the pool's storage, which drops its volumes.
+func (s *Store) storagePoolDelete(args libvirt.StoragePoolDeleteArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.pool(args.Pool)
+	if err != nil {
+		return nil, err
+	}
+	if rec.Active {
+		return nil, errorf(libvirt.ErrOperationInvalid, "Requested operation is not valid: storage pool '%s' is still active", rec.Name)
+	}
+	rec.Volumes = map[string]*VolumeRecord{}
+	return nil, s.save()
+}
+
+func (s *Store) storagePoolUndefine(args libvirt.StoragePoolUndefineArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uuid, rec, err := s.pool(args.Pool)
+	if err != nil {
+		return nil, err
+	}
+	if rec.Active {
+		return nil, errorf(libvirt.ErrOperationInvalid, "Requested operation is not valid: storage pool '%s' is still active", rec.Name)
+	}
+	delete(s.Pools, uuid)
+	return nil, s.save()
+}
+
+func (s *Store) storagePoolListAllVolumes(args libvirt.StoragePoolListAllVolumesArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.activePool(args.Pool)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &libvirt.StoragePoolListAllVolumesRet{Vols: []libvirt.StorageVol{}}
+	for _, name := range sortedKeys(rec.Volumes) {
+		ret.Vols = append(ret.Vols, libvirt.StorageVol{Pool: rec.Name, Name: name, Key: rec.Volumes[name].Key})
+	}
+	ret.Ret = uint32(len(ret.Vols))
+	return ret, nil
+}
+
+// volume returns the record referenced by vol and its pool. The caller must hold s.mu.
+func (s *Store) volume(vol libvirt.StorageVol) (*PoolRecord, *VolumeRecord, error) {
+	_, pool := s.poolByName(vol.Pool)
+	if pool != nil {
+		if rec, ok := pool.Volumes[vol.Name]; ok {
+			return pool, rec, nil
+		}
+	}
+	return nil, nil, errorf(libvirt.ErrNoStorageVol, "Storage volume not found: no storage vol with matching name '%s'", vol.Name)
+}
+
+// findVolume returns the volume matching a predicate across all active pools.
+func (s *Store) findVolume(match func(rec *VolumeRecord) bool) (libvirt.StorageVol, bool) {
+	for _, uuid := range sortedKeys(s.Pools) {
+		pool := s.Pools[uuid]
+		if !pool.Active {
+			continue
+		}
+		for _, name := range sortedKeys(pool.Volumes) {
+			if rec := pool.Volumes[name]; match(rec) {
+				return libvirt.StorageVol{Pool: pool.Name, Name: name, Key: rec.Key}, true
+			}
+		}
+	}
+	return libvirt.StorageVol{}, false
+}
+
+// createVolume adds a volume to an active pool. The volume lives at the pool target
+// path and its key is that path, as with libvirt's directory pools.
+func (s *Store) createVolume(pool libvirt.StoragePool, xml string, allocation uint64) (libvirt.StorageVol, error) {
+	_, rec, err := s.activePool(pool)
+	if err != nil {
+		return libvirt.StorageVol{}, err
+	}
+
+	var def libvirtxml.StorageVolume
+	if err := def.Unmarshal(xml); err != nil {
+		return libvirt.StorageVol{}, errorf(libvirt.ErrXMLError, "XML error: %s", err)
+	}
+	if def.Name == "" {
+		return libvirt.StorageVol{}, errorf(libvirt.ErrXMLError, "XML error: missing volume name element")
+	}
+	if def.Capacity == nil {
+		return libvirt.StorageVol{}, errorf(libvirt.ErrXMLError, "XML error: missing capacity element")
+	}
+	if _, exists := rec.Volumes[def.Name]; exists {
+		return libvirt.StorageVol{}, errorf(libvirt.ErrStorageVolExist, "storage volume '%s' exists already", def.Name)
+	}
+
+	path := poolPath(rec) + "/" + def.Name
+	def.Key = path
+	def.Capacity = &libvirtxml.StorageVolumeSize{Unit: "bytes", Value: sizeBytes(def.Capacity.Value, def.Capacity.Unit, "bytes")}
+	def.Allocation = nil
+	def.Physical = nil
+	if def.Target == nil {
+		def.Target = &libvirtxml.StorageVolumeTarget{}
+	}
+	def.Target.Path = path
+	if def.Target.Format == nil || def.Target.Format.Type == "" {
+		def.Target.Format = &libvirtxml.StorageVolumeTargetFormat{Type: "raw"}
+	}
+
+	out, err := def.Marshal()
+	if err != nil {
+		return libvirt.StorageVol{}, errorf(libvirt.ErrXMLError, "XML error: %s", err)
+	}
+
+	rec.Volumes[def.Name] = &VolumeRecord{Key: path, XML: out, Allocation: allocation}
+	return libvirt.StorageVol{Pool: rec.Name, Name: def.Name, Key: path}, s.save()
+}
+
+func (s *Store) storageVolCreateXML(args libvirt.StorageVolCreateXMLArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vol, err := s.createVolume(args.Pool, args.XML, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &libvirt.StorageVolCreateXMLRet{Vol: vol}, nil
+}
+
+// storageVolCreateXMLFrom creates a volume holding a copy of another volume.
+func (s *Store) storageVolCreateXMLFrom(args libvirt.StorageVolCreateXMLFromArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, source, err := s.volume(args.Clonevol)
+	if err != nil {
+		return nil, err
+	}
+
+	vol, err := s.createVolume(args.Pool, args.XML, source.Allocation)
+	if err != nil {
+		return nil, err
+	}
+	return &libvirt.StorageVolCreateXMLFromRet{Vol: vol}, nil
+}
+
+func (s *Store) storageVolLookupByName(args libvirt.StorageVolLookupByNameArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, pool, err := s.activePool(args.Pool)
+	if err != nil {
+		return nil, err
+	}
+	rec, ok := pool.Volumes[args.Name]
+	if !ok {
+		return nil, errorf(libvirt.ErrNoStorageVol, "Storage volume not found: no storage vol with matching name '%s'", args.Name)
+	}
+	return &libvirt.StorageVolLookupByNameRet{Vol: libvirt.StorageVol{Pool: pool.Name, Name: args.Name, Key: rec.Key}}, nil
+}
+
+func (s *Store) storageVolLookupByKey(args libvirt.StorageVolLookupByKeyArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vol, ok := s.findVolume(func(rec *VolumeRecord) bool { return rec.Key == args.Key })
+	if !ok {
+		return nil, errorf(libvirt.ErrNoStorageVol, "Storage volume not found: no storage vol with matching key %s", args.Key)
+	}
+	return &libvirt.StorageVolLookupByKeyRet{Vol: vol}, nil
+}
+
+// Volume keys are their paths on the mock host.
+func (s *Store) storageVolLookupByPath(args libvirt.StorageVolLookupByPathArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vol, ok := s.findVolume(func(rec *VolumeRecord) bool { return rec.Key == args.Path })
+	if !ok {
+		return nil, errorf(libvirt.ErrNoStorageVol, "Storage volume not found: no storage vol with matching path '%s'", args.Path)
+	}
+	return &libvirt.StorageVolLookupByPathRet{Vol: vol}, nil
+}
+
+func (s *Store) storageVolGetXMLDesc(args libvirt.StorageVolGetXMLDescArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.volume(args.Vol)
+	if err != nil {
+		return nil, err
+	}
+
+	var def libvirtxml.StorageVolume
+	if err := def.Unmarshal(rec.XML); err != nil {
+		return nil, errorf(libvirt.ErrInternalError, "internal error: %s", err)
+	}
+	def.Allocation = &libvirtxml.StorageVolumeSize{Unit: "bytes", Value: rec.Allocation}
+
+	xml, err := def.Marshal()
+	if err != nil {
+		return nil, errorf(libvirt.ErrInternalError, "internal error: %s", err)
+	}
+	return &libvirt.StorageVolGetXMLDescRet{XML: xml}, nil
+}
+
+func (s *Store) storageVolGetInfo(args libvirt.StorageVolGetInfoArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rec, err := s.volume(args.Vol)
+	if err != nil {
+		return nil, err
+	}
+
+	var def libvirtxml.StorageVolume
+	if err := def.Unmarshal(rec.XML); err != nil {
+		return nil, errorf(libvirt.ErrInternalError, "internal error: %s", err)
+	}
+
+	ret := &libvirt.StorageVolGetInfoRet{Type: int8(libvirt.StorageVolFile), Allocation: rec.Allocation}
+	if def.Capacity != nil {
+		ret.Capacity = def.Capacity.Value
+	}
+	return ret, nil
+}
+
+func (s *Store) storageVolDelete(args libvirt.StorageVolDeleteArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pool, _, err := s.volume(args.Vol)
+	if err != nil {
+		return nil, err
+	}
+	delete(pool.Volumes, args.Vol.Name)
+	return nil, s.save()
+}
+
+func (s *Store) storageVolWipe(args libvirt.StorageVolWipeArgs) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, _, err := s.volume(args.Vol)
+	return nil, err
+}
+
+// storageVolUpload accepts the upload stream that follows the call. The volume
+// allocation grows to cover the uploaded range once the stream completes.
+func storageVolUpload(sess *session, payload []byte) (any, error) {
+	var args libvirt.StorageVolUploadArgs
+	if err := decodeXDR(payload, &args); err != nil {
+		return nil, errorf(libvirt.ErrRPC, "failed to decode arguments: %s", err)
+	}
+
+	s := sess.server.store
+	s.mu.Lock()
+	_, _, err := s.volume(args.Vol)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	sess.pending = &upload{
+		finish: func(size uint64) error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			_, rec, err := s.volume(args.Vol)
+			if err != nil {
+				return err
+			}
+			rec.Allocation = max(rec.Allocation, args.Offset+size)
+			return s.save()
+		},
+	}
+	return nil, nil
+}
+
+// sizeBytes converts a libvirt scaled integer to bytes, using defaultUnit when the unit
+// is omitted.
+func sizeBytes(value uint64, unit, defaultUnit string) uint64 {
+	if unit == "" {
+		unit = defaultUnit
+	}
+	switch unit {
+	case "KB":
+		return value * 1000
+	case "k", "K", "KiB":
+		return value * 1024
+	case "MB":
+		return value * 1000 * 1000
+	case "M", "MiB":
+		return value * 1024 * 1024
+	case "GB":
+		return value * 1000 * 1000 * 1000
+	case "G", "GiB":
+		return value * 1024 * 1024 * 1024
+	case "TB":
+		return value * 1000 * 1000 * 1000 * 1000
+	case "T", "TiB":
+		return value * 1024 * 1024 * 1024 * 1024
+	default:
+		return value
+	}
+}
@@ -0,0 +1,226 @@
+package mock
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/digitalocean/go-libvirt"
+)
+
+// Store holds the objects of a mock libvirt host. A store is either kept in memory for
+// the lifetime of the process or persisted to a JSON file after every change, so that
+// separate terraform runs share the same host.
+type Store struct {
+	mu   sync.Mutex
+	path string
+
+	Domains      map[string]*DomainRecord  `json:"domains"`
+	Networks     map[string]*NetworkRecord `json:"networks"`
+	Pools        map[string]*PoolRecord    `json:"pools"`
+	NextDomainID int32                     `json:"next_domain_id"`
+}
+
+// DomainRecord is a domain known to the mock host, keyed by UUID in the store.
+type DomainRecord struct {
+	Name       string `json:"name"`
+	XML        string `json:"xml"`
+	ID         int32  `json:"id"`
+	State      int32  `json:"state"`
+	Autostart  bool   `json:"autostart"`
+	Persistent bool   `json:"persistent"`
+}
+
+// NetworkRecord is a network known to the mock host, keyed by UUID in the store.
+type NetworkRecord struct {
+	Name      string `json:"name"`
+	XML       string `json:"xml"`
+	Active    bool   `json:"active"`
+	Autostart bool   `json:"autostart"`
+}
+
+// PoolRecord is a storage pool known to the mock host, keyed by UUID in the store.
+type PoolRecord struct {
+	Name      string                   `json:"name"`
+	XML       string                   `json:"xml"`
+	Active    bool                     `json:"active"`
+	Autostart bool                     `json:"autostart"`
+	Volumes   map[string]*VolumeRecord `json:"volumes"`
+}
+
+// VolumeRecord is a storage volume, keyed by name in its pool. Uploaded content is not
+// kept; only its size is recorded as the volume allocation.
+type VolumeRecord struct {
+	Key        string `json:"key"`
+	XML        string `json:"xml"`
+	Allocation uint64 `json:"allocation"`
+}
+
+var (
+	storesMu sync.Mutex
+	stores   = map[string]*Store{}
+)
+
+// MemoryStore returns the in-memory store with the given name, creating and seeding it
+// on first use. Every connection in the process using the same name shares the store.
+func MemoryStore(name string) *Store {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+
+	key := "memory:" + name
+	store, ok := stores[key]
+	if !ok {
+		store = newStore("")
+		store.seed()
+		stores[key] = store
+	}
+	return store
+}
+
+// FileStore returns the store persisted to the JSON file at path. The file is read on
+// first use in the process; a missing file is created with the default seed objects.
+func FileStore(path string) (*Store, error) {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+
+	key := "file:" + path
+	if store, ok := stores[key]; ok {
+		return store, nil
+	}
+
+	store := newStore(path)
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		store.seed()
+		if err := store.save(); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to read mock state: %w", err)
+	default:
+		if err := json.Unmarshal(data, store); err != nil {
+			return nil, fmt.Errorf("failed to parse mock state %s: %w", path, err)
+		}
+		store.ensureMaps()
+	}
+
+	stores[key] = store
+	return store, nil
+}
+
+func newStore(path string) *Store {
+	store := &Store{path: path, NextDomainID: 1}
+	store.ensureMaps()
+	return store
+}
+
+func (s *Store) ensureMaps() {
+	if s.Domains == nil {
+		s.Domains = map[string]*DomainRecord{}
+	}
+	if s.Networks == nil {
+		s.Networks = map[string]*NetworkRecord{}
+	}
+	if s.Pools == nil {
+		s.Pools = map[string]*PoolRecord{}
+	}
+	for _, pool := range s.Pools {
+		if pool.Volumes == nil {
+			pool.Volumes = map[string]*VolumeRecord{}
+		}
+	}
+	if s.NextDomainID < 1 {
+		s.NextDomainID = 1
+	}
+}
+
+// seed adds the objects a freshly installed libvirt host usually has: an active
+// "default" directory pool and an active "default" NAT network.
+func (s *Store) seed() {
+	poolUUID := newUUID()
+	s.Pools[uuidString(poolUUID)] = &PoolRecord{
+		Name: "default",
+		XML: fmt.Sprintf(`<pool type="dir"><name>default</name><uuid>%s</uuid>`+
+			`<target><path>/var/lib/libvirt/images</path></target></pool>`, uuidString(poolUUID)),
+		Active:    true,
+		Autostart: true,
+		Volumes:   map[string]*VolumeRecord{},
+	}
+
+	networkUUID := newUUID()
+	s.Networks[uuidString(networkUUID)] = &NetworkRecord{
+		Name: "default",
+		XML: fmt.Sprintf(`<network><name>default</name><uuid>%s</uuid><forward mode="nat"/>`+
+			`<bridge name="virbr0" stp="on" delay="0"/><ip address="192.168.122.1" netmask="255.255.255.0">`+
+			`<dhcp><range start="192.168.122.2" end="192.168.122.254"/></dhcp></ip></network>`, uuidString(networkUUID)),
+		Active:    true,
+		Autostart: true,
+	}
+}
+
+// save persists file-backed stores. The caller must hold s.mu.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode mock state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".libvirt-mock-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write mock state: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write mock state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write mock state: %w", err)
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// sortedKeys returns the keys of m in a stable order so listings are deterministic.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func newUUID() libvirt.UUID {
+	var uuid libvirt.UUID
+	_, _ = rand.Read(uuid[:])
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+	return uuid
+}
+
+func uuidString(uuid libvirt.UUID) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
+}
+
+func parseUUID(s string) (libvirt.UUID, error) {
+	var uuid libvirt.UUID
+	data, err := hex.DecodeString(strings.ReplaceAll(s, "-", ""))
+	if err != nil || len(data) != len(uuid) {
+		return uuid, fmt.Errorf("invalid UUID '%s'", s)
+	}
+	copy(uuid[:], data)
+	return uuid, nil
+}
@@ -0,0 +1,270 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+)
+
+// The remote protocol payloads are XDR encoded with the same rules as the go-xdr
+// fork vendored by go-libvirt, which is not importable. Only the subset needed to
+// exchange the exported *Args and *Ret structs of go-libvirt is implemented:
+// integers of any width are sent as 32-bit words (64-bit ones as hypers), byte
+// slices and arrays as opaque data, and structs field by field.
+
+// encodeXDR returns the XDR encoding of v.
+func encodeXDR(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if v == nil {
+		return nil, nil
+	}
+	if err := encodeValue(&buf, reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeXDR decodes data into the value pointed to by v.
+func decodeXDR(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("xdr: decode target must be a non-nil pointer")
+	}
+	return decodeValue(bytes.NewReader(data), rv.Elem())
+}
+
+func encodeValue(w *bytes.Buffer, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return fmt.Errorf("xdr: cannot encode nil %s", v.Type())
+		}
+		return encodeValue(w, v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			writeUint32(w, 1)
+		} else {
+			writeUint32(w, 0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		writeUint32(w, uint32(int32(v.Int())))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		writeUint32(w, uint32(v.Uint()))
+	case reflect.Int64:
+		writeUint64(w, uint64(v.Int()))
+	case reflect.Uint64:
+		writeUint64(w, v.Uint())
+	case reflect.Float32:
+		writeUint32(w, math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		writeUint64(w, math.Float64bits(v.Float()))
+	case reflect.String:
+		writeOpaque(w, []byte(v.String()), true)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			writeOpaque(w, v.Bytes(), true)
+			return nil
+		}
+		writeUint32(w, uint32(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			if err := encodeValue(w, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			data := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(data), v)
+			writeOpaque(w, data, false)
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := encodeValue(w, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			if err := encodeValue(w, v.Field(i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("xdr: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func decodeValue(r *bytes.Reader, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeValue(r, v.Elem())
+	case reflect.Bool:
+		n, err := readUint32(r)
+		if err != nil {
+			return err
+		}
+		v.SetBool(n != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		n, err := readUint32(r)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(int32(n)))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		n, err := readUint32(r)
+		if err != nil {
+			return err
+		}
+		v.SetUint(uint64(n))
+	case reflect.Int64:
+		n, err := readUint64(r)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case reflect.Uint64:
+		n, err := readUint64(r)
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32:
+		n, err := readUint32(r)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(float64(math.Float32frombits(n)))
+	case reflect.Float64:
+		n, err := readUint64(r)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(math.Float64frombits(n))
+	case reflect.String:
+		data, err := readOpaque(r, -1)
+		if err != nil {
+			return err
+		}
+		v.SetString(string(data))
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			data, err := readOpaque(r, -1)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(data)
+			return nil
+		}
+		n, err := readUint32(r)
+		if err != nil {
+			return err
+		}
+		if int(n) > r.Len() {
+			return fmt.Errorf("xdr: array length %d exceeds remaining data", n)
+		}
+		slice := reflect.MakeSlice(v.Type(), int(n), int(n))
+		for i := 0; i < int(n); i++ {
+			if err := decodeValue(r, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			data, err := readOpaque(r, v.Len())
+			if err != nil {
+				return err
+			}
+			reflect.Copy(v, reflect.ValueOf(data))
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := decodeValue(r, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			if err := decodeValue(r, v.Field(i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("xdr: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func writeUint32(w *bytes.Buffer, n uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], n)
+	w.Write(b[:])
+}
+
+func writeUint64(w *bytes.Buffer, n uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], n)
+	w.Write(b[:])
+}
+
+// writeOpaque writes opaque data padded to a multiple of four bytes, preceded by
+// its length when variable is set.
+func writeOpaque(w *bytes.Buffer, data []byte, variable bool) {
+	if variable {
+		writeUint32(w, uint32(len(data)))
+	}
+	w.Write(data)
+	if pad := (4 - len(data)%4) % 4; pad > 0 {
+		w.Write(make([]byte, pad))
+	}
+}
+
+func readUint32(r *bytes.Reader) (uint32, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, fmt.Errorf("xdr: %w", err)
+	}
+	return binary.BigEndian.Uint32(b[:]), nil
+}
+
+func readUint64(r *bytes.Reader) (uint64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, fmt.Errorf("xdr: %w", err)
+	}
+	return binary.BigEndian.Uint64(b[:]), nil
+}
+
+// readOpaque reads opaque data of the given fixed size, or of the size given by a
+// length prefix when size is negative.
+func readOpaque(r *bytes.Reader, size int) ([]byte, error) {
+	if size < 0 {
+		n, err := readUint32(r)
+		if err != nil {
+			return nil, err
+		}
+		if int(n) > r.Len() {
+			return nil, fmt.Errorf("xdr: opaque length %d exceeds remaining data", n)
+		}
+		size = int(n)
+	}
+
+	data := make([]byte, size+(4-size%4)%4)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("xdr: %w", err)
+	}
+	return data[:size], nil
+}
@@ -0,0 +1,329 @@
+// Package osinfo reads the operating system descriptions of the osinfo database
+// (osinfo-db), the data virt-install and virt-manager use to pick devices and resources
+// that suit a guest.
+//
+// Only the parts needed to choose domain defaults are read: short IDs, family, supported
+// devices, resources and firmware. Devices, resources and firmware are inherited through
+// derives-from and clones links the same way libosinfo does.
+package osinfo
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Device IDs of the PCI devices used to pick disk buses and network models.
+const (
+	DeviceVirtioBlockLegacy = "http://pcisig.com/pci/1af4/1001"
+	DeviceVirtioBlock       = "http://pcisig.com/pci/1af4/1042"
+	DeviceVirtioNetLegacy   = "http://pcisig.com/pci/1af4/1000"
+	DeviceVirtioNet         = "http://pcisig.com/pci/1af4/1041"
+	DeviceE1000e            = "http://pcisig.com/pci/8086/10d3"
+	DeviceE1000             = "http://pcisig.com/pci/8086/100e"
+	DeviceRTL8139           = "http://pcisig.com/pci/10ec/8139"
+)
+
+// DefaultDirs returns the database directories libosinfo reads, in increasing order of
+// precedence: the system database, the local and the user directory. They can be
+// overridden with OSINFO_SYSTEM_DIR, OSINFO_LOCAL_DIR and OSINFO_USER_DIR.
+func DefaultDirs() []string {
+	system := os.Getenv("OSINFO_SYSTEM_DIR")
+	if system == "" {
+		system = "/usr/share/osinfo"
+	}
+	local := os.Getenv("OSINFO_LOCAL_DIR")
+	if local == "" {
+		local = "/etc/osinfo"
+	}
+	dirs := []string{system, local}
+
+	if user := os.Getenv("OSINFO_USER_DIR"); user != "" {
+		dirs = append(dirs, user)
+	} else if config, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(config, "osinfo"))
+	}
+	return dirs
+}
+
+// DB is a loaded osinfo database.
+type DB struct {
+	oses     map[string]*OS
+	shortIDs map[string]*OS
+}
+
+// Load reads the operating systems in the os directory of each of dirs. Directories that
+// do not exist are skipped, but at least one must exist. An OS defined again in a later
+// directory replaces the earlier definition.
+func Load(dirs []string) (*DB, error) {
+	db := &DB{oses: map[string]*OS{}, shortIDs: map[string]*OS{}}
+
+	found := false
+	for _, dir := range dirs {
+		osDir := filepath.Join(dir, "os")
+		if _, err := os.Stat(osDir); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		found = true
+
+		err := filepath.WalkDir(osDir, func(file string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || filepath.Ext(file) != ".xml" {
+				return err
+			}
+			return db.loadFile(file)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no osinfo database found in %s: %w", strings.Join(dirs, ", "), fs.ErrNotExist)
+	}
+
+	for _, o := range db.oses {
+		for _, shortID := range o.xml.ShortIDs {
+			db.shortIDs[shortID] = o
+		}
+	}
+	return db, nil
+}
+
+func (db *DB) loadFile(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	var doc struct {
+		OSes []osXML `xml:"os"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parsing %s: %w", file, err)
+	}
+	for i := range doc.OSes {
+		db.oses[doc.OSes[i].ID] = &OS{db: db, xml: doc.OSes[i]}
+	}
+	return nil
+}
+
+// Lookup returns the OS with the given short ID, such as "ubuntu24.04" or "win2k22".
+func (db *DB) Lookup(shortID string) (*OS, bool) {
+	o, ok := db.shortIDs[shortID]
+	return o, ok
+}
+
+// ShortIDs returns the short IDs of all operating systems, sorted.
+func (db *DB) ShortIDs() []string {
+	ids := make([]string, 0, len(db.shortIDs))
+	for id := range db.shortIDs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+type osXML struct {
+	ID          string         `xml:"id,attr"`
+	ShortIDs    []string       `xml:"short-id"`
+	Name        string         `xml:"name"`
+	Family      string         `xml:"family"`
+	Distro      string         `xml:"distro"`
+	DerivesFrom []linkXML      `xml:"derives-from"`
+	Clones      []linkXML      `xml:"clones"`
+	Devices     []deviceXML    `xml:"devices>device"`
+	Resources   []resourcesXML `xml:"resources"`
+	Firmware    []firmwareXML  `xml:"firmware"`
+}
+
+type linkXML struct {
+	ID string `xml:"id,attr"`
+}
+
+type deviceXML struct {
+	ID        string `xml:"id,attr"`
+	Supported string `xml:"supported,attr"`
+}
+
+type resourcesXML struct {
+	Arch        string        `xml:"arch,attr"`
+	Minimum     *resourcesSet `xml:"minimum"`
+	Recommended *resourcesSet `xml:"recommended"`
+}
+
+type resourcesSet struct {
+	CPUs int   `xml:"n-cpus"`
+	RAM  int64 `xml:"ram"`
+}
+
+type firmwareXML struct {
+	Arch      string `xml:"arch,attr"`
+	Type      string `xml:"type,attr"`
+	Supported string `xml:"supported,attr"`
+}
+
+// OS is an operating system of the database.
+type OS struct {
+	db  *DB
+	xml osXML
+}
+
+// ID returns the URI identifying the OS, such as "http://ubuntu.com/ubuntu/24.04".
+func (o *OS) ID() string { return o.xml.ID }
+
+// Name returns the human-readable name of the OS.
+func (o *OS) Name() string { return o.xml.Name }
+
+// IsWindows reports whether the OS belongs to the Windows NT family.
+func (o *OS) IsWindows() bool {
+	return o.xml.Family == "winnt" || o.xml.Distro == "win"
+}
+
+// parents returns the operating systems o inherits from.
+func (o *OS) parents() []*OS {
+	var parents []*OS
+	for _, link := range slices.Concat(o.xml.DerivesFrom, o.xml.Clones) {
+		if parent, ok := o.db.oses[link.ID]; ok && parent != o {
+			parents = append(parents, parent)
+		}
+	}
+	return parents
+}
+
+// Devices returns the IDs of the devices the OS supports, including inherited ones.
+func (o *OS) Devices() []string {
+	devices := map[string]bool{}
+	o.collectDevices(devices, map[*OS]bool{})
+
+	ids := make([]string, 0, len(devices))
+	for id, supported := range devices {
+		if supported {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (o *OS) collectDevices(devices map[string]bool, seen map[*OS]bool) {
+	if seen[o] {
+		return
+	}
+	seen[o] = true
+
+	for _, parent := range o.parents() {
+		parent.collectDevices(devices, seen)
+	}
+	for _, device := range o.xml.Devices {
+		devices[device.ID] = device.Supported != "false"
+	}
+}
+
+// SupportsDevice reports whether the OS supports any of the given devices.
+func (o *OS) SupportsDevice(ids ...string) bool {
+	devices := o.Devices()
+	for _, id := range ids {
+		if _, found := slices.BinarySearch(devices, id); found {
+			return true
+		}
+	}
+	return false
+}
+
+// Resources are the CPU and memory requirements of an OS.
+type Resources struct {
+	// CPUs is the number of virtual CPUs, 0 if unknown
+	CPUs int
+
+	// RAM is the memory in bytes, 0 if unknown
+	RAM int64
+}
+
+// MinimumResources returns the minimum resources for arch, falling back to the values
+// for all architectures and to the OS the values are inherited from.
+func (o *OS) MinimumResources(arch string) Resources {
+	return o.resources(arch, func(r resourcesXML) *resourcesSet { return r.Minimum }, map[*OS]bool{})
+}
+
+// RecommendedResources returns the recommended resources for arch, like MinimumResources.
+func (o *OS) RecommendedResources(arch string) Resources {
+	return o.resources(arch, func(r resourcesXML) *resourcesSet { return r.Recommended }, map[*OS]bool{})
+}
+
+func (o *OS) resources(arch string, set func(resourcesXML) *resourcesSet, seen map[*OS]bool) Resources {
+	var result Resources
+	if seen[o] {
+		return result
+	}
+	seen[o] = true
+
+	for _, wanted := range []string{arch, "all"} {
+		for _, r := range o.xml.Resources {
+			values := set(r)
+			if r.Arch != wanted || values == nil {
+				continue
+			}
+			if result.CPUs == 0 {
+				result.CPUs = values.CPUs
+			}
+			if result.RAM == 0 {
+				result.RAM = values.RAM
+			}
+		}
+	}
+
+	for _, parent := range o.parents() {
+		if result.CPUs != 0 && result.RAM != 0 {
+			break
+		}
+		inherited := parent.resources(arch, set, seen)
+		if result.CPUs == 0 {
+			result.CPUs = inherited.CPUs
+		}
+		if result.RAM == 0 {
+			result.RAM = inherited.RAM
+		}
+	}
+	return result
+}
+
+// FirmwareSupport reports whether the OS supports booting with the firmware type ("bios"
+// or "efi") on arch. known is false when the database says nothing about it.
+func (o *OS) FirmwareSupport(arch, firmwareType string) (supported, known bool) {
+	return o.firmwareSupport(arch, firmwareType, map[*OS]bool{})
+}
+
+func (o *OS) firmwareSupport(arch, firmwareType string, seen map[*OS]bool) (bool, bool) {
+	if seen[o] {
+		return false, false
+	}
+	seen[o] = true
+
+	for _, firmware := range o.xml.Firmware {
+		if firmware.Arch == arch && firmware.Type == firmwareType {
+			return firmware.Supported != "false", true
+		}
+	}
+	for _, parent := range o.parents() {
+		if supported, known := parent.firmwareSupport(arch, firmwareType, seen); known {
+			return supported, true
+		}
+	}
+	return false, false
+}
+
+// RequiresTPM reports whether the OS lists a TPM among its devices, which osinfo-db does
+// for operating systems that do not install without one, such as Windows 11.
+func (o *OS) RequiresTPM() bool {
+	for _, id := range o.Devices() {
+		if strings.Contains(strings.ToLower(id), "tpm") {
+			return true
+		}
+	}
+	return false
+}
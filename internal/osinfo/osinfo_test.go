@@ -0,0 +1,93 @@
+package osinfo
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func loadTestDB(t *testing.T) *DB {
+	t.Helper()
+
+	db, err := Load([]string{filepath.Join(t.TempDir(), "missing"), "testdata"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestLoad(t *testing.T) {
+	db := loadTestDB(t)
+
+	expected := []string{"ubuntu22.04", "ubuntu24.04", "ubuntujammy", "win11", "win2k22"}
+	if ids := db.ShortIDs(); !slices.Equal(ids, expected) {
+		t.Errorf("expected short IDs %v, got %v", expected, ids)
+	}
+
+	jammy, ok := db.Lookup("ubuntujammy")
+	if !ok || jammy.ID() != "http://ubuntu.com/ubuntu/22.04" {
+		t.Errorf("expected the alias to resolve to Ubuntu 22.04, got %v", jammy)
+	}
+	if _, ok := db.Lookup("ubuntu99.04"); ok {
+		t.Error("expected an unknown short ID not to be found")
+	}
+
+	_, err := Load([]string{filepath.Join(t.TempDir(), "missing")})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a not exist error without a database, got %v", err)
+	}
+}
+
+func TestOSInheritance(t *testing.T) {
+	db := loadTestDB(t)
+	noble, _ := db.Lookup("ubuntu24.04")
+
+	if !noble.SupportsDevice(DeviceVirtioBlock) || !noble.SupportsDevice(DeviceVirtioNet) {
+		t.Error("expected the virtio devices to be inherited")
+	}
+	if noble.SupportsDevice(DeviceE1000) {
+		t.Error("expected the unsupported device not to be inherited")
+	}
+
+	if res := noble.MinimumResources("x86_64"); res.CPUs != 2 || res.RAM != 2<<30 {
+		t.Errorf("expected the x86_64 minimum resources, got %+v", res)
+	}
+	if res := noble.MinimumResources("aarch64"); res.CPUs != 1 || res.RAM != 1<<30 {
+		t.Errorf("expected the inherited minimum resources, got %+v", res)
+	}
+	if res := noble.RecommendedResources("x86_64"); res.CPUs != 0 || res.RAM != 4<<30 {
+		t.Errorf("expected the inherited recommended resources, got %+v", res)
+	}
+
+	if supported, known := noble.FirmwareSupport("x86_64", "efi"); !supported || !known {
+		t.Error("expected EFI support to be inherited")
+	}
+	if _, known := noble.FirmwareSupport("x86_64", "bios"); known {
+		t.Error("expected BIOS support to be unknown")
+	}
+	if noble.IsWindows() || noble.RequiresTPM() {
+		t.Error("expected Ubuntu not to be Windows nor to require a TPM")
+	}
+}
+
+func TestOSWindows(t *testing.T) {
+	db := loadTestDB(t)
+	win11, _ := db.Lookup("win11")
+
+	if !win11.IsWindows() || !win11.RequiresTPM() {
+		t.Error("expected Windows 11 to be Windows and to require a TPM")
+	}
+	if !win11.SupportsDevice(DeviceE1000e) {
+		t.Error("expected the devices of the cloned OS to be inherited")
+	}
+	if supported, known := win11.FirmwareSupport("x86_64", "bios"); supported || !known {
+		t.Error("expected BIOS to be unsupported")
+	}
+
+	win2k22, _ := db.Lookup("win2k22")
+	if win2k22.RequiresTPM() {
+		t.Error("expected the TPM of the cloning OS not to be inherited upwards")
+	}
+}
@@ -50,6 +50,7 @@ func (r *CloudInitDiskResource) Metadata(ctx context.Context, req resource.Metad
 // Schema defines the resource schema
 func (r *CloudInitDiskResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Version:     resourceSchemaVersion,
 		Description: "Generates a cloud-init ISO disk image that can be attached to a domain.",
 		MarkdownDescription: `
 Generates a cloud-init configuration disk as an ISO image with the "cidata" volume label.
@@ -157,17 +158,11 @@ func (r *CloudInitDiskResource) Create(ctx context.Context, req resource.CreateR
 	})
 
 	// Create checksum for ID
-	h := sha256.New()
-	h.Write([]byte(userData))
-	h.Write([]byte(metaData))
-	if !model.NetworkConfig.IsNull() {
-		h.Write([]byte(model.NetworkConfig.ValueString()))
-	}
-	checksum := fmt.Sprintf("%x", h.Sum(nil))
-	model.ID = types.StringValue(checksum[:16]) // Use first 16 chars of checksum
+	id := cloudInitDiskID(userData, metaData, model.NetworkConfig)
+	model.ID = types.StringValue(id)
 
 	// Create temp directory for cloud-init ISOs if it doesn't exist
-	tmpDir := filepath.Join(os.TempDir(), "terraform-provider-libvirt-cloudinit")
+	tmpDir := cloudInitDiskDir()
 	if err := os.MkdirAll(tmpDir, 0755); err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to Create Temp Directory",
@@ -177,7 +172,7 @@ func (r *CloudInitDiskResource) Create(ctx context.Context, req resource.CreateR
 	}
 
 	// Generate ISO path using checksum for uniqueness
-	isoPath := filepath.Join(tmpDir, fmt.Sprintf("cloudinit-%s.iso", checksum[:16]))
+	isoPath := cloudInitDiskPath(id)
 
 	// Check if ISO already exists (for idempotency)
 	if _, err := os.Stat(isoPath); err == nil {
@@ -339,3 +334,25 @@ func (r *CloudInitDiskResource) generateCloudInitISO(ctx context.Context, isoPat
 
 	return nil
 }
+
+// cloudInitDiskID returns the first 16 characters of the checksum of the disk content
+func cloudInitDiskID(userData, metaData string, networkConfig types.String) string {
+	h := sha256.New()
+	h.Write([]byte(userData))
+	h.Write([]byte(metaData))
+	if !networkConfig.IsNull() {
+		h.Write([]byte(networkConfig.ValueString()))
+	}
+	checksum := fmt.Sprintf("%x", h.Sum(nil))
+	return checksum[:16]
+}
+
+// cloudInitDiskDir returns the directory where generated ISOs are kept
+func cloudInitDiskDir() string {
+	return filepath.Join(os.TempDir(), "terraform-provider-libvirt-cloudinit")
+}
+
+// cloudInitDiskPath returns the ISO path for a disk ID
+func cloudInitDiskPath(id string) string {
+	return filepath.Join(cloudInitDiskDir(), fmt.Sprintf("cloudinit-%s.iso", id))
+}
@@ -0,0 +1,199 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/generated"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework-validators/datasourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ datasource.DataSource                     = &DomainDataSource{}
+	_ datasource.DataSourceWithConfigValidators = &DomainDataSource{}
+)
+
+func NewDomainDataSource() datasource.DataSource {
+	return &DomainDataSource{}
+}
+
+type DomainDataSource struct {
+	client *libvirt.Client
+}
+
+// DomainDataSourceModel embeds the generated domain model and adds runtime flags.
+type DomainDataSourceModel struct {
+	generated.DomainModel
+
+	Running    types.Bool `tfsdk:"running"`
+	Autostart  types.Bool `tfsdk:"autostart"`
+	Persistent types.Bool `tfsdk:"persistent"`
+}
+
+func (d *DomainDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_domain"
+}
+
+func (d *DomainDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	attrs, err := dataSourceAttributes(generated.DomainSchema(nil).Attributes)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unsupported Domain Schema",
+			"Failed to build the data source schema from the domain schema: "+err.Error(),
+		)
+		return
+	}
+
+	attrs["name"] = schema.StringAttribute{
+		Optional:            true,
+		Computed:            true,
+		MarkdownDescription: "Name of the domain to look up. Exactly one of `name` or `uuid` must be set.",
+	}
+	attrs["uuid"] = schema.StringAttribute{
+		Optional:            true,
+		Computed:            true,
+		MarkdownDescription: "UUID of the domain to look up. Exactly one of `name` or `uuid` must be set.",
+	}
+	attrs["running"] = schema.BoolAttribute{
+		Computed:            true,
+		MarkdownDescription: "Whether the domain is currently running.",
+	}
+	attrs["autostart"] = schema.BoolAttribute{
+		Computed:            true,
+		MarkdownDescription: "Whether the domain is started automatically when the host boots.",
+	}
+	attrs["persistent"] = schema.BoolAttribute{
+		Computed:            true,
+		MarkdownDescription: "Whether the domain has a persistent definition (false for transient domains).",
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Looks up an existing libvirt domain by name or UUID.\n\n" +
+			"The domain does not need to be managed by Terraform. All attributes follow the " +
+			"`libvirt_domain` resource schema and are populated from the domain XML reported by libvirt, " +
+			"so the data source can be used to read devices (for example interface MAC addresses) of " +
+			"domains created by other tools.",
+		Attributes: attrs,
+	}
+}
+
+func (d *DomainDataSource) ConfigValidators(ctx context.Context) []datasource.ConfigValidator {
+	return []datasource.ConfigValidator{
+		datasourcevalidator.ExactlyOneOf(
+			path.MatchRoot("name"),
+			path.MatchRoot("uuid"),
+		),
+	}
+}
+
+func (d *DomainDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*libvirt.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *libvirt.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+func (d *DomainDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config DomainDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var domain golibvirt.Domain
+	var err error
+	var identifier string
+	if !config.UUID.IsNull() {
+		identifier = config.UUID.ValueString()
+		domain, err = d.client.LookupDomainByUUID(identifier)
+	} else {
+		identifier = config.Name.ValueString()
+		domain, err = d.client.Libvirt().DomainLookupByName(identifier)
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Domain Not Found",
+			fmt.Sprintf("Unable to find domain '%s': %s", identifier, err),
+		)
+		return
+	}
+
+	xmlDesc, err := d.client.Libvirt().DomainGetXMLDesc(domain, 0)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Read Domain",
+			fmt.Sprintf("Failed to get XML for domain '%s': %s", domain.Name, err),
+		)
+		return
+	}
+
+	parsedDomain, err := libvirt.UnmarshalDomainXML(xmlDesc)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Parse Domain XML",
+			"Failed to parse domain XML from libvirt: "+err.Error(),
+		)
+		return
+	}
+
+	// A nil plan populates every field reported by libvirt, as on import.
+	model, err := generated.DomainFromXML(ctx, parsedDomain, nil)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Convert Domain",
+			"Failed to convert domain XML to state: "+err.Error(),
+		)
+		return
+	}
+
+	state := DomainDataSourceModel{DomainModel: *model}
+
+	domainState, _, err := d.client.Libvirt().DomainGetState(domain, 0)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Get Domain State",
+			fmt.Sprintf("Failed to read state of domain '%s': %s", domain.Name, err),
+		)
+		return
+	}
+	state.Running = types.BoolValue(uint32(domainState) == uint32(golibvirt.DomainRunning))
+
+	autostart, err := d.client.Libvirt().DomainGetAutostart(domain)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Get Autostart Status",
+			fmt.Sprintf("Failed to read autostart setting of domain '%s': %s", domain.Name, err),
+		)
+		return
+	}
+	state.Autostart = types.BoolValue(autostart == 1)
+
+	persistent, err := d.client.Libvirt().DomainIsPersistent(domain)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Get Domain Persistence",
+			fmt.Sprintf("Failed to read persistence of domain '%s': %s", domain.Name, err),
+		)
+		return
+	}
+	state.Persistent = types.BoolValue(persistent == 1)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
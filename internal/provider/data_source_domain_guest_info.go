@@ -0,0 +1,410 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = &DomainGuestInfoDataSource{}
+
+func NewDomainGuestInfoDataSource() datasource.DataSource {
+	return &DomainGuestInfoDataSource{}
+}
+
+type DomainGuestInfoDataSource struct {
+	client *libvirt.Client
+}
+
+type DomainGuestInfoDataSourceModel struct {
+	ID          types.String           `tfsdk:"id"`
+	Domain      types.String           `tfsdk:"domain"`
+	Hostname    types.String           `tfsdk:"hostname"`
+	OS          *GuestOSModel          `tfsdk:"os"`
+	Timezone    *GuestTimezoneModel    `tfsdk:"timezone"`
+	Users       []GuestUserModel       `tfsdk:"users"`
+	Filesystems []GuestFilesystemModel `tfsdk:"filesystems"`
+	Disks       []GuestDiskModel       `tfsdk:"disks"`
+}
+
+type GuestOSModel struct {
+	ID            types.String `tfsdk:"id"`
+	Name          types.String `tfsdk:"name"`
+	PrettyName    types.String `tfsdk:"pretty_name"`
+	Version       types.String `tfsdk:"version"`
+	VersionID     types.String `tfsdk:"version_id"`
+	KernelRelease types.String `tfsdk:"kernel_release"`
+	KernelVersion types.String `tfsdk:"kernel_version"`
+	Machine       types.String `tfsdk:"machine"`
+	Variant       types.String `tfsdk:"variant"`
+	VariantID     types.String `tfsdk:"variant_id"`
+}
+
+type GuestTimezoneModel struct {
+	Name   types.String `tfsdk:"name"`
+	Offset types.Int64  `tfsdk:"offset"`
+}
+
+type GuestUserModel struct {
+	Name      types.String `tfsdk:"name"`
+	Domain    types.String `tfsdk:"domain"`
+	LoginTime types.Int64  `tfsdk:"login_time"`
+}
+
+type GuestFilesystemModel struct {
+	Mountpoint types.String               `tfsdk:"mountpoint"`
+	Name       types.String               `tfsdk:"name"`
+	Type       types.String               `tfsdk:"type"`
+	TotalBytes types.Int64                `tfsdk:"total_bytes"`
+	UsedBytes  types.Int64                `tfsdk:"used_bytes"`
+	Disks      []GuestFilesystemDiskModel `tfsdk:"disks"`
+}
+
+type GuestFilesystemDiskModel struct {
+	Alias  types.String `tfsdk:"alias"`
+	Serial types.String `tfsdk:"serial"`
+	Device types.String `tfsdk:"device"`
+}
+
+type GuestDiskModel struct {
+	Name         types.String   `tfsdk:"name"`
+	Partition    types.Bool     `tfsdk:"partition"`
+	Dependencies []types.String `tfsdk:"dependencies"`
+	Serial       types.String   `tfsdk:"serial"`
+	Alias        types.String   `tfsdk:"alias"`
+	GuestAlias   types.String   `tfsdk:"guest_alias"`
+}
+
+func (d *DomainGuestInfoDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_domain_guest_info"
+}
+
+func (d *DomainGuestInfoDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Queries information reported by the QEMU guest agent of a running domain.\n\n" +
+			"This data source uses libvirt's `virDomainGetGuestInfo` API. The domain must be running " +
+			"and have `qemu-guest-agent` installed and connected through a `virtio` channel named " +
+			"`org.qemu.guest_agent.0`. Attributes the agent does not report are left null or empty.",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Internal identifier for this data source (domain UUID).",
+			},
+			"domain": schema.StringAttribute{
+				Required: true,
+				MarkdownDescription: "Domain UUID or name to query. Use `libvirt_domain.example.id` or " +
+					"`libvirt_domain.example.name` to reference a managed domain.",
+			},
+			"hostname": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Hostname of the guest.",
+			},
+			"os": schema.SingleNestedAttribute{
+				Computed:            true,
+				MarkdownDescription: "Operating system of the guest, as reported from `os-release` on Linux guests.",
+				Attributes: map[string]schema.Attribute{
+					"id": schema.StringAttribute{
+						Computed:            true,
+						MarkdownDescription: "Operating system identifier (e.g., `fedora`).",
+					},
+					"name": schema.StringAttribute{
+						Computed:            true,
+						MarkdownDescription: "Operating system name (e.g., `Fedora Linux`).",
+					},
+					"pretty_name": schema.StringAttribute{
+						Computed:            true,
+						MarkdownDescription: "Human readable operating system name including the version.",
+					},
+					"version": schema.StringAttribute{
+						Computed:            true,
+						MarkdownDescription: "Operating system version.",
+					},
+					"version_id": schema.StringAttribute{
+						Computed:            true,
+						MarkdownDescription: "Operating system version identifier (e.g., `40`).",
+					},
+					"kernel_release": schema.StringAttribute{
+						Computed:            true,
+						MarkdownDescription: "Kernel release (e.g., `6.8.5-301.fc40.x86_64`).",
+					},
+					"kernel_version": schema.StringAttribute{
+						Computed:            true,
+						MarkdownDescription: "Kernel version string.",
+					},
+					"machine": schema.StringAttribute{
+						Computed:            true,
+						MarkdownDescription: "Machine hardware name (e.g., `x86_64`).",
+					},
+					"variant": schema.StringAttribute{
+						Computed:            true,
+						MarkdownDescription: "Operating system variant (e.g., `Server Edition`).",
+					},
+					"variant_id": schema.StringAttribute{
+						Computed:            true,
+						MarkdownDescription: "Operating system variant identifier (e.g., `server`).",
+					},
+				},
+			},
+			"timezone": schema.SingleNestedAttribute{
+				Computed:            true,
+				MarkdownDescription: "Timezone of the guest.",
+				Attributes: map[string]schema.Attribute{
+					"name": schema.StringAttribute{
+						Computed:            true,
+						MarkdownDescription: "Timezone name (e.g., `UTC` or `CEST`).",
+					},
+					"offset": schema.Int64Attribute{
+						Computed:            true,
+						MarkdownDescription: "Offset from UTC in seconds.",
+					},
+				},
+			},
+			"users": schema.ListNestedAttribute{
+				Computed:            true,
+				MarkdownDescription: "Users currently logged in to the guest.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "User name.",
+						},
+						"domain": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Domain of the user (Windows guests only).",
+						},
+						"login_time": schema.Int64Attribute{
+							Computed:            true,
+							MarkdownDescription: "Login time in milliseconds since the Unix epoch.",
+						},
+					},
+				},
+			},
+			"filesystems": schema.ListNestedAttribute{
+				Computed:            true,
+				MarkdownDescription: "Filesystems mounted in the guest.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"mountpoint": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Path where the filesystem is mounted.",
+						},
+						"name": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Device name in the guest (e.g., `vda1`).",
+						},
+						"type": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Filesystem type (e.g., `xfs`).",
+						},
+						"total_bytes": schema.Int64Attribute{
+							Computed:            true,
+							MarkdownDescription: "Total size of the filesystem in bytes.",
+						},
+						"used_bytes": schema.Int64Attribute{
+							Computed:            true,
+							MarkdownDescription: "Used space of the filesystem in bytes.",
+						},
+						"disks": schema.ListNestedAttribute{
+							Computed:            true,
+							MarkdownDescription: "Disks backing the filesystem.",
+							NestedObject: schema.NestedAttributeObject{
+								Attributes: map[string]schema.Attribute{
+									"alias": schema.StringAttribute{
+										Computed:            true,
+										MarkdownDescription: "Target device of the disk in the domain definition (e.g., `vda`).",
+									},
+									"serial": schema.StringAttribute{
+										Computed:            true,
+										MarkdownDescription: "Serial number of the disk.",
+									},
+									"device": schema.StringAttribute{
+										Computed:            true,
+										MarkdownDescription: "Device node in the guest (e.g., `/dev/vda1`).",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"disks": schema.ListNestedAttribute{
+				Computed:            true,
+				MarkdownDescription: "Block devices seen by the guest.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Device node in the guest (e.g., `/dev/vda`).",
+						},
+						"partition": schema.BoolAttribute{
+							Computed:            true,
+							MarkdownDescription: "Whether the device is a partition.",
+						},
+						"dependencies": schema.ListAttribute{
+							Computed:            true,
+							ElementType:         types.StringType,
+							MarkdownDescription: "Device nodes this device depends on (e.g., the disk of a partition).",
+						},
+						"serial": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Serial number of the disk.",
+						},
+						"alias": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Target device of the disk in the domain definition (e.g., `vda`).",
+						},
+						"guest_alias": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Alternative name of the device in the guest (e.g., a device mapper name).",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *DomainGuestInfoDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*libvirt.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *libvirt.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+func (d *DomainGuestInfoDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config DomainGuestInfoDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	domainIdentifier := config.Domain.ValueString()
+	domain, err := lookupDomain(d.client, domainIdentifier)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Domain Not Found",
+			fmt.Sprintf("Unable to find domain '%s': %s", domainIdentifier, err),
+		)
+		return
+	}
+
+	// Passing no types asks for everything the agent supports, instead of failing
+	// when an older agent lacks one of the commands.
+	params, err := d.client.Libvirt().DomainGetGuestInfo(domain, 0, 0)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Get Guest Info",
+			fmt.Sprintf("Unable to query the guest agent of domain '%s': %s\n\n"+
+				"Make sure the domain is running and qemu-guest-agent is installed and started in the guest.",
+				domainIdentifier, err),
+		)
+		return
+	}
+
+	state := guestInfoFromParams(params)
+	state.ID = types.StringValue(libvirt.UUIDString(domain.UUID))
+	state.Domain = config.Domain
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+// guestInfoFromParams converts the flat typed parameter list reported by the guest
+// agent (e.g. "fs.0.disk.1.serial") into the data source model.
+func guestInfoFromParams(params []golibvirt.TypedParam) DomainGuestInfoDataSourceModel {
+	p := newTypedParams(params)
+
+	model := DomainGuestInfoDataSourceModel{
+		Hostname: p.string("hostname"),
+		// Initialize as empty slices (not nil) so Terraform gets [] instead of null
+		Users:       []GuestUserModel{},
+		Filesystems: []GuestFilesystemModel{},
+		Disks:       []GuestDiskModel{},
+	}
+
+	if p.has("os.") {
+		model.OS = &GuestOSModel{
+			ID:            p.string("os.id"),
+			Name:          p.string("os.name"),
+			PrettyName:    p.string("os.pretty-name"),
+			Version:       p.string("os.version"),
+			VersionID:     p.string("os.version-id"),
+			KernelRelease: p.string("os.kernel-release"),
+			KernelVersion: p.string("os.kernel-version"),
+			Machine:       p.string("os.machine"),
+			Variant:       p.string("os.variant"),
+			VariantID:     p.string("os.variant-id"),
+		}
+	}
+
+	if p.has("timezone.") {
+		model.Timezone = &GuestTimezoneModel{
+			Name:   p.string("timezone.name"),
+			Offset: p.int64("timezone.offset"),
+		}
+	}
+
+	for i := 0; i < p.count("user.count"); i++ {
+		prefix := fmt.Sprintf("user.%d.", i)
+		model.Users = append(model.Users, GuestUserModel{
+			Name:      p.string(prefix + "name"),
+			Domain:    p.string(prefix + "domain"),
+			LoginTime: p.int64(prefix + "login-time"),
+		})
+	}
+
+	for i := 0; i < p.count("fs.count"); i++ {
+		prefix := fmt.Sprintf("fs.%d.", i)
+		fs := GuestFilesystemModel{
+			Mountpoint: p.string(prefix + "mountpoint"),
+			Name:       p.string(prefix + "name"),
+			Type:       p.string(prefix + "fstype"),
+			TotalBytes: p.int64(prefix + "total-bytes"),
+			UsedBytes:  p.int64(prefix + "used-bytes"),
+			Disks:      []GuestFilesystemDiskModel{},
+		}
+		for j := 0; j < p.count(prefix+"disk.count"); j++ {
+			diskPrefix := fmt.Sprintf("%sdisk.%d.", prefix, j)
+			fs.Disks = append(fs.Disks, GuestFilesystemDiskModel{
+				Alias:  p.string(diskPrefix + "alias"),
+				Serial: p.string(diskPrefix + "serial"),
+				Device: p.string(diskPrefix + "device"),
+			})
+		}
+		model.Filesystems = append(model.Filesystems, fs)
+	}
+
+	for i := 0; i < p.count("disk.count"); i++ {
+		prefix := fmt.Sprintf("disk.%d.", i)
+		disk := GuestDiskModel{
+			Name:         p.string(prefix + "name"),
+			Partition:    p.bool(prefix + "partition"),
+			Dependencies: []types.String{},
+			Serial:       p.string(prefix + "serial"),
+			Alias:        p.string(prefix + "alias"),
+			GuestAlias:   p.string(prefix + "guest_alias"),
+		}
+		for j := 0; j < p.count(prefix+"dependency.count"); j++ {
+			disk.Dependencies = append(disk.Dependencies, p.string(fmt.Sprintf("%sdependency.%d.name", prefix, j)))
+		}
+		model.Disks = append(model.Disks, disk)
+	}
+
+	return model
+}
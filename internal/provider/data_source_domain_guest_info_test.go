@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestGuestInfoFromParams(t *testing.T) {
+	t.Parallel()
+
+	param := func(field string, value *golibvirt.TypedParamValue) golibvirt.TypedParam {
+		return golibvirt.TypedParam{Field: field, Value: *value}
+	}
+	str := golibvirt.NewTypedParamValueString
+	count := golibvirt.NewTypedParamValueUint
+
+	model := guestInfoFromParams([]golibvirt.TypedParam{
+		param("hostname", str("web-1")),
+		param("os.id", str("fedora")),
+		param("os.version-id", str("40")),
+		param("timezone.name", str("CEST")),
+		param("timezone.offset", golibvirt.NewTypedParamValueInt(7200)),
+		param("user.count", count(1)),
+		param("user.0.name", str("core")),
+		param("user.0.login-time", golibvirt.NewTypedParamValueUllong(1712345678000)),
+		param("fs.count", count(1)),
+		param("fs.0.mountpoint", str("/")),
+		param("fs.0.fstype", str("xfs")),
+		param("fs.0.total-bytes", golibvirt.NewTypedParamValueUllong(1000)),
+		param("fs.0.used-bytes", golibvirt.NewTypedParamValueUllong(250)),
+		param("fs.0.disk.count", count(1)),
+		param("fs.0.disk.0.alias", str("vda")),
+		param("disk.count", count(2)),
+		param("disk.0.name", str("/dev/vda")),
+		param("disk.0.partition", golibvirt.NewTypedParamValueBoolean(0)),
+		param("disk.1.name", str("/dev/vda1")),
+		param("disk.1.partition", golibvirt.NewTypedParamValueBoolean(1)),
+		param("disk.1.dependency.count", count(1)),
+		param("disk.1.dependency.0.name", str("/dev/vda")),
+	})
+
+	if model.Hostname.ValueString() != "web-1" {
+		t.Errorf("unexpected hostname %s", model.Hostname)
+	}
+	if model.OS == nil || model.OS.ID.ValueString() != "fedora" || model.OS.VersionID.ValueString() != "40" {
+		t.Errorf("unexpected os %+v", model.OS)
+	}
+	if !model.OS.KernelRelease.IsNull() {
+		t.Errorf("expected unreported kernel release to be null, got %s", model.OS.KernelRelease)
+	}
+	if model.Timezone == nil || model.Timezone.Offset.ValueInt64() != 7200 {
+		t.Errorf("unexpected timezone %+v", model.Timezone)
+	}
+	if len(model.Users) != 1 || model.Users[0].LoginTime.ValueInt64() != 1712345678000 {
+		t.Errorf("unexpected users %+v", model.Users)
+	}
+	if len(model.Filesystems) != 1 || model.Filesystems[0].UsedBytes.ValueInt64() != 250 ||
+		len(model.Filesystems[0].Disks) != 1 || model.Filesystems[0].Disks[0].Alias.ValueString() != "vda" {
+		t.Errorf("unexpected filesystems %+v", model.Filesystems)
+	}
+	if len(model.Disks) != 2 || model.Disks[0].Partition.ValueBool() || !model.Disks[1].Partition.ValueBool() {
+		t.Fatalf("unexpected disks %+v", model.Disks)
+	}
+	if len(model.Disks[1].Dependencies) != 1 || model.Disks[1].Dependencies[0].ValueString() != "/dev/vda" {
+		t.Errorf("unexpected dependencies %v", model.Disks[1].Dependencies)
+	}
+}
+
+func TestGuestInfoFromParamsEmpty(t *testing.T) {
+	t.Parallel()
+
+	model := guestInfoFromParams(nil)
+	if model.OS != nil || model.Timezone != nil || !model.Hostname.IsNull() {
+		t.Errorf("expected missing sections to be null, got %+v", model)
+	}
+	if model.Users == nil || model.Filesystems == nil || model.Disks == nil {
+		t.Errorf("expected empty lists, got %+v", model)
+	}
+}
+
+func TestDomainGuestInfoDataSourceRead(t *testing.T) {
+	client := testMockClient(t)
+	domain, err := client.Libvirt().DomainDefineXML(`<domain type="kvm"><name>guest</name>` +
+		`<memory unit="MiB">256</memory><os><type>hvm</type></os><devices>` +
+		`<disk type="file" device="disk"><source file="/var/lib/libvirt/images/guest.qcow2"/><target dev="vda" bus="virtio"/></disk>` +
+		`</devices></domain>`)
+	if err != nil {
+		t.Fatalf("failed to define domain: %v", err)
+	}
+
+	config := map[string]tftypes.Value{
+		"domain": tftypes.NewValue(tftypes.String, "guest"),
+	}
+	if _, diags := testReadDataSource(t, NewDomainGuestInfoDataSource(), client, config); !diags.HasError() {
+		t.Fatal("expected an error for a domain that is not running")
+	}
+
+	if err := client.Libvirt().DomainCreate(domain); err != nil {
+		t.Fatalf("failed to start domain: %v", err)
+	}
+	state, diags := testReadDataSource(t, NewDomainGuestInfoDataSource(), client, config)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	var model DomainGuestInfoDataSourceModel
+	if diags := state.Get(context.Background(), &model); diags.HasError() {
+		t.Fatalf("failed to read state: %v", diags)
+	}
+	if model.Hostname.ValueString() != "guest" || model.OS == nil || model.OS.Name.ValueString() != "Mock Linux" {
+		t.Errorf("unexpected guest info %+v", model)
+	}
+	if len(model.Filesystems) != 1 || model.Filesystems[0].Mountpoint.ValueString() != "/" {
+		t.Errorf("unexpected filesystems %+v", model.Filesystems)
+	}
+	if len(model.Disks) != 1 || model.Disks[0].Alias.ValueString() != "vda" {
+		t.Errorf("unexpected disks %+v", model.Disks)
+	}
+}
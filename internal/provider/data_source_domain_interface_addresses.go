@@ -3,6 +3,9 @@ package provider
 import (
 	"context"
 	"fmt"
+	"net/netip"
+	"strings"
+	"time"
 
 	golibvirt "github.com/digitalocean/go-libvirt"
 	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
@@ -24,10 +27,20 @@ type DomainInterfaceAddressesDataSource struct {
 }
 
 type DomainInterfaceAddressesDataSourceModel struct {
-	ID         types.String            `tfsdk:"id"`
-	Domain     types.String            `tfsdk:"domain"`
-	Source     types.String            `tfsdk:"source"`
-	Interfaces []InterfaceAddressModel `tfsdk:"interfaces"`
+	ID               types.String                 `tfsdk:"id"`
+	Domain           types.String                 `tfsdk:"domain"`
+	Source           types.String                 `tfsdk:"source"`
+	MAC              types.String                 `tfsdk:"mac"`
+	AddressFamily    types.String                 `tfsdk:"address_family"`
+	ExcludeLinkLocal types.Bool                   `tfsdk:"exclude_link_local"`
+	ExcludeLoopback  types.Bool                   `tfsdk:"exclude_loopback"`
+	Wait             *InterfaceAddressesWaitModel `tfsdk:"wait"`
+	Interfaces       []InterfaceAddressModel      `tfsdk:"interfaces"`
+}
+
+// InterfaceAddressesWaitModel describes the wait block of the data source.
+type InterfaceAddressesWaitModel struct {
+	Timeout types.Int64 `tfsdk:"timeout"`
 }
 
 type InterfaceAddressModel struct {
@@ -50,7 +63,10 @@ func (d *DomainInterfaceAddressesDataSource) Schema(ctx context.Context, req dat
 	resp.Schema = schema.Schema{
 		MarkdownDescription: "Queries IP addresses for a libvirt domain's network interfaces.\n\n" +
 			"This data source uses libvirt's `virDomainInterfaceAddresses` API to retrieve " +
-			"IP address information from DHCP leases or the QEMU guest agent.",
+			"IP address information from DHCP leases, the host ARP table or the QEMU guest agent.\n\n" +
+			"Addresses can be filtered by interface MAC address and address family. With a `wait` " +
+			"block, the data source polls until a matching address appears instead of returning " +
+			"an empty list while the guest is still booting.",
 
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
@@ -67,10 +83,42 @@ func (d *DomainInterfaceAddressesDataSource) Schema(ctx context.Context, req dat
 				MarkdownDescription: "Source to query for IP addresses:\n" +
 					"- `lease` - Query DHCP server leases (fast, no guest agent needed)\n" +
 					"- `agent` - Query QEMU guest agent (requires qemu-guest-agent installed in guest)\n" +
-					"- `any` - Try both sources (default)\n\n" +
-					"If not specified, attempts both sources.",
+					"- `arp` - Query the host ARP table (no guest agent or libvirt DHCP needed, IPv4 only)\n" +
+					"- `any` - Try `lease` then `agent` (default)\n\n" +
+					"If not specified, attempts `lease` and `agent`. The ARP table is only queried with `arp`.",
+				Validators: []validator.String{
+					stringvalidator.OneOf("lease", "agent", "arp", "any"),
+				},
+			},
+			"mac": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Only return the interface with this MAC address (case-insensitive).",
+			},
+			"address_family": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Only return addresses of this family: `ipv4` or `ipv6`.",
 				Validators: []validator.String{
-					stringvalidator.OneOf("lease", "agent", "any"),
+					stringvalidator.OneOf("ipv4", "ipv6"),
+				},
+			},
+			"exclude_link_local": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "Omit link-local addresses (`169.254.0.0/16` and `fe80::/10`).",
+			},
+			"exclude_loopback": schema.BoolAttribute{
+				Optional: true,
+				MarkdownDescription: "Omit loopback addresses (`127.0.0.0/8` and `::1`), as reported by the " +
+					"guest agent for the `lo` interface.",
+			},
+			"wait": schema.SingleNestedAttribute{
+				Optional: true,
+				MarkdownDescription: "Poll until at least one address matching the filters is found. " +
+					"Reading fails if none appears within the timeout.",
+				Attributes: map[string]schema.Attribute{
+					"timeout": schema.Int64Attribute{
+						Optional:            true,
+						MarkdownDescription: "Maximum time to wait in seconds. Default: 300.",
+					},
 				},
 			},
 			"interfaces": schema.ListNestedAttribute{
@@ -132,15 +180,15 @@ func (d *DomainInterfaceAddressesDataSource) Configure(ctx context.Context, req
 
 // lookupDomain looks up a domain by UUID or name.
 // Tries UUID first (since it has a specific format), then falls back to name.
-func (d *DomainInterfaceAddressesDataSource) lookupDomain(nameOrUUID string) (golibvirt.Domain, error) {
+func lookupDomain(client *libvirt.Client, nameOrUUID string) (golibvirt.Domain, error) {
 	// Try UUID first
-	domain, err := d.client.LookupDomainByUUID(nameOrUUID)
+	domain, err := client.LookupDomainByUUID(nameOrUUID)
 	if err == nil {
 		return domain, nil
 	}
 
 	// Fall back to name lookup
-	domain, err = d.client.Libvirt().DomainLookupByName(nameOrUUID)
+	domain, err = client.Libvirt().DomainLookupByName(nameOrUUID)
 	if err != nil {
 		return golibvirt.Domain{}, fmt.Errorf("domain not found by UUID or name '%s': %w", nameOrUUID, err)
 	}
@@ -158,7 +206,7 @@ func (d *DomainInterfaceAddressesDataSource) Read(ctx context.Context, req datas
 
 	// Lookup domain by UUID or name
 	domainIdentifier := config.Domain.ValueString()
-	domain, err := d.lookupDomain(domainIdentifier)
+	domain, err := lookupDomain(d.client, domainIdentifier)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Domain Not Found",
@@ -168,17 +216,19 @@ func (d *DomainInterfaceAddressesDataSource) Read(ctx context.Context, req datas
 	}
 
 	// Determine source(s) to query
-	sources := []golibvirt.DomainInterfaceAddressesSource{}
 	sourceStr := "any"
 	if !config.Source.IsNull() && !config.Source.IsUnknown() {
 		sourceStr = config.Source.ValueString()
 	}
 
+	var sources []golibvirt.DomainInterfaceAddressesSource
 	switch sourceStr {
 	case "lease":
 		sources = []golibvirt.DomainInterfaceAddressesSource{golibvirt.DomainInterfaceAddressesSrcLease}
 	case "agent":
 		sources = []golibvirt.DomainInterfaceAddressesSource{golibvirt.DomainInterfaceAddressesSrcAgent}
+	case "arp":
+		sources = []golibvirt.DomainInterfaceAddressesSource{golibvirt.DomainInterfaceAddressesSrcArp}
 	case "any":
 		sources = []golibvirt.DomainInterfaceAddressesSource{
 			golibvirt.DomainInterfaceAddressesSrcLease,
@@ -186,23 +236,49 @@ func (d *DomainInterfaceAddressesDataSource) Read(ctx context.Context, req datas
 		}
 	}
 
-	// Try each source until we get results
-	var ifaces []golibvirt.DomainInterface
-	var lastErr error
+	filter := interfaceAddressFilter{
+		MAC:              strings.ToLower(config.MAC.ValueString()),
+		Family:           config.AddressFamily.ValueString(),
+		ExcludeLinkLocal: config.ExcludeLinkLocal.ValueBool(),
+		ExcludeLoopback:  config.ExcludeLoopback.ValueBool(),
+	}
 
-	for _, source := range sources {
-		ifaces, err = d.client.Libvirt().DomainInterfaceAddresses(domain, uint32(source), 0)
-		if err == nil && len(ifaces) > 0 {
-			// Found interfaces with at least one result
-			break
+	interfaces, lastErr := queryInterfaceAddresses(d.client, domain, sources, filter)
+
+	if config.Wait != nil {
+		timeout := defaultInterfaceAddressesWaitTimeout
+		if !config.Wait.Timeout.IsNull() && config.Wait.Timeout.ValueInt64() > 0 {
+			timeout = time.Duration(config.Wait.Timeout.ValueInt64()) * time.Second
 		}
-		if err != nil {
-			lastErr = err
+		deadline := time.Now().Add(timeout)
+
+		for !hasInterfaceAddresses(interfaces) {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				detail := fmt.Sprintf("No address matching the filters appeared on domain '%s' within %s.", domainIdentifier, timeout)
+				if lastErr != nil {
+					detail += fmt.Sprintf("\n\nLast error: %s", lastErr)
+				}
+				resp.Diagnostics.AddError("Timeout Waiting for Interface Addresses", detail)
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				resp.Diagnostics.AddError(
+					"Timeout Waiting for Interface Addresses",
+					fmt.Sprintf("Context canceled while waiting for addresses on domain '%s'.", domainIdentifier),
+				)
+				return
+			case <-time.After(min(interfaceAddressesPollInterval, remaining)):
+			}
+
+			interfaces, lastErr = queryInterfaceAddresses(d.client, domain, sources, filter)
 		}
 	}
 
 	// If we got no interfaces and had errors, report it
-	if len(ifaces) == 0 && lastErr != nil {
+	if len(interfaces) == 0 && lastErr != nil {
 		resp.Diagnostics.AddWarning(
 			"No Interface Addresses Found",
 			fmt.Sprintf("Unable to retrieve interface addresses for domain '%s': %s\n\n"+
@@ -214,10 +290,100 @@ func (d *DomainInterfaceAddressesDataSource) Read(ctx context.Context, req datas
 		)
 	}
 
-	// Convert to model
+	// Populate result
+	uuidStr := libvirt.UUIDString(domain.UUID)
+	config.ID = types.StringValue(uuidStr)
+	config.Interfaces = interfaces
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+}
+
+const defaultInterfaceAddressesWaitTimeout = 300 * time.Second
+
+// interfaceAddressesPollInterval is the delay between queries while waiting for addresses.
+var interfaceAddressesPollInterval = 5 * time.Second
+
+// interfaceAddressFilter selects the interfaces and addresses returned by the data source.
+type interfaceAddressFilter struct {
+	MAC              string
+	Family           string
+	ExcludeLinkLocal bool
+	ExcludeLoopback  bool
+}
+
+func (f interfaceAddressFilter) matchesInterface(iface golibvirt.DomainInterface) bool {
+	if f.MAC == "" {
+		return true
+	}
+	return len(iface.Hwaddr) > 0 && strings.EqualFold(iface.Hwaddr[0], f.MAC)
+}
+
+func (f interfaceAddressFilter) matchesAddress(addr golibvirt.DomainIPAddr) bool {
+	if f.Family != "" && f.Family != ipAddrTypeName(addr.Type) {
+		return false
+	}
+	if !f.ExcludeLinkLocal && !f.ExcludeLoopback {
+		return true
+	}
+
+	ip, err := netip.ParseAddr(addr.Addr)
+	if err != nil {
+		return true
+	}
+	if f.ExcludeLinkLocal && ip.IsLinkLocalUnicast() {
+		return false
+	}
+	if f.ExcludeLoopback && ip.IsLoopback() {
+		return false
+	}
+	return true
+}
+
+func ipAddrTypeName(addrType int32) string {
+	if addrType == int32(golibvirt.IPAddrTypeIpv6) {
+		return "ipv6"
+	}
+	return "ipv4"
+}
+
+// queryInterfaceAddresses tries each source in order and returns the filtered interfaces
+// of the first one reporting a matching address. If none does, the interfaces of the
+// first source that returned any are used, so interfaces without addresses are still
+// listed. The last query error is returned alongside.
+func queryInterfaceAddresses(client *libvirt.Client, domain golibvirt.Domain, sources []golibvirt.DomainInterfaceAddressesSource, filter interfaceAddressFilter) ([]InterfaceAddressModel, error) {
 	// Initialize as empty slice (not nil) so Terraform gets [] instead of null
+	result := []InterfaceAddressModel{}
+	var lastErr error
+
+	for _, source := range sources {
+		ifaces, err := client.Libvirt().DomainInterfaceAddresses(domain, uint32(source), 0)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		interfaces := filterInterfaceAddresses(ifaces, filter)
+		if hasInterfaceAddresses(interfaces) {
+			return interfaces, lastErr
+		}
+		if len(result) == 0 {
+			result = interfaces
+		}
+	}
+
+	return result, lastErr
+}
+
+// filterInterfaceAddresses converts the interfaces reported by libvirt to the model,
+// dropping interfaces and addresses that do not match filter.
+func filterInterfaceAddresses(ifaces []golibvirt.DomainInterface, filter interfaceAddressFilter) []InterfaceAddressModel {
 	interfaces := []InterfaceAddressModel{}
 	for _, iface := range ifaces {
+		if !filter.matchesInterface(iface) {
+			continue
+		}
+
 		ifaceModel := InterfaceAddressModel{
 			Name: types.StringValue(iface.Name),
 		}
@@ -229,17 +395,14 @@ func (d *DomainInterfaceAddressesDataSource) Read(ctx context.Context, req datas
 			ifaceModel.Hwaddr = types.StringNull()
 		}
 
-		// Convert addresses
 		// Initialize as empty slice (not nil) so Terraform gets [] instead of null
 		addrs := []IPAddressModel{}
 		for _, addr := range iface.Addrs {
-			addrType := "ipv4"
-			if addr.Type == int32(golibvirt.IPAddrTypeIpv6) {
-				addrType = "ipv6"
+			if !filter.matchesAddress(addr) {
+				continue
 			}
-
 			addrs = append(addrs, IPAddressModel{
-				Type:   types.StringValue(addrType),
+				Type:   types.StringValue(ipAddrTypeName(addr.Type)),
 				Addr:   types.StringValue(addr.Addr),
 				Prefix: types.Int64Value(int64(addr.Prefix)),
 			})
@@ -248,12 +411,14 @@ func (d *DomainInterfaceAddressesDataSource) Read(ctx context.Context, req datas
 
 		interfaces = append(interfaces, ifaceModel)
 	}
+	return interfaces
+}
 
-	// Populate result
-	uuidStr := libvirt.UUIDString(domain.UUID)
-	config.ID = types.StringValue(uuidStr)
-	config.Interfaces = interfaces
-
-	// Save data into Terraform state
-	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+func hasInterfaceAddresses(interfaces []InterfaceAddressModel) bool {
+	for _, iface := range interfaces {
+		if len(iface.Addrs) > 0 {
+			return true
+		}
+	}
+	return false
 }
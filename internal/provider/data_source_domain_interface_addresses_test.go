@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestInterfaceAddressFilter(t *testing.T) {
+	t.Parallel()
+
+	ipv4 := int32(golibvirt.IPAddrTypeIpv4)
+	ipv6 := int32(golibvirt.IPAddrTypeIpv6)
+
+	tests := []struct {
+		name   string
+		filter interfaceAddressFilter
+		addr   golibvirt.DomainIPAddr
+		want   bool
+	}{
+		{"no filter", interfaceAddressFilter{}, golibvirt.DomainIPAddr{Type: ipv6, Addr: "fe80::1"}, true},
+		{"family match", interfaceAddressFilter{Family: "ipv4"}, golibvirt.DomainIPAddr{Type: ipv4, Addr: "10.0.0.2"}, true},
+		{"family mismatch", interfaceAddressFilter{Family: "ipv4"}, golibvirt.DomainIPAddr{Type: ipv6, Addr: "fd00::2"}, false},
+		{"ipv4 link-local", interfaceAddressFilter{ExcludeLinkLocal: true}, golibvirt.DomainIPAddr{Type: ipv4, Addr: "169.254.10.1"}, false},
+		{"ipv6 link-local", interfaceAddressFilter{ExcludeLinkLocal: true}, golibvirt.DomainIPAddr{Type: ipv6, Addr: "fe80::5054:ff:fe00:1"}, false},
+		{"global with link-local excluded", interfaceAddressFilter{ExcludeLinkLocal: true}, golibvirt.DomainIPAddr{Type: ipv6, Addr: "2001:db8::1"}, true},
+		{"ipv4 loopback", interfaceAddressFilter{ExcludeLoopback: true}, golibvirt.DomainIPAddr{Type: ipv4, Addr: "127.0.0.1"}, false},
+		{"ipv6 loopback", interfaceAddressFilter{ExcludeLoopback: true}, golibvirt.DomainIPAddr{Type: ipv6, Addr: "::1"}, false},
+		{"loopback kept", interfaceAddressFilter{ExcludeLinkLocal: true}, golibvirt.DomainIPAddr{Type: ipv4, Addr: "127.0.0.1"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.matchesAddress(tt.addr); got != tt.want {
+				t.Fatalf("matchesAddress(%s) = %v, want %v", tt.addr.Addr, got, tt.want)
+			}
+		})
+	}
+
+	filter := interfaceAddressFilter{MAC: "52:54:00:aa:bb:cc"}
+	if !filter.matchesInterface(golibvirt.DomainInterface{Hwaddr: golibvirt.OptString{"52:54:00:AA:BB:CC"}}) {
+		t.Error("expected MAC comparison to ignore case")
+	}
+	if filter.matchesInterface(golibvirt.DomainInterface{}) {
+		t.Error("expected an interface without MAC not to match")
+	}
+}
+
+func TestDomainInterfaceAddressesDataSourceRead(t *testing.T) {
+	client := testMockClient(t)
+	domain, err := client.Libvirt().DomainDefineXML(`<domain type="kvm"><name>addrs</name>` +
+		`<memory unit="MiB">256</memory><os><type>hvm</type></os><devices>` +
+		`<interface type="network"><mac address="52:54:00:00:00:01"/><source network="default"/></interface>` +
+		`<interface type="network"><mac address="52:54:00:00:00:02"/><source network="default"/></interface>` +
+		`</devices></domain>`)
+	if err != nil {
+		t.Fatalf("failed to define domain: %v", err)
+	}
+
+	read := func(config map[string]tftypes.Value) []InterfaceAddressModel {
+		t.Helper()
+		config["domain"] = tftypes.NewValue(tftypes.String, "addrs")
+		state, diags := testReadDataSource(t, NewDomainInterfaceAddressesDataSource(), client, config)
+		if diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+		var model DomainInterfaceAddressesDataSourceModel
+		if diags := state.Get(context.Background(), &model); diags.HasError() {
+			t.Fatalf("failed to read state: %v", diags)
+		}
+		return model.Interfaces
+	}
+
+	waitType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{"timeout": tftypes.Number}}
+	wait := tftypes.NewValue(waitType, map[string]tftypes.Value{
+		"timeout": tftypes.NewValue(tftypes.Number, big.NewFloat(1)),
+	})
+
+	_, diags := testReadDataSource(t, NewDomainInterfaceAddressesDataSource(), client, map[string]tftypes.Value{
+		"domain": tftypes.NewValue(tftypes.String, "addrs"),
+		"wait":   wait,
+	})
+	if !diags.HasError() || !strings.Contains(diags[0].Summary(), "Timeout") {
+		t.Fatalf("expected a timeout for a domain that is not running, got %v", diags)
+	}
+
+	if err := client.Libvirt().DomainCreate(domain); err != nil {
+		t.Fatalf("failed to start domain: %v", err)
+	}
+
+	agent := read(map[string]tftypes.Value{
+		"source":             tftypes.NewValue(tftypes.String, "agent"),
+		"exclude_link_local": tftypes.NewValue(tftypes.Bool, true),
+		"exclude_loopback":   tftypes.NewValue(tftypes.Bool, true),
+		"wait":               wait,
+	})
+	if len(agent) != 3 {
+		t.Fatalf("expected lo, eth0 and eth1, got %+v", agent)
+	}
+	if len(agent[0].Addrs) != 0 {
+		t.Errorf("expected loopback addresses to be excluded, got %+v", agent[0].Addrs)
+	}
+	for _, iface := range agent[1:] {
+		if len(iface.Addrs) != 1 || iface.Addrs[0].Type.ValueString() != "ipv4" {
+			t.Errorf("expected only the IPv4 address on %s, got %+v", iface.Name.ValueString(), iface.Addrs)
+		}
+	}
+
+	ipv6 := read(map[string]tftypes.Value{
+		"source":         tftypes.NewValue(tftypes.String, "agent"),
+		"mac":            tftypes.NewValue(tftypes.String, "52:54:00:00:00:02"),
+		"address_family": tftypes.NewValue(tftypes.String, "ipv6"),
+	})
+	if len(ipv6) != 1 || ipv6[0].Name.ValueString() != "eth1" {
+		t.Fatalf("expected only eth1, got %+v", ipv6)
+	}
+	if len(ipv6[0].Addrs) != 1 || !strings.HasPrefix(ipv6[0].Addrs[0].Addr.ValueString(), "fe80::") {
+		t.Errorf("expected the link-local IPv6 address, got %+v", ipv6[0].Addrs)
+	}
+
+	arp := read(map[string]tftypes.Value{
+		"source": tftypes.NewValue(tftypes.String, "arp"),
+		"mac":    tftypes.NewValue(tftypes.String, "52:54:00:00:00:01"),
+	})
+	if len(arp) != 1 || len(arp[0].Addrs) != 1 || arp[0].Addrs[0].Addr.ValueString() != "192.168.122.3" {
+		t.Fatalf("unexpected ARP result %+v", arp)
+	}
+}
@@ -0,0 +1,428 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = &DomainStatsDataSource{}
+
+func NewDomainStatsDataSource() datasource.DataSource {
+	return &DomainStatsDataSource{}
+}
+
+type DomainStatsDataSource struct {
+	client *libvirt.Client
+}
+
+type DomainStatsDataSourceModel struct {
+	ID      types.String       `tfsdk:"id"`
+	Domain  types.String       `tfsdk:"domain"`
+	Domains []DomainStatsModel `tfsdk:"domains"`
+}
+
+type DomainStatsModel struct {
+	Name       types.String                `tfsdk:"name"`
+	UUID       types.String                `tfsdk:"uuid"`
+	State      types.String                `tfsdk:"state"`
+	CPU        *DomainCPUStatsModel        `tfsdk:"cpu"`
+	Balloon    *DomainBalloonStatsModel    `tfsdk:"balloon"`
+	VCPUs      []DomainVCPUStatsModel      `tfsdk:"vcpus"`
+	Interfaces []DomainInterfaceStatsModel `tfsdk:"interfaces"`
+	Blocks     []DomainBlockStatsModel     `tfsdk:"blocks"`
+}
+
+type DomainCPUStatsModel struct {
+	Time   types.Int64 `tfsdk:"time"`
+	User   types.Int64 `tfsdk:"user"`
+	System types.Int64 `tfsdk:"system"`
+}
+
+type DomainBalloonStatsModel struct {
+	Current     types.Int64 `tfsdk:"current"`
+	Maximum     types.Int64 `tfsdk:"maximum"`
+	Available   types.Int64 `tfsdk:"available"`
+	Usable      types.Int64 `tfsdk:"usable"`
+	Unused      types.Int64 `tfsdk:"unused"`
+	RSS         types.Int64 `tfsdk:"rss"`
+	DiskCaches  types.Int64 `tfsdk:"disk_caches"`
+	SwapIn      types.Int64 `tfsdk:"swap_in"`
+	SwapOut     types.Int64 `tfsdk:"swap_out"`
+	MajorFaults types.Int64 `tfsdk:"major_faults"`
+	MinorFaults types.Int64 `tfsdk:"minor_faults"`
+	LastUpdate  types.Int64 `tfsdk:"last_update"`
+}
+
+type DomainVCPUStatsModel struct {
+	ID    types.Int64  `tfsdk:"id"`
+	State types.String `tfsdk:"state"`
+	Time  types.Int64  `tfsdk:"time"`
+	Wait  types.Int64  `tfsdk:"wait"`
+	Delay types.Int64  `tfsdk:"delay"`
+}
+
+type DomainInterfaceStatsModel struct {
+	Name      types.String `tfsdk:"name"`
+	RxBytes   types.Int64  `tfsdk:"rx_bytes"`
+	RxPackets types.Int64  `tfsdk:"rx_packets"`
+	RxErrors  types.Int64  `tfsdk:"rx_errors"`
+	RxDrops   types.Int64  `tfsdk:"rx_drops"`
+	TxBytes   types.Int64  `tfsdk:"tx_bytes"`
+	TxPackets types.Int64  `tfsdk:"tx_packets"`
+	TxErrors  types.Int64  `tfsdk:"tx_errors"`
+	TxDrops   types.Int64  `tfsdk:"tx_drops"`
+}
+
+type DomainBlockStatsModel struct {
+	Name          types.String `tfsdk:"name"`
+	Path          types.String `tfsdk:"path"`
+	ReadRequests  types.Int64  `tfsdk:"read_requests"`
+	ReadBytes     types.Int64  `tfsdk:"read_bytes"`
+	ReadTime      types.Int64  `tfsdk:"read_time"`
+	WriteRequests types.Int64  `tfsdk:"write_requests"`
+	WriteBytes    types.Int64  `tfsdk:"write_bytes"`
+	WriteTime     types.Int64  `tfsdk:"write_time"`
+	FlushRequests types.Int64  `tfsdk:"flush_requests"`
+	FlushTime     types.Int64  `tfsdk:"flush_time"`
+	Errors        types.Int64  `tfsdk:"errors"`
+	Allocation    types.Int64  `tfsdk:"allocation"`
+	Capacity      types.Int64  `tfsdk:"capacity"`
+	Physical      types.Int64  `tfsdk:"physical"`
+}
+
+// domainStatsTypes are the statistics groups queried by the data source.
+const domainStatsTypes = golibvirt.DomainStatsState | golibvirt.DomainStatsCPUTotal |
+	golibvirt.DomainStatsBalloon | golibvirt.DomainStatsVCPU |
+	golibvirt.DomainStatsInterface | golibvirt.DomainStatsBlock
+
+var vcpuStateNames = map[int64]string{
+	int64(golibvirt.VCPUOffline): "offline",
+	int64(golibvirt.VCPURunning): "running",
+	int64(golibvirt.VCPUBlocked): "blocked",
+}
+
+func (d *DomainStatsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_domain_stats"
+}
+
+// counterAttributes returns computed Int64 attributes for the given counters.
+func counterAttributes(descriptions map[string]string) map[string]schema.Attribute {
+	attrs := make(map[string]schema.Attribute, len(descriptions))
+	for name, description := range descriptions {
+		attrs[name] = schema.Int64Attribute{
+			Computed:            true,
+			MarkdownDescription: description,
+		}
+	}
+	return attrs
+}
+
+func (d *DomainStatsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	vcpuAttrs := counterAttributes(map[string]string{
+		"id":    "Index of the virtual CPU.",
+		"time":  "Virtual CPU time spent, in nanoseconds.",
+		"wait":  "Time the virtual CPU wanted to run but was not scheduled, in nanoseconds.",
+		"delay": "Time the virtual CPU thread was enqueued by the host scheduler but not running, in nanoseconds.",
+	})
+	vcpuAttrs["state"] = schema.StringAttribute{
+		Computed:            true,
+		MarkdownDescription: "State of the virtual CPU: `offline`, `running` or `blocked`.",
+	}
+
+	interfaceAttrs := counterAttributes(map[string]string{
+		"rx_bytes":   "Bytes received.",
+		"rx_packets": "Packets received.",
+		"rx_errors":  "Receive errors.",
+		"rx_drops":   "Received packets dropped.",
+		"tx_bytes":   "Bytes transmitted.",
+		"tx_packets": "Packets transmitted.",
+		"tx_errors":  "Transmit errors.",
+		"tx_drops":   "Transmitted packets dropped.",
+	})
+	interfaceAttrs["name"] = schema.StringAttribute{
+		Computed:            true,
+		MarkdownDescription: "Name of the interface on the host (e.g., `vnet0`).",
+	}
+
+	blockAttrs := counterAttributes(map[string]string{
+		"read_requests":  "Read requests.",
+		"read_bytes":     "Bytes read.",
+		"read_time":      "Total time spent on reads, in nanoseconds.",
+		"write_requests": "Write requests.",
+		"write_bytes":    "Bytes written.",
+		"write_time":     "Total time spent on writes, in nanoseconds.",
+		"flush_requests": "Flush requests.",
+		"flush_time":     "Total time spent on cache flushes, in nanoseconds.",
+		"errors":         "Error count (Xen only).",
+		"allocation":     "Highest allocated offset of the image in bytes, as reported by the hypervisor.",
+		"capacity":       "Logical size of the disk in bytes.",
+		"physical":       "Physical size of the image on the host in bytes.",
+	})
+	blockAttrs["name"] = schema.StringAttribute{
+		Computed:            true,
+		MarkdownDescription: "Target device of the disk (e.g., `vda`).",
+	}
+	blockAttrs["path"] = schema.StringAttribute{
+		Computed:            true,
+		MarkdownDescription: "Source path of the disk on the host, if it is backed by a file or block device.",
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Reads runtime statistics of libvirt domains.\n\n" +
+			"This data source uses libvirt's `virConnectGetAllDomainStats` API. Counters that the " +
+			"hypervisor does not report (for example CPU and device counters of a domain that is not " +
+			"running, or balloon details without a `virtio` memory balloon) are null or empty.",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Internal identifier for this data source.",
+			},
+			"domain": schema.StringAttribute{
+				Optional: true,
+				MarkdownDescription: "Domain UUID or name to query. If not specified, statistics for all domains " +
+					"on the host are returned.",
+			},
+			"domains": schema.ListNestedAttribute{
+				Computed:            true,
+				MarkdownDescription: "Statistics per domain.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Name of the domain.",
+						},
+						"uuid": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "UUID of the domain.",
+						},
+						"state": schema.StringAttribute{
+							Computed: true,
+							MarkdownDescription: "Current state: `nostate`, `running`, `blocked`, `paused`, `shutdown`, " +
+								"`shutoff`, `crashed` or `pmsuspended`.",
+						},
+						"cpu": schema.SingleNestedAttribute{
+							Computed:            true,
+							MarkdownDescription: "CPU time used by the domain.",
+							Attributes: counterAttributes(map[string]string{
+								"time":   "Total CPU time, in nanoseconds.",
+								"user":   "User CPU time, in nanoseconds.",
+								"system": "System CPU time, in nanoseconds.",
+							}),
+						},
+						"balloon": schema.SingleNestedAttribute{
+							Computed:            true,
+							MarkdownDescription: "Memory balloon statistics. All sizes are in KiB.",
+							Attributes: counterAttributes(map[string]string{
+								"current":      "Memory currently used by the domain.",
+								"maximum":      "Maximum memory the domain may use.",
+								"available":    "Memory available to the guest, as reported by the guest.",
+								"usable":       "Memory the guest can use without swapping.",
+								"unused":       "Memory left completely unused by the guest.",
+								"rss":          "Resident set size of the domain process on the host.",
+								"disk_caches":  "Memory the guest can reclaim from its disk caches.",
+								"swap_in":      "Memory swapped in by the guest.",
+								"swap_out":     "Memory swapped out by the guest.",
+								"major_faults": "Page faults that required disk IO in the guest.",
+								"minor_faults": "Page faults handled without disk IO in the guest.",
+								"last_update":  "Time the guest last updated the statistics, in seconds since the Unix epoch.",
+							}),
+						},
+						"vcpus": schema.ListNestedAttribute{
+							Computed:            true,
+							MarkdownDescription: "Statistics per virtual CPU.",
+							NestedObject: schema.NestedAttributeObject{
+								Attributes: vcpuAttrs,
+							},
+						},
+						"interfaces": schema.ListNestedAttribute{
+							Computed:            true,
+							MarkdownDescription: "Statistics per network interface.",
+							NestedObject: schema.NestedAttributeObject{
+								Attributes: interfaceAttrs,
+							},
+						},
+						"blocks": schema.ListNestedAttribute{
+							Computed:            true,
+							MarkdownDescription: "Statistics per disk.",
+							NestedObject: schema.NestedAttributeObject{
+								Attributes: blockAttrs,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *DomainStatsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*libvirt.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *libvirt.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+func (d *DomainStatsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config DomainStatsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// No domains means all domains of the host
+	var domains []golibvirt.Domain
+	if !config.Domain.IsNull() {
+		domainIdentifier := config.Domain.ValueString()
+		domain, err := lookupDomain(d.client, domainIdentifier)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Domain Not Found",
+				fmt.Sprintf("Unable to find domain '%s': %s", domainIdentifier, err),
+			)
+			return
+		}
+		domains = []golibvirt.Domain{domain}
+	}
+
+	records, err := d.client.Libvirt().ConnectGetAllDomainStats(domains, uint32(domainStatsTypes), 0)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Get Domain Statistics",
+			fmt.Sprintf("Unable to read domain statistics: %s", err),
+		)
+		return
+	}
+
+	// Initialize as empty slice (not nil) so Terraform gets [] instead of null
+	config.Domains = []DomainStatsModel{}
+	uuids := make([]string, 0, len(records))
+	for _, record := range records {
+		stats := domainStatsFromParams(record.Params)
+		stats.Name = types.StringValue(record.Dom.Name)
+		stats.UUID = types.StringValue(libvirt.UUIDString(record.Dom.UUID))
+		config.Domains = append(config.Domains, stats)
+		uuids = append(uuids, stats.UUID.ValueString())
+	}
+
+	config.ID = types.StringValue(strconv.Itoa(hashString(strings.Join(uuids, ","))))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+}
+
+// domainStatsFromParams converts the typed parameters of one domain stats record into
+// the data source model. Groups the hypervisor did not report are left null or empty.
+func domainStatsFromParams(params []golibvirt.TypedParam) DomainStatsModel {
+	p := newTypedParams(params)
+
+	stats := DomainStatsModel{
+		State:      types.StringNull(),
+		VCPUs:      []DomainVCPUStatsModel{},
+		Interfaces: []DomainInterfaceStatsModel{},
+		Blocks:     []DomainBlockStatsModel{},
+	}
+
+	if state := p.int64("state.state"); !state.IsNull() {
+		stats.State = types.StringValue(domainStateNames[golibvirt.DomainState(state.ValueInt64())])
+	}
+
+	if p.has("cpu.") {
+		stats.CPU = &DomainCPUStatsModel{
+			Time:   p.int64("cpu.time"),
+			User:   p.int64("cpu.user"),
+			System: p.int64("cpu.system"),
+		}
+	}
+
+	if p.has("balloon.") {
+		stats.Balloon = &DomainBalloonStatsModel{
+			Current:     p.int64(golibvirt.DomainStatsBalloonCurrent),
+			Maximum:     p.int64(golibvirt.DomainStatsBalloonMaximum),
+			Available:   p.int64(golibvirt.DomainStatsBalloonAvailable),
+			Usable:      p.int64(golibvirt.DomainStatsBalloonUsable),
+			Unused:      p.int64(golibvirt.DomainStatsBalloonUnused),
+			RSS:         p.int64(golibvirt.DomainStatsBalloonRss),
+			DiskCaches:  p.int64(golibvirt.DomainStatsBalloonDiskCaches),
+			SwapIn:      p.int64(golibvirt.DomainStatsBalloonSwapIn),
+			SwapOut:     p.int64(golibvirt.DomainStatsBalloonSwapOut),
+			MajorFaults: p.int64(golibvirt.DomainStatsBalloonMajorFault),
+			MinorFaults: p.int64(golibvirt.DomainStatsBalloonMinorFault),
+			LastUpdate:  p.int64(golibvirt.DomainStatsBalloonLastUpdate),
+		}
+	}
+
+	// Offline vCPUs of a hotpluggable set have no entries, so walk up to the maximum.
+	for i := 0; i < p.count(golibvirt.DomainStatsVCPUMaximum); i++ {
+		prefix := fmt.Sprintf("vcpu.%d.", i)
+		if !p.has(prefix) {
+			continue
+		}
+		vcpu := DomainVCPUStatsModel{
+			ID:    types.Int64Value(int64(i)),
+			State: types.StringNull(),
+			Time:  p.int64(prefix + "time"),
+			Wait:  p.int64(prefix + "wait"),
+			Delay: p.int64(prefix + "delay"),
+		}
+		if state := p.int64(prefix + "state"); !state.IsNull() {
+			vcpu.State = types.StringValue(vcpuStateNames[state.ValueInt64()])
+		}
+		stats.VCPUs = append(stats.VCPUs, vcpu)
+	}
+
+	for i := 0; i < p.count("net.count"); i++ {
+		prefix := fmt.Sprintf("net.%d.", i)
+		stats.Interfaces = append(stats.Interfaces, DomainInterfaceStatsModel{
+			Name:      p.string(prefix + "name"),
+			RxBytes:   p.int64(prefix + "rx.bytes"),
+			RxPackets: p.int64(prefix + "rx.pkts"),
+			RxErrors:  p.int64(prefix + "rx.errs"),
+			RxDrops:   p.int64(prefix + "rx.drop"),
+			TxBytes:   p.int64(prefix + "tx.bytes"),
+			TxPackets: p.int64(prefix + "tx.pkts"),
+			TxErrors:  p.int64(prefix + "tx.errs"),
+			TxDrops:   p.int64(prefix + "tx.drop"),
+		})
+	}
+
+	for i := 0; i < p.count(golibvirt.DomainStatsBlockCount); i++ {
+		prefix := fmt.Sprintf("block.%d", i)
+		stats.Blocks = append(stats.Blocks, DomainBlockStatsModel{
+			Name:          p.string(prefix + golibvirt.DomainStatsBlockSuffixName),
+			Path:          p.string(prefix + golibvirt.DomainStatsBlockSuffixPath),
+			ReadRequests:  p.int64(prefix + golibvirt.DomainStatsBlockSuffixRdReqs),
+			ReadBytes:     p.int64(prefix + golibvirt.DomainStatsBlockSuffixRdBytes),
+			ReadTime:      p.int64(prefix + golibvirt.DomainStatsBlockSuffixRdTimes),
+			WriteRequests: p.int64(prefix + golibvirt.DomainStatsBlockSuffixWrReqs),
+			WriteBytes:    p.int64(prefix + golibvirt.DomainStatsBlockSuffixWrBytes),
+			WriteTime:     p.int64(prefix + golibvirt.DomainStatsBlockSuffixWrTimes),
+			FlushRequests: p.int64(prefix + golibvirt.DomainStatsBlockSuffixFlReqs),
+			FlushTime:     p.int64(prefix + golibvirt.DomainStatsBlockSuffixFlTimes),
+			Errors:        p.int64(prefix + golibvirt.DomainStatsBlockSuffixErrors),
+			Allocation:    p.int64(prefix + golibvirt.DomainStatsBlockSuffixAllocation),
+			Capacity:      p.int64(prefix + golibvirt.DomainStatsBlockSuffixCapacity),
+			Physical:      p.int64(prefix + golibvirt.DomainStatsBlockSuffixPhysical),
+		})
+	}
+
+	return stats
+}
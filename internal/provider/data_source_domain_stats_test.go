@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestDomainStatsFromParams(t *testing.T) {
+	t.Parallel()
+
+	param := func(field string, value *golibvirt.TypedParamValue) golibvirt.TypedParam {
+		return golibvirt.TypedParam{Field: field, Value: *value}
+	}
+	ullong := golibvirt.NewTypedParamValueUllong
+	count := golibvirt.NewTypedParamValueUint
+
+	stats := domainStatsFromParams([]golibvirt.TypedParam{
+		param("state.state", golibvirt.NewTypedParamValueInt(int32(golibvirt.DomainRunning))),
+		param("cpu.time", ullong(42)),
+		param("balloon.current", ullong(1048576)),
+		param("balloon.usable", ullong(65536)),
+		param("vcpu.current", count(1)),
+		param("vcpu.maximum", count(2)),
+		param("vcpu.1.state", golibvirt.NewTypedParamValueInt(int32(golibvirt.VCPURunning))),
+		param("vcpu.1.time", ullong(7)),
+		param("net.count", count(1)),
+		param("net.0.name", golibvirt.NewTypedParamValueString("vnet3")),
+		param("net.0.rx.bytes", ullong(100)),
+		param("block.count", count(1)),
+		param("block.0.name", golibvirt.NewTypedParamValueString("vda")),
+		param("block.0.capacity", ullong(10737418240)),
+		param("block.0.allocation", ullong(9663676416)),
+	})
+
+	if stats.State.ValueString() != "running" {
+		t.Errorf("unexpected state %s", stats.State)
+	}
+	if stats.CPU == nil || stats.CPU.Time.ValueInt64() != 42 || !stats.CPU.User.IsNull() {
+		t.Errorf("unexpected cpu %+v", stats.CPU)
+	}
+	if stats.Balloon == nil || stats.Balloon.Usable.ValueInt64() != 65536 {
+		t.Errorf("unexpected balloon %+v", stats.Balloon)
+	}
+	if len(stats.VCPUs) != 1 || stats.VCPUs[0].ID.ValueInt64() != 1 || stats.VCPUs[0].State.ValueString() != "running" {
+		t.Errorf("expected only the online vcpu 1, got %+v", stats.VCPUs)
+	}
+	if len(stats.Interfaces) != 1 || stats.Interfaces[0].RxBytes.ValueInt64() != 100 {
+		t.Errorf("unexpected interfaces %+v", stats.Interfaces)
+	}
+	if len(stats.Blocks) != 1 || stats.Blocks[0].Capacity.ValueInt64() != 10737418240 || !stats.Blocks[0].Path.IsNull() {
+		t.Errorf("unexpected blocks %+v", stats.Blocks)
+	}
+}
+
+func TestDomainStatsDataSourceRead(t *testing.T) {
+	client := testMockClient(t)
+
+	running, err := client.Libvirt().DomainDefineXML(`<domain type="kvm"><name>running</name>` +
+		`<memory unit="MiB">512</memory><vcpu>2</vcpu><os><type>hvm</type></os><devices>` +
+		`<disk type="file" device="disk"><source file="/var/lib/libvirt/images/running.qcow2"/><target dev="vda" bus="virtio"/></disk>` +
+		`<interface type="network"><source network="default"/></interface>` +
+		`</devices></domain>`)
+	if err != nil {
+		t.Fatalf("failed to define domain: %v", err)
+	}
+	if err := client.Libvirt().DomainCreate(running); err != nil {
+		t.Fatalf("failed to start domain: %v", err)
+	}
+	if _, err := client.Libvirt().DomainDefineXML(`<domain type="kvm"><name>stopped</name>` +
+		`<memory unit="MiB">256</memory><os><type>hvm</type></os></domain>`); err != nil {
+		t.Fatalf("failed to define domain: %v", err)
+	}
+
+	read := func(config map[string]tftypes.Value) []DomainStatsModel {
+		t.Helper()
+		state, diags := testReadDataSource(t, NewDomainStatsDataSource(), client, config)
+		if diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+		var model DomainStatsDataSourceModel
+		if diags := state.Get(context.Background(), &model); diags.HasError() {
+			t.Fatalf("failed to read state: %v", diags)
+		}
+		return model.Domains
+	}
+
+	if domains := read(nil); len(domains) != 2 {
+		t.Fatalf("expected stats for 2 domains, got %d", len(domains))
+	}
+
+	domains := read(map[string]tftypes.Value{
+		"domain": tftypes.NewValue(tftypes.String, "running"),
+	})
+	if len(domains) != 1 {
+		t.Fatalf("expected stats for 1 domain, got %d", len(domains))
+	}
+	stats := domains[0]
+	if stats.Name.ValueString() != "running" || stats.State.ValueString() != "running" {
+		t.Errorf("unexpected domain %s in state %s", stats.Name, stats.State)
+	}
+	if stats.Balloon == nil || stats.Balloon.Maximum.ValueInt64() != 512*1024 {
+		t.Errorf("unexpected balloon %+v", stats.Balloon)
+	}
+	if len(stats.VCPUs) != 2 || len(stats.Interfaces) != 1 || len(stats.Blocks) != 1 {
+		t.Errorf("unexpected device counters: %d vcpus, %d interfaces, %d blocks",
+			len(stats.VCPUs), len(stats.Interfaces), len(stats.Blocks))
+	}
+	if stats.Blocks[0].Path.ValueString() != "/var/lib/libvirt/images/running.qcow2" {
+		t.Errorf("unexpected block path %s", stats.Blocks[0].Path)
+	}
+
+	stopped := read(map[string]tftypes.Value{
+		"domain": tftypes.NewValue(tftypes.String, "stopped"),
+	})[0]
+	if stopped.State.ValueString() != "shutoff" || stopped.CPU != nil || len(stopped.Blocks) != 0 {
+		t.Errorf("unexpected stats for stopped domain %+v", stopped)
+	}
+}
@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+const testDomainDataSourceXML = `<domain type="kvm">
+  <name>appliance</name>
+  <memory unit="MiB">512</memory>
+  <vcpu>2</vcpu>
+  <os><type arch="x86_64" machine="q35">hvm</type></os>
+  <devices>
+    <interface type="network">
+      <mac address="52:54:00:12:34:56"/>
+      <source network="default"/>
+    </interface>
+  </devices>
+</domain>`
+
+func TestDomainDataSourceReadByName(t *testing.T) {
+	client := testMockClient(t)
+	domain, err := client.Libvirt().DomainDefineXML(testDomainDataSourceXML)
+	if err != nil {
+		t.Fatalf("failed to define domain: %v", err)
+	}
+	if err := client.Libvirt().DomainCreate(domain); err != nil {
+		t.Fatalf("failed to start domain: %v", err)
+	}
+
+	state, diags := testReadDataSource(t, NewDomainDataSource(), client, map[string]tftypes.Value{
+		"name": tftypes.NewValue(tftypes.String, "appliance"),
+	})
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	var model DomainDataSourceModel
+	if diags := state.Get(context.Background(), &model); diags.HasError() {
+		t.Fatalf("failed to read state: %v", diags)
+	}
+	if model.UUID.ValueString() != libvirt.UUIDString(domain.UUID) {
+		t.Errorf("unexpected uuid %s", model.UUID.ValueString())
+	}
+	if model.Memory.ValueInt64() != 512 || model.VCPU.ValueInt64() != 2 {
+		t.Errorf("unexpected memory %s or vcpu %s", model.Memory, model.VCPU)
+	}
+	if !model.Running.ValueBool() || !model.Persistent.ValueBool() || model.Autostart.ValueBool() {
+		t.Errorf("unexpected flags: running=%s persistent=%s autostart=%s", model.Running, model.Persistent, model.Autostart)
+	}
+
+	var mac types.String
+	macPath := path.Root("devices").AtName("interfaces").AtListIndex(0).AtName("mac").AtName("address")
+	if diags := state.GetAttribute(context.Background(), macPath, &mac); diags.HasError() {
+		t.Fatalf("failed to read mac address: %v", diags)
+	}
+	if mac.ValueString() != "52:54:00:12:34:56" {
+		t.Errorf("unexpected mac address %s", mac)
+	}
+}
+
+func TestDomainDataSourceReadByUUIDNotFound(t *testing.T) {
+	client := testMockClient(t)
+
+	_, diags := testReadDataSource(t, NewDomainDataSource(), client, map[string]tftypes.Value{
+		"uuid": tftypes.NewValue(tftypes.String, "8f1c7e0a-54b4-4a4f-9a52-3c1d2b7e9f01"),
+	})
+	if !diags.HasError() {
+		t.Fatal("expected an error for a missing domain")
+	}
+}
+
+func TestAccDomainDataSource_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckDomainDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDomainDataSourceConfig("test-domain-datasource"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.libvirt_domain.by_name", "uuid", "libvirt_domain.test", "uuid"),
+					resource.TestCheckResourceAttrPair("data.libvirt_domain.by_uuid", "name", "libvirt_domain.test", "name"),
+					resource.TestCheckResourceAttr("data.libvirt_domain.by_name", "vcpu", "1"),
+					resource.TestCheckResourceAttr("data.libvirt_domain.by_name", "running", "false"),
+					resource.TestCheckResourceAttr("data.libvirt_domain.by_name", "persistent", "true"),
+					resource.TestCheckResourceAttr("data.libvirt_domain.by_name", "autostart", "false"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDomainDataSourceConfig(name string) string {
+	return fmt.Sprintf(`
+resource "libvirt_domain" "test" {
+  name        = %[1]q
+  memory      = 512
+  memory_unit = "MiB"
+  vcpu        = 1
+  type        = "kvm"
+
+  os = {
+    type         = "hvm"
+    type_arch    = "x86_64"
+    type_machine = "q35"
+  }
+}
+
+data "libvirt_domain" "by_name" {
+  name = libvirt_domain.test.name
+}
+
+data "libvirt_domain" "by_uuid" {
+  uuid = libvirt_domain.test.uuid
+}
+`, name)
+}
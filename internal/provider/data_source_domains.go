@@ -0,0 +1,358 @@
+package provider
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = &DomainsDataSource{}
+
+func NewDomainsDataSource() datasource.DataSource {
+	return &DomainsDataSource{}
+}
+
+type DomainsDataSource struct {
+	client *libvirt.Client
+}
+
+type DomainsDataSourceModel struct {
+	ID           types.String         `tfsdk:"id"`
+	States       []types.String       `tfsdk:"states"`
+	Active       types.Bool           `tfsdk:"active"`
+	Persistent   types.Bool           `tfsdk:"persistent"`
+	Autostart    types.Bool           `tfsdk:"autostart"`
+	NameRegex    types.String         `tfsdk:"name_regex"`
+	MetadataTags map[string]string    `tfsdk:"metadata_tags"`
+	Domains      []DomainSummaryModel `tfsdk:"domains"`
+}
+
+type DomainSummaryModel struct {
+	Name       types.String `tfsdk:"name"`
+	UUID       types.String `tfsdk:"uuid"`
+	ID         types.Int64  `tfsdk:"id"`
+	State      types.String `tfsdk:"state"`
+	Running    types.Bool   `tfsdk:"running"`
+	Autostart  types.Bool   `tfsdk:"autostart"`
+	Persistent types.Bool   `tfsdk:"persistent"`
+}
+
+var domainsStateFlags = map[string]golibvirt.ConnectListAllDomainsFlags{
+	"running": golibvirt.ConnectListDomainsRunning,
+	"paused":  golibvirt.ConnectListDomainsPaused,
+	"shutoff": golibvirt.ConnectListDomainsShutoff,
+	"other":   golibvirt.ConnectListDomainsOther,
+}
+
+var domainStateNames = map[golibvirt.DomainState]string{
+	golibvirt.DomainNostate:     "nostate",
+	golibvirt.DomainRunning:     "running",
+	golibvirt.DomainBlocked:     "blocked",
+	golibvirt.DomainPaused:      "paused",
+	golibvirt.DomainShutdown:    "shutdown",
+	golibvirt.DomainShutoff:     "shutoff",
+	golibvirt.DomainCrashed:     "crashed",
+	golibvirt.DomainPmsuspended: "pmsuspended",
+}
+
+func (d *DomainsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_domains"
+}
+
+func (d *DomainsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Lists libvirt domains on the host.\n\n" +
+			"This data source uses libvirt's `virConnectListAllDomains` API and returns every domain, " +
+			"managed by Terraform or not, that matches all of the configured filters. " +
+			"It is useful to generate inventories (for example for Ansible or monitoring) from Terraform.",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Internal identifier for this data source (hash of the returned UUIDs).",
+			},
+			"states": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				MarkdownDescription: "Only return domains in one of these states: `running`, `paused`, `shutoff` or `other`. " +
+					"`other` matches any remaining state, such as `crashed` or `pmsuspended`.",
+				Validators: []validator.List{
+					listvalidator.ValueStringsAre(stringvalidator.OneOf("running", "paused", "shutoff", "other")),
+				},
+			},
+			"active": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "If set, only return active (`true`) or inactive (`false`) domains.",
+			},
+			"persistent": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "If set, only return persistent (`true`) or transient (`false`) domains.",
+			},
+			"autostart": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "If set, only return domains with autostart enabled (`true`) or disabled (`false`).",
+			},
+			"name_regex": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Only return domains whose name matches this regular expression ([RE2 syntax](https://github.com/google/re2/wiki/Syntax)).",
+			},
+			"metadata_tags": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				MarkdownDescription: "Only return domains whose `metadata` XML contains, for every entry, an element with " +
+					"the key as local name and the value as text, at any depth and in any namespace. " +
+					"For example `{ role = \"web\" }` matches `<app:role xmlns:app=\"...\">web</app:role>`.",
+			},
+			"domains": schema.ListNestedAttribute{
+				Computed:            true,
+				MarkdownDescription: "Matching domains, in the order reported by libvirt.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Domain name.",
+						},
+						"uuid": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Domain UUID.",
+						},
+						"id": schema.Int64Attribute{
+							Computed:            true,
+							MarkdownDescription: "Runtime domain ID, null when the domain is not active.",
+						},
+						"state": schema.StringAttribute{
+							Computed: true,
+							MarkdownDescription: "Domain state: `nostate`, `running`, `blocked`, `paused`, `shutdown`, " +
+								"`shutoff`, `crashed` or `pmsuspended`.",
+						},
+						"running": schema.BoolAttribute{
+							Computed:            true,
+							MarkdownDescription: "Whether the domain is running.",
+						},
+						"autostart": schema.BoolAttribute{
+							Computed:            true,
+							MarkdownDescription: "Whether the domain is started automatically when the host boots.",
+						},
+						"persistent": schema.BoolAttribute{
+							Computed:            true,
+							MarkdownDescription: "Whether the domain has a persistent definition.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *DomainsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*libvirt.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *libvirt.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+// domainsListFlags translates the filters libvirt can apply itself into list flags.
+func domainsListFlags(data DomainsDataSourceModel) golibvirt.ConnectListAllDomainsFlags {
+	var flags golibvirt.ConnectListAllDomainsFlags
+	for _, state := range data.States {
+		flags |= domainsStateFlags[state.ValueString()]
+	}
+	flags |= boolFilterFlags(data.Active, golibvirt.ConnectListDomainsActive, golibvirt.ConnectListDomainsInactive)
+	flags |= boolFilterFlags(data.Persistent, golibvirt.ConnectListDomainsPersistent, golibvirt.ConnectListDomainsTransient)
+	flags |= boolFilterFlags(data.Autostart, golibvirt.ConnectListDomainsAutostart, golibvirt.ConnectListDomainsNoAutostart)
+	return flags
+}
+
+// boolFilterFlags returns the flag selecting objects where the filter holds, or none when
+// the filter is not set.
+func boolFilterFlags(filter types.Bool, yes, no golibvirt.ConnectListAllDomainsFlags) golibvirt.ConnectListAllDomainsFlags {
+	if filter.IsNull() || filter.IsUnknown() {
+		return 0
+	}
+	if filter.ValueBool() {
+		return yes
+	}
+	return no
+}
+
+func (d *DomainsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data DomainsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var nameRegex *regexp.Regexp
+	if !data.NameRegex.IsNull() && !data.NameRegex.IsUnknown() {
+		var err error
+		nameRegex, err = regexp.Compile(data.NameRegex.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Invalid Name Regex",
+				fmt.Sprintf("Unable to compile name_regex '%s': %s", data.NameRegex.ValueString(), err),
+			)
+			return
+		}
+	}
+
+	domains, _, err := d.client.Libvirt().ConnectListAllDomains(1, domainsListFlags(data))
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to List Domains",
+			fmt.Sprintf("Unable to list domains: %s", err),
+		)
+		return
+	}
+
+	// Initialize as empty slice (not nil) so Terraform gets [] instead of null
+	summaries := []DomainSummaryModel{}
+	var uuids []string
+	for _, domain := range domains {
+		if nameRegex != nil && !nameRegex.MatchString(domain.Name) {
+			continue
+		}
+
+		summary, matched, err := d.domainSummary(domain, data.MetadataTags)
+		if golibvirt.IsNotFound(err) {
+			// The domain disappeared while listing.
+			continue
+		}
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Failed to Read Domain",
+				fmt.Sprintf("Unable to read domain '%s': %s", domain.Name, err),
+			)
+			return
+		}
+		if !matched {
+			continue
+		}
+
+		summaries = append(summaries, summary)
+		uuids = append(uuids, summary.UUID.ValueString())
+	}
+
+	data.Domains = summaries
+	data.ID = types.StringValue(strconv.Itoa(hashString(strings.Join(uuids, ","))))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// domainSummary reads the runtime flags of a domain and reports whether its metadata
+// matches the requested tags.
+func (d *DomainsDataSource) domainSummary(domain golibvirt.Domain, tags map[string]string) (DomainSummaryModel, bool, error) {
+	if len(tags) > 0 {
+		xmlDesc, err := d.client.Libvirt().DomainGetXMLDesc(domain, 0)
+		if err != nil {
+			return DomainSummaryModel{}, false, err
+		}
+		parsed, err := libvirt.UnmarshalDomainXML(xmlDesc)
+		if err != nil {
+			return DomainSummaryModel{}, false, err
+		}
+		if parsed.Metadata == nil || !metadataHasTags(parsed.Metadata.XML, tags) {
+			return DomainSummaryModel{}, false, nil
+		}
+	}
+
+	state, _, err := d.client.Libvirt().DomainGetState(domain, 0)
+	if err != nil {
+		return DomainSummaryModel{}, false, err
+	}
+	autostart, err := d.client.Libvirt().DomainGetAutostart(domain)
+	if err != nil {
+		return DomainSummaryModel{}, false, err
+	}
+	persistent, err := d.client.Libvirt().DomainIsPersistent(domain)
+	if err != nil {
+		return DomainSummaryModel{}, false, err
+	}
+
+	summary := DomainSummaryModel{
+		Name:       types.StringValue(domain.Name),
+		UUID:       types.StringValue(libvirt.UUIDString(domain.UUID)),
+		ID:         types.Int64Null(),
+		State:      types.StringValue(domainStateNames[golibvirt.DomainState(state)]),
+		Running:    types.BoolValue(golibvirt.DomainState(state) == golibvirt.DomainRunning),
+		Autostart:  types.BoolValue(autostart == 1),
+		Persistent: types.BoolValue(persistent == 1),
+	}
+	if domain.ID > 0 {
+		summary.ID = types.Int64Value(int64(domain.ID))
+	}
+	return summary, true, nil
+}
+
+// metadataHasTags reports whether the inner XML of a domain <metadata> element contains,
+// for every tag, a leaf element with the tag as local name and the value as text.
+func metadataHasTags(metadata string, tags map[string]string) bool {
+	values := map[string][]string{}
+
+	decoder := xml.NewDecoder(strings.NewReader("<metadata>" + metadata + "</metadata>"))
+	type element struct {
+		name     string
+		text     strings.Builder
+		hasChild bool
+	}
+	var stack []*element
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return false
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if len(stack) > 0 {
+				stack[len(stack)-1].hasChild = true
+			}
+			stack = append(stack, &element{name: t.Name.Local})
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		case xml.EndElement:
+			current := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !current.hasChild {
+				values[current.name] = append(values[current.name], strings.TrimSpace(current.text.String()))
+			}
+		}
+	}
+
+	for key, want := range tags {
+		if !slices.Contains(values[key], want) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,174 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestMetadataHasTags(t *testing.T) {
+	t.Parallel()
+
+	metadata := `<app:info xmlns:app="https://example.com/app"><app:role>web</app:role>` +
+		`<app:env> prod </app:env><app:group><app:name>frontend</app:name></app:group></app:info>`
+
+	tests := []struct {
+		name string
+		tags map[string]string
+		want bool
+	}{
+		{"single", map[string]string{"role": "web"}, true},
+		{"all", map[string]string{"role": "web", "env": "prod", "name": "frontend"}, true},
+		{"wrong value", map[string]string{"role": "db"}, false},
+		{"missing", map[string]string{"owner": "ops"}, false},
+		{"container element", map[string]string{"group": "frontend"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := metadataHasTags(metadata, tt.tags); got != tt.want {
+				t.Fatalf("metadataHasTags(%v) = %v, want %v", tt.tags, got, tt.want)
+			}
+		})
+	}
+
+	if metadataHasTags("<unclosed>", map[string]string{"unclosed": ""}) {
+		t.Fatal("expected malformed metadata not to match")
+	}
+}
+
+func TestDomainsListFlags(t *testing.T) {
+	t.Parallel()
+
+	flags := domainsListFlags(DomainsDataSourceModel{
+		States:     []types.String{types.StringValue("running"), types.StringValue("paused")},
+		Active:     types.BoolNull(),
+		Persistent: types.BoolValue(true),
+		Autostart:  types.BoolValue(false),
+	})
+	want := golibvirt.ConnectListDomainsRunning | golibvirt.ConnectListDomainsPaused |
+		golibvirt.ConnectListDomainsPersistent | golibvirt.ConnectListDomainsNoAutostart
+	if flags != want {
+		t.Fatalf("unexpected flags %d, want %d", flags, want)
+	}
+}
+
+func TestDomainsDataSourceRead(t *testing.T) {
+	client := testMockClient(t)
+
+	define := func(name, role string) golibvirt.Domain {
+		t.Helper()
+		domain, err := client.Libvirt().DomainDefineXML(fmt.Sprintf(`<domain type="kvm"><name>%s</name>`+
+			`<metadata><app:role xmlns:app="https://example.com/app">%s</app:role></metadata>`+
+			`<memory unit="MiB">256</memory><os><type>hvm</type></os></domain>`, name, role))
+		if err != nil {
+			t.Fatalf("failed to define domain %s: %v", name, err)
+		}
+		return domain
+	}
+	web := define("web-1", "web")
+	define("web-2", "web")
+	define("db-1", "db")
+	if err := client.Libvirt().DomainCreate(web); err != nil {
+		t.Fatalf("failed to start domain: %v", err)
+	}
+
+	read := func(config map[string]tftypes.Value) []DomainSummaryModel {
+		t.Helper()
+		state, diags := testReadDataSource(t, NewDomainsDataSource(), client, config)
+		if diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+		var model DomainsDataSourceModel
+		if diags := state.Get(context.Background(), &model); diags.HasError() {
+			t.Fatalf("failed to read state: %v", diags)
+		}
+		return model.Domains
+	}
+
+	if domains := read(nil); len(domains) != 3 {
+		t.Fatalf("expected 3 domains, got %d", len(domains))
+	}
+
+	running := read(map[string]tftypes.Value{
+		"states": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+			tftypes.NewValue(tftypes.String, "running"),
+		}),
+	})
+	if len(running) != 1 || running[0].Name.ValueString() != "web-1" {
+		t.Fatalf("expected only web-1 to be running, got %v", running)
+	}
+	if running[0].State.ValueString() != "running" || running[0].ID.IsNull() || !running[0].Persistent.ValueBool() {
+		t.Errorf("unexpected summary %+v", running[0])
+	}
+
+	tagged := read(map[string]tftypes.Value{
+		"name_regex": tftypes.NewValue(tftypes.String, "-2$"),
+		"metadata_tags": tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, map[string]tftypes.Value{
+			"role": tftypes.NewValue(tftypes.String, "web"),
+		}),
+	})
+	if len(tagged) != 1 || tagged[0].Name.ValueString() != "web-2" {
+		t.Fatalf("expected only web-2, got %v", tagged)
+	}
+	if tagged[0].State.ValueString() != "shutoff" || !tagged[0].ID.IsNull() {
+		t.Errorf("unexpected summary %+v", tagged[0])
+	}
+}
+
+func TestAccDomainsDataSource_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckDomainDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDomainsDataSourceConfig("test-domains-datasource"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.libvirt_domains.test", "domains.#", "1"),
+					resource.TestCheckResourceAttrPair("data.libvirt_domains.test", "domains.0.uuid", "libvirt_domain.test", "uuid"),
+					resource.TestCheckResourceAttr("data.libvirt_domains.test", "domains.0.state", "shutoff"),
+					resource.TestCheckResourceAttr("data.libvirt_domains.test", "domains.0.persistent", "true"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDomainsDataSourceConfig(name string) string {
+	return fmt.Sprintf(`
+resource "libvirt_domain" "test" {
+  name        = %[1]q
+  memory      = 512
+  memory_unit = "MiB"
+  vcpu        = 1
+  type        = "kvm"
+
+  metadata = {
+    xml = "<tf:inventory xmlns:tf=\"https://example.com/terraform\"><tf:group>%[1]s</tf:group></tf:inventory>"
+  }
+
+  os = {
+    type         = "hvm"
+    type_arch    = "x86_64"
+    type_machine = "q35"
+  }
+}
+
+data "libvirt_domains" "test" {
+  name_regex = "^test-domains-"
+  states     = ["shutoff"]
+
+  metadata_tags = {
+    group = %[1]q
+  }
+
+  depends_on = [libvirt_domain.test]
+}
+`, name)
+}
@@ -0,0 +1,265 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/generated"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"libvirt.org/go/libvirtxml"
+)
+
+var _ resource.ResourceWithModifyPlan = &DomainResource{}
+
+// ModifyPlan fills in the defaults of os_variant, so that they show up in the plan, and
+// validates the planned domain against the domain capabilities of the host, so that
+// settings the hypervisor does not support are reported at plan time instead of failing
+// when the domain is defined.
+func (r *DomainResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if r.client == nil || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan DomainResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Problems with the plan itself are reported when it is applied
+	planData, diags := prepareDomainPlan(ctx, &plan)
+	if diags.HasError() {
+		return
+	}
+	def, err := generated.DomainToXML(ctx, &planData.SanitizedModel)
+	if err != nil || def == nil {
+		return
+	}
+
+	if !plan.OSVariant.IsUnknown() {
+		osDefaults, diags := r.applyOSVariant(ctx, plan.OSVariant, def)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		// Settings that are only known after apply may fill in defaults differently then
+		if !osDefaults.IsNull() && !osVariantInputsKnown(req.Config.Raw) {
+			osDefaults = types.MapUnknown(types.StringType)
+		}
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("os_variant_defaults"), osDefaults)...)
+	}
+
+	resp.Diagnostics.Append(validateDomainCapabilities(r.client, def)...)
+}
+
+// validateDomainCapabilities checks def against the domain capabilities for its virt type,
+// architecture and machine type. Values that are unknown at plan time are empty in def and
+// are not checked.
+func validateDomainCapabilities(client *libvirt.Client, def *libvirtxml.Domain) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var arch, machine string
+	if def.OS != nil && def.OS.Type != nil {
+		arch = def.OS.Type.Arch
+		machine = def.OS.Type.Machine
+	}
+
+	caps, err := client.DomainCapabilities(arch, machine, def.Type)
+	if err != nil {
+		var libvirtErr golibvirt.Error
+		if errors.As(err, &libvirtErr) &&
+			(libvirtErr.Code == uint32(golibvirt.ErrInvalidArg) || libvirtErr.Code == uint32(golibvirt.ErrConfigUnsupported)) {
+			attrPath := path.Root("type")
+			switch {
+			case machine != "":
+				attrPath = path.Root("os").AtName("type_machine")
+			case arch != "":
+				attrPath = path.Root("os").AtName("type_arch")
+			}
+			diags.AddAttributeError(
+				attrPath,
+				"Unsupported Domain Configuration",
+				fmt.Sprintf("The hypervisor does not support domain type %q, architecture %q and machine type %q: %s",
+					def.Type, arch, machine, err),
+			)
+			return diags
+		}
+
+		diags.AddWarning(
+			"Skipping Domain Capabilities Validation",
+			"Failed to query the domain capabilities of the host: "+err.Error(),
+		)
+		return diags
+	}
+
+	if def.OS != nil {
+		validateDomainOSCapabilities(&diags, caps, def.OS)
+	}
+	if def.CPU != nil {
+		validateDomainCPUCapabilities(&diags, caps, def.CPU)
+	}
+	if def.Devices != nil {
+		validateDomainDeviceCapabilities(&diags, caps, def.Devices)
+	}
+	return diags
+}
+
+func validateDomainOSCapabilities(diags *diag.Diagnostics, caps *libvirtxml.DomainCaps, os *libvirtxml.DomainOS) {
+	if caps.OS == nil {
+		return
+	}
+
+	if os.Firmware != "" {
+		checkCapsEnum(diags, path.Root("os").AtName("firmware"), "firmware", os.Firmware, caps.OS.Enums, "firmware")
+	}
+	validateDomainFirmwareFeatures(diags, caps, os)
+
+	if os.Loader == nil || caps.OS.Loader == nil {
+		return
+	}
+	if caps.OS.Loader.Supported == "no" {
+		diags.AddAttributeError(
+			path.Root("os").AtName("loader"),
+			"Unsupported Firmware Loader",
+			"The hypervisor does not support configuring a firmware loader for this machine type.",
+		)
+		return
+	}
+	if os.Loader.Type != "" {
+		checkCapsEnum(diags, path.Root("os").AtName("loader_type"), "loader type", os.Loader.Type, caps.OS.Loader.Enums, "type")
+	}
+	if os.Loader.Secure == "yes" {
+		checkCapsEnum(diags, path.Root("os").AtName("loader_secure"), "secure boot", os.Loader.Secure, caps.OS.Loader.Enums, "secure")
+	}
+	if os.Loader.Path != "" && len(caps.OS.Loader.Values) > 0 && !slices.Contains(caps.OS.Loader.Values, os.Loader.Path) {
+		// Custom firmware builds are valid, so an unknown image is only a warning
+		diags.AddAttributeWarning(
+			path.Root("os").AtName("loader"),
+			"Unknown Firmware Loader",
+			fmt.Sprintf("The firmware image %q is not among the images libvirt reports for this machine type: %s. "+
+				"The domain fails to start if the image does not exist on the host.",
+				os.Loader.Path, strings.Join(caps.OS.Loader.Values, ", ")),
+		)
+	}
+}
+
+func validateDomainCPUCapabilities(diags *diag.Diagnostics, caps *libvirtxml.DomainCaps, cpu *libvirtxml.DomainCPU) {
+	if caps.CPU == nil {
+		return
+	}
+
+	mode := cpu.Mode
+	if mode == "" && cpu.Model != nil && cpu.Model.Value != "" {
+		mode = "custom"
+	}
+	if mode == "" {
+		return
+	}
+
+	var capsMode *libvirtxml.DomainCapsCPUMode
+	var supportedModes []string
+	for i := range caps.CPU.Modes {
+		if caps.CPU.Modes[i].Supported != "no" {
+			supportedModes = append(supportedModes, caps.CPU.Modes[i].Name)
+		}
+		if caps.CPU.Modes[i].Name == mode {
+			capsMode = &caps.CPU.Modes[i]
+		}
+	}
+	if capsMode == nil || capsMode.Supported == "no" {
+		diags.AddAttributeError(
+			path.Root("cpu").AtName("mode"),
+			"Unsupported CPU Mode",
+			fmt.Sprintf("The hypervisor does not support CPU mode %q. Supported modes: %s.", mode, strings.Join(supportedModes, ", ")),
+		)
+		return
+	}
+
+	if mode != "custom" || cpu.Model == nil || cpu.Model.Value == "" {
+		return
+	}
+
+	var models []string
+	for _, model := range capsMode.Models {
+		if model.Name != cpu.Model.Value {
+			if model.Usable != "no" {
+				models = append(models, model.Name)
+			}
+			continue
+		}
+		if model.Usable == "no" {
+			diags.AddAttributeError(
+				path.Root("cpu").AtName("model"),
+				"Unusable CPU Model",
+				fmt.Sprintf("CPU model %q is known to the hypervisor but cannot be used on this host because it lacks required CPU features.", model.Name),
+			)
+		}
+		return
+	}
+	diags.AddAttributeError(
+		path.Root("cpu").AtName("model"),
+		"Unsupported CPU Model",
+		fmt.Sprintf("The hypervisor does not know CPU model %q. Usable models: %s.", cpu.Model.Value, strings.Join(models, ", ")),
+	)
+}
+
+func validateDomainDeviceCapabilities(diags *diag.Diagnostics, caps *libvirtxml.DomainCaps, devices *libvirtxml.DomainDeviceList) {
+	if caps.Devices == nil {
+		return
+	}
+	devicesPath := path.Root("devices")
+
+	if disk := caps.Devices.Disk; disk != nil {
+		for i, d := range devices.Disks {
+			diskPath := devicesPath.AtName("disks").AtListIndex(i)
+			if d.Device != "" {
+				checkCapsEnum(diags, diskPath.AtName("device"), "disk device", d.Device, disk.Enums, "diskDevice")
+			}
+			if d.Target != nil && d.Target.Bus != "" {
+				checkCapsEnum(diags, diskPath.AtName("target").AtName("bus"), "disk bus", d.Target.Bus, disk.Enums, "bus")
+			}
+		}
+	}
+
+	if video := caps.Devices.Video; video != nil {
+		for i, v := range devices.Videos {
+			if v.Model.Type != "" {
+				checkCapsEnum(diags, devicesPath.AtName("videos").AtListIndex(i).AtName("model").AtName("type"), "video model", v.Model.Type, video.Enums, "modelType")
+			}
+		}
+	}
+
+	if tpm := caps.Devices.TPM; tpm != nil {
+		for i, t := range devices.TPMs {
+			if t.Model != "" {
+				checkCapsEnum(diags, devicesPath.AtName("tpms").AtListIndex(i).AtName("model"), "TPM model", t.Model, tpm.Enums, "model")
+			}
+		}
+	}
+}
+
+// checkCapsEnum reports an error at attrPath if the capabilities list values for the named
+// enum and value is not one of them. Enums that are missing or empty are not checked.
+func checkCapsEnum(diags *diag.Diagnostics, attrPath path.Path, what, value string, enums []libvirtxml.DomainCapsEnum, name string) {
+	for _, enum := range enums {
+		if enum.Name != name || len(enum.Values) == 0 {
+			continue
+		}
+		if !slices.Contains(enum.Values, value) {
+			diags.AddAttributeError(
+				attrPath,
+				"Unsupported Domain Configuration",
+				fmt.Sprintf("The hypervisor does not support %s %q. Supported values: %s.", what, value, strings.Join(enum.Values, ", ")),
+			)
+		}
+		return
+	}
+}
@@ -0,0 +1,188 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"libvirt.org/go/libvirtxml"
+)
+
+func TestValidateDomainCapabilities(t *testing.T) {
+	client := testMockClient(t)
+
+	q35 := func(os *libvirtxml.DomainOS) *libvirtxml.DomainOS {
+		os.Type = &libvirtxml.DomainOSType{Type: "hvm", Arch: "x86_64", Machine: "q35"}
+		return os
+	}
+
+	tests := []struct {
+		name     string
+		def      libvirtxml.Domain
+		errPath  path.Path
+		warnings int
+	}{
+		{
+			name: "supported configuration",
+			def: libvirtxml.Domain{
+				Type: "kvm",
+				OS: q35(&libvirtxml.DomainOS{
+					Firmware: "efi",
+					Loader:   &libvirtxml.DomainLoader{Path: "/usr/share/OVMF/OVMF_CODE.fd", Type: "pflash"},
+				}),
+				CPU: &libvirtxml.DomainCPU{Mode: "custom", Model: &libvirtxml.DomainCPUModel{Value: "Skylake-Client"}},
+				Devices: &libvirtxml.DomainDeviceList{
+					Disks: []libvirtxml.DomainDisk{{Device: "disk", Target: &libvirtxml.DomainDiskTarget{Dev: "vda", Bus: "virtio"}}},
+				},
+			},
+		},
+		{
+			name:    "unsupported machine type",
+			def:     libvirtxml.Domain{Type: "kvm", OS: &libvirtxml.DomainOS{Type: &libvirtxml.DomainOSType{Type: "hvm", Machine: "virt"}}},
+			errPath: path.Root("os").AtName("type_machine"),
+		},
+		{
+			name:    "unsupported architecture",
+			def:     libvirtxml.Domain{Type: "kvm", OS: &libvirtxml.DomainOS{Type: &libvirtxml.DomainOSType{Type: "hvm", Arch: "aarch64"}}},
+			errPath: path.Root("os").AtName("type_arch"),
+		},
+		{
+			name:    "unsupported virt type",
+			def:     libvirtxml.Domain{Type: "xen"},
+			errPath: path.Root("type"),
+		},
+		{
+			name:    "unsupported firmware",
+			def:     libvirtxml.Domain{Type: "kvm", OS: q35(&libvirtxml.DomainOS{Firmware: "coreboot"})},
+			errPath: path.Root("os").AtName("firmware"),
+		},
+		{
+			name: "secure boot firmware features",
+			def: libvirtxml.Domain{Type: "kvm", OS: q35(&libvirtxml.DomainOS{
+				Firmware: "efi",
+				FirmwareInfo: &libvirtxml.DomainOSFirmwareInfo{Features: []libvirtxml.DomainOSFirmwareFeature{
+					{Name: "secure-boot", Enabled: "yes"},
+					{Name: "enrolled-keys", Enabled: "yes"},
+				}},
+			})},
+		},
+		{
+			name: "secure boot without SMM",
+			def: libvirtxml.Domain{Type: "kvm", OS: &libvirtxml.DomainOS{
+				Type:     &libvirtxml.DomainOSType{Type: "hvm", Arch: "x86_64", Machine: "pc"},
+				Firmware: "efi",
+				FirmwareInfo: &libvirtxml.DomainOSFirmwareInfo{Features: []libvirtxml.DomainOSFirmwareFeature{
+					{Name: "secure-boot", Enabled: "yes"},
+				}},
+			}},
+			errPath: path.Root("os").AtName("firmware_info").AtName("features").AtListIndex(0).AtName("enabled"),
+		},
+		{
+			name: "firmware features without firmware",
+			def: libvirtxml.Domain{Type: "kvm", OS: q35(&libvirtxml.DomainOS{
+				FirmwareInfo: &libvirtxml.DomainOSFirmwareInfo{Features: []libvirtxml.DomainOSFirmwareFeature{
+					{Name: "secure-boot", Enabled: "yes"},
+				}},
+			})},
+			errPath: path.Root("os").AtName("firmware_info").AtName("features"),
+		},
+		{
+			name: "enrolled keys without secure boot",
+			def: libvirtxml.Domain{Type: "kvm", OS: q35(&libvirtxml.DomainOS{
+				Firmware: "efi",
+				FirmwareInfo: &libvirtxml.DomainOSFirmwareInfo{Features: []libvirtxml.DomainOSFirmwareFeature{
+					{Name: "secure-boot", Enabled: "no"},
+					{Name: "enrolled-keys", Enabled: "yes"},
+				}},
+			})},
+			errPath: path.Root("os").AtName("firmware_info").AtName("features"),
+		},
+		{
+			name: "unknown loader image",
+			def: libvirtxml.Domain{Type: "kvm", OS: q35(&libvirtxml.DomainOS{
+				Loader: &libvirtxml.DomainLoader{Path: "/opt/firmware/custom.fd"},
+			})},
+			warnings: 1,
+		},
+		{
+			name:    "unknown CPU model",
+			def:     libvirtxml.Domain{Type: "kvm", CPU: &libvirtxml.DomainCPU{Model: &libvirtxml.DomainCPUModel{Value: "Power10"}}},
+			errPath: path.Root("cpu").AtName("model"),
+		},
+		{
+			name:    "unusable CPU model",
+			def:     libvirtxml.Domain{Type: "kvm", CPU: &libvirtxml.DomainCPU{Mode: "custom", Model: &libvirtxml.DomainCPUModel{Value: "EPYC"}}},
+			errPath: path.Root("cpu").AtName("model"),
+		},
+		{
+			name:    "unsupported CPU mode",
+			def:     libvirtxml.Domain{Type: "kvm", CPU: &libvirtxml.DomainCPU{Mode: "host-other"}},
+			errPath: path.Root("cpu").AtName("mode"),
+		},
+		{
+			name: "unsupported disk bus",
+			def: libvirtxml.Domain{Type: "kvm", Devices: &libvirtxml.DomainDeviceList{
+				Disks: []libvirtxml.DomainDisk{
+					{Target: &libvirtxml.DomainDiskTarget{Dev: "vda", Bus: "virtio"}},
+					{Target: &libvirtxml.DomainDiskTarget{Dev: "hda", Bus: "ide"}},
+				},
+			}},
+			errPath: path.Root("devices").AtName("disks").AtListIndex(1).AtName("target").AtName("bus"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			diags := validateDomainCapabilities(client, &tc.def)
+
+			if len(tc.errPath.Steps()) == 0 {
+				if diags.HasError() {
+					t.Fatalf("unexpected errors: %v", diags)
+				}
+			} else {
+				if diags.ErrorsCount() != 1 {
+					t.Fatalf("expected one error, got %v", diags)
+				}
+				withPath, ok := diags.Errors()[0].(interface{ Path() path.Path })
+				if !ok || !withPath.Path().Equal(tc.errPath) {
+					t.Fatalf("expected an error at %s, got %v", tc.errPath, diags)
+				}
+			}
+			if diags.WarningsCount() != tc.warnings {
+				t.Errorf("expected %d warnings, got %v", tc.warnings, diags)
+			}
+		})
+	}
+}
+
+func TestDomainResourceModifyPlanCapabilities(t *testing.T) {
+	client := testMockClient(t)
+	r := NewDomainResource()
+	schemaResp := testResource(t, r, client)
+	ctx := context.Background()
+
+	objectType := schemaResp.Schema.Type().TerraformType(ctx)
+	raw := testValue(t, objectType, map[string]any{
+		"name":   "test-caps",
+		"memory": 256,
+		"type":   "kvm",
+		"os": map[string]any{
+			"type":         "hvm",
+			"type_machine": "virt",
+		},
+	})
+
+	resp := &fwresource.ModifyPlanResponse{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw}}
+	r.(fwresource.ResourceWithModifyPlan).ModifyPlan(ctx, fwresource.ModifyPlanRequest{
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw},
+		State:  tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)},
+	}, resp)
+
+	if !resp.Diagnostics.HasError() || resp.Diagnostics[0].Summary() != "Unsupported Domain Configuration" {
+		t.Fatalf("expected an unsupported machine type error, got %v", resp.Diagnostics)
+	}
+}
@@ -0,0 +1,417 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"libvirt.org/go/libvirtxml"
+)
+
+// DomainCloneFromModel describes the domain a new domain is cloned from.
+type DomainCloneFromModel struct {
+	Domain types.String `tfsdk:"domain"`
+	Pool   types.String `tfsdk:"pool"`
+	Linked types.Bool   `tfsdk:"linked"`
+}
+
+func domainCloneFromSchemaAttribute() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Description: "Create the domain as a copy of an existing domain, like virt-clone. The source domain definition " +
+			"is used as the base for the settings that are not configured, and its writable disks are cloned into " +
+			"volumes that are deleted with the domain. Conflicts with os_variant. Changing it replaces the domain.",
+		MarkdownDescription: "Create the domain as a copy of an existing domain, like `virt-clone`. The source domain definition " +
+			"is used as the base for the settings that are not configured: each configured top-level attribute and each " +
+			"configured kind of device replaces the one of the source, except disks, which replace the source disk with " +
+			"the same target device and are otherwise added. The UUID, the MAC addresses and the NVRAM are " +
+			"regenerated, and writable disks are cloned into new volumes named after the domain and the disk target. " +
+			"The cloned volumes are deleted with the domain unless `destroy.remove_storage` is `[\"none\"]`. " +
+			"Settings that are not configured are kept as they are on updates. Conflicts with `os_variant`. " +
+			"Changing it replaces the domain.",
+		Optional: true,
+		PlanModifiers: []planmodifier.Object{
+			objectplanmodifier.RequiresReplace(),
+		},
+		Attributes: map[string]schema.Attribute{
+			"domain": schema.StringAttribute{
+				Description: "Name or UUID of the domain to clone. It should be shut off while it is cloned.",
+				Required:    true,
+			},
+			"pool": schema.StringAttribute{
+				Description: "Storage pool for the cloned disks. Defaults to the pool of each source volume.",
+				Optional:    true,
+			},
+			"linked": schema.BoolAttribute{
+				Description: "Create qcow2 overlays backed by the source volumes instead of full copies. " +
+					"The source volumes must then be kept unchanged for as long as the clone exists.",
+				Optional: true,
+			},
+		},
+	}
+}
+
+// domainClone holds the volumes to create for a cloned domain.
+type domainClone struct {
+	volumes []domainCloneVolume
+}
+
+type domainCloneVolume struct {
+	dev    string
+	pool   golibvirt.StoragePool
+	source golibvirt.StorageVol
+	xml    string
+	linked bool
+}
+
+var cloneFromPath = path.Root("create").AtName("clone_from")
+
+// domainClonedVolumesKey is the private state key of the volumes created for a cloned
+// domain, which are deleted with it.
+const domainClonedVolumesKey = "cloned_volumes"
+
+// clonedVolume records a volume created for a cloned domain in private state.
+type clonedVolume struct {
+	Dev string `json:"dev"`
+	Key string `json:"key"`
+}
+
+// prepareDomainClone reads the domain cloneFrom refers to and returns it with the UUID,
+// MAC addresses and NVRAM reset and def applied on top of it. Writable disks taken from
+// the source point at the volumes the returned clone creates, which is only done with
+// createVolumes once the definition is known to be valid.
+func prepareDomainClone(client *libvirt.Client, cloneFrom *DomainCloneFromModel, def *libvirtxml.Domain) (*libvirtxml.Domain, *domainClone, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	ref := cloneFrom.Domain.ValueString()
+	source, err := client.Libvirt().DomainLookupByName(ref)
+	if err != nil {
+		var uuidErr error
+		if source, uuidErr = client.LookupDomainByUUID(ref); uuidErr != nil {
+			diags.AddAttributeError(cloneFromPath.AtName("domain"), "Source Domain Not Found",
+				fmt.Sprintf("Failed to look up domain %q to clone: %s", ref, err))
+			return nil, nil, diags
+		}
+	}
+
+	xmlDesc, err := client.Libvirt().DomainGetXMLDesc(source, golibvirt.DomainXMLInactive|golibvirt.DomainXMLSecure)
+	if err != nil {
+		diags.AddAttributeError(cloneFromPath.AtName("domain"), "Failed to Read Source Domain",
+			fmt.Sprintf("Failed to get XML of domain %q: %s", ref, err))
+		return nil, nil, diags
+	}
+	base, err := libvirt.UnmarshalDomainXML(xmlDesc)
+	if err != nil {
+		diags.AddAttributeError(cloneFromPath.AtName("domain"), "Failed to Read Source Domain",
+			fmt.Sprintf("Failed to parse XML of domain %q: %s", ref, err))
+		return nil, nil, diags
+	}
+	resetClonedDomainIdentity(base)
+
+	// Disks are merged by target device rather than replaced as a whole, so that a
+	// configured disk can be added next to the cloned ones
+	var sourceDisks, configuredDisks []libvirtxml.DomainDisk
+	if base.Devices != nil {
+		sourceDisks = base.Devices.Disks
+	}
+	if def.Devices != nil {
+		configuredDisks = def.Devices.Disks
+	}
+	overlayDomain(base, def)
+
+	clone := &domainClone{}
+	if base.Devices != nil {
+		disks, fromSource := mergeClonedDisks(sourceDisks, configuredDisks)
+		base.Devices.Disks = disks
+		for i := range disks {
+			if fromSource[i] {
+				diags.Append(clone.addDisk(client, cloneFrom, base.Name, &disks[i])...)
+			}
+		}
+	}
+	if diags.HasError() {
+		return nil, nil, diags
+	}
+	return base, clone, diags
+}
+
+// mergeClonedDisks returns the disks of the source domain with the configured disks
+// replacing the ones with the same target device, followed by the other configured
+// disks. fromSource tells which of the returned disks are taken from the source.
+func mergeClonedDisks(source, configured []libvirtxml.DomainDisk) (disks []libvirtxml.DomainDisk, fromSource []bool) {
+	configuredByDev := make(map[string]int, len(configured))
+	for i := range configured {
+		if configured[i].Target != nil && configured[i].Target.Dev != "" {
+			configuredByDev[configured[i].Target.Dev] = i
+		}
+	}
+
+	used := make([]bool, len(configured))
+	for i := range source {
+		if source[i].Target != nil {
+			if j, ok := configuredByDev[source[i].Target.Dev]; ok {
+				disks = append(disks, configured[j])
+				fromSource = append(fromSource, false)
+				used[j] = true
+				continue
+			}
+		}
+		disks = append(disks, source[i])
+		fromSource = append(fromSource, true)
+	}
+	for i := range configured {
+		if !used[i] {
+			disks = append(disks, configured[i])
+			fromSource = append(fromSource, false)
+		}
+	}
+	return disks, fromSource
+}
+
+// resetClonedDomainIdentity clears what must differ between a domain and its clone, so
+// that libvirt generates it again.
+func resetClonedDomainIdentity(def *libvirtxml.Domain) {
+	def.UUID = ""
+	def.ID = nil
+	def.GenID = nil
+
+	if def.OS != nil && def.OS.NVRam != nil {
+		// Without a path libvirt creates a new NVRAM file from the template
+		if def.OS.NVRam.Template == "" {
+			def.OS.NVRam = nil
+		} else {
+			def.OS.NVRam = &libvirtxml.DomainNVRam{
+				Template:       def.OS.NVRam.Template,
+				TemplateFormat: def.OS.NVRam.TemplateFormat,
+				Format:         def.OS.NVRam.Format,
+			}
+		}
+	}
+
+	if def.Devices != nil {
+		for i := range def.Devices.Interfaces {
+			def.Devices.Interfaces[i].MAC = nil
+			def.Devices.Interfaces[i].Target = nil
+		}
+	}
+}
+
+// overlayDomain sets the settings configured in override on base. Each top-level setting
+// replaces the one of base, and within devices each kind of device does.
+func overlayDomain(base, override *libvirtxml.Domain) {
+	overlayStruct(reflect.ValueOf(base).Elem(), reflect.ValueOf(override).Elem())
+}
+
+func overlayStruct(dst, src reflect.Value) {
+	for i := 0; i < src.NumField(); i++ {
+		field := src.Field(i)
+		if field.IsZero() {
+			continue
+		}
+		if src.Type().Field(i).Name == "Devices" && !dst.Field(i).IsNil() {
+			overlayStruct(dst.Field(i).Elem(), field.Elem())
+			continue
+		}
+		dst.Field(i).Set(field)
+	}
+}
+
+// addDisk plans the clone of the volume behind disk when the domain writes to it, and
+// points disk at the new volume.
+func (c *domainClone) addDisk(client *libvirt.Client, cloneFrom *DomainCloneFromModel, name string, disk *libvirtxml.DomainDisk) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if (disk.Device != "" && disk.Device != "disk") || disk.ReadOnly != nil || disk.Shareable != nil || disk.Source == nil {
+		return diags
+	}
+
+	volume, err := lookupDiskVolume(client, disk)
+	if errors.Is(err, errDiskNotVolume) {
+		diags.AddAttributeError(cloneFromPath, "Cannot Clone Disk",
+			fmt.Sprintf("Disk %s of the source domain is not a file, block device or storage volume.", diskTargetDev(disk)))
+		return diags
+	}
+	if err != nil {
+		diags.AddAttributeError(cloneFromPath, "Cannot Clone Disk",
+			fmt.Sprintf("The source of disk %s is not a volume in a storage pool: %s", diskTargetDev(disk), err))
+		return diags
+	}
+
+	poolName := volume.Pool
+	if !cloneFrom.Pool.IsNull() && !cloneFrom.Pool.IsUnknown() {
+		poolName = cloneFrom.Pool.ValueString()
+	}
+	pool, err := client.Libvirt().StoragePoolLookupByName(poolName)
+	if err != nil {
+		diags.AddAttributeError(cloneFromPath.AtName("pool"), "Storage Pool Not Found",
+			fmt.Sprintf("Failed to look up pool %q for the cloned disks: %s", poolName, err))
+		return diags
+	}
+
+	volumeXML, err := client.Libvirt().StorageVolGetXMLDesc(volume, 0)
+	if err != nil {
+		diags.AddAttributeError(cloneFromPath, "Cannot Clone Disk",
+			fmt.Sprintf("Failed to get XML of volume %q: %s", volume.Name, err))
+		return diags
+	}
+	var sourceDef libvirtxml.StorageVolume
+	if err := sourceDef.Unmarshal(volumeXML); err != nil {
+		diags.AddAttributeError(cloneFromPath, "Cannot Clone Disk",
+			fmt.Sprintf("Failed to parse XML of volume %q: %s", volume.Name, err))
+		return diags
+	}
+
+	linked := cloneFrom.Linked.ValueBool()
+	ext := filepath.Ext(volume.Name)
+	if linked {
+		ext = ".qcow2"
+	}
+	cloneDef := libvirtxml.StorageVolume{
+		Name:     fmt.Sprintf("%s-%s%s", name, diskTargetDev(disk), ext),
+		Capacity: sourceDef.Capacity,
+		Target:   &libvirtxml.StorageVolumeTarget{},
+	}
+	if sourceDef.Target != nil {
+		cloneDef.Target.Format = sourceDef.Target.Format
+	}
+	if linked {
+		cloneDef.Target.Format = &libvirtxml.StorageVolumeTargetFormat{Type: "qcow2"}
+		cloneDef.BackingStore = &libvirtxml.StorageVolumeBackingStore{Path: sourceDef.Key}
+		if sourceDef.Target != nil {
+			cloneDef.BackingStore.Path = sourceDef.Target.Path
+			cloneDef.BackingStore.Format = sourceDef.Target.Format
+		}
+	}
+	cloneXML, err := cloneDef.Marshal()
+	if err != nil {
+		diags.AddAttributeError(cloneFromPath, "Cannot Clone Disk",
+			fmt.Sprintf("Failed to marshal XML of the clone of volume %q: %s", volume.Name, err))
+		return diags
+	}
+
+	c.volumes = append(c.volumes, domainCloneVolume{dev: diskTargetDev(disk), pool: pool, source: volume, xml: cloneXML, linked: linked})
+
+	disk.Source = &libvirtxml.DomainDiskSource{
+		Volume:        &libvirtxml.DomainDiskSourceVolume{Pool: pool.Name, Volume: cloneDef.Name},
+		StartupPolicy: disk.Source.StartupPolicy,
+	}
+	disk.BackingStore = nil
+	if linked {
+		if disk.Driver == nil {
+			disk.Driver = &libvirtxml.DomainDiskDriver{Name: "qemu"}
+		}
+		disk.Driver.Type = "qcow2"
+	}
+	return diags
+}
+
+func diskTargetDev(disk *libvirtxml.DomainDisk) string {
+	if disk.Target == nil || disk.Target.Dev == "" {
+		return "disk"
+	}
+	return disk.Target.Dev
+}
+
+// createVolumes creates the cloned volumes. When one fails, the ones already created are
+// deleted again.
+func (c *domainClone) createVolumes(ctx context.Context, client *libvirt.Client) ([]golibvirt.StorageVol, error) {
+	var created []golibvirt.StorageVol
+	for _, volume := range c.volumes {
+		var vol golibvirt.StorageVol
+		var err error
+		if volume.linked {
+			vol, err = client.Libvirt().StorageVolCreateXML(volume.pool, volume.xml, 0)
+		} else {
+			vol, err = client.Libvirt().StorageVolCreateXMLFrom(volume.pool, volume.xml, volume.source, 0)
+		}
+		if err != nil {
+			deleteClonedVolumes(ctx, client, created)
+			return nil, fmt.Errorf("cloning volume %q: %w", volume.source.Name, err)
+		}
+		created = append(created, vol)
+	}
+	return created, nil
+}
+
+// deleteClonedVolumes removes volumes created for a clone whose creation failed.
+func deleteClonedVolumes(ctx context.Context, client *libvirt.Client, volumes []golibvirt.StorageVol) {
+	for _, vol := range volumes {
+		if err := client.Libvirt().StorageVolDelete(vol, 0); err != nil {
+			tflog.Warn(ctx, "Failed to delete cloned volume during cleanup", map[string]any{
+				"volume": vol.Name,
+				"error":  err.Error(),
+			})
+		}
+	}
+}
+
+// clonedVolumesValue returns the private state value recording the created volumes, in
+// the order createVolumes returned them.
+func (c *domainClone) clonedVolumesValue(created []golibvirt.StorageVol) ([]byte, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	volumes := make([]clonedVolume, len(created))
+	for i, vol := range created {
+		volumes[i] = clonedVolume{Dev: c.volumes[i].dev, Key: vol.Key}
+	}
+	value, err := json.Marshal(volumes)
+	if err != nil {
+		diags.AddError(
+			"Failed to Record Cloned Volumes",
+			fmt.Sprintf("Unable to encode the cloned volumes, they will not be deleted with the domain: %s", err),
+		)
+		return nil, diags
+	}
+	return value, diags
+}
+
+// removeClonedVolumes deletes the volumes recorded in value that still exist, unless the
+// destroy options keep storage with remove_storage = ["none"]. Volumes already removed,
+// for example with remove_storage = ["all"], are skipped.
+func removeClonedVolumes(client *libvirt.Client, value []byte, options domainStopOptions) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if len(value) == 0 || (len(options.RemoveStorage) > 0 && options.RemoveStorage[0] == removeStorageNone) {
+		return diags
+	}
+
+	var cloned []clonedVolume
+	if err := json.Unmarshal(value, &cloned); err != nil {
+		diags.AddWarning("Storage Not Removed",
+			"Failed to read the volumes created for the cloned domain, they are left in place: "+err.Error())
+		return diags
+	}
+
+	var volumes []domainStorageVolume
+	for _, v := range cloned {
+		volume, err := client.Libvirt().StorageVolLookupByKey(v.Key)
+		if err != nil {
+			continue
+		}
+		volumes = append(volumes, domainStorageVolume{dev: v.Dev, volume: volume})
+	}
+	return removeDomainStorage(client, volumes, options.WipeStorage)
+}
+
+// overlayDefinedDomain applies def on top of the current definition of domain, so that
+// the settings of a cloned domain that are not configured are kept on updates.
+func overlayDefinedDomain(client *libvirt.Client, domain golibvirt.Domain, def *libvirtxml.Domain) (*libvirtxml.Domain, error) {
+	xmlDesc, err := client.Libvirt().DomainGetXMLDesc(domain, golibvirt.DomainXMLInactive|golibvirt.DomainXMLSecure)
+	if err != nil {
+		return nil, err
+	}
+	base, err := libvirt.UnmarshalDomainXML(xmlDesc)
+	if err != nil {
+		return nil, err
+	}
+	overlayDomain(base, def)
+	return base, nil
+}
@@ -0,0 +1,388 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	libvirtclient "github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"libvirt.org/go/libvirtxml"
+)
+
+const testGoldenDomainXML = `<domain type="kvm">
+  <name>golden</name>
+  <uuid>8f9a3c2e-1b7d-4e5f-9a0b-1c2d3e4f5a6b</uuid>
+  <memory unit="KiB">1048576</memory>
+  <vcpu>2</vcpu>
+  <os>
+    <type>hvm</type>
+    <nvram template="/usr/share/OVMF/OVMF_VARS.fd">/var/lib/libvirt/qemu/nvram/golden_VARS.fd</nvram>
+  </os>
+  <devices>
+    <disk type="file" device="disk">
+      <driver name="qemu" type="qcow2"/>
+      <source file="/var/lib/libvirt/images/golden.qcow2"/>
+      <target dev="vda" bus="virtio"/>
+    </disk>
+    <disk type="file" device="cdrom">
+      <source file="/var/lib/libvirt/images/tools.iso"/>
+      <target dev="sda" bus="sata"/>
+      <readonly/>
+    </disk>
+    <interface type="network">
+      <mac address="52:54:00:aa:bb:cc"/>
+      <source network="default"/>
+    </interface>
+  </devices>
+</domain>`
+
+func testDefineGoldenDomain(t *testing.T, client *libvirtclient.Client) {
+	t.Helper()
+
+	testCreateVolume(t, client, "golden.qcow2", "qcow2")
+	if _, err := client.Libvirt().DomainDefineXML(testGoldenDomainXML); err != nil {
+		t.Fatalf("failed to define golden domain: %v", err)
+	}
+}
+
+func testDomainDef(t *testing.T, client *libvirtclient.Client, name string) *libvirtxml.Domain {
+	t.Helper()
+
+	domain, err := client.Libvirt().DomainLookupByName(name)
+	if err != nil {
+		t.Fatalf("failed to look up domain: %v", err)
+	}
+	xmlDesc, err := client.Libvirt().DomainGetXMLDesc(domain, golibvirt.DomainXMLSecure)
+	if err != nil {
+		t.Fatalf("failed to get domain XML: %v", err)
+	}
+	def, err := libvirtclient.UnmarshalDomainXML(xmlDesc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return def
+}
+
+func TestDomainResourceCloneFrom(t *testing.T) {
+	client := testMockClient(t)
+	testDefineGoldenDomain(t, client)
+
+	state, diags := testCreateResource(t, NewDomainResource(), client, map[string]any{
+		"name":   "clone",
+		"type":   "kvm",
+		"memory": 512,
+		"create": map[string]any{
+			"clone_from": map[string]any{"domain": "golden"},
+		},
+	})
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	def := testDomainDef(t, client, "clone")
+	if def.UUID == "8f9a3c2e-1b7d-4e5f-9a0b-1c2d3e4f5a6b" {
+		t.Error("expected a new UUID")
+	}
+	if def.Memory.Value != 512 {
+		t.Errorf("expected the configured memory, got %+v", def.Memory)
+	}
+	if def.VCPU == nil || def.VCPU.Value != 2 {
+		t.Errorf("expected the vCPUs of the source domain, got %+v", def.VCPU)
+	}
+	if nvram := def.OS.NVRam; nvram == nil || nvram.NVRam != "" || nvram.Template != "/usr/share/OVMF/OVMF_VARS.fd" {
+		t.Errorf("expected the NVRAM to be created again from the template, got %+v", nvram)
+	}
+	if mac := def.Devices.Interfaces[0].MAC; mac == nil || mac.Address == "52:54:00:aa:bb:cc" {
+		t.Errorf("expected a new MAC address, got %+v", mac)
+	}
+
+	disk := def.Devices.Disks[0]
+	if disk.Source.Volume == nil || disk.Source.Volume.Pool != "default" || disk.Source.Volume.Volume != "clone-vda.qcow2" {
+		t.Errorf("expected the disk to use the cloned volume, got %+v", disk.Source)
+	}
+	if cdrom := def.Devices.Disks[1]; cdrom.Source.File == nil || cdrom.Source.File.File != "/var/lib/libvirt/images/tools.iso" {
+		t.Errorf("expected the read-only CD-ROM to be shared, got %+v", cdrom.Source)
+	}
+
+	pool, err := client.Libvirt().StoragePoolLookupByName("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Libvirt().StorageVolLookupByName(pool, "clone-vda.qcow2"); err != nil {
+		t.Errorf("expected the cloned volume to exist: %v", err)
+	}
+
+	if devices := testStateValue(t, state, path.Root("devices")); devices != "<null>" {
+		t.Errorf("expected the devices of the source domain not to be stored, got %s", devices)
+	}
+}
+
+func TestDomainResourceCloneFromDisks(t *testing.T) {
+	tests := []struct {
+		name        string
+		dev         string
+		wantDevs    []string
+		wantCloned  bool
+		wantSources map[string]string
+	}{
+		{
+			name:        "adds configured disk",
+			dev:         "vdb",
+			wantDevs:    []string{"vda", "sda", "vdb"},
+			wantCloned:  true,
+			wantSources: map[string]string{"vda": "clone-vda.qcow2", "vdb": "data.qcow2"},
+		},
+		{
+			name:        "replaces source disk with the same target",
+			dev:         "vda",
+			wantDevs:    []string{"vda", "sda"},
+			wantSources: map[string]string{"vda": "data.qcow2"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := testMockClient(t)
+			testDefineGoldenDomain(t, client)
+			testCreateVolume(t, client, "data.qcow2", "qcow2")
+
+			state, diags := testCreateResource(t, NewDomainResource(), client, map[string]any{
+				"name": "clone",
+				"type": "kvm",
+				"create": map[string]any{
+					"clone_from": map[string]any{"domain": "golden"},
+				},
+				"devices": map[string]any{
+					"disks": []any{
+						map[string]any{
+							"source": map[string]any{"volume": map[string]any{"pool": "default", "volume": "data.qcow2"}},
+							"target": map[string]any{"dev": tc.dev, "bus": "virtio"},
+						},
+					},
+				},
+			})
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			def := testDomainDef(t, client, "clone")
+			var devs []string
+			for _, disk := range def.Devices.Disks {
+				dev := disk.Target.Dev
+				devs = append(devs, dev)
+				if want, ok := tc.wantSources[dev]; ok && (disk.Source.Volume == nil || disk.Source.Volume.Volume != want) {
+					t.Errorf("expected disk %s to use volume %s, got %+v", dev, want, disk.Source)
+				}
+			}
+			if strings.Join(devs, ",") != strings.Join(tc.wantDevs, ",") {
+				t.Errorf("expected disks %v, got %v", tc.wantDevs, devs)
+			}
+
+			pool, err := client.Libvirt().StoragePoolLookupByName("default")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := client.Libvirt().StorageVolLookupByName(pool, "clone-vda.qcow2"); (err == nil) != tc.wantCloned {
+				t.Errorf("expected cloned volume=%t, got lookup error %v", tc.wantCloned, err)
+			}
+
+			diskPath := path.Root("devices").AtName("disks")
+			if dev := testStateValue(t, state, diskPath.AtListIndex(0).AtName("target").AtName("dev")); dev != tc.dev {
+				t.Errorf("expected the configured disk in state, got %s", dev)
+			}
+			if disk := testStateValue(t, state, diskPath.AtListIndex(1)); disk != "<null>" {
+				t.Errorf("expected only the configured disk in state, got %s", disk)
+			}
+		})
+	}
+}
+
+func TestDomainResourceCloneFromLinked(t *testing.T) {
+	client := testMockClient(t)
+	testDefineGoldenDomain(t, client)
+
+	_, diags := testCreateResource(t, NewDomainResource(), client, map[string]any{
+		"name": "linked",
+		"type": "kvm",
+		"create": map[string]any{
+			"clone_from": map[string]any{"domain": "golden", "linked": true},
+		},
+	})
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	pool, err := client.Libvirt().StoragePoolLookupByName("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	volume, err := client.Libvirt().StorageVolLookupByName(pool, "linked-vda.qcow2")
+	if err != nil {
+		t.Fatalf("expected the overlay volume to exist: %v", err)
+	}
+	volumeXML, err := client.Libvirt().StorageVolGetXMLDesc(volume, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(volumeXML, "<path>/var/lib/libvirt/images/golden.qcow2</path>") {
+		t.Errorf("expected the overlay to be backed by the source volume, got\n%s", volumeXML)
+	}
+}
+
+func TestDomainResourceCloneFromMissing(t *testing.T) {
+	client := testMockClient(t)
+
+	_, diags := testCreateResource(t, NewDomainResource(), client, map[string]any{
+		"name": "orphan",
+		"type": "kvm",
+		"create": map[string]any{
+			"clone_from": map[string]any{"domain": "missing"},
+		},
+	})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "Source Domain Not Found" {
+		t.Fatalf("expected a source domain not found error, got %v", diags)
+	}
+}
+
+func TestDomainResourceCloneFromDestroy(t *testing.T) {
+	tests := []struct {
+		name    string
+		destroy map[string]any
+		kept    bool
+	}{
+		{name: "deletes cloned volumes"},
+		{name: "keeps cloned volumes with remove_storage none", destroy: map[string]any{"remove_storage": []any{"none"}}, kept: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := testMockClient(t)
+			testDefineGoldenDomain(t, client)
+
+			r := NewDomainResource()
+			created := testCreateResourceResponse(t, r, client, map[string]any{
+				"name": "clone",
+				"type": "kvm",
+				"create": map[string]any{
+					"clone_from": map[string]any{"domain": "golden"},
+				},
+				"destroy": tc.destroy,
+			})
+			if created.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", created.Diagnostics)
+			}
+
+			resp := &fwresource.DeleteResponse{State: created.State}
+			r.Delete(context.Background(), fwresource.DeleteRequest{State: created.State, Private: created.Private}, resp)
+			if resp.Diagnostics.HasError() || resp.Diagnostics.WarningsCount() > 0 {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+			if _, err := client.Libvirt().DomainLookupByName("clone"); err == nil {
+				t.Error("expected the domain to be undefined")
+			}
+
+			pool, err := client.Libvirt().StoragePoolLookupByName("default")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := client.Libvirt().StorageVolLookupByName(pool, "clone-vda.qcow2"); (err == nil) != tc.kept {
+				t.Errorf("expected cloned volume kept=%t, got lookup error %v", tc.kept, err)
+			}
+			if _, err := client.Libvirt().StorageVolLookupByName(pool, "golden.qcow2"); err != nil {
+				t.Errorf("expected the source volume to be kept: %v", err)
+			}
+		})
+	}
+}
+
+func TestDomainResourceCloneFromDestroyLookup(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, client *libvirtclient.Client, resp *fwresource.CreateResponse)
+		wantErr bool
+	}{
+		{
+			name: "domain already undefined",
+			prepare: func(t *testing.T, client *libvirtclient.Client, _ *fwresource.CreateResponse) {
+				domain, err := client.Libvirt().DomainLookupByName("clone")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := client.Libvirt().DomainUndefineFlags(domain, golibvirt.DomainUndefineNvram); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "lookup failure",
+			prepare: func(t *testing.T, _ *libvirtclient.Client, resp *fwresource.CreateResponse) {
+				if diags := resp.State.SetAttribute(context.Background(), path.Root("uuid"), "not-a-uuid"); diags.HasError() {
+					t.Fatalf("unexpected diagnostics: %v", diags)
+				}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := testMockClient(t)
+			testDefineGoldenDomain(t, client)
+
+			r := NewDomainResource()
+			created := testCreateResourceResponse(t, r, client, map[string]any{
+				"name": "clone",
+				"type": "kvm",
+				"create": map[string]any{
+					"clone_from": map[string]any{"domain": "golden"},
+				},
+			})
+			if created.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", created.Diagnostics)
+			}
+			tc.prepare(t, client, created)
+
+			resp := &fwresource.DeleteResponse{State: created.State}
+			r.Delete(context.Background(), fwresource.DeleteRequest{State: created.State, Private: created.Private}, resp)
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%t, got diagnostics: %v", tc.wantErr, resp.Diagnostics)
+			}
+
+			pool, err := client.Libvirt().StoragePoolLookupByName("default")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := client.Libvirt().StorageVolLookupByName(pool, "clone-vda.qcow2"); (err == nil) != tc.wantErr {
+				t.Errorf("expected cloned volume kept=%t, got lookup error %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDomainResourceCloneFromConflictsWithOSVariant(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	r := NewDomainResource()
+	schemaResp := testResource(t, r, nil)
+	objectType := schemaResp.Schema.Type().TerraformType(ctx)
+	config := tfsdk.Config{Schema: schemaResp.Schema, Raw: testValue(t, objectType, map[string]any{
+		"name":       "clone",
+		"os_variant": "debian12",
+		"create": map[string]any{
+			"clone_from": map[string]any{"domain": "golden"},
+		},
+	})}
+
+	resp := &fwresource.ValidateConfigResponse{}
+	for _, v := range r.(fwresource.ResourceWithConfigValidators).ConfigValidators(ctx) {
+		v.ValidateResource(ctx, fwresource.ValidateConfigRequest{Config: config}, resp)
+	}
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected clone_from and os_variant to conflict")
+	}
+}
@@ -0,0 +1,286 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+const (
+	defaultConsoleWaitTimeout = 300 * time.Second
+	defaultConsoleWaitLines   = 20
+
+	// maxConsoleLineLength bounds the unterminated line kept while waiting for a newline.
+	maxConsoleLineLength = 4096
+)
+
+// DomainWaitForConsoleModel describes create.wait_for_console.
+type DomainWaitForConsoleModel struct {
+	Pattern types.String `tfsdk:"pattern"`
+	Timeout types.Int64  `tfsdk:"timeout"`
+	Device  types.String `tfsdk:"device"`
+	Lines   types.Int64  `tfsdk:"lines"`
+}
+
+type consoleWaitConfig struct {
+	Pattern *regexp.Regexp
+	Timeout time.Duration
+	Device  string
+	Lines   int
+}
+
+func domainWaitForConsoleSchemaAttribute() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Description: "Wait after starting the domain until a line of its console output matches a pattern. " +
+			"The console is opened with the force flag, disconnecting any other console session. " +
+			"Useful for guests without a guest agent or DHCP lease, where wait_for_ip cannot work.",
+		Optional: true,
+		Attributes: map[string]schema.Attribute{
+			"pattern": schema.StringAttribute{
+				Description: "Regular expression (Go RE2 syntax) matched against each console line, e.g. 'login:'. " +
+					"The last, not yet terminated line is matched too, so prompts are detected.",
+				Required: true,
+			},
+			"timeout": schema.Int64Attribute{
+				Description: "Maximum time to wait for the pattern in seconds. Default: 300.",
+				Optional:    true,
+			},
+			"device": schema.StringAttribute{
+				Description: "Alias of the console or serial device to open, e.g. 'serial0'. Default: the first console.",
+				Optional:    true,
+			},
+			"lines": schema.Int64Attribute{
+				Description: "Number of trailing console lines included in the error when the wait fails. Default: 20.",
+				Optional:    true,
+			},
+		},
+	}
+}
+
+func domainWaitForConsoleAttrTypes() map[string]attr.Type {
+	return map[string]attr.Type{
+		"pattern": types.StringType,
+		"timeout": types.Int64Type,
+		"device":  types.StringType,
+		"lines":   types.Int64Type,
+	}
+}
+
+// consoleWaitConfigFromCreate returns the wait_for_console settings of the create
+// block, or nil when no wait is configured.
+func consoleWaitConfigFromCreate(ctx context.Context, createVal types.Object) (*consoleWaitConfig, diag.Diagnostics) {
+	if createVal.IsNull() || createVal.IsUnknown() {
+		return nil, nil
+	}
+
+	var createModel DomainCreateModel
+	diags := createVal.As(ctx, &createModel, basetypes.ObjectAsOptions{})
+	if diags.HasError() || createModel.WaitForConsole.IsNull() || createModel.WaitForConsole.IsUnknown() {
+		return nil, diags
+	}
+
+	var waitModel DomainWaitForConsoleModel
+	diags.Append(createModel.WaitForConsole.As(ctx, &waitModel, basetypes.ObjectAsOptions{})...)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	pattern, err := regexp.Compile(waitModel.Pattern.ValueString())
+	if err != nil {
+		diags.AddError(
+			"Invalid Console Pattern",
+			fmt.Sprintf("create.wait_for_console.pattern is not a valid regular expression: %s", err),
+		)
+		return nil, diags
+	}
+
+	cfg := &consoleWaitConfig{
+		Pattern: pattern,
+		Timeout: defaultConsoleWaitTimeout,
+		Device:  waitModel.Device.ValueString(),
+		Lines:   defaultConsoleWaitLines,
+	}
+	if !waitModel.Timeout.IsNull() && waitModel.Timeout.ValueInt64() > 0 {
+		cfg.Timeout = time.Duration(waitModel.Timeout.ValueInt64()) * time.Second
+	}
+	if !waitModel.Lines.IsNull() && waitModel.Lines.ValueInt64() > 0 {
+		cfg.Lines = int(waitModel.Lines.ValueInt64())
+	}
+
+	return cfg, diags
+}
+
+// ansiEscape matches terminal control sequences, which would otherwise break patterns
+// on colored or cursor-positioned console output.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// consoleBuffer is the writer a console stream is copied into. It keeps the last lines
+// of output and signals once a line matches the pattern.
+type consoleBuffer struct {
+	mu       sync.Mutex
+	pattern  *regexp.Regexp
+	maxLines int
+	lines    []string
+	partial  string
+	matched  chan struct{}
+	done     bool
+}
+
+func newConsoleBuffer(pattern *regexp.Regexp, maxLines int) *consoleBuffer {
+	return &consoleBuffer{
+		pattern:  pattern,
+		maxLines: maxLines,
+		matched:  make(chan struct{}),
+	}
+}
+
+// Write never fails: go-libvirt cannot abort an incoming stream, so returning an error
+// would leave the stream packets undelivered and block the connection.
+func (b *consoleBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.done {
+		return len(p), nil
+	}
+
+	text := b.partial + string(p)
+	for !b.done {
+		i := strings.IndexByte(text, '\n')
+		if i < 0 {
+			break
+		}
+		b.addLine(text[:i])
+		text = text[i+1:]
+	}
+
+	if len(text) > maxConsoleLineLength {
+		text = text[len(text)-maxConsoleLineLength:]
+	}
+	b.partial = text
+	if !b.done && b.pattern.MatchString(cleanConsoleLine(text)) {
+		b.match()
+	}
+
+	return len(p), nil
+}
+
+func (b *consoleBuffer) addLine(line string) {
+	line = cleanConsoleLine(line)
+	b.lines = append(b.lines, line)
+	if len(b.lines) > b.maxLines {
+		b.lines = b.lines[len(b.lines)-b.maxLines:]
+	}
+	if b.pattern.MatchString(line) {
+		b.match()
+	}
+}
+
+func (b *consoleBuffer) match() {
+	b.done = true
+	close(b.matched)
+}
+
+func cleanConsoleLine(line string) string {
+	return strings.TrimRight(ansiEscape.ReplaceAllString(line, ""), "\r")
+}
+
+// stop discards any further output.
+func (b *consoleBuffer) stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.done = true
+}
+
+// tail returns the last lines of output, including an unterminated last line.
+func (b *consoleBuffer) tail() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lines := b.lines
+	if partial := cleanConsoleLine(b.partial); partial != "" {
+		lines = append(lines[:len(lines):len(lines)], partial)
+	}
+	if len(lines) > b.maxLines {
+		lines = lines[len(lines)-b.maxLines:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// waitForConsole opens the console of a running domain and waits until its output
+// matches the configured pattern. It returns the last lines of output seen, for
+// diagnostics.
+func waitForConsole(ctx context.Context, client *libvirt.Client, domain golibvirt.Domain, cfg *consoleWaitConfig) (string, error) {
+	buf := newConsoleBuffer(cfg.Pattern, cfg.Lines)
+
+	var device golibvirt.OptString
+	if cfg.Device != "" {
+		device = golibvirt.OptString{cfg.Device}
+	}
+
+	// The console is read on a connection of its own: go-libvirt cannot abort an incoming
+	// stream, so closing the connection is the only way to end it once the wait is over
+	// without holding a forced console on the guest for the rest of the apply.
+	conn, err := libvirt.NewClient(ctx, client.URI())
+	if err != nil {
+		return "", fmt.Errorf("failed to open a connection for the console: %w", err)
+	}
+
+	streamErr := make(chan error, 1)
+	go func() {
+		streamErr <- conn.Libvirt().DomainOpenConsole(domain, device, buf, uint32(golibvirt.DomainConsoleForce))
+	}()
+
+	// endStream closes the console connection and waits for the stream to end.
+	endStream := func() {
+		buf.stop()
+		_ = conn.Close()
+		<-streamErr
+	}
+
+	timer := time.NewTimer(cfg.Timeout)
+	defer timer.Stop()
+
+	select {
+	case <-buf.matched:
+		endStream()
+		return buf.tail(), nil
+	case err := <-streamErr:
+		_ = conn.Close()
+		// The stream may end right after delivering the matching output
+		select {
+		case <-buf.matched:
+			return buf.tail(), nil
+		default:
+		}
+		if err != nil {
+			return buf.tail(), fmt.Errorf("failed to read console: %w", err)
+		}
+		return buf.tail(), fmt.Errorf("console closed before pattern %q appeared", cfg.Pattern)
+	case <-timer.C:
+		endStream()
+		return buf.tail(), fmt.Errorf("timeout waiting for pattern %q on the console after %s", cfg.Pattern, cfg.Timeout)
+	case <-ctx.Done():
+		endStream()
+		return buf.tail(), fmt.Errorf("context canceled while waiting for console output")
+	}
+}
+
+// consoleWaitErrorDetail formats a failed console wait for a diagnostic.
+func consoleWaitErrorDetail(prefix string, err error, output string) string {
+	if output == "" {
+		return fmt.Sprintf("%s: %s\n\nNo console output was received.", prefix, err)
+	}
+	return fmt.Sprintf("%s: %s\n\nLast console output:\n%s", prefix, err, output)
+}
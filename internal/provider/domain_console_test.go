@@ -0,0 +1,151 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestConsoleBuffer(t *testing.T) {
+	t.Parallel()
+
+	buf := newConsoleBuffer(regexp.MustCompile(`login:`), 2)
+	for _, chunk := range []string{"Booting\r\n", "\x1b[32mOK\x1b[0m cloud-init\r", "\nhost lo", "gin: "} {
+		if _, err := buf.Write([]byte(chunk)); err != nil {
+			t.Fatalf("unexpected write error: %v", err)
+		}
+	}
+
+	select {
+	case <-buf.matched:
+	default:
+		t.Fatal("expected the unterminated prompt line to match")
+	}
+	if got, want := buf.tail(), "OK cloud-init\nhost login: "; got != want {
+		t.Errorf("unexpected tail %q, want %q", got, want)
+	}
+
+	// output after the match is discarded
+	if _, err := buf.Write([]byte("more\n")); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	if strings.Contains(buf.tail(), "more") {
+		t.Errorf("expected output after the match to be discarded, got %q", buf.tail())
+	}
+}
+
+func TestConsoleBufferBoundsPartialLine(t *testing.T) {
+	t.Parallel()
+
+	buf := newConsoleBuffer(regexp.MustCompile(`never`), 5)
+	_, _ = buf.Write([]byte(strings.Repeat("x", 3*maxConsoleLineLength)))
+	if len(buf.tail()) != maxConsoleLineLength {
+		t.Errorf("expected partial line to be bounded to %d bytes, got %d", maxConsoleLineLength, len(buf.tail()))
+	}
+}
+
+func testCreateObject(t *testing.T, waitForConsole attr.Value) types.Object {
+	t.Helper()
+
+	obj, diags := types.ObjectValue(
+		map[string]attr.Type{
+			"paused":           types.BoolType,
+			"autodestroy":      types.BoolType,
+			"bypass_cache":     types.BoolType,
+			"force_boot":       types.BoolType,
+			"validate":         types.BoolType,
+			"reset_nvram":      types.BoolType,
+			"wait_for_console": types.ObjectType{AttrTypes: domainWaitForConsoleAttrTypes()},
+		},
+		map[string]attr.Value{
+			"paused":           types.BoolNull(),
+			"autodestroy":      types.BoolNull(),
+			"bypass_cache":     types.BoolNull(),
+			"force_boot":       types.BoolNull(),
+			"validate":         types.BoolNull(),
+			"reset_nvram":      types.BoolNull(),
+			"wait_for_console": waitForConsole,
+		},
+	)
+	if diags.HasError() {
+		t.Fatalf("failed to create create object: %v", diags)
+	}
+	return obj
+}
+
+func TestConsoleWaitConfigFromCreate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cfg, diags := consoleWaitConfigFromCreate(ctx, testCreateObject(t, types.ObjectNull(domainWaitForConsoleAttrTypes())))
+	if diags.HasError() || cfg != nil {
+		t.Fatalf("expected no wait without wait_for_console, got %+v (%v)", cfg, diags)
+	}
+
+	wait := func(pattern string) types.Object {
+		return types.ObjectValueMust(domainWaitForConsoleAttrTypes(), map[string]attr.Value{
+			"pattern": types.StringValue(pattern),
+			"timeout": types.Int64Value(60),
+			"device":  types.StringNull(),
+			"lines":   types.Int64Null(),
+		})
+	}
+
+	cfg, diags = consoleWaitConfigFromCreate(ctx, testCreateObject(t, wait(`login:\s*$`)))
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if cfg.Timeout != time.Minute || cfg.Lines != defaultConsoleWaitLines || cfg.Device != "" {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	if _, diags := consoleWaitConfigFromCreate(ctx, testCreateObject(t, wait(`(`))); !diags.HasError() {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestWaitForConsole(t *testing.T) {
+	client := testMockClient(t)
+	domain, err := client.Libvirt().DomainDefineXML(`<domain type="kvm"><name>console</name>` +
+		`<memory unit="MiB">256</memory><os><type>hvm</type></os>` +
+		`<devices><serial type="pty"><target port="0"/></serial></devices></domain>`)
+	if err != nil {
+		t.Fatalf("failed to define domain: %v", err)
+	}
+	if err := client.Libvirt().DomainCreate(domain); err != nil {
+		t.Fatalf("failed to start domain: %v", err)
+	}
+
+	cfg := &consoleWaitConfig{Pattern: regexp.MustCompile(`console login:`), Timeout: 5 * time.Second, Lines: 3}
+	output, err := waitForConsole(context.Background(), client, domain, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(output, "console login: ") {
+		t.Errorf("unexpected output %q", output)
+	}
+
+	// An early match ends the stream while the console is still writing
+	cfg.Pattern = regexp.MustCompile(`Mock Linux`)
+	if _, err := waitForConsole(context.Background(), client, domain, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := client.Libvirt().DomainGetState(domain, 0); err != nil {
+		t.Fatalf("expected the client to stay usable after the console wait: %v", err)
+	}
+
+	cfg.Pattern = regexp.MustCompile(`cloud-init finished`)
+	output, err = waitForConsole(context.Background(), client, domain, cfg)
+	if err == nil {
+		t.Fatal("expected an error when the console closes without a match")
+	}
+	if detail := consoleWaitErrorDetail("wait failed", err, output); !strings.Contains(detail, "Mock Linux 1.0 console ttyS0") {
+		t.Errorf("expected the console output in the error, got %q", detail)
+	}
+}
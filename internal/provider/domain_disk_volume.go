@@ -0,0 +1,299 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/generated"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"libvirt.org/go/libvirtxml"
+)
+
+func domainDiskSourceVolumeIDSchemaAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		Description: "ID (key) of a storage volume to use as the disk source, such as libvirt_volume.id. " +
+			"The volume is looked up at apply time and attached by pool and name, with the driver format taken " +
+			"from the volume unless driver.type is set. Conflicts with the other source types.",
+		MarkdownDescription: "ID (key) of a storage volume to use as the disk source, such as `libvirt_volume.id`. " +
+			"The volume is looked up at apply time and attached by pool and name, with the driver format taken " +
+			"from the volume unless `driver.type` is set. Conflicts with the other source types.",
+		Optional: true,
+	}
+}
+
+// addDiskVolumeIDSchemaAttribute adds volume_id to the source of each disk in devices.
+func addDiskVolumeIDSchemaAttribute(devices schema.SingleNestedAttribute) schema.SingleNestedAttribute {
+	disksAttr, ok := devices.Attributes["disks"].(schema.ListNestedAttribute)
+	if !ok {
+		return devices
+	}
+	sourceAttr, ok := disksAttr.NestedObject.Attributes["source"].(schema.SingleNestedAttribute)
+	if !ok {
+		return devices
+	}
+
+	sourceAttr.Attributes["volume_id"] = domainDiskSourceVolumeIDSchemaAttribute()
+	disksAttr.NestedObject.Attributes["source"] = sourceAttr
+	devices.Attributes["disks"] = disksAttr
+	return devices
+}
+
+func domainDiskSourceAttributeTypesWithVolumeID() map[string]attr.Type {
+	base := copyAttrTypesMap(generated.DomainDiskSourceAttributeTypes())
+	base["volume_id"] = types.StringType
+	return base
+}
+
+func domainDiskAttributeTypesWithVolumeID() map[string]attr.Type {
+	base := copyAttrTypesMap(generated.DomainDiskAttributeTypes())
+	base["source"] = types.ObjectType{AttrTypes: domainDiskSourceAttributeTypesWithVolumeID()}
+	return base
+}
+
+// replaceDisksType returns the attribute types of devices with the element type of disks
+// replaced by diskTypes.
+func replaceDisksType(ctx context.Context, devices types.Object, diskTypes map[string]attr.Type) map[string]attr.Type {
+	attrTypes := copyAttrTypesMap(devices.AttributeTypes(ctx))
+	attrTypes["disks"] = types.ListType{ElemType: types.ObjectType{AttrTypes: diskTypes}}
+	return attrTypes
+}
+
+// stripDiskVolumeIDs removes volume_id from the source of each disk in devices, so that
+// the disks match the generated types, and returns the values indexed like the disks.
+func stripDiskVolumeIDs(ctx context.Context, devices types.Object) (types.Object, []types.String, diag.Diagnostics) {
+	if devices.IsNull() || devices.IsUnknown() {
+		return devices, nil, nil
+	}
+
+	volumeIDs, disks, diags := convertDiskSources(ctx, devices.Attributes()["disks"], generated.DomainDiskAttributeTypes(), generated.DomainDiskSourceAttributeTypes(), nil)
+	if diags.HasError() {
+		return devices, nil, diags
+	}
+
+	attrs := make(map[string]attr.Value, len(devices.Attributes()))
+	for k, v := range devices.Attributes() {
+		attrs[k] = v
+	}
+	attrs["disks"] = disks
+
+	cleanObj, diags := types.ObjectValue(replaceDisksType(ctx, devices, generated.DomainDiskAttributeTypes()), attrs)
+	return cleanObj, volumeIDs, diags
+}
+
+// applyDiskVolumeIDs converts the disks in devices back to the resource schema types,
+// restoring the volume_id values stripped by stripDiskVolumeIDs.
+func applyDiskVolumeIDs(ctx context.Context, devices types.Object, volumeIDs []types.String) (types.Object, diag.Diagnostics) {
+	attrTypes := replaceDisksType(ctx, devices, domainDiskAttributeTypesWithVolumeID())
+	if devices.IsNull() {
+		return types.ObjectNull(attrTypes), nil
+	}
+	if devices.IsUnknown() {
+		return types.ObjectUnknown(attrTypes), nil
+	}
+
+	_, disks, diags := convertDiskSources(ctx, devices.Attributes()["disks"], domainDiskAttributeTypesWithVolumeID(), domainDiskSourceAttributeTypesWithVolumeID(), volumeIDs)
+	if diags.HasError() {
+		return types.ObjectNull(attrTypes), diags
+	}
+
+	attrs := make(map[string]attr.Value, len(devices.Attributes()))
+	for k, v := range devices.Attributes() {
+		attrs[k] = v
+	}
+	attrs["disks"] = disks
+	return types.ObjectValue(attrTypes, attrs)
+}
+
+// convertDiskSources rebuilds the disks list with the given disk and source types. The
+// volume_id of each source is returned and, when the source types include it, set from
+// volumeIDs.
+func convertDiskSources(ctx context.Context, rawDisks attr.Value, diskTypes, sourceTypes map[string]attr.Type, volumeIDs []types.String) ([]types.String, attr.Value, diag.Diagnostics) {
+	diskType := types.ObjectType{AttrTypes: diskTypes}
+	_, withVolumeID := sourceTypes["volume_id"]
+
+	disks, ok := rawDisks.(basetypes.ListValue)
+	if !ok || disks.IsNull() {
+		return nil, types.ListNull(diskType), nil
+	}
+	if disks.IsUnknown() {
+		return nil, types.ListUnknown(diskType), nil
+	}
+
+	elements := disks.Elements()
+	found := make([]types.String, len(elements))
+	newDisks := make([]attr.Value, len(elements))
+	for i, element := range elements {
+		found[i] = types.StringNull()
+
+		disk, ok := element.(basetypes.ObjectValue)
+		if !ok {
+			return nil, types.ListNull(diskType), diag.Diagnostics{
+				diag.NewErrorDiagnostic("Invalid disk value", "Expected disk entry to be an object."),
+			}
+		}
+		if disk.IsNull() || disk.IsUnknown() {
+			newDisks[i] = types.ObjectNull(diskTypes)
+			if disk.IsUnknown() {
+				newDisks[i] = types.ObjectUnknown(diskTypes)
+			}
+			continue
+		}
+
+		volumeID := types.StringNull()
+		if withVolumeID && i < len(volumeIDs) {
+			volumeID = volumeIDs[i]
+		}
+
+		var newSource attr.Value
+		source, _ := disk.Attributes()["source"].(basetypes.ObjectValue)
+		switch {
+		case source.IsUnknown():
+			newSource = types.ObjectUnknown(sourceTypes)
+		case source.IsNull() && volumeID.IsNull():
+			newSource = types.ObjectNull(sourceTypes)
+		default:
+			sourceAttrs := make(map[string]attr.Value, len(sourceTypes))
+			for name, attrType := range sourceTypes {
+				if value, ok := source.Attributes()[name]; ok && !source.IsNull() {
+					sourceAttrs[name] = value
+				} else {
+					sourceAttrs[name] = nullValue(ctx, attrType)
+				}
+			}
+			if id, ok := source.Attributes()["volume_id"].(types.String); ok {
+				found[i] = id
+			}
+			if withVolumeID {
+				sourceAttrs["volume_id"] = volumeID
+			}
+
+			var diags diag.Diagnostics
+			newSource, diags = types.ObjectValue(sourceTypes, sourceAttrs)
+			if diags.HasError() {
+				return nil, types.ListNull(diskType), diags
+			}
+		}
+
+		diskAttrs := make(map[string]attr.Value, len(diskTypes))
+		for k, v := range disk.Attributes() {
+			diskAttrs[k] = v
+		}
+		diskAttrs["source"] = newSource
+
+		newDisk, diags := types.ObjectValue(diskTypes, diskAttrs)
+		if diags.HasError() {
+			return nil, types.ListNull(diskType), diags
+		}
+		newDisks[i] = newDisk
+	}
+
+	list, diags := types.ListValue(diskType, newDisks)
+	return found, list, diags
+}
+
+// nullValue returns the null value of attrType.
+func nullValue(ctx context.Context, attrType attr.Type) attr.Value {
+	value, err := attrType.ValueFromTerraform(ctx, tftypes.NewValue(attrType.TerraformType(ctx), nil))
+	if err != nil {
+		panic(fmt.Sprintf("creating null value of %s: %s", attrType, err))
+	}
+	return value
+}
+
+// resolveDiskVolumeIDs attaches the volumes referenced by volume_id to the disks of def,
+// which are indexed like volumeIDs. Each volume is looked up by key and referenced by
+// pool and name, and its format is used as the driver type when none is configured.
+func resolveDiskVolumeIDs(client *libvirt.Client, def *libvirtxml.Domain, volumeIDs []types.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if def.Devices == nil {
+		return diags
+	}
+
+	for i, volumeID := range volumeIDs {
+		if volumeID.IsNull() || volumeID.IsUnknown() || i >= len(def.Devices.Disks) {
+			continue
+		}
+		attrPath := path.Root("devices").AtName("disks").AtListIndex(i).AtName("source").AtName("volume_id")
+		disk := &def.Devices.Disks[i]
+
+		if src := disk.Source; src != nil && (src.File != nil || src.Block != nil || src.Dir != nil || src.Network != nil ||
+			src.Volume != nil || src.NVME != nil || src.VHostUser != nil || src.VHostVDPA != nil) {
+			diags.AddAttributeError(attrPath, "Conflicting Disk Source",
+				"volume_id cannot be combined with another source type (file, block, dir, network, volume, nvme, vhost_user or vhost_vdpa).")
+			continue
+		}
+
+		volume, err := client.Libvirt().StorageVolLookupByKey(volumeID.ValueString())
+		if err != nil {
+			diags.AddAttributeError(attrPath, "Volume Not Found",
+				fmt.Sprintf("Failed to look up storage volume %q: %s", volumeID.ValueString(), err))
+			continue
+		}
+		pool, err := client.Libvirt().StoragePoolLookupByVolume(volume)
+		if err != nil {
+			diags.AddAttributeError(attrPath, "Volume Not Found",
+				fmt.Sprintf("Failed to look up the pool of storage volume %q: %s", volumeID.ValueString(), err))
+			continue
+		}
+		volumeXML, err := client.Libvirt().StorageVolGetXMLDesc(volume, 0)
+		if err != nil {
+			diags.AddAttributeError(attrPath, "Failed to Read Volume",
+				fmt.Sprintf("Failed to get XML of storage volume %q: %s", volumeID.ValueString(), err))
+			continue
+		}
+		var volumeDef libvirtxml.StorageVolume
+		if err := volumeDef.Unmarshal(volumeXML); err != nil {
+			diags.AddAttributeError(attrPath, "Failed to Read Volume",
+				fmt.Sprintf("Failed to parse XML of storage volume %q: %s", volumeID.ValueString(), err))
+			continue
+		}
+
+		if disk.Source == nil {
+			disk.Source = &libvirtxml.DomainDiskSource{}
+		}
+		disk.Source.Volume = &libvirtxml.DomainDiskSourceVolume{Pool: pool.Name, Volume: volume.Name}
+
+		if volumeDef.Target != nil && volumeDef.Target.Format != nil && volumeDef.Target.Format.Type != "" {
+			if disk.Driver == nil {
+				disk.Driver = &libvirtxml.DomainDiskDriver{Name: "qemu"}
+			}
+			if disk.Driver.Type == "" {
+				disk.Driver.Type = volumeDef.Target.Format.Type
+			}
+		}
+	}
+	return diags
+}
+
+// errDiskNotVolume is returned by lookupDiskVolume for disks whose source cannot be a
+// storage volume, such as network disks.
+var errDiskNotVolume = errors.New("disk source is not a file, block device or storage volume")
+
+// lookupDiskVolume returns the storage volume behind the source of disk.
+func lookupDiskVolume(client *libvirt.Client, disk *libvirtxml.DomainDisk) (golibvirt.StorageVol, error) {
+	switch {
+	case disk.Source == nil:
+		return golibvirt.StorageVol{}, errDiskNotVolume
+	case disk.Source.File != nil:
+		return client.Libvirt().StorageVolLookupByPath(disk.Source.File.File)
+	case disk.Source.Block != nil:
+		return client.Libvirt().StorageVolLookupByPath(disk.Source.Block.Dev)
+	case disk.Source.Volume != nil:
+		pool, err := client.Libvirt().StoragePoolLookupByName(disk.Source.Volume.Pool)
+		if err != nil {
+			return golibvirt.StorageVol{}, err
+		}
+		return client.Libvirt().StorageVolLookupByName(pool, disk.Source.Volume.Volume)
+	default:
+		return golibvirt.StorageVol{}, errDiskNotVolume
+	}
+}
@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	libvirtclient "github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+func testCreateVolume(t *testing.T, client *libvirtclient.Client, name, format string) string {
+	t.Helper()
+
+	pool, err := client.Libvirt().StoragePoolLookupByName("default")
+	if err != nil {
+		t.Fatalf("failed to look up pool: %v", err)
+	}
+	volume, err := client.Libvirt().StorageVolCreateXML(pool,
+		"<volume><name>"+name+"</name><capacity>1048576</capacity>"+
+			"<target><format type='"+format+"'/></target></volume>", 0)
+	if err != nil {
+		t.Fatalf("failed to create volume: %v", err)
+	}
+	return volume.Key
+}
+
+func TestDomainResourceDiskVolumeID(t *testing.T) {
+	client := testMockClient(t)
+	key := testCreateVolume(t, client, "root.qcow2", "qcow2")
+
+	state, diags := testCreateResource(t, NewDomainResource(), client, map[string]any{
+		"name":   "test-volume-id",
+		"memory": 256,
+		"type":   "kvm",
+		"os":     map[string]any{"type": "hvm"},
+		"devices": map[string]any{
+			"disks": []any{
+				map[string]any{
+					"source": map[string]any{"volume_id": key},
+					"target": map[string]any{"dev": "vda", "bus": "virtio"},
+				},
+			},
+		},
+	})
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	domain, err := client.Libvirt().DomainLookupByName("test-volume-id")
+	if err != nil {
+		t.Fatalf("failed to look up domain: %v", err)
+	}
+	xmlDesc, err := client.Libvirt().DomainGetXMLDesc(domain, golibvirt.DomainXMLSecure)
+	if err != nil {
+		t.Fatalf("failed to get domain XML: %v", err)
+	}
+	for _, expected := range []string{`pool="default" volume="root.qcow2"`, `type="qcow2"`} {
+		if !strings.Contains(xmlDesc, expected) {
+			t.Errorf("expected %s in the defined XML, got\n%s", expected, xmlDesc)
+		}
+	}
+
+	sourcePath := path.Root("devices").AtName("disks").AtListIndex(0).AtName("source")
+	if id := testStateValue(t, state, sourcePath.AtName("volume_id")); id != key {
+		t.Errorf("expected the volume ID in state, got %q", id)
+	}
+	if volume := testStateValue(t, state, sourcePath.AtName("volume")); volume != "<null>" {
+		t.Errorf("expected the resolved volume not to be stored, got %s", volume)
+	}
+
+	state, diags = testReadResource(t, NewDomainResource(), client, state)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if id := testStateValue(t, state, sourcePath.AtName("volume_id")); id != key {
+		t.Errorf("expected the volume ID to be kept on refresh, got %q", id)
+	}
+	if driver := testStateValue(t, state, path.Root("devices").AtName("disks").AtListIndex(0).AtName("driver")); driver != "<null>" {
+		t.Errorf("expected the driver format not to be stored, got %s", driver)
+	}
+}
+
+func TestDomainResourceDiskVolumeIDErrors(t *testing.T) {
+	client := testMockClient(t)
+	key := testCreateVolume(t, client, "data.raw", "raw")
+
+	tests := []struct {
+		name    string
+		source  map[string]any
+		summary string
+	}{
+		{
+			name:    "unknown volume",
+			source:  map[string]any{"volume_id": "/var/lib/libvirt/images/missing.qcow2"},
+			summary: "Volume Not Found",
+		},
+		{
+			name: "conflicting source",
+			source: map[string]any{
+				"volume_id": key,
+				"file":      map[string]any{"file": "/tmp/disk.img"},
+			},
+			summary: "Conflicting Disk Source",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, diags := testCreateResource(t, NewDomainResource(), client, map[string]any{
+				"name":   "test-volume-id-error",
+				"memory": 256,
+				"type":   "kvm",
+				"os":     map[string]any{"type": "hvm"},
+				"devices": map[string]any{
+					"disks": []any{
+						map[string]any{
+							"source": tc.source,
+							"target": map[string]any{"dev": "vda"},
+						},
+					},
+				},
+			})
+			if !diags.HasError() || diags.Errors()[0].Summary() != tc.summary {
+				t.Fatalf("expected %q, got %v", tc.summary, diags)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package provider
+
+import (
+	"fmt"
+	"slices"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"libvirt.org/go/libvirtxml"
+)
+
+func domainNVRAMTemplateSchemaAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		Description: "Path of the NVRAM template the UEFI variable store is created from when the domain has none yet, " +
+			"such as an OVMF_VARS image with enrolled Secure Boot keys. Only used when os.nv_ram does not set a template. " +
+			"With os.firmware = \"efi\" libvirt selects the template matching the firmware features on its own.",
+		MarkdownDescription: "Path of the NVRAM template the UEFI variable store is created from when the domain has none yet, " +
+			"such as an `OVMF_VARS` image with enrolled Secure Boot keys. Only used when `os.nv_ram` does not set a template. " +
+			"With `os.firmware = \"efi\"` libvirt selects the template matching the firmware features on its own.",
+		Optional: true,
+	}
+}
+
+// applyNVRAMTemplate sets template as the NVRAM template of def unless one is configured.
+// libvirt only copies the template when the NVRAM file does not exist, so an existing
+// variable store is kept unless it is reset.
+func applyNVRAMTemplate(def *libvirtxml.Domain, template string) {
+	if template == "" {
+		return
+	}
+	if def.OS == nil {
+		def.OS = &libvirtxml.DomainOS{}
+	}
+	if def.OS.NVRam == nil {
+		def.OS.NVRam = &libvirtxml.DomainNVRam{}
+	}
+	if def.OS.NVRam.Template == "" {
+		def.OS.NVRam.Template = template
+	}
+}
+
+// keepDomainFirmware pins the loader and NVRAM that libvirt selected for os.firmware when
+// domain was defined, so that the redefined domain keeps booting the firmware its variable
+// store was created for instead of whatever image the firmware descriptors of the host
+// prefer now. A loader the domain capabilities no longer list is left to libvirt to select
+// again.
+func keepDomainFirmware(client *libvirt.Client, domain golibvirt.Domain, def *libvirtxml.Domain) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if def.OS == nil || def.OS.Firmware == "" || (def.OS.Loader != nil && def.OS.Loader.Path != "") {
+		return diags
+	}
+
+	xmlDesc, err := client.Libvirt().DomainGetXMLDesc(domain, golibvirt.DomainXMLInactive)
+	if err != nil {
+		diags.AddWarning("Failed to Keep Firmware",
+			"Failed to read the firmware selected for the domain, libvirt selects it again: "+err.Error())
+		return diags
+	}
+	current, err := libvirt.UnmarshalDomainXML(xmlDesc)
+	if err != nil {
+		diags.AddWarning("Failed to Keep Firmware",
+			"Failed to parse the firmware selected for the domain, libvirt selects it again: "+err.Error())
+		return diags
+	}
+	if current.OS == nil || current.OS.Firmware != def.OS.Firmware || current.OS.Loader == nil || current.OS.Loader.Path == "" {
+		return diags
+	}
+
+	var arch, machine string
+	if def.OS.Type != nil {
+		arch = def.OS.Type.Arch
+		machine = def.OS.Type.Machine
+	}
+	caps, err := client.DomainCapabilities(arch, machine, def.Type)
+	if err != nil {
+		diags.AddWarning("Failed to Keep Firmware",
+			"Failed to query the domain capabilities of the host, libvirt selects the firmware again: "+err.Error())
+		return diags
+	}
+	if caps.OS == nil || caps.OS.Loader == nil || !slices.Contains(caps.OS.Loader.Values, current.OS.Loader.Path) {
+		return diags
+	}
+
+	loader := *current.OS.Loader
+	if def.OS.Loader != nil {
+		if def.OS.Loader.Readonly != "" {
+			loader.Readonly = def.OS.Loader.Readonly
+		}
+		if def.OS.Loader.Secure != "" {
+			loader.Secure = def.OS.Loader.Secure
+		}
+		if def.OS.Loader.Type != "" {
+			loader.Type = def.OS.Loader.Type
+		}
+	}
+	def.OS.Loader = &loader
+
+	if current.OS.NVRam == nil {
+		return diags
+	}
+	if def.OS.NVRam == nil {
+		def.OS.NVRam = &libvirtxml.DomainNVRam{}
+	}
+	if def.OS.NVRam.NVRam == "" && def.OS.NVRam.Source == nil {
+		def.OS.NVRam.NVRam = current.OS.NVRam.NVRam
+	}
+	if def.OS.NVRam.Template == "" {
+		def.OS.NVRam.Template = current.OS.NVRam.Template
+	}
+	return diags
+}
+
+// validateDomainFirmwareFeatures checks the firmware features libvirt matches against the
+// firmware descriptors of the host to select the loader and NVRAM template when
+// os.firmware is set.
+func validateDomainFirmwareFeatures(diags *diag.Diagnostics, caps *libvirtxml.DomainCaps, os *libvirtxml.DomainOS) {
+	if os.FirmwareInfo == nil || len(os.FirmwareInfo.Features) == 0 {
+		return
+	}
+	featuresPath := path.Root("os").AtName("firmware_info").AtName("features")
+
+	if os.Firmware == "" {
+		diags.AddAttributeError(
+			featuresPath,
+			"Missing Firmware Type",
+			"Firmware features are only used to select the firmware automatically, which requires os.firmware to be set, for example to \"efi\".",
+		)
+		return
+	}
+
+	enabled := make(map[string]string, len(os.FirmwareInfo.Features))
+	for i, feature := range os.FirmwareInfo.Features {
+		enabledPath := featuresPath.AtListIndex(i).AtName("enabled")
+		if feature.Enabled != "yes" && feature.Enabled != "no" {
+			diags.AddAttributeError(
+				enabledPath,
+				"Invalid Firmware Feature",
+				fmt.Sprintf("Firmware feature %q must be enabled with \"yes\" or \"no\", got %q.", feature.Name, feature.Enabled),
+			)
+			continue
+		}
+		enabled[feature.Name] = feature.Enabled
+
+		if feature.Name == "secure-boot" && feature.Enabled == "yes" && caps.OS.Loader != nil {
+			checkCapsEnum(diags, enabledPath, "secure boot", feature.Enabled, caps.OS.Loader.Enums, "secure")
+		}
+	}
+
+	if enabled["enrolled-keys"] == "yes" && enabled["secure-boot"] == "no" {
+		diags.AddAttributeError(
+			featuresPath,
+			"Conflicting Firmware Features",
+			"Firmware feature \"enrolled-keys\" cannot be enabled when \"secure-boot\" is disabled.",
+		)
+	}
+}
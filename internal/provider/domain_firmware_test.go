@@ -0,0 +1,124 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"libvirt.org/go/libvirtxml"
+)
+
+func TestDomainResourceNVRAMTemplate(t *testing.T) {
+	client := testMockClient(t)
+
+	state, diags := testCreateResource(t, NewDomainResource(), client, map[string]any{
+		"name":   "secure-boot",
+		"memory": 512,
+		"type":   "kvm",
+		"os": map[string]any{
+			"type":         "hvm",
+			"type_machine": "q35",
+			"firmware":     "efi",
+			"firmware_info": map[string]any{
+				"features": []any{
+					map[string]any{"name": "secure-boot", "enabled": "yes"},
+				},
+			},
+		},
+		"create": map[string]any{
+			"nvram_template": "/usr/share/OVMF/OVMF_VARS.secboot.fd",
+		},
+	})
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	def := testDomainDef(t, client, "secure-boot")
+	if nvram := def.OS.NVRam; nvram == nil || nvram.Template != "/usr/share/OVMF/OVMF_VARS.secboot.fd" {
+		t.Errorf("expected the NVRAM template to be set, got %+v", nvram)
+	}
+	if info := def.OS.FirmwareInfo; info == nil || len(info.Features) != 1 || info.Features[0].Name != "secure-boot" {
+		t.Errorf("expected the firmware features to be kept, got %+v", info)
+	}
+
+	if nvram := testStateValue(t, state, path.Root("os").AtName("nv_ram")); nvram != "<null>" {
+		t.Errorf("expected the NVRAM template not to be stored in os.nv_ram, got %s", nvram)
+	}
+
+	state, diags = testReadResource(t, NewDomainResource(), client, state)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if template := testStateValue(t, state, path.Root("create").AtName("nvram_template")); template != "/usr/share/OVMF/OVMF_VARS.secboot.fd" {
+		t.Errorf("expected the NVRAM template to be kept on refresh, got %q", template)
+	}
+}
+
+func TestKeepDomainFirmware(t *testing.T) {
+	tests := []struct {
+		name       string
+		loader     string
+		def        *libvirtxml.DomainOS
+		wantLoader string
+		wantNVRAM  string
+	}{
+		{
+			name:       "keeps the selected firmware",
+			loader:     "/usr/share/OVMF/OVMF_CODE.secboot.fd",
+			def:        &libvirtxml.DomainOS{Firmware: "efi"},
+			wantLoader: "/usr/share/OVMF/OVMF_CODE.secboot.fd",
+			wantNVRAM:  "/usr/share/OVMF/OVMF_VARS.secboot.fd",
+		},
+		{
+			name:   "selects firmware the host no longer lists again",
+			loader: "/usr/share/OVMF/OVMF_CODE.removed.fd",
+			def:    &libvirtxml.DomainOS{Firmware: "efi"},
+		},
+		{
+			name:       "keeps a configured loader",
+			loader:     "/usr/share/OVMF/OVMF_CODE.secboot.fd",
+			def:        &libvirtxml.DomainOS{Firmware: "efi", Loader: &libvirtxml.DomainLoader{Path: "/usr/share/OVMF/OVMF_CODE.fd"}},
+			wantLoader: "/usr/share/OVMF/OVMF_CODE.fd",
+		},
+		{
+			name:   "ignores domains without firmware selection",
+			loader: "/usr/share/OVMF/OVMF_CODE.secboot.fd",
+			def:    &libvirtxml.DomainOS{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := testMockClient(t)
+			domain, err := client.Libvirt().DomainDefineXML(fmt.Sprintf(`<domain type="kvm">
+  <name>secure-boot</name>
+  <memory unit="KiB">524288</memory>
+  <os firmware="efi">
+    <type arch="x86_64" machine="q35">hvm</type>
+    <loader readonly="yes" secure="yes" type="pflash">%s</loader>
+    <nvram template="/usr/share/OVMF/OVMF_VARS.secboot.fd">/var/lib/libvirt/qemu/nvram/secure-boot_VARS.fd</nvram>
+  </os>
+</domain>`, tc.loader))
+			if err != nil {
+				t.Fatalf("failed to define domain: %v", err)
+			}
+
+			tc.def.Type = &libvirtxml.DomainOSType{Type: "hvm", Arch: "x86_64", Machine: "q35"}
+			def := &libvirtxml.Domain{Type: "kvm", OS: tc.def}
+			if diags := keepDomainFirmware(client, domain, def); diags.HasError() || diags.WarningsCount() > 0 {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			var loader, nvram string
+			if def.OS.Loader != nil {
+				loader = def.OS.Loader.Path
+			}
+			if def.OS.NVRam != nil {
+				nvram = def.OS.NVRam.Template
+			}
+			if loader != tc.wantLoader || nvram != tc.wantNVRAM {
+				t.Errorf("expected loader %q and NVRAM template %q, got %q and %q", tc.wantLoader, tc.wantNVRAM, loader, nvram)
+			}
+		})
+	}
+}
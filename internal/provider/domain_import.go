@@ -0,0 +1,196 @@
+package provider
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"libvirt.org/go/libvirtxml"
+)
+
+var (
+	// versionedMachineTypes maps versioned machine types to the alias libvirt expands
+	versionedMachineTypes = []struct {
+		pattern *regexp.Regexp
+		alias   string
+	}{
+		{regexp.MustCompile(`^pc-q35-\d+(\.\d+)*$`), "q35"},
+		{regexp.MustCompile(`^pc-i440fx-\d+(\.\d+)*$`), "pc"},
+		{regexp.MustCompile(`^virt-\d+(\.\d+)*$`), "virt"},
+	}
+
+	// generatedInterfaceTarget matches the tap and macvtap names libvirt assigns
+	generatedInterfaceTarget = regexp.MustCompile(`^(vnet|macvtap|macvlan)\d+$`)
+)
+
+// normalizeImportedDomain removes what libvirt generates when it defines or starts a
+// domain, so that the configuration generated on import is small and plans cleanly.
+// Everything removed here is added back by libvirt when the domain is defined again:
+//
+//   - device addresses and aliases
+//   - implicit controllers: PCI controllers and the first USB, IDE, SATA and
+//     virtio-serial controller (a USB controller with model "none" is kept)
+//   - the default virtio memory balloon and the PS/2 mouse and keyboard
+//   - dynamic security labels and the default /machine resource partition
+//   - versioned machine types such as pc-q35-8.2, which become machineAlias (q35) when
+//     it is set
+//   - runtime state of running domains: tap device names, automatically allocated
+//     graphics ports, listen elements mirroring the listen attribute and disk
+//     backing chains
+func normalizeImportedDomain(def *libvirtxml.Domain, machineAlias string) {
+	if def.OS != nil && def.OS.Type != nil && machineAlias != "" {
+		def.OS.Type.Machine = machineAlias
+	}
+
+	var secLabels []libvirtxml.DomainSecLabel
+	for _, label := range def.SecLabel {
+		if label.Type != "dynamic" {
+			secLabels = append(secLabels, label)
+		}
+	}
+	def.SecLabel = secLabels
+
+	if def.Resource != nil && def.Resource.Partition == "/machine" && def.Resource.FibreChannel == nil {
+		def.Resource = nil
+	}
+
+	if def.Devices == nil {
+		return
+	}
+	devices := def.Devices
+	clearDeviceAddresses(devices)
+
+	var controllers []libvirtxml.DomainController
+	for _, controller := range devices.Controllers {
+		if !isImplicitController(controller) {
+			controllers = append(controllers, controller)
+		}
+	}
+	devices.Controllers = controllers
+
+	if balloon := devices.MemBalloon; balloon != nil && balloon.Model == "virtio" &&
+		balloon.Stats == nil && balloon.Driver == nil && balloon.AutoDeflate == "" && balloon.FreePageReporting == "" {
+		devices.MemBalloon = nil
+	}
+
+	var inputs []libvirtxml.DomainInput
+	for _, input := range devices.Inputs {
+		if input.Bus == "ps2" && (input.Type == "mouse" || input.Type == "keyboard") {
+			continue
+		}
+		inputs = append(inputs, input)
+	}
+	devices.Inputs = inputs
+
+	for i := range devices.Interfaces {
+		if target := devices.Interfaces[i].Target; target != nil && generatedInterfaceTarget.MatchString(target.Dev) {
+			devices.Interfaces[i].Target = nil
+		}
+	}
+
+	for i := range devices.Disks {
+		devices.Disks[i].BackingStore = nil
+	}
+
+	for i := range devices.Graphics {
+		normalizeImportedGraphics(&devices.Graphics[i])
+	}
+}
+
+// importedMachineAlias returns the alias of the versioned machine type of def, such as q35
+// for pc-q35-8.2, when the host currently expands the alias to that same machine type.
+// Otherwise the versioned type pins an older machine ABI and is kept, and it returns "".
+func importedMachineAlias(client *libvirt.Client, def *libvirtxml.Domain) string {
+	if def.OS == nil || def.OS.Type == nil {
+		return ""
+	}
+	machine := def.OS.Type.Machine
+	for _, m := range versionedMachineTypes {
+		if !m.pattern.MatchString(machine) {
+			continue
+		}
+		caps, err := client.DomainCapabilities(def.OS.Type.Arch, m.alias, def.Type)
+		if err != nil || caps.Machine != machine {
+			return ""
+		}
+		return m.alias
+	}
+	return ""
+}
+
+// isImplicitController reports whether libvirt adds controller on its own.
+func isImplicitController(controller libvirtxml.DomainController) bool {
+	index := controller.Index != nil && *controller.Index == 0
+	switch controller.Type {
+	case "pci":
+		return true
+	case "usb":
+		return index && controller.Model != "none"
+	case "ide", "sata", "virtio-serial":
+		return index
+	}
+	return false
+}
+
+func normalizeImportedGraphics(graphics *libvirtxml.DomainGraphic) {
+	switch {
+	case graphics.VNC != nil:
+		vnc := graphics.VNC
+		if vnc.AutoPort == "yes" {
+			vnc.Port = 0
+		}
+		vnc.Listeners = mirroredListeners(vnc.Listen, vnc.Listeners)
+	case graphics.Spice != nil:
+		spice := graphics.Spice
+		if spice.AutoPort == "yes" {
+			spice.Port = 0
+			spice.TLSPort = 0
+		}
+		spice.Listeners = mirroredListeners(spice.Listen, spice.Listeners)
+	}
+}
+
+// mirroredListeners drops the listen element libvirt adds to mirror the listen attribute.
+func mirroredListeners(listen string, listeners []libvirtxml.DomainGraphicListener) []libvirtxml.DomainGraphicListener {
+	if listen == "" || len(listeners) != 1 {
+		return listeners
+	}
+	if address := listeners[0].Address; address != nil && address.Address == listen {
+		return nil
+	}
+	return listeners
+}
+
+// clearDeviceAddresses drops the address and alias of every device. Only the fields of
+// the device itself are cleared: nested addresses, such as the host address of a PCI
+// passthrough device, are configuration.
+func clearDeviceAddresses(devices *libvirtxml.DomainDeviceList) {
+	v := reflect.ValueOf(devices).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.Slice:
+			for j := 0; j < field.Len(); j++ {
+				clearAddressFields(field.Index(j))
+			}
+		case reflect.Ptr:
+			if !field.IsNil() {
+				clearAddressFields(field.Elem())
+			}
+		}
+	}
+}
+
+func clearAddressFields(device reflect.Value) {
+	if device.Kind() != reflect.Struct {
+		return
+	}
+	for _, name := range []string{"Address", "Alias"} {
+		field := device.FieldByName(name)
+		if field.IsValid() && field.Kind() == reflect.Ptr && field.CanSet() &&
+			strings.HasPrefix(field.Type().Elem().Name(), "Domain") {
+			field.Set(reflect.Zero(field.Type()))
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"libvirt.org/go/libvirtxml"
+)
+
+// testImportedDomainXML is a domain as libvirt reports it while running, with everything
+// libvirt fills in on its own.
+const testImportedDomainXML = `<domain type='kvm' id='3'>
+  <name>test-import</name>
+  <uuid>6c0b9a5e-8f4e-4d1b-9a57-3f8f2d1c0e11</uuid>
+  <memory unit='KiB'>1048576</memory>
+  <vcpu placement='static'>1</vcpu>
+  <resource>
+    <partition>/machine</partition>
+  </resource>
+  <os>
+    <type arch='x86_64' machine='pc-q35-8.2'>hvm</type>
+  </os>
+  <devices>
+    <disk type='file' device='disk'>
+      <driver name='qemu' type='qcow2'/>
+      <source file='/var/lib/libvirt/images/test.qcow2' index='1'/>
+      <backingStore type='file' index='2'>
+        <format type='qcow2'/>
+        <source file='/var/lib/libvirt/images/base.qcow2'/>
+      </backingStore>
+      <target dev='vda' bus='virtio'/>
+      <alias name='virtio-disk0'/>
+      <address type='pci' domain='0x0000' bus='0x04' slot='0x00' function='0x0'/>
+    </disk>
+    <controller type='usb' index='0' model='qemu-xhci' ports='15'>
+      <alias name='usb'/>
+      <address type='pci' domain='0x0000' bus='0x02' slot='0x00' function='0x0'/>
+    </controller>
+    <controller type='pci' index='0' model='pcie-root'>
+      <alias name='pcie.0'/>
+    </controller>
+    <controller type='pci' index='1' model='pcie-root-port'>
+      <model name='pcie-root-port'/>
+      <target chassis='1' port='0x10'/>
+      <alias name='pci.1'/>
+      <address type='pci' domain='0x0000' bus='0x00' slot='0x02' function='0x0' multifunction='on'/>
+    </controller>
+    <controller type='sata' index='0'>
+      <alias name='ide'/>
+      <address type='pci' domain='0x0000' bus='0x00' slot='0x1f' function='0x2'/>
+    </controller>
+    <controller type='scsi' index='0' model='virtio-scsi'>
+      <alias name='scsi0'/>
+      <address type='pci' domain='0x0000' bus='0x03' slot='0x00' function='0x0'/>
+    </controller>
+    <interface type='network'>
+      <mac address='52:54:00:6b:3c:58'/>
+      <source network='default'/>
+      <target dev='vnet2'/>
+      <model type='virtio'/>
+      <alias name='net0'/>
+      <address type='pci' domain='0x0000' bus='0x01' slot='0x00' function='0x0'/>
+    </interface>
+    <input type='tablet' bus='usb'>
+      <alias name='input0'/>
+      <address type='usb' bus='0' port='1'/>
+    </input>
+    <input type='mouse' bus='ps2'>
+      <alias name='input1'/>
+    </input>
+    <input type='keyboard' bus='ps2'>
+      <alias name='input2'/>
+    </input>
+    <graphics type='vnc' port='5900' autoport='yes' listen='127.0.0.1'>
+      <listen type='address' address='127.0.0.1'/>
+    </graphics>
+    <memballoon model='virtio'>
+      <alias name='balloon0'/>
+      <address type='pci' domain='0x0000' bus='0x05' slot='0x00' function='0x0'/>
+    </memballoon>
+  </devices>
+  <seclabel type='dynamic' model='selinux' relabel='yes'>
+    <label>system_u:system_r:svirt_t:s0:c392,c662</label>
+    <imagelabel>system_u:object_r:svirt_image_t:s0:c392,c662</imagelabel>
+  </seclabel>
+  <seclabel type='static' model='dac' relabel='no'>
+    <label>+107:+107</label>
+  </seclabel>
+</domain>`
+
+func TestNormalizeImportedDomain(t *testing.T) {
+	t.Parallel()
+
+	var def libvirtxml.Domain
+	if err := def.Unmarshal(testImportedDomainXML); err != nil {
+		t.Fatal(err)
+	}
+	normalizeImportedDomain(&def, "q35")
+
+	if def.OS.Type.Machine != "q35" {
+		t.Errorf("expected machine type q35, got %q", def.OS.Type.Machine)
+	}
+	if def.Resource != nil {
+		t.Error("expected the default resource partition to be removed")
+	}
+	if len(def.SecLabel) != 1 || def.SecLabel[0].Type != "static" {
+		t.Errorf("expected only the static seclabel to remain, got %+v", def.SecLabel)
+	}
+
+	devices := def.Devices
+	disk := devices.Disks[0]
+	if disk.Address != nil || disk.Alias != nil || disk.BackingStore != nil {
+		t.Errorf("expected disk address, alias and backing store to be removed, got %+v", disk)
+	}
+	if disk.Source == nil || disk.Source.File == nil || disk.Target.Dev != "vda" {
+		t.Errorf("expected disk source and target to remain, got %+v", disk)
+	}
+	if len(devices.Controllers) != 1 || devices.Controllers[0].Type != "scsi" || devices.Controllers[0].Address != nil {
+		t.Errorf("expected only the scsi controller without address to remain, got %+v", devices.Controllers)
+	}
+	iface := devices.Interfaces[0]
+	if iface.Target != nil || iface.Address != nil || iface.MAC == nil {
+		t.Errorf("expected interface target and address to be removed and the MAC kept, got %+v", iface)
+	}
+	if len(devices.Inputs) != 1 || devices.Inputs[0].Type != "tablet" {
+		t.Errorf("expected only the tablet input to remain, got %+v", devices.Inputs)
+	}
+	vnc := devices.Graphics[0].VNC
+	if vnc.Port != 0 || vnc.Listeners != nil || vnc.Listen != "127.0.0.1" {
+		t.Errorf("expected the allocated port and mirrored listener to be removed, got %+v", vnc)
+	}
+	if devices.MemBalloon != nil {
+		t.Error("expected the default memory balloon to be removed")
+	}
+}
+
+func TestNormalizeImportedDomainKeepsExplicitSettings(t *testing.T) {
+	t.Parallel()
+
+	zero := uint(0)
+	def := libvirtxml.Domain{
+		OS: &libvirtxml.DomainOS{Type: &libvirtxml.DomainOSType{Machine: "pc-q35-rhel9.4.0"}},
+		Devices: &libvirtxml.DomainDeviceList{
+			Controllers: []libvirtxml.DomainController{{Type: "usb", Index: &zero, Model: "none"}},
+			MemBalloon:  &libvirtxml.DomainMemBalloon{Model: "virtio", Stats: &libvirtxml.DomainMemBalloonStats{Period: 10}},
+			Interfaces:  []libvirtxml.DomainInterface{{Target: &libvirtxml.DomainInterfaceTarget{Dev: "tap-web"}}},
+			Graphics:    []libvirtxml.DomainGraphic{{VNC: &libvirtxml.DomainGraphicVNC{Port: 5901, AutoPort: "no"}}},
+		},
+	}
+	normalizeImportedDomain(&def, "")
+
+	if def.OS.Type.Machine != "pc-q35-rhel9.4.0" {
+		t.Errorf("expected a vendor machine type to be kept, got %q", def.OS.Type.Machine)
+	}
+	if len(def.Devices.Controllers) != 1 {
+		t.Error("expected a USB controller with model none to be kept")
+	}
+	if def.Devices.MemBalloon == nil {
+		t.Error("expected a configured memory balloon to be kept")
+	}
+	if def.Devices.Interfaces[0].Target == nil {
+		t.Error("expected a custom interface target to be kept")
+	}
+	if def.Devices.Graphics[0].VNC.Port != 5901 {
+		t.Error("expected a fixed graphics port to be kept")
+	}
+}
+
+func TestImportedMachineAlias(t *testing.T) {
+	client := testMockClient(t)
+
+	tests := map[string]string{
+		"pc-q35-8.2":       "q35",
+		"pc-i440fx-8.2":    "pc",
+		"pc-q35-7.0":       "",
+		"pc-q35-rhel9.4.0": "",
+		"q35":              "",
+	}
+	for machine, want := range tests {
+		def := &libvirtxml.Domain{
+			Type: "kvm",
+			OS:   &libvirtxml.DomainOS{Type: &libvirtxml.DomainOSType{Arch: "x86_64", Machine: machine, Type: "hvm"}},
+		}
+		if got := importedMachineAlias(client, def); got != want {
+			t.Errorf("%s: expected alias %q, got %q", machine, want, got)
+		}
+	}
+}
+
+func TestDomainResourceImportNormalized(t *testing.T) {
+	client := testMockClient(t)
+	if _, err := client.Libvirt().DomainDefineXML(testImportedDomainXML); err != nil {
+		t.Fatalf("failed to define domain: %v", err)
+	}
+
+	r := NewDomainResource()
+	schemaResp := testResource(t, r, client)
+	objectType := schemaResp.Schema.Type().TerraformType(context.Background())
+	state := tfsdk.State{Schema: schemaResp.Schema, Raw: testValue(t, objectType, map[string]any{
+		"uuid": "6c0b9a5e-8f4e-4d1b-9a57-3f8f2d1c0e11",
+	})}
+
+	state, diags := testReadResource(t, r, client, state)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	if machine := testStateValue(t, state, path.Root("os").AtName("type_machine")); machine != "q35" {
+		t.Errorf("expected machine type q35, got %q", machine)
+	}
+	if addr := testStateValue(t, state, path.Root("devices").AtName("disks").AtListIndex(0).AtName("address")); addr != "<null>" {
+		t.Errorf("expected no disk address, got %s", addr)
+	}
+	if balloon := testStateValue(t, state, path.Root("devices").AtName("mem_balloon")); balloon != "<null>" {
+		t.Errorf("expected no memory balloon, got %s", balloon)
+	}
+	if dev := testStateValue(t, state, path.Root("devices").AtName("disks").AtListIndex(0).AtName("target").AtName("dev")); dev != "vda" {
+		t.Errorf("expected the disk target to be imported, got %q", dev)
+	}
+}
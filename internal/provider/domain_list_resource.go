@@ -0,0 +1,169 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/list/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces
+var (
+	_ list.ListResource              = &DomainListResource{}
+	_ list.ListResourceWithConfigure = &DomainListResource{}
+)
+
+// NewDomainListResource creates a new domain list resource
+func NewDomainListResource() list.ListResource {
+	return &DomainListResource{}
+}
+
+// DomainListResource enumerates persistent domains for terraform query
+type DomainListResource struct {
+	client *libvirt.Client
+}
+
+// DomainListResourceModel describes the list block configuration
+type DomainListResourceModel struct {
+	NamePrefix types.String `tfsdk:"name_prefix"`
+	State      types.String `tfsdk:"state"`
+}
+
+var domainListStateFlags = map[string]golibvirt.ConnectListAllDomainsFlags{
+	"active":   golibvirt.ConnectListDomainsActive,
+	"inactive": golibvirt.ConnectListDomainsInactive,
+	"running":  golibvirt.ConnectListDomainsRunning,
+	"paused":   golibvirt.ConnectListDomainsPaused,
+	"shutoff":  golibvirt.ConnectListDomainsShutoff,
+}
+
+// Metadata returns the resource type name being listed
+func (l *DomainListResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_domain"
+}
+
+// ListResourceConfigSchema defines the filters accepted by the list block
+func (l *DomainListResource) ListResourceConfigSchema(ctx context.Context, req list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Lists persistent libvirt domains (`virConnectListAllDomains`).",
+		Attributes: map[string]schema.Attribute{
+			"name_prefix": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Only list domains whose name starts with this prefix.",
+			},
+			"state": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Only list domains in this state: `active`, `inactive`, `running`, `paused` or `shutoff`.",
+				Validators: []validator.String{
+					stringvalidator.OneOf("active", "inactive", "running", "paused", "shutoff"),
+				},
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the list resource
+func (l *DomainListResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*libvirt.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected List Resource Configure Type",
+			fmt.Sprintf("Expected *libvirt.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	l.client = client
+}
+
+// List streams the domains matching the configured filters
+func (l *DomainListResource) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	var config DomainListResourceModel
+	diags := req.Config.Get(ctx, &config)
+	if diags.HasError() {
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	// Transient domains cannot be managed by libvirt_domain, so only persistent ones are listed.
+	flags := golibvirt.ConnectListDomainsPersistent
+	if !config.State.IsNull() && !config.State.IsUnknown() {
+		flags |= domainListStateFlags[config.State.ValueString()]
+	}
+
+	domains, _, err := l.client.Libvirt().ConnectListAllDomains(1, flags)
+	if err != nil {
+		diags.AddError(
+			"Failed to List Domains",
+			fmt.Sprintf("Unable to list domains: %s", err),
+		)
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	domainResource := &DomainResource{client: l.client}
+
+	stream.Results = func(push func(list.ListResult) bool) {
+		var count int64
+		for _, domain := range domains {
+			if !listNameMatches(config.NamePrefix, domain.Name) {
+				continue
+			}
+			if listLimitReached(req.Limit, count) {
+				return
+			}
+			count++
+
+			result := req.NewListResult(ctx)
+			result.DisplayName = domain.Name
+
+			uuid := types.StringValue(libvirt.UUIDString(domain.UUID))
+			result.Diagnostics.Append(result.Identity.Set(ctx, DomainResourceIdentityModel{UUID: uuid})...)
+
+			if req.IncludeResource && !result.Diagnostics.HasError() {
+				result.Diagnostics.Append(domainListResultResource(ctx, domainResource, &result, uuid)...)
+			}
+
+			if !push(result) {
+				return
+			}
+		}
+	}
+}
+
+func domainListResultResource(ctx context.Context, r *DomainResource, result *list.ListResult, uuid types.String) diag.Diagnostics {
+	var model DomainResourceModel
+	diags := seedResourceModel(ctx, result.Resource, path.Root("uuid"), uuid, &model)
+	if diags.HasError() {
+		return diags
+	}
+
+	found, readDiags := r.readDomain(ctx, &model)
+	diags.Append(readDiags...)
+	if diags.HasError() {
+		return diags
+	}
+	if !found {
+		diags.AddError(
+			"Domain Not Found",
+			fmt.Sprintf("Domain %s disappeared while listing", uuid.ValueString()),
+		)
+		return diags
+	}
+
+	diags.Append(result.Resource.Set(ctx, &model)...)
+	return diags
+}
@@ -0,0 +1,262 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/osinfo"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"libvirt.org/go/libvirtxml"
+)
+
+// osVariantSchemaAttributes returns the os_variant attribute of libvirt_domain and the
+// computed attribute listing the defaults it fills in.
+func osVariantSchemaAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"os_variant": schema.StringAttribute{
+			Description: "Short ID of the guest operating system in the osinfo database (e.g. ubuntu24.04, win2k22), " +
+				"used to fill settings that are not configured with the values recommended for it, like virt-install --osinfo. " +
+				"Conflicts with create.clone_from.",
+			MarkdownDescription: "Short ID of the guest operating system in the osinfo database (e.g. `ubuntu24.04`, `win2k22`), " +
+				"used to fill settings that are not configured with the values recommended for it, like `virt-install --osinfo`: " +
+				"memory and vCPUs (the minimum for the OS), the disk bus and network model (virtio when the OS supports it), " +
+				"UEFI firmware when the OS cannot boot with BIOS, a TPM when the OS requires one, and for Windows the clock " +
+				"and Hyper-V enlightenments. Configured values always win. The database is read on the machine running Terraform. " +
+				"Conflicts with `create.clone_from`.",
+			Optional: true,
+		},
+		"os_variant_defaults": schema.MapAttribute{
+			Description: "Settings filled in from os_variant, keyed by attribute path.",
+			MarkdownDescription: "Settings filled in from `os_variant`, keyed by attribute path. They are applied to the domain XML but not stored in the attributes themselves. " +
+				"Known after apply when a setting they depend on, such as `memory`, is only known then.",
+			Computed:    true,
+			ElementType: types.StringType,
+		},
+	}
+}
+
+// applyOSVariant fills the settings def leaves unset with the defaults of the OS variant
+// and returns them keyed by attribute path. Without a variant the result is null.
+func (r *DomainResource) applyOSVariant(ctx context.Context, variant types.String, def *libvirtxml.Domain) (types.Map, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	if variant.IsNull() || variant.IsUnknown() {
+		return types.MapNull(types.StringType), diags
+	}
+
+	db, err := r.client.OSInfo()
+	if err != nil {
+		detail := "Failed to load the osinfo database: " + err.Error()
+		if errors.Is(err, fs.ErrNotExist) {
+			detail = "The osinfo database (osinfo-db) is not installed on the machine running Terraform: " + err.Error()
+		}
+		diags.AddAttributeError(path.Root("os_variant"), "Failed to Load OS Information", detail)
+		return types.MapNull(types.StringType), diags
+	}
+
+	os, ok := db.Lookup(variant.ValueString())
+	if !ok {
+		diags.AddAttributeError(
+			path.Root("os_variant"),
+			"Unknown OS Variant",
+			fmt.Sprintf("The osinfo database has no operating system with short ID %q. "+
+				"Run 'osinfo-query os' to list the known variants.", variant.ValueString()),
+		)
+		return types.MapNull(types.StringType), diags
+	}
+
+	defaults, mapDiags := types.MapValueFrom(ctx, types.StringType, applyOSVariantDefaults(def, os))
+	diags.Append(mapDiags...)
+	return defaults, diags
+}
+
+// osVariantInputsKnown reports whether the configured settings applyOSVariantDefaults
+// looks at are all known, so the defaults computed at plan time match the ones applied.
+func osVariantInputsKnown(config tftypes.Value) bool {
+	for _, name := range []string{"memory", "vcpu", "os", "clock", "features"} {
+		if !configValueKnown(config, tftypes.NewAttributePath().WithAttributeName(name)) {
+			return false
+		}
+	}
+
+	devicesPath := tftypes.NewAttributePath().WithAttributeName("devices")
+	if !configValueKnown(config, devicesPath.WithAttributeName("tpms")) {
+		return false
+	}
+	for list, attrs := range map[string][]string{"disks": {"device", "target"}, "interfaces": {"model"}} {
+		listPath := devicesPath.WithAttributeName(list)
+		value, _, err := tftypes.WalkAttributePath(config, listPath)
+		listValue, ok := value.(tftypes.Value)
+		if err != nil || !ok {
+			continue
+		}
+		if !listValue.IsKnown() {
+			return false
+		}
+		var elements []tftypes.Value
+		if listValue.IsNull() || listValue.As(&elements) != nil {
+			continue
+		}
+		for i := range elements {
+			for _, attr := range attrs {
+				if !configValueKnown(config, listPath.WithElementKeyInt(i).WithAttributeName(attr)) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// configValueKnown reports whether the value at attrPath in config is fully known. Paths
+// that do not exist, for example below a null object, are known.
+func configValueKnown(config tftypes.Value, attrPath *tftypes.AttributePath) bool {
+	value, _, err := tftypes.WalkAttributePath(config, attrPath)
+	if err != nil {
+		return true
+	}
+	typed, ok := value.(tftypes.Value)
+	return !ok || typed.IsFullyKnown()
+}
+
+// applyOSVariantDefaults fills the settings def leaves unset with the values osinfo
+// recommends for os, and returns what was filled in keyed by attribute path.
+func applyOSVariantDefaults(def *libvirtxml.Domain, os *osinfo.OS) map[string]string {
+	applied := map[string]string{}
+
+	arch := "x86_64"
+	if def.OS != nil && def.OS.Type != nil && def.OS.Type.Arch != "" {
+		arch = def.OS.Type.Arch
+	}
+
+	minimum := os.MinimumResources(arch)
+	if def.Memory == nil && minimum.RAM > 0 {
+		def.Memory = &libvirtxml.DomainMemory{Value: uint(minimum.RAM / 1024), Unit: "KiB"}
+		applied["memory"] = fmt.Sprintf("%d KiB", def.Memory.Value)
+	}
+	if def.VCPU == nil && minimum.CPUs > 0 {
+		def.VCPU = &libvirtxml.DomainVCPU{Value: uint(minimum.CPUs)}
+		applied["vcpu"] = fmt.Sprint(minimum.CPUs)
+	}
+
+	if def.OS == nil || (def.OS.Firmware == "" && def.OS.Loader == nil) {
+		efi, efiKnown := os.FirmwareSupport(arch, "efi")
+		bios, biosKnown := os.FirmwareSupport(arch, "bios")
+		if efi && efiKnown && !bios && biosKnown {
+			if def.OS == nil {
+				def.OS = &libvirtxml.DomainOS{}
+			}
+			def.OS.Firmware = "efi"
+			applied["os.firmware"] = "efi"
+		}
+	}
+
+	devices := def.Devices
+	if devices == nil {
+		devices = &libvirtxml.DomainDeviceList{}
+	}
+
+	diskBus := "sata"
+	if os.SupportsDevice(osinfo.DeviceVirtioBlock, osinfo.DeviceVirtioBlockLegacy) {
+		diskBus = "virtio"
+	}
+	for i := range devices.Disks {
+		disk := &devices.Disks[i]
+		if (disk.Device != "" && disk.Device != "disk") || disk.Target == nil || disk.Target.Bus != "" {
+			continue
+		}
+		// libvirt derives the bus from these target name prefixes
+		if dev := disk.Target.Dev; strings.HasPrefix(dev, "hd") || strings.HasPrefix(dev, "sd") || strings.HasPrefix(dev, "xvd") {
+			continue
+		}
+		disk.Target.Bus = diskBus
+		applied[fmt.Sprintf("devices.disks[%d].target.bus", i)] = diskBus
+	}
+
+	if netModel := osVariantNetworkModel(os); netModel != "" {
+		for i := range devices.Interfaces {
+			if devices.Interfaces[i].Model == nil {
+				devices.Interfaces[i].Model = &libvirtxml.DomainInterfaceModel{Type: netModel}
+				applied[fmt.Sprintf("devices.interfaces[%d].model.type", i)] = netModel
+			}
+		}
+	}
+
+	if os.RequiresTPM() && len(devices.TPMs) == 0 {
+		model := "tpm-crb"
+		if arch == "aarch64" {
+			model = "tpm-tis-device"
+		}
+		devices.TPMs = []libvirtxml.DomainTPM{{
+			Model: model,
+			Backend: &libvirtxml.DomainTPMBackend{
+				Emulator: &libvirtxml.DomainTPMBackendEmulator{Version: "2.0"},
+			},
+		}}
+		def.Devices = devices
+		applied["devices.tpms"] = model + " (emulator 2.0)"
+	}
+
+	if os.IsWindows() {
+		applyWindowsDefaults(def, applied)
+	}
+	return applied
+}
+
+// osVariantNetworkModel returns the best network model os has drivers for.
+func osVariantNetworkModel(os *osinfo.OS) string {
+	switch {
+	case os.SupportsDevice(osinfo.DeviceVirtioNet, osinfo.DeviceVirtioNetLegacy):
+		return "virtio"
+	case os.SupportsDevice(osinfo.DeviceE1000e):
+		return "e1000e"
+	case os.SupportsDevice(osinfo.DeviceE1000):
+		return "e1000"
+	case os.SupportsDevice(osinfo.DeviceRTL8139):
+		return "rtl8139"
+	}
+	return ""
+}
+
+// applyWindowsDefaults sets the clock and Hyper-V enlightenments virt-install uses for
+// Windows guests.
+func applyWindowsDefaults(def *libvirtxml.Domain, applied map[string]string) {
+	if def.Clock == nil {
+		def.Clock = &libvirtxml.DomainClock{
+			Offset: "localtime",
+			Timer: []libvirtxml.DomainTimer{
+				{Name: "rtc", TickPolicy: "catchup"},
+				{Name: "pit", TickPolicy: "delay"},
+				{Name: "hpet", Present: "no"},
+				{Name: "hypervclock", Present: "yes"},
+			},
+		}
+		applied["clock"] = "localtime with rtc, pit, hpet and hypervclock timers"
+	}
+
+	if def.Features == nil {
+		def.Features = &libvirtxml.DomainFeatureList{
+			ACPI: &libvirtxml.DomainFeature{},
+			APIC: &libvirtxml.DomainFeatureAPIC{},
+		}
+		applied["features.acpi"] = "true"
+		applied["features.apic"] = "enabled"
+	}
+	if def.Features.HyperV == nil {
+		def.Features.HyperV = &libvirtxml.DomainFeatureHyperV{
+			Relaxed: &libvirtxml.DomainFeatureState{State: "on"},
+			VAPIC:   &libvirtxml.DomainFeatureState{State: "on"},
+			Spinlocks: &libvirtxml.DomainFeatureHyperVSpinlocks{
+				DomainFeatureState: libvirtxml.DomainFeatureState{State: "on"},
+				Retries:            8191,
+			},
+		}
+		applied["features.hyperv"] = "relaxed, vapic, spinlocks (8191 retries)"
+	}
+}
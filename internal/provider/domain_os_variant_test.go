@@ -0,0 +1,283 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/osinfo"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"libvirt.org/go/libvirtxml"
+)
+
+// testOSInfoDB is a minimal osinfo database with a Linux and a Windows variant.
+var testOSInfoDB = map[string]string{
+	"os/debian.org/debian-12.xml": `<libosinfo version="0.0.1">
+  <os id="http://debian.org/debian/12">
+    <short-id>debian12</short-id>
+    <family>linux</family>
+    <distro>debian</distro>
+    <devices>
+      <device id="http://pcisig.com/pci/1af4/1041"/>
+      <device id="http://pcisig.com/pci/1af4/1042"/>
+    </devices>
+    <resources arch="all">
+      <minimum>
+        <n-cpus>1</n-cpus>
+        <ram>1073741824</ram>
+      </minimum>
+    </resources>
+  </os>
+</libosinfo>`,
+	"os/microsoft.com/win-11.xml": `<libosinfo version="0.0.1">
+  <os id="http://microsoft.com/win/11">
+    <short-id>win11</short-id>
+    <family>winnt</family>
+    <distro>win</distro>
+    <devices>
+      <device id="http://pcisig.com/pci/8086/10d3"/>
+      <device id="http://qemu.org/chardev/tpm/emulator"/>
+    </devices>
+    <resources arch="x86_64">
+      <minimum>
+        <n-cpus>2</n-cpus>
+        <ram>4294967296</ram>
+      </minimum>
+    </resources>
+    <firmware arch="x86_64" type="efi" supported="true"/>
+    <firmware arch="x86_64" type="bios" supported="false"/>
+  </os>
+</libosinfo>`,
+}
+
+func testOSInfoDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range testOSInfoDB {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestApplyOSVariantDefaults(t *testing.T) {
+	t.Parallel()
+
+	db, err := osinfo.Load([]string{testOSInfoDir(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	debian, _ := db.Lookup("debian12")
+	def := &libvirtxml.Domain{
+		Memory: &libvirtxml.DomainMemory{Value: 512, Unit: "MiB"},
+		Devices: &libvirtxml.DomainDeviceList{
+			Disks: []libvirtxml.DomainDisk{
+				{Device: "disk", Target: &libvirtxml.DomainDiskTarget{Dev: "vda"}},
+				{Device: "disk", Target: &libvirtxml.DomainDiskTarget{Dev: "sda"}},
+				{Device: "cdrom", Target: &libvirtxml.DomainDiskTarget{Dev: "vdc"}},
+			},
+			Interfaces: []libvirtxml.DomainInterface{
+				{},
+				{Model: &libvirtxml.DomainInterfaceModel{Type: "e1000"}},
+			},
+		},
+	}
+	applied := applyOSVariantDefaults(def, debian)
+
+	if def.Memory.Value != 512 || applied["memory"] != "" {
+		t.Errorf("expected the configured memory to win, got %+v", def.Memory)
+	}
+	if def.VCPU == nil || def.VCPU.Value != 1 || applied["vcpu"] != "1" {
+		t.Errorf("expected the minimum vCPUs, got %+v", def.VCPU)
+	}
+	if def.Devices.Disks[0].Target.Bus != "virtio" || applied["devices.disks[0].target.bus"] != "virtio" {
+		t.Errorf("expected a virtio disk bus, got %q", def.Devices.Disks[0].Target.Bus)
+	}
+	if def.Devices.Disks[1].Target.Bus != "" || def.Devices.Disks[2].Target.Bus != "" {
+		t.Error("expected the bus implied by the target name and of CD-ROMs to be left alone")
+	}
+	if def.Devices.Interfaces[0].Model.Type != "virtio" || def.Devices.Interfaces[1].Model.Type != "e1000" {
+		t.Errorf("expected a virtio network model only where none is configured, got %+v", def.Devices.Interfaces)
+	}
+	if def.OS != nil || def.Clock != nil || def.Features != nil || len(def.Devices.TPMs) != 0 {
+		t.Errorf("expected no firmware, clock, features or TPM for Linux, got %+v", def)
+	}
+
+	win11, _ := db.Lookup("win11")
+	def = &libvirtxml.Domain{
+		OS:    &libvirtxml.DomainOS{Type: &libvirtxml.DomainOSType{Type: "hvm"}},
+		Clock: &libvirtxml.DomainClock{Offset: "utc"},
+		Devices: &libvirtxml.DomainDeviceList{
+			Interfaces: []libvirtxml.DomainInterface{{}},
+		},
+	}
+	applied = applyOSVariantDefaults(def, win11)
+
+	if def.Memory == nil || def.Memory.Value != 4<<20 || def.Memory.Unit != "KiB" {
+		t.Errorf("expected the minimum memory in KiB, got %+v", def.Memory)
+	}
+	if def.OS.Firmware != "efi" {
+		t.Errorf("expected UEFI firmware, got %q", def.OS.Firmware)
+	}
+	if len(def.Devices.TPMs) != 1 || def.Devices.TPMs[0].Backend.Emulator.Version != "2.0" {
+		t.Errorf("expected an emulated TPM 2.0, got %+v", def.Devices.TPMs)
+	}
+	if def.Devices.Interfaces[0].Model.Type != "e1000e" {
+		t.Errorf("expected an e1000e network model, got %+v", def.Devices.Interfaces[0].Model)
+	}
+	if def.Clock.Offset != "utc" || applied["clock"] != "" {
+		t.Errorf("expected the configured clock to win, got %+v", def.Clock)
+	}
+	if def.Features == nil || def.Features.ACPI == nil || def.Features.HyperV == nil || def.Features.HyperV.Spinlocks.Retries != 8191 {
+		t.Errorf("expected ACPI and Hyper-V enlightenments, got %+v", def.Features)
+	}
+}
+
+func TestDomainResourceOSVariant(t *testing.T) {
+	client := testMockClient(t)
+	client.SetOSInfoDir(testOSInfoDir(t))
+
+	state, diags := testCreateResource(t, NewDomainResource(), client, map[string]any{
+		"name":       "test-os-variant",
+		"type":       "kvm",
+		"os_variant": "win11",
+		"os":         map[string]any{"type": "hvm"},
+		"vcpu":       4,
+	})
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	domain, err := client.Libvirt().DomainLookupByName("test-os-variant")
+	if err != nil {
+		t.Fatalf("failed to look up domain: %v", err)
+	}
+	xmlDesc, err := client.Libvirt().DomainGetXMLDesc(domain, golibvirt.DomainXMLSecure)
+	if err != nil {
+		t.Fatalf("failed to get domain XML: %v", err)
+	}
+	for _, expected := range []string{"<vcpu>4</vcpu>", `firmware="efi"`, "<tpm", "hypervclock"} {
+		if !strings.Contains(xmlDesc, expected) {
+			t.Errorf("expected %s in the defined XML, got\n%s", expected, xmlDesc)
+		}
+	}
+
+	if firmware := testStateValue(t, state, path.Root("os_variant_defaults").AtMapKey("os.firmware")); firmware != "efi" {
+		t.Errorf("expected the firmware default in state, got %q", firmware)
+	}
+	if vcpu := testStateValue(t, state, path.Root("vcpu")); vcpu != "4" {
+		t.Errorf("expected the configured vCPUs in state, got %s", vcpu)
+	}
+	if clock := testStateValue(t, state, path.Root("clock")); clock != "<null>" {
+		t.Errorf("expected the clock default not to be stored in the attribute, got %s", clock)
+	}
+}
+
+func TestDomainResourceOSVariantUnknown(t *testing.T) {
+	client := testMockClient(t)
+	client.SetOSInfoDir(testOSInfoDir(t))
+
+	_, diags := testCreateResource(t, NewDomainResource(), client, map[string]any{
+		"name":       "test-os-variant-unknown",
+		"memory":     256,
+		"type":       "kvm",
+		"os_variant": "plan9",
+		"os":         map[string]any{"type": "hvm"},
+	})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "Unknown OS Variant" {
+		t.Fatalf("expected an unknown OS variant error, got %v", diags)
+	}
+	if _, err := client.Libvirt().DomainLookupByName("test-os-variant-unknown"); err == nil {
+		t.Error("expected the domain not to be defined")
+	}
+}
+
+func TestDomainResourceModifyPlanOSVariant(t *testing.T) {
+	client := testMockClient(t)
+	client.SetOSInfoDir(testOSInfoDir(t))
+	r := NewDomainResource()
+	schemaResp := testResource(t, r, client)
+	ctx := context.Background()
+
+	objectType := schemaResp.Schema.Type().TerraformType(ctx)
+	raw := testValue(t, objectType, map[string]any{
+		"name":       "test-os-variant-plan",
+		"type":       "kvm",
+		"os_variant": "debian12",
+		"os":         map[string]any{"type": "hvm"},
+	})
+
+	resp := &fwresource.ModifyPlanResponse{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw}}
+	r.(fwresource.ResourceWithModifyPlan).ModifyPlan(ctx, fwresource.ModifyPlanRequest{
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw},
+		State:  tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)},
+	}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+
+	var defaults map[string]string
+	if diags := resp.Plan.GetAttribute(ctx, path.Root("os_variant_defaults"), &defaults); diags.HasError() {
+		t.Fatalf("failed to read os_variant_defaults: %v", diags)
+	}
+	if defaults["memory"] != "1048576 KiB" || defaults["vcpu"] != "1" {
+		t.Errorf("expected the memory and vCPU defaults in the plan, got %v", defaults)
+	}
+
+	// A setting only known after apply may change the defaults, so they are unknown too
+	raw = testValue(t, objectType, map[string]any{
+		"name":       "test-os-variant-plan",
+		"type":       "kvm",
+		"memory":     tftypes.UnknownValue,
+		"os_variant": "debian12",
+		"os":         map[string]any{"type": "hvm"},
+	})
+	resp = &fwresource.ModifyPlanResponse{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw}}
+	r.(fwresource.ResourceWithModifyPlan).ModifyPlan(ctx, fwresource.ModifyPlanRequest{
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw},
+		State:  tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)},
+	}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+	if value := testStateValue(t, tfsdk.State(resp.Plan), path.Root("os_variant_defaults")); value != "<unknown>" {
+		t.Errorf("expected unknown defaults with an unknown memory size, got %s", value)
+	}
+	// Other unknown settings do not affect the defaults
+	known := testValue(t, objectType, map[string]any{
+		"name":        "test-os-variant-plan",
+		"description": tftypes.UnknownValue,
+		"memory":      tftypes.UnknownValue,
+	})
+	if osVariantInputsKnown(known) {
+		t.Error("expected an unknown memory size to make the inputs unknown")
+	}
+	known = testValue(t, objectType, map[string]any{
+		"name":        "test-os-variant-plan",
+		"description": tftypes.UnknownValue,
+		"devices": map[string]any{
+			"disks": []any{map[string]any{
+				"source": map[string]any{"file": map[string]any{"file": tftypes.UnknownValue}},
+				"target": map[string]any{"dev": "vda"},
+			}},
+		},
+	})
+	if !osVariantInputsKnown(known) {
+		t.Error("expected settings the defaults do not use to be ignored")
+	}
+}
@@ -0,0 +1,311 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/action"
+	"github.com/hashicorp/terraform-plugin-framework/action/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// Ensure the implementation satisfies the expected interfaces
+var (
+	_ action.Action              = &DomainPowerAction{}
+	_ action.ActionWithConfigure = &DomainPowerAction{}
+)
+
+// domainPowerOperation identifies the libvirt call performed by a DomainPowerAction.
+type domainPowerOperation string
+
+const (
+	domainPowerReboot   domainPowerOperation = "reboot"
+	domainPowerReset    domainPowerOperation = "reset"
+	domainPowerShutdown domainPowerOperation = "shutdown"
+	domainPowerStart    domainPowerOperation = "start"
+)
+
+// NewDomainRebootAction creates the libvirt_domain_reboot action
+func NewDomainRebootAction() action.Action {
+	return &DomainPowerAction{operation: domainPowerReboot}
+}
+
+// NewDomainResetAction creates the libvirt_domain_reset action
+func NewDomainResetAction() action.Action {
+	return &DomainPowerAction{operation: domainPowerReset}
+}
+
+// NewDomainShutdownAction creates the libvirt_domain_shutdown action
+func NewDomainShutdownAction() action.Action {
+	return &DomainPowerAction{operation: domainPowerShutdown}
+}
+
+// NewDomainStartAction creates the libvirt_domain_start action
+func NewDomainStartAction() action.Action {
+	return &DomainPowerAction{operation: domainPowerStart}
+}
+
+// DomainPowerAction runs a single power operation against an existing domain.
+type DomainPowerAction struct {
+	client    *libvirt.Client
+	operation domainPowerOperation
+}
+
+// DomainPowerActionModel describes the action configuration
+type DomainPowerActionModel struct {
+	Domain types.String `tfsdk:"domain"`
+	Wait   types.Object `tfsdk:"wait"`
+}
+
+// DomainPowerActionWaitModel describes what the action waits for after the operation.
+type DomainPowerActionWaitModel struct {
+	State   types.String `tfsdk:"state"`
+	IP      types.Object `tfsdk:"ip"`
+	Timeout types.Int64  `tfsdk:"timeout"`
+}
+
+// DomainPowerActionWaitIPModel selects the interface and source used to wait for an IP.
+type DomainPowerActionWaitIPModel struct {
+	MAC    types.String `tfsdk:"mac"`
+	Source types.String `tfsdk:"source"`
+}
+
+type domainPowerWaitOptions struct {
+	State    string
+	WaitIP   bool
+	MAC      string
+	Source   string
+	Timeout  int64
+	Required bool
+}
+
+var domainStatesByName = map[string]golibvirt.DomainState{
+	"running": golibvirt.DomainRunning,
+	"paused":  golibvirt.DomainPaused,
+	"shutoff": golibvirt.DomainShutoff,
+}
+
+// Metadata returns the action type name
+func (a *DomainPowerAction) Metadata(ctx context.Context, req action.MetadataRequest, resp *action.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_domain_" + string(a.operation)
+}
+
+// Schema defines the schema for the action
+func (a *DomainPowerAction) Schema(ctx context.Context, req action.SchemaRequest, resp *action.SchemaResponse) {
+	var description string
+	switch a.operation {
+	case domainPowerReboot:
+		description = "Requests a guest reboot of a libvirt domain (`virDomainReboot`)."
+	case domainPowerReset:
+		description = "Resets a libvirt domain as if the reset button was pressed, without a guest shutdown (`virDomainReset`)."
+	case domainPowerShutdown:
+		description = "Requests a guest shutdown of a libvirt domain (`virDomainShutdownFlags`)."
+	case domainPowerStart:
+		description = "Starts a defined, inactive libvirt domain (`virDomainCreate`)."
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: description + "\n\n" +
+			"The action only changes the runtime state of the domain; it does not modify its definition or the " +
+			"`running` attribute of a `libvirt_domain` resource.",
+		Attributes: map[string]schema.Attribute{
+			"domain": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "UUID of the domain. Use `libvirt_domain.example.uuid` to reference a managed domain.",
+			},
+			"wait": schema.SingleNestedAttribute{
+				Optional:            true,
+				MarkdownDescription: "Wait for the domain to reach a state and/or obtain an IP address after the operation.",
+				Attributes: map[string]schema.Attribute{
+					"state": schema.StringAttribute{
+						Optional:            true,
+						MarkdownDescription: "Domain state to wait for: `running`, `paused` or `shutoff`.",
+						Validators: []validator.String{
+							stringvalidator.OneOf("running", "paused", "shutoff"),
+						},
+					},
+					"ip": schema.SingleNestedAttribute{
+						Optional:            true,
+						MarkdownDescription: "Wait until an interface reports an IP address.",
+						Attributes: map[string]schema.Attribute{
+							"mac": schema.StringAttribute{
+								Optional:            true,
+								MarkdownDescription: "MAC address of the interface to wait for. If unset, any interface is accepted.",
+							},
+							"source": schema.StringAttribute{
+								Optional:            true,
+								MarkdownDescription: "Source to query for IP addresses: `lease`, `agent`, or `any`. Default: `any`.",
+								Validators: []validator.String{
+									stringvalidator.OneOf("lease", "agent", "any"),
+								},
+							},
+						},
+					},
+					"timeout": schema.Int64Attribute{
+						Optional:            true,
+						MarkdownDescription: "Maximum time in seconds to wait for the state and the IP address together. Default: 300.",
+					},
+				},
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the action
+func (a *DomainPowerAction) Configure(ctx context.Context, req action.ConfigureRequest, resp *action.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*libvirt.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Action Configure Type",
+			fmt.Sprintf("Expected *libvirt.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	a.client = client
+}
+
+// Invoke performs the power operation and the optional wait
+func (a *DomainPowerAction) Invoke(ctx context.Context, req action.InvokeRequest, resp *action.InvokeResponse) {
+	var config DomainPowerActionModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	waitOptions, diags := domainPowerWaitOptionsFromObject(ctx, config.Wait)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	uuid := config.Domain.ValueString()
+	domain, err := a.client.LookupDomainByUUID(uuid)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Domain Not Found",
+			fmt.Sprintf("Unable to find domain '%s': %s", uuid, err),
+		)
+		return
+	}
+
+	resp.SendProgress(action.InvokeProgressEvent{
+		Message: fmt.Sprintf("Running %s on domain %s", a.operation, domain.Name),
+	})
+
+	if err := a.run(domain); err != nil {
+		resp.Diagnostics.AddError(
+			"Domain Power Operation Failed",
+			fmt.Sprintf("Failed to %s domain '%s': %s", a.operation, domain.Name, err),
+		)
+		return
+	}
+
+	if !waitOptions.Required {
+		return
+	}
+
+	// The timeout covers the state and IP address waits together
+	waitCtx, cancel := context.WithTimeout(ctx, time.Duration(waitOptions.Timeout)*time.Second)
+	defer cancel()
+
+	if waitOptions.State != "" {
+		resp.SendProgress(action.InvokeProgressEvent{
+			Message: fmt.Sprintf("Waiting for domain %s to reach state %s", domain.Name, waitOptions.State),
+		})
+
+		targetState := domainStatesByName[waitOptions.State]
+		if err := waitForDomainState(waitCtx, a.client, domain, uint32(targetState), time.Duration(waitOptions.Timeout)*time.Second); err != nil {
+			resp.Diagnostics.AddError(
+				"Timeout Waiting for Domain State",
+				fmt.Sprintf("Domain '%s' did not reach state %s: %s", domain.Name, waitOptions.State, err),
+			)
+			return
+		}
+	}
+
+	if waitOptions.WaitIP {
+		resp.SendProgress(action.InvokeProgressEvent{
+			Message: fmt.Sprintf("Waiting for domain %s to obtain an IP address", domain.Name),
+		})
+
+		if err := waitForInterfaceIP(waitCtx, a.client, domain, waitOptions.MAC, waitOptions.Timeout, waitOptions.Source); err != nil {
+			resp.Diagnostics.AddError(
+				"Failed to Wait for IP Address",
+				fmt.Sprintf("Domain '%s' did not obtain an IP address: %s", domain.Name, err),
+			)
+			return
+		}
+	}
+}
+
+func (a *DomainPowerAction) run(domain golibvirt.Domain) error {
+	switch a.operation {
+	case domainPowerReboot:
+		return a.client.Libvirt().DomainReboot(domain, golibvirt.DomainRebootDefault)
+	case domainPowerReset:
+		return a.client.Libvirt().DomainReset(domain, 0)
+	case domainPowerShutdown:
+		return a.client.Libvirt().DomainShutdownFlags(domain, golibvirt.DomainShutdownDefault)
+	case domainPowerStart:
+		return a.client.Libvirt().DomainCreate(domain)
+	default:
+		return fmt.Errorf("unsupported operation: %s", a.operation)
+	}
+}
+
+func domainPowerWaitOptionsFromObject(ctx context.Context, waitVal types.Object) (domainPowerWaitOptions, diag.Diagnostics) {
+	options := domainPowerWaitOptions{
+		Timeout: 300,
+		Source:  "any",
+	}
+
+	if waitVal.IsNull() || waitVal.IsUnknown() {
+		return options, nil
+	}
+
+	var waitModel DomainPowerActionWaitModel
+	diags := waitVal.As(ctx, &waitModel, basetypes.ObjectAsOptions{})
+	if diags.HasError() {
+		return options, diags
+	}
+
+	if !waitModel.Timeout.IsNull() && !waitModel.Timeout.IsUnknown() && waitModel.Timeout.ValueInt64() > 0 {
+		options.Timeout = waitModel.Timeout.ValueInt64()
+	}
+
+	if !waitModel.State.IsNull() && !waitModel.State.IsUnknown() {
+		options.State = waitModel.State.ValueString()
+		options.Required = true
+	}
+
+	if !waitModel.IP.IsNull() && !waitModel.IP.IsUnknown() {
+		var ipModel DomainPowerActionWaitIPModel
+		diags.Append(waitModel.IP.As(ctx, &ipModel, basetypes.ObjectAsOptions{})...)
+		if diags.HasError() {
+			return options, diags
+		}
+
+		options.WaitIP = true
+		options.Required = true
+		if !ipModel.MAC.IsNull() && !ipModel.MAC.IsUnknown() {
+			options.MAC = ipModel.MAC.ValueString()
+		}
+		if !ipModel.Source.IsNull() && !ipModel.Source.IsUnknown() {
+			options.Source = ipModel.Source.ValueString()
+		}
+	}
+
+	return options, diags
+}
@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+
+	"github.com/hashicorp/terraform-plugin-framework/action"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestDomainPowerActionTypeNames(t *testing.T) {
+	tests := map[string]func() action.Action{
+		"libvirt_domain_reboot":   NewDomainRebootAction,
+		"libvirt_domain_reset":    NewDomainResetAction,
+		"libvirt_domain_shutdown": NewDomainShutdownAction,
+		"libvirt_domain_start":    NewDomainStartAction,
+	}
+
+	for want, factory := range tests {
+		resp := &action.MetadataResponse{}
+		factory().Metadata(context.Background(), action.MetadataRequest{ProviderTypeName: "libvirt"}, resp)
+		if resp.TypeName != want {
+			t.Fatalf("unexpected action type name: got=%s want=%s", resp.TypeName, want)
+		}
+	}
+}
+
+func TestDomainPowerWaitOptionsFromObject(t *testing.T) {
+	t.Parallel()
+
+	ipAttrTypes := map[string]attr.Type{
+		"mac":    types.StringType,
+		"source": types.StringType,
+	}
+	waitAttrTypes := map[string]attr.Type{
+		"state":   types.StringType,
+		"ip":      types.ObjectType{AttrTypes: ipAttrTypes},
+		"timeout": types.Int64Type,
+	}
+
+	t.Run("null does not wait", func(t *testing.T) {
+		t.Parallel()
+
+		options, diags := domainPowerWaitOptionsFromObject(context.Background(), types.ObjectNull(waitAttrTypes))
+		if diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+		if options.Required {
+			t.Fatal("expected no wait when wait is null")
+		}
+	})
+
+	t.Run("state and ip", func(t *testing.T) {
+		t.Parallel()
+
+		wait := types.ObjectValueMust(waitAttrTypes, map[string]attr.Value{
+			"state": types.StringValue("running"),
+			"ip": types.ObjectValueMust(ipAttrTypes, map[string]attr.Value{
+				"mac":    types.StringValue("52:54:00:00:00:01"),
+				"source": types.StringNull(),
+			}),
+			"timeout": types.Int64Value(60),
+		})
+
+		options, diags := domainPowerWaitOptionsFromObject(context.Background(), wait)
+		if diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+		if !options.Required || !options.WaitIP {
+			t.Fatalf("expected state and ip wait, got %+v", options)
+		}
+		if options.State != "running" || options.MAC != "52:54:00:00:00:01" || options.Source != "any" || options.Timeout != 60 {
+			t.Fatalf("unexpected options: %+v", options)
+		}
+	})
+}
+
+func TestWaitForDomainStateStopsWithContext(t *testing.T) {
+	client := testMockClient(t)
+	domain, err := client.Libvirt().DomainDefineXML(`<domain type="kvm">
+  <name>stopped</name>
+  <memory unit="KiB">262144</memory>
+  <os><type>hvm</type></os>
+</domain>`)
+	if err != nil {
+		t.Fatalf("failed to define domain: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := waitForDomainState(ctx, client, domain, uint32(golibvirt.DomainRunning), time.Minute); err == nil {
+		t.Fatal("expected the wait to fail for a domain that is not running")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the wait to stop with its context, took %s", elapsed)
+	}
+}
@@ -0,0 +1,169 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	removeStorageAll  = "all"
+	removeStorageNone = "none"
+)
+
+func domainRemoveStorageSchemaAttribute() schema.ListAttribute {
+	return schema.ListAttribute{
+		Description: "Storage volumes to delete after the domain is undefined: [\"all\"] for every disk except " +
+			"read-only and shareable ones and those attached with source.volume_id, which are usually managed by a libvirt_volume " +
+			"resource of their own, [\"none\"] to keep them, or the target devs of the disks to delete, such as [\"vda\"]. " +
+			"Disk sources are resolved to volumes in storage pools; other sources are left alone. Defaults to deleting only " +
+			"the volumes cloned by create.clone_from.",
+		MarkdownDescription: "Storage volumes to delete after the domain is undefined: `[\"all\"]` for every disk except " +
+			"read-only and shareable ones and those attached with `source.volume_id`, which are usually managed by a " +
+			"`libvirt_volume` resource of their own, `[\"none\"]` to keep them, or the target devs of the disks to delete, such as " +
+			"`[\"vda\"]`. Disk sources are resolved to volumes in storage pools; other sources are left alone. Defaults to " +
+			"deleting only the volumes cloned by `create.clone_from`.",
+		ElementType: types.StringType,
+		Optional:    true,
+		Validators: []validator.List{
+			removeStorageValidator{},
+		},
+	}
+}
+
+// removeStorageValidator checks that all and none are not combined with other values.
+type removeStorageValidator struct{}
+
+func (v removeStorageValidator) Description(ctx context.Context) string {
+	return `value must be ["all"], ["none"] or a list of disk target devs`
+}
+
+func (v removeStorageValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v removeStorageValidator) ValidateList(ctx context.Context, req validator.ListRequest, resp *validator.ListResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	elements := req.ConfigValue.Elements()
+	for i, element := range elements {
+		value, ok := element.(types.String)
+		if !ok || value.IsUnknown() {
+			continue
+		}
+		if value.IsNull() || value.ValueString() == "" {
+			resp.Diagnostics.AddAttributeError(req.Path.AtListIndex(i), "Invalid Storage Removal",
+				"Disk target devs to remove must not be empty.")
+			continue
+		}
+		if keyword := value.ValueString(); (keyword == removeStorageAll || keyword == removeStorageNone) && len(elements) > 1 {
+			resp.Diagnostics.AddAttributeError(req.Path.AtListIndex(i), "Invalid Storage Removal",
+				fmt.Sprintf("%q cannot be combined with other values.", keyword))
+		}
+	}
+}
+
+// domainStorageVolume is a volume behind a disk of a domain that is being destroyed.
+type domainStorageVolume struct {
+	dev    string
+	volume golibvirt.StorageVol
+}
+
+// domainStorageToRemove resolves the disks of domain selected by options.RemoveStorage to
+// their volumes. It runs before the domain is undefined, while its definition is still
+// known. Failures are reported as warnings so that the domain is undefined regardless.
+func domainStorageToRemove(client *libvirt.Client, domain golibvirt.Domain, options domainStopOptions) ([]domainStorageVolume, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	removeStorage := options.RemoveStorage
+	if len(removeStorage) == 0 || removeStorage[0] == removeStorageNone {
+		return nil, diags
+	}
+	all := removeStorage[0] == removeStorageAll
+
+	xmlDesc, err := client.Libvirt().DomainGetXMLDesc(domain, golibvirt.DomainXMLInactive)
+	if err != nil {
+		diags.AddWarning("Storage Not Removed",
+			"Failed to get domain XML to find the storage to remove, it is left in place: "+err.Error())
+		return nil, diags
+	}
+	def, err := libvirt.UnmarshalDomainXML(xmlDesc)
+	if err != nil {
+		diags.AddWarning("Storage Not Removed",
+			"Failed to parse domain XML to find the storage to remove, it is left in place: "+err.Error())
+		return nil, diags
+	}
+
+	var volumes []domainStorageVolume
+	found := make(map[string]bool, len(removeStorage))
+	if def.Devices != nil {
+		for i := range def.Devices.Disks {
+			disk := &def.Devices.Disks[i]
+			dev := diskTargetDev(disk)
+			if all {
+				// Read-only and shareable disks, such as installation media, are usually
+				// used by other domains as well
+				if disk.ReadOnly != nil || disk.Shareable != nil {
+					continue
+				}
+			} else if !slices.Contains(removeStorage, dev) {
+				continue
+			}
+			found[dev] = true
+
+			volume, err := lookupDiskVolume(client, disk)
+			if err != nil {
+				if !all || !errors.Is(err, errDiskNotVolume) {
+					diags.AddWarning("Storage Not Removed",
+						fmt.Sprintf("The source of disk %s is not a volume in a storage pool and is left in place: %s", dev, err))
+				}
+				continue
+			}
+			if all && slices.Contains(options.KeepVolumes, volume.Key) {
+				continue
+			}
+			if !slices.ContainsFunc(volumes, func(v domainStorageVolume) bool { return v.volume.Key == volume.Key }) {
+				volumes = append(volumes, domainStorageVolume{dev: dev, volume: volume})
+			}
+		}
+	}
+
+	if !all {
+		for _, dev := range removeStorage {
+			if !found[dev] {
+				diags.AddWarning("Storage Not Removed",
+					fmt.Sprintf("The domain has no disk with target dev %q.", dev))
+			}
+		}
+	}
+	return volumes, diags
+}
+
+// removeDomainStorage deletes volumes, wiping them first when wipe is set. Failures are
+// reported as warnings, since the domain itself is already gone.
+func removeDomainStorage(client *libvirt.Client, volumes []domainStorageVolume, wipe bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, v := range volumes {
+		if wipe {
+			if err := client.Libvirt().StorageVolWipe(v.volume, 0); err != nil {
+				diags.AddWarning("Failed to Remove Storage",
+					fmt.Sprintf("Failed to wipe volume %q of disk %s, it was not deleted: %s", v.volume.Key, v.dev, err))
+				continue
+			}
+		}
+		if err := client.Libvirt().StorageVolDelete(v.volume, 0); err != nil {
+			diags.AddWarning("Failed to Remove Storage",
+				fmt.Sprintf("Failed to delete volume %q of disk %s: %s", v.volume.Key, v.dev, err))
+		}
+	}
+	return diags
+}
@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"testing"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	libvirtclient "github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+)
+
+func testDefineDomainWithDisks(t *testing.T, client *libvirtclient.Client) golibvirt.Domain {
+	t.Helper()
+
+	testCreateVolume(t, client, "ci-root.qcow2", "qcow2")
+	testCreateVolume(t, client, "ci-data.raw", "raw")
+	testCreateVolume(t, client, "installer.iso", "raw")
+	domain, err := client.Libvirt().DomainDefineXML(`<domain type="kvm">
+  <name>ci</name>
+  <memory unit="KiB">524288</memory>
+  <os><type>hvm</type></os>
+  <devices>
+    <disk type="volume" device="disk">
+      <source pool="default" volume="ci-root.qcow2"/>
+      <target dev="vda" bus="virtio"/>
+    </disk>
+    <disk type="file" device="disk">
+      <source file="/var/lib/libvirt/images/ci-data.raw"/>
+      <target dev="vdb" bus="virtio"/>
+    </disk>
+    <disk type="file" device="cdrom">
+      <source file="/var/lib/libvirt/images/installer.iso"/>
+      <target dev="sda" bus="sata"/>
+      <readonly/>
+    </disk>
+  </devices>
+</domain>`)
+	if err != nil {
+		t.Fatalf("failed to define domain: %v", err)
+	}
+	return domain
+}
+
+func TestDestroyDomainRemoveStorage(t *testing.T) {
+	tests := []struct {
+		name          string
+		removeStorage []string
+		keepVolumes   []string
+		wipe          bool
+		removed       []string
+		kept          []string
+		warnings      int
+	}{
+		{
+			name: "default keeps storage",
+			kept: []string{"ci-root.qcow2", "ci-data.raw", "installer.iso"},
+		},
+		{
+			name:          "none",
+			removeStorage: []string{"none"},
+			kept:          []string{"ci-root.qcow2", "ci-data.raw", "installer.iso"},
+		},
+		{
+			name:          "all skips read-only disks",
+			removeStorage: []string{"all"},
+			wipe:          true,
+			removed:       []string{"ci-root.qcow2", "ci-data.raw"},
+			kept:          []string{"installer.iso"},
+		},
+		{
+			name:          "all skips volume_id disks",
+			removeStorage: []string{"all"},
+			keepVolumes:   []string{"ci-root.qcow2"},
+			removed:       []string{"ci-data.raw"},
+			kept:          []string{"ci-root.qcow2", "installer.iso"},
+		},
+		{
+			name:          "selected disks",
+			removeStorage: []string{"vdb", "vdz"},
+			removed:       []string{"ci-data.raw"},
+			kept:          []string{"ci-root.qcow2", "installer.iso"},
+			warnings:      1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := testMockClient(t)
+			domain := testDefineDomainWithDisks(t, client)
+			pool, err := client.Libvirt().StoragePoolLookupByName("default")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			options := domainStopOptions{RemoveStorage: tc.removeStorage, WipeStorage: tc.wipe}
+			for _, name := range tc.keepVolumes {
+				volume, err := client.Libvirt().StorageVolLookupByName(pool, name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				options.KeepVolumes = append(options.KeepVolumes, volume.Key)
+			}
+
+			diags := destroyDomain(client, domain, options)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if diags.WarningsCount() != tc.warnings {
+				t.Errorf("expected %d warnings, got %v", tc.warnings, diags)
+			}
+			if _, err := client.Libvirt().DomainLookupByName("ci"); err == nil {
+				t.Error("expected the domain to be undefined")
+			}
+
+			for _, name := range tc.removed {
+				if _, err := client.Libvirt().StorageVolLookupByName(pool, name); err == nil {
+					t.Errorf("expected volume %s to be deleted", name)
+				}
+			}
+			for _, name := range tc.kept {
+				if _, err := client.Libvirt().StorageVolLookupByName(pool, name); err != nil {
+					t.Errorf("expected volume %s to be kept: %v", name, err)
+				}
+			}
+		})
+	}
+}
@@ -2,27 +2,35 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	golibvirt "github.com/digitalocean/go-libvirt"
 	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/generated"
 	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/identityschema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"libvirt.org/go/libvirtxml"
 )
 
 // Ensure the implementation satisfies the expected interfaces
 var (
-	_ resource.Resource                = &DomainResource{}
-	_ resource.ResourceWithConfigure   = &DomainResource{}
-	_ resource.ResourceWithImportState = &DomainResource{}
+	_ resource.Resource                     = &DomainResource{}
+	_ resource.ResourceWithConfigure        = &DomainResource{}
+	_ resource.ResourceWithImportState      = &DomainResource{}
+	_ resource.ResourceWithIdentity         = &DomainResource{}
+	_ resource.ResourceWithConfigValidators = &DomainResource{}
 )
 
 // NewDomainResource creates a new domain resource
@@ -39,11 +47,21 @@ type DomainResource struct {
 type DomainResourceModel struct {
 	generated.DomainModel
 
-	Running   types.Bool   `tfsdk:"running"`
-	Autostart types.Bool   `tfsdk:"autostart"`
-	Create    types.Object `tfsdk:"create"`
-	Update    types.Object `tfsdk:"update"`
-	Destroy   types.Object `tfsdk:"destroy"`
+	Running           types.Bool   `tfsdk:"running"`
+	Autostart         types.Bool   `tfsdk:"autostart"`
+	MACSeed           types.String `tfsdk:"mac_seed"`
+	XMLPatches        types.List   `tfsdk:"xml_patches"`
+	ReadbackIgnore    types.List   `tfsdk:"readback_ignore"`
+	OSVariant         types.String `tfsdk:"os_variant"`
+	OSVariantDefaults types.Map    `tfsdk:"os_variant_defaults"`
+	Create            types.Object `tfsdk:"create"`
+	Update            types.Object `tfsdk:"update"`
+	Destroy           types.Object `tfsdk:"destroy"`
+}
+
+// DomainResourceIdentityModel describes the identity of a domain.
+type DomainResourceIdentityModel struct {
+	UUID types.String `tfsdk:"uuid"`
 }
 
 // DomainInterfaceWaitForIPModel describes wait_for_ip overrides.
@@ -68,35 +86,63 @@ type DomainCreateModel struct {
 	ForceBoot   types.Bool `tfsdk:"force_boot"`
 	Validate    types.Bool `tfsdk:"validate"`
 	ResetNVRAM  types.Bool `tfsdk:"reset_nvram"`
+
+	WaitForConsole types.Object `tfsdk:"wait_for_console"`
+}
+
+// domainCreateExtras holds the create settings that only libvirt_domain supports.
+type domainCreateExtras struct {
+	CloneFrom     *DomainCloneFromModel
+	NVRAMTemplate string
 }
 
 // DomainUpdateModel describes domain update stop behavior.
 type DomainUpdateModel struct {
-	Shutdown types.Object `tfsdk:"shutdown"`
+	Shutdown   types.Object `tfsdk:"shutdown"`
+	ResetNVRAM types.String `tfsdk:"reset_nvram"`
 }
 
 // DomainDestroyModel describes domain shutdown behavior
 type DomainDestroyModel struct {
-	Graceful types.Bool   `tfsdk:"graceful"`
-	Shutdown types.Object `tfsdk:"shutdown"`
+	Graceful      types.Bool   `tfsdk:"graceful"`
+	Shutdown      types.Object `tfsdk:"shutdown"`
+	RemoveStorage types.List   `tfsdk:"remove_storage"`
+	WipeStorage   types.Bool   `tfsdk:"wipe_storage"`
 }
 
 // DomainShutdownModel describes optional shutdown wait behavior.
 type DomainShutdownModel struct {
 	Timeout types.Int64 `tfsdk:"timeout"`
+	Mode    types.List  `tfsdk:"mode"`
 }
 
 type domainStopOptions struct {
 	Flags           golibvirt.DomainDestroyFlagsValues
 	ShutdownEnabled bool
 	ShutdownTimeout time.Duration
+	ShutdownModes   []golibvirt.DomainShutdownFlagValues
 	ForceOnTimeout  bool
+
+	// ResetNVRAM deletes the NVRAM of the domain when it is redefined on update.
+	ResetNVRAM bool
+
+	// RemoveStorage selects the disks whose volumes are deleted after the domain is
+	// undefined on destroy, and WipeStorage wipes them first.
+	RemoveStorage []string
+	WipeStorage   bool
+
+	// KeepVolumes holds the keys of volumes attached with volume_id, which
+	// RemoveStorage ["all"] leaves in place.
+	KeepVolumes []string
 }
 
 type domainPlanData struct {
 	SanitizedModel generated.DomainModel
 	WaitConfigs    []interfaceWaitForIPConfig
 	WaitAttributes []attr.Value
+	DiskVolumeIDs  []types.String
+	Create         types.Object
+	CreateExtras   domainCreateExtras
 }
 
 const (
@@ -106,9 +152,16 @@ const (
 	libvirtVersionDomainUndefineKeepTpmMin   uint64 = 8_009_000
 )
 
-func domainUndefineFlagsForUpdate(libvirtVersion uint64) golibvirt.DomainUndefineFlagsValues {
+// domainUndefineFlagsForUpdate returns the flags to undefine a domain that is defined again
+// right after. The NVRAM is kept so that UEFI variables such as enrolled Secure Boot keys
+// and boot entries survive the update, unless resetNVRAM asks to create it again from the
+// template.
+func domainUndefineFlagsForUpdate(libvirtVersion uint64, resetNVRAM bool) golibvirt.DomainUndefineFlagsValues {
 	var flags golibvirt.DomainUndefineFlagsValues
-	if libvirtVersion >= libvirtVersionDomainUndefineKeepNvramMin {
+	switch {
+	case resetNVRAM && libvirtVersion >= libvirtVersionDomainUndefineNvramMin:
+		flags |= golibvirt.DomainUndefineNvram
+	case !resetNVRAM && libvirtVersion >= libvirtVersionDomainUndefineKeepNvramMin:
 		flags |= golibvirt.DomainUndefineKeepNvram
 	}
 	if libvirtVersion >= libvirtVersionDomainUndefineKeepTpmMin {
@@ -155,10 +208,58 @@ func domainDevicesSchemaAttributeWithWaitForIP() schema.SingleNestedAttribute {
 
 	interfaceAttrs := interfacesAttr.NestedObject.Attributes
 	interfaceAttrs["wait_for_ip"] = domainInterfaceWaitForIPSchemaAttribute()
+	interfaceAttrs["addresses"] = domainInterfaceAddressesSchemaAttribute()
+	if macAttr, ok := interfaceAttrs["mac"].(schema.SingleNestedAttribute); ok {
+		macAttr.Computed = true
+		macAttr.Description += " If omitted, a stable locally administered address derived from the domain name, " +
+			"interface index and mac_seed is generated at plan time and kept across updates. Existing interfaces " +
+			"are matched by source and model, so removing or reordering interfaces does not change their MACs."
+		macAttr.MarkdownDescription = macAttr.Description
+		macAttr.PlanModifiers = append(macAttr.PlanModifiers, DeterministicMACPlanModifier())
+		interfaceAttrs["mac"] = macAttr
+	}
 	interfacesAttr.NestedObject.Attributes = interfaceAttrs
 	baseAttr.Attributes["interfaces"] = interfacesAttr
 
-	return baseAttr
+	return addDiskVolumeIDSchemaAttribute(baseAttr)
+}
+
+func domainInterfaceAddressesSchemaAttribute() schema.ListNestedAttribute {
+	return schema.ListNestedAttribute{
+		Description: "IP addresses of the interface, discovered after wait_for_ip and refreshed on every read " +
+			"from DHCP leases, and from the QEMU guest agent when a wait_for_ip of the domain uses the 'agent' or " +
+			"'any' source. Empty while the domain is not running.",
+		Computed: true,
+		NestedObject: schema.NestedAttributeObject{
+			Attributes: map[string]schema.Attribute{
+				"type": schema.StringAttribute{
+					Description: "Address type: 'ipv4' or 'ipv6'.",
+					Computed:    true,
+				},
+				"addr": schema.StringAttribute{
+					Description: "IP address.",
+					Computed:    true,
+				},
+				"prefix": schema.Int64Attribute{
+					Description: "Network prefix length.",
+					Computed:    true,
+				},
+				"source": schema.StringAttribute{
+					Description: "Where the address was discovered: 'lease' or 'agent'.",
+					Computed:    true,
+				},
+			},
+		},
+	}
+}
+
+func domainInterfaceAddressAttrTypes() map[string]attr.Type {
+	return map[string]attr.Type{
+		"type":   types.StringType,
+		"addr":   types.StringType,
+		"prefix": types.Int64Type,
+		"source": types.StringType,
+	}
 }
 
 func domainInterfaceWaitForIPAttrTypes() map[string]attr.Type {
@@ -194,6 +295,9 @@ func domainInterfaceAttributeTypesWithWaitForIP() map[string]attr.Type {
 	base["wait_for_ip"] = types.ObjectType{
 		AttrTypes: domainInterfaceWaitForIPAttrTypes(),
 	}
+	base["addresses"] = types.ListType{
+		ElemType: types.ObjectType{AttrTypes: domainInterfaceAddressAttrTypes()},
+	}
 	return base
 }
 
@@ -204,6 +308,9 @@ func domainDeviceListAttributeTypesWithWaitForIP() map[string]attr.Type {
 			ElemType: types.ObjectType{AttrTypes: domainInterfaceAttributeTypesWithWaitForIP()},
 		}
 	}
+	base["disks"] = types.ListType{
+		ElemType: types.ObjectType{AttrTypes: domainDiskAttributeTypesWithVolumeID()},
+	}
 	return base
 }
 
@@ -212,7 +319,12 @@ func prepareDomainPlan(ctx context.Context, model *DomainResourceModel) (domainP
 		SanitizedModel: model.DomainModel,
 	}
 
-	cleanDevices, configs, waitAttrs, diags := stripWaitForIP(ctx, model.Devices)
+	devices, volumeIDs, diags := stripDiskVolumeIDs(ctx, model.Devices)
+	if diags.HasError() {
+		return result, diags
+	}
+
+	cleanDevices, configs, waitAttrs, diags := stripWaitForIP(ctx, devices)
 	if diags.HasError() {
 		return result, diags
 	}
@@ -220,7 +332,10 @@ func prepareDomainPlan(ctx context.Context, model *DomainResourceModel) (domainP
 	result.SanitizedModel.Devices = cleanDevices
 	result.WaitConfigs = configs
 	result.WaitAttributes = waitAttrs
-	return result, nil
+	result.DiskVolumeIDs = volumeIDs
+
+	result.Create, result.CreateExtras, diags = stripDomainCreateExtras(ctx, model.Create)
+	return result, diags
 }
 
 func stripWaitForIP(ctx context.Context, devices types.Object) (types.Object, []interfaceWaitForIPConfig, []attr.Value, diag.Diagnostics) {
@@ -313,7 +428,7 @@ func stripWaitForIP(ctx context.Context, devices types.Object) (types.Object, []
 
 		newIfaceAttrs := make(map[string]attr.Value, len(ifaceAttrs))
 		for k, v := range ifaceAttrs {
-			if k == "wait_for_ip" {
+			if k == "wait_for_ip" || k == "addresses" {
 				continue
 			}
 			newIfaceAttrs[k] = v
@@ -336,7 +451,10 @@ func stripWaitForIP(ctx context.Context, devices types.Object) (types.Object, []
 	return cleanObj, waitConfigs, waitAttrs, diags
 }
 
-func applyWaitForIPValues(ctx context.Context, devices types.Object, waitValues []attr.Value) (types.Object, diag.Diagnostics) {
+// applyWaitForIPValues converts devices back to the resource schema types, restoring the
+// wait_for_ip values and setting the addresses of each interface from addresses, which
+// is indexed like the interfaces list.
+func applyWaitForIPValues(ctx context.Context, devices types.Object, waitValues []attr.Value, addresses [][]attr.Value) (types.Object, diag.Diagnostics) {
 	if devices.IsNull() || devices.IsUnknown() {
 		if len(waitValues) == 0 {
 			if devices.IsUnknown() {
@@ -397,6 +515,19 @@ func applyWaitForIPValues(ctx context.Context, devices types.Object, waitValues
 		}
 		newIfaceAttrs["wait_for_ip"] = waitVal
 
+		var ifaceAddresses []attr.Value
+		if i < len(addresses) {
+			ifaceAddresses = addresses[i]
+		}
+		addressList, diags := types.ListValue(
+			types.ObjectType{AttrTypes: domainInterfaceAddressAttrTypes()},
+			ifaceAddresses,
+		)
+		if diags.HasError() {
+			return types.ObjectNull(domainDeviceListAttributeTypesWithWaitForIP()), diags
+		}
+		newIfaceAttrs["addresses"] = addressList
+
 		newIface, diags := types.ObjectValue(domainInterfaceAttributeTypesWithWaitForIP(), newIfaceAttrs)
 		if diags.HasError() {
 			return types.ObjectNull(domainDeviceListAttributeTypesWithWaitForIP()), diags
@@ -488,7 +619,10 @@ func domainShutdownTimeoutFromObject(ctx context.Context, shutdownVal types.Obje
 	return defaultTimeout, nil
 }
 
-func domainUpdateOptionsFromUpdate(ctx context.Context, updateVal types.Object) (domainStopOptions, diag.Diagnostics) {
+// domainUpdateOptionsFromUpdate returns the options to stop and redefine the domain on
+// update. priorVal is the update setting in state, so that the NVRAM is only reset when
+// update.reset_nvram changes.
+func domainUpdateOptionsFromUpdate(ctx context.Context, updateVal, priorVal types.Object) (domainStopOptions, diag.Diagnostics) {
 	options := domainStopOptions{
 		ShutdownEnabled: true,
 		ShutdownTimeout: 30 * time.Second,
@@ -508,10 +642,27 @@ func domainUpdateOptionsFromUpdate(ctx context.Context, updateVal types.Object)
 	if !updateModel.Shutdown.IsNull() && !updateModel.Shutdown.IsUnknown() {
 		timeout, timeoutDiags := domainShutdownTimeoutFromObject(ctx, updateModel.Shutdown, options.ShutdownTimeout)
 		diags.Append(timeoutDiags...)
+		// Updates always force a stop when the shutdown does not complete
+		modes, _, modeDiags := domainShutdownModesFromObject(ctx, updateModel.Shutdown)
+		diags.Append(modeDiags...)
 		if diags.HasError() {
 			return options, diags
 		}
 		options.ShutdownTimeout = timeout
+		options.ShutdownModes = modes
+	}
+
+	if !updateModel.ResetNVRAM.IsNull() && !updateModel.ResetNVRAM.IsUnknown() {
+		prior := types.StringNull()
+		if !priorVal.IsNull() && !priorVal.IsUnknown() {
+			var priorModel DomainUpdateModel
+			diags.Append(priorVal.As(ctx, &priorModel, basetypes.ObjectAsOptions{})...)
+			if diags.HasError() {
+				return options, diags
+			}
+			prior = priorModel.ResetNVRAM
+		}
+		options.ResetNVRAM = !updateModel.ResetNVRAM.Equal(prior)
 	}
 
 	return options, diags
@@ -537,24 +688,31 @@ func domainDestroyOptionsFromDestroy(ctx context.Context, destroyVal types.Objec
 		options.ShutdownTimeout = 30 * time.Second
 		timeout, timeoutDiags := domainShutdownTimeoutFromObject(ctx, destroyModel.Shutdown, options.ShutdownTimeout)
 		diags.Append(timeoutDiags...)
+		modes, force, modeDiags := domainShutdownModesFromObject(ctx, destroyModel.Shutdown)
+		diags.Append(modeDiags...)
 		if diags.HasError() {
 			return options, diags
 		}
 		options.ShutdownTimeout = timeout
+		options.ShutdownModes = modes
+		options.ForceOnTimeout = force
 	}
 
-	return options, nil
-}
+	if !destroyModel.RemoveStorage.IsNull() && !destroyModel.RemoveStorage.IsUnknown() {
+		diags.Append(destroyModel.RemoveStorage.ElementsAs(ctx, &options.RemoveStorage, false)...)
+		if diags.HasError() {
+			return options, diags
+		}
+	}
+	options.WipeStorage = destroyModel.WipeStorage.ValueBool()
 
-// Metadata returns the resource type name
-func (r *DomainResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
-	resp.TypeName = req.ProviderTypeName + "_domain"
+	return options, nil
 }
 
-// Schema defines the schema for the resource
-func (r *DomainResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
-	overrides := map[string]schema.Attribute{
-		"devices": domainDevicesSchemaAttributeWithWaitForIP(),
+// domainLifecycleSchemaAttributes returns the attributes controlling the domain lifecycle,
+// shared by the resources that define domains.
+func domainLifecycleSchemaAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
 		"running": schema.BoolAttribute{
 			Description: "Whether the domain should be started after creation.",
 			Optional:    true,
@@ -572,7 +730,13 @@ func (r *DomainResource) Schema(ctx context.Context, req resource.SchemaRequest,
 				"bypass_cache": schema.BoolAttribute{Optional: true},
 				"force_boot":   schema.BoolAttribute{Optional: true},
 				"validate":     schema.BoolAttribute{Optional: true},
-				"reset_nvram":  schema.BoolAttribute{Optional: true},
+				"reset_nvram": schema.BoolAttribute{
+					Description: "Reset the NVRAM from its template every time Terraform starts the domain, including the restart after an update. " +
+						"Use update.reset_nvram to reset it only once.",
+					Optional: true,
+				},
+
+				"wait_for_console": domainWaitForConsoleSchemaAttribute(),
 			},
 		},
 		"update": schema.SingleNestedAttribute{
@@ -587,8 +751,16 @@ func (r *DomainResource) Schema(ctx context.Context, req resource.SchemaRequest,
 							Description: "Experimental: seconds to wait for guest shutdown before forcing a stop during update. Defaults to 30.",
 							Optional:    true,
 						},
+						"mode": domainShutdownModeSchemaAttribute("Updates always force a stop after the last method, so a final 'destroy' changes nothing."),
 					},
 				},
+				"reset_nvram": schema.StringAttribute{
+					Description: "Arbitrary value whose change deletes the NVRAM of the domain on the update that applies it, " +
+						"so that it is created again from its template and firmware is selected again. Later updates keep the NVRAM. " +
+						"By default the NVRAM, with the UEFI variables and enrolled Secure Boot keys, is kept across updates. " +
+						"create.reset_nvram resets it on every start regardless of this setting.",
+					Optional: true,
+				},
 			},
 		},
 		"destroy": schema.SingleNestedAttribute{
@@ -607,11 +779,98 @@ func (r *DomainResource) Schema(ctx context.Context, req resource.SchemaRequest,
 							Description: "Experimental: seconds to wait for guest shutdown before failing destroy. Defaults to 30.",
 							Optional:    true,
 						},
+						"mode": domainShutdownModeSchemaAttribute("End the list with 'destroy' to force a stop instead of failing destroy."),
 					},
 				},
+				"remove_storage": domainRemoveStorageSchemaAttribute(),
+				"wipe_storage": schema.BoolAttribute{
+					Description: "Wipe the volumes selected by remove_storage before deleting them.",
+					Optional:    true,
+				},
 			},
 		},
 	}
+}
+
+// domainCreateSchemaAttributeWithExtras returns the create attribute of libvirt_domain,
+// which adds settings that depend on the generated XML to the start flags shared with
+// libvirt_domain_xml.
+func domainCreateSchemaAttributeWithExtras(create schema.Attribute) schema.Attribute {
+	createAttr, ok := create.(schema.SingleNestedAttribute)
+	if !ok {
+		return create
+	}
+	attributes := make(map[string]schema.Attribute, len(createAttr.Attributes)+2)
+	for name, attribute := range createAttr.Attributes {
+		attributes[name] = attribute
+	}
+	attributes["clone_from"] = domainCloneFromSchemaAttribute()
+	attributes["nvram_template"] = domainNVRAMTemplateSchemaAttribute()
+	createAttr.Attributes = attributes
+	return createAttr
+}
+
+// stripDomainCreateExtras removes the settings added by domainCreateSchemaAttributeWithExtras
+// from the create block, so that the start flags can be read like the ones of
+// libvirt_domain_xml, and returns them parsed.
+func stripDomainCreateExtras(ctx context.Context, create types.Object) (types.Object, domainCreateExtras, diag.Diagnostics) {
+	var extras domainCreateExtras
+	if create.IsNull() || create.IsUnknown() {
+		return create, extras, nil
+	}
+
+	attrs := make(map[string]attr.Value, len(create.Attributes()))
+	attrTypes := make(map[string]attr.Type, len(create.Attributes()))
+	for name, value := range create.Attributes() {
+		if name == "clone_from" || name == "nvram_template" {
+			continue
+		}
+		attrs[name] = value
+		attrTypes[name] = value.Type(ctx)
+	}
+	clean, diags := types.ObjectValue(attrTypes, attrs)
+	if diags.HasError() {
+		return create, extras, diags
+	}
+
+	if template, ok := create.Attributes()["nvram_template"].(types.String); ok {
+		extras.NVRAMTemplate = template.ValueString()
+	}
+
+	cloneFrom, ok := create.Attributes()["clone_from"].(basetypes.ObjectValue)
+	if !ok || cloneFrom.IsNull() || cloneFrom.IsUnknown() {
+		return clean, extras, diags
+	}
+	extras.CloneFrom = &DomainCloneFromModel{}
+	diags.Append(cloneFrom.As(ctx, extras.CloneFrom, basetypes.ObjectAsOptions{})...)
+	return clean, extras, diags
+}
+
+// Metadata returns the resource type name
+func (r *DomainResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_domain"
+}
+
+// Schema defines the schema for the resource
+func (r *DomainResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	overrides := map[string]schema.Attribute{
+		"devices": domainDevicesSchemaAttributeWithWaitForIP(),
+		"mac_seed": schema.StringAttribute{
+			Description: "Seed mixed into the MAC addresses generated for interfaces without a configured MAC. " +
+				"Set it to get different addresses for domains with the same name, e.g. on different hosts. " +
+				"Changing it only affects interfaces that do not have a MAC address yet.",
+			Optional: true,
+		},
+		"xml_patches":     xmlPatchesSchemaAttribute(),
+		"readback_ignore": readbackIgnoreSchemaAttribute(),
+	}
+	for name, attribute := range domainLifecycleSchemaAttributes() {
+		overrides[name] = attribute
+	}
+	overrides["create"] = domainCreateSchemaAttributeWithExtras(overrides["create"])
+	for name, attribute := range osVariantSchemaAttributes() {
+		overrides[name] = attribute
+	}
 
 	schemaDef := generated.DomainSchema(overrides)
 	schemaDef.Description = "Manages a libvirt domain (virtual machine)."
@@ -621,9 +880,39 @@ Manages a libvirt domain (virtual machine).
 This resource follows the [libvirt domain XML schema](https://libvirt.org/formatdomain.html) closely,
 providing fine-grained control over VM configuration.
 `
+	schemaDef.Version = resourceSchemaVersion
 	resp.Schema = schemaDef
 }
 
+// IdentitySchema defines the identity of the resource
+func (r *DomainResource) IdentitySchema(ctx context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = identityschema.Schema{
+		Attributes: map[string]identityschema.Attribute{
+			"uuid": identityschema.StringAttribute{
+				Description:       "UUID of the domain.",
+				RequiredForImport: true,
+			},
+		},
+	}
+}
+
+// setDomainIdentity records the domain UUID as the resource identity.
+func setDomainIdentity(ctx context.Context, identity *tfsdk.ResourceIdentity, uuid types.String) diag.Diagnostics {
+	return setResourceIdentity(ctx, identity, DomainResourceIdentityModel{UUID: uuid})
+}
+
+// ConfigValidators rejects settings that cannot be combined
+func (r *DomainResource) ConfigValidators(ctx context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		// The defaults of os_variant would be computed at plan time without the settings
+		// of the source domain
+		resourcevalidator.Conflicting(
+			path.MatchRoot("os_variant"),
+			path.MatchRoot("create").AtName("clone_from"),
+		),
+	}
+}
+
 // Configure adds the provider configured client to the resource
 func (r *DomainResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
@@ -642,8 +931,10 @@ func (r *DomainResource) Configure(ctx context.Context, req resource.ConfigureRe
 	r.client = client
 }
 
-func (r *DomainResource) stopDomainIfRunning(domain golibvirt.Domain, options domainStopOptions) (bool, error) {
-	domainState, _, err := r.client.Libvirt().DomainGetState(domain, 0)
+// stopDomainIfRunning stops a running domain according to options. The returned bool
+// reports whether waiting for a guest shutdown timed out.
+func stopDomainIfRunning(client *libvirt.Client, domain golibvirt.Domain, options domainStopOptions) (bool, error) {
+	domainState, _, err := client.Libvirt().DomainGetState(domain, 0)
 	if err != nil {
 		return false, fmt.Errorf("check domain state: %w", err)
 	}
@@ -653,24 +944,22 @@ func (r *DomainResource) stopDomainIfRunning(domain golibvirt.Domain, options do
 	}
 
 	if options.ShutdownEnabled {
-		if err := r.client.Libvirt().DomainShutdown(domain); err != nil {
-			return false, fmt.Errorf("request guest shutdown: %w", err)
+		timedOut, err := shutdownDomain(client, domain, options)
+		if err == nil {
+			return false, nil
 		}
-
-		if err := waitForDomainState(r.client, domain, uint32(golibvirt.DomainShutoff), options.ShutdownTimeout); err != nil {
-			if !options.ForceOnTimeout {
-				return true, fmt.Errorf("wait for shutdown: %w", err)
-			}
-
-			if destroyErr := r.client.Libvirt().DomainDestroyFlags(domain, options.Flags); destroyErr != nil {
-				return false, fmt.Errorf("force stop after shutdown timeout: %w", destroyErr)
-			}
+		// A failed request only falls back to a forced stop when shutdown modes are chained
+		if !options.ForceOnTimeout || (!timedOut && len(options.ShutdownModes) == 0) {
+			return timedOut, err
 		}
 
+		if destroyErr := client.Libvirt().DomainDestroyFlags(domain, options.Flags); destroyErr != nil {
+			return false, fmt.Errorf("force stop after %s: %w", err, destroyErr)
+		}
 		return false, nil
 	}
 
-	if err := r.client.Libvirt().DomainDestroyFlags(domain, options.Flags); err != nil {
+	if err := client.Libvirt().DomainDestroyFlags(domain, options.Flags); err != nil {
 		return false, fmt.Errorf("force stop running domain: %w", err)
 	}
 
@@ -700,6 +989,28 @@ func (r *DomainResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
+	resp.Diagnostics.Append(resolveDiskVolumeIDs(r.client, domainXML, planData.DiskVolumeIDs)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var clone *domainClone
+	if planData.CreateExtras.CloneFrom != nil {
+		domainXML, clone, diags = prepareDomainClone(r.client, planData.CreateExtras.CloneFrom, domainXML)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	applyNVRAMTemplate(domainXML, planData.CreateExtras.NVRAMTemplate)
+
+	osDefaults, diags := r.applyOSVariant(ctx, plan.OSVariant, domainXML)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	xmlString, err := libvirt.MarshalDomainXML(domainXML)
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -709,8 +1020,33 @@ func (r *DomainResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
-	domain, err := r.client.Libvirt().DomainDefineXML(xmlString)
+	patchedXML, diags := applyXMLPatches(ctx, xmlString, plan.XMLPatches)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(validateXML(r.client, "domain", patchedXML, generated.DomainXMLPaths)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var clonedVolumes []golibvirt.StorageVol
+	if clone != nil {
+		clonedVolumes, err = clone.createVolumes(ctx, r.client)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				cloneFromPath,
+				"Failed to Clone Disks",
+				"Failed to clone the disks of the source domain: "+err.Error(),
+			)
+			return
+		}
+	}
+
+	domain, err := defineDomainXML(r.client, patchedXML)
 	if err != nil {
+		deleteClonedVolumes(ctx, r.client, clonedVolumes)
 		resp.Diagnostics.AddError(
 			"Domain Creation Failed",
 			"Failed to define domain in libvirt: "+err.Error(),
@@ -727,11 +1063,14 @@ func (r *DomainResource) Create(ctx context.Context, req resource.CreateRequest,
 		if undefErr := r.client.Libvirt().DomainUndefine(domain); undefErr != nil {
 			tflog.Warn(ctx, "Failed to undefine domain during cleanup", map[string]any{"error": undefErr.Error()})
 		}
+		deleteClonedVolumes(ctx, r.client, clonedVolumes)
 	}
 
 	if !plan.Running.IsNull() && plan.Running.ValueBool() {
-		flags, startDiags := domainStartFlagsFromCreate(ctx, plan.Create)
+		flags, startDiags := domainStartFlagsFromCreate(ctx, planData.Create)
 		resp.Diagnostics.Append(startDiags...)
+		consoleWait, consoleDiags := consoleWaitConfigFromCreate(ctx, planData.Create)
+		resp.Diagnostics.Append(consoleDiags...)
 		if resp.Diagnostics.HasError() {
 			cleanupOnError()
 			return
@@ -746,6 +1085,17 @@ func (r *DomainResource) Create(ctx context.Context, req resource.CreateRequest,
 			return
 		}
 
+		if consoleWait != nil {
+			if output, err := waitForConsole(ctx, r.client, domain, consoleWait); err != nil {
+				cleanupOnError()
+				resp.Diagnostics.AddError(
+					"Failed to Wait for Console Output",
+					consoleWaitErrorDetail("Domain was created and started but the console did not show the expected output", err, output),
+				)
+				return
+			}
+		}
+
 		for _, waitCfg := range planData.WaitConfigs {
 			if err := waitForInterfaceIP(ctx, r.client, domain, waitCfg.MAC, waitCfg.Timeout, waitCfg.Source); err != nil {
 				cleanupOnError()
@@ -762,19 +1112,13 @@ func (r *DomainResource) Create(ctx context.Context, req resource.CreateRequest,
 		}
 	}
 
-	if !plan.Autostart.IsNull() && !plan.Autostart.IsUnknown() {
-		autostart := int32(0)
-		if plan.Autostart.ValueBool() {
-			autostart = 1
-		}
-		if err := r.client.Libvirt().DomainSetAutostart(domain, autostart); err != nil {
-			cleanupOnError()
-			resp.Diagnostics.AddError(
-				"Failed to Set Autostart",
-				"Domain was created but failed to set autostart: "+err.Error(),
-			)
-			return
-		}
+	if err := setDomainAutostart(r.client, domain, plan.Autostart); err != nil {
+		cleanupOnError()
+		resp.Diagnostics.AddError(
+			"Failed to Set Autostart",
+			"Domain was created but failed to set autostart: "+err.Error(),
+		)
+		return
 	}
 
 	xmlDesc, err := r.client.Libvirt().DomainGetXMLDesc(domain, golibvirt.DomainXMLSecure)
@@ -787,6 +1131,16 @@ func (r *DomainResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
+	xmlDesc, err = restoreXMLPatches(ctx, xmlDesc, plan.XMLPatches, func() (string, error) { return xmlString, nil })
+	if err != nil {
+		cleanupOnError()
+		resp.Diagnostics.AddError(
+			"Failed to Read Domain",
+			"Failed to ignore xml_patches in domain XML: "+err.Error(),
+		)
+		return
+	}
+
 	parsedDomain, err := libvirt.UnmarshalDomainXML(xmlDesc)
 	if err != nil {
 		cleanupOnError()
@@ -808,15 +1162,27 @@ func (r *DomainResource) Create(ctx context.Context, req resource.CreateRequest,
 	}
 
 	state := DomainResourceModel{
-		DomainModel: *stateModel,
-		Running:     plan.Running,
-		Autostart:   plan.Autostart,
-		Create:      plan.Create,
-		Update:      plan.Update,
-		Destroy:     plan.Destroy,
+		DomainModel:       *stateModel,
+		Running:           plan.Running,
+		Autostart:         plan.Autostart,
+		MACSeed:           plan.MACSeed,
+		XMLPatches:        plan.XMLPatches,
+		ReadbackIgnore:    plan.ReadbackIgnore,
+		OSVariant:         plan.OSVariant,
+		OSVariantDefaults: osDefaults,
+		Create:            plan.Create,
+		Update:            plan.Update,
+		Destroy:           plan.Destroy,
+	}
+
+	state.Devices, diags = applyDiskVolumeIDs(ctx, state.Devices, planData.DiskVolumeIDs)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		cleanupOnError()
+		return
 	}
 
-	state.Devices, diags = applyWaitForIPValues(ctx, state.Devices, planData.WaitAttributes)
+	state.Devices, diags = applyWaitForIPValues(ctx, state.Devices, planData.WaitAttributes, domainInterfaceAddresses(ctx, r.client, domain, parsedDomain, waitForIPUsesAgent(ctx, planData.WaitAttributes)))
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		cleanupOnError()
@@ -844,6 +1210,14 @@ func (r *DomainResource) Create(ctx context.Context, req resource.CreateRequest,
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+	resp.Diagnostics.Append(setDomainIdentity(ctx, resp.Identity, state.UUID)...)
+	if clone != nil {
+		clonedValue, diags := clone.clonedVolumesValue(clonedVolumes)
+		resp.Diagnostics.Append(diags...)
+		if !diags.HasError() {
+			resp.Diagnostics.Append(resp.Private.SetKey(ctx, domainClonedVolumesKey, clonedValue)...)
+		}
+	}
 }
 
 // Read reads the domain state
@@ -854,12 +1228,30 @@ func (r *DomainResource) Read(ctx context.Context, req resource.ReadRequest, res
 		return
 	}
 
+	found, diags := r.readDomain(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !found {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+	resp.Diagnostics.Append(setDomainIdentity(ctx, resp.Identity, state.UUID)...)
+}
+
+// readDomain refreshes state from libvirt. It returns false if the domain no longer exists.
+// When state only carries the UUID (import or listing), every field reported by libvirt is populated.
+func (r *DomainResource) readDomain(ctx context.Context, state *DomainResourceModel) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
 	originalMetadata := state.Metadata
 	originalID := state.ID
 
 	if state.UUID.IsNull() || state.UUID.IsUnknown() {
-		resp.State.RemoveResource(ctx)
-		return
+		return false, diags
 	}
 
 	// Detect import: after ImportState, only UUID is set — Name will be null.
@@ -868,61 +1260,95 @@ func (r *DomainResource) Read(ctx context.Context, req resource.ReadRequest, res
 
 	var plan *generated.DomainModel
 	var waitAttrs []attr.Value
+	var volumeIDs []types.String
 
 	if !isImport {
-		planData, diags := prepareDomainPlan(ctx, &state)
-		resp.Diagnostics.Append(diags...)
-		if resp.Diagnostics.HasError() {
-			return
+		planData, planDiags := prepareDomainPlan(ctx, state)
+		diags.Append(planDiags...)
+		if diags.HasError() {
+			return true, diags
 		}
 		plan = &planData.SanitizedModel
 		waitAttrs = planData.WaitAttributes
+		volumeIDs = planData.DiskVolumeIDs
+
+		ignorePaths, ignoreDiags := readbackIgnorePaths(ctx, state.ReadbackIgnore)
+		diags.Append(ignoreDiags...)
+		if diags.HasError() {
+			return true, diags
+		}
+		ctx = generated.WithReadbackIgnore(ctx, ignorePaths)
 	}
 
 	domain, err := r.client.LookupDomainByUUID(state.UUID.ValueString())
 	if err != nil {
-		resp.State.RemoveResource(ctx)
-		return
+		return false, diags
 	}
 
 	xmlDesc, err := r.client.Libvirt().DomainGetXMLDesc(domain, golibvirt.DomainXMLSecure)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		diags.AddError(
 			"Failed to Read Domain",
 			"Failed to get domain XML: "+err.Error(),
 		)
-		return
+		return true, diags
+	}
+
+	if !isImport {
+		xmlDesc, err = restoreXMLPatches(ctx, xmlDesc, state.XMLPatches, func() (string, error) {
+			domainXML, err := generated.DomainToXML(ctx, plan)
+			if err != nil {
+				return "", err
+			}
+			return libvirt.MarshalDomainXML(domainXML)
+		})
+		if err != nil {
+			diags.AddError(
+				"Failed to Read Domain",
+				"Failed to ignore xml_patches in domain XML: "+err.Error(),
+			)
+			return true, diags
+		}
 	}
 
 	parsedDomain, err := libvirt.UnmarshalDomainXML(xmlDesc)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		diags.AddError(
 			"Failed to Parse Domain XML",
 			"Failed to parse domain XML from libvirt: "+err.Error(),
 		)
-		return
+		return true, diags
+	}
+
+	// Without a plan every field libvirt reports ends up in state, so drop what libvirt
+	// generated on its own to keep generated configuration minimal
+	if isImport {
+		normalizeImportedDomain(parsedDomain, importedMachineAlias(r.client, parsedDomain))
 	}
 
 	stateModel, err := generated.DomainFromXML(ctx, parsedDomain, plan)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		diags.AddError(
 			"Failed to Convert Domain",
 			"Failed to convert domain XML to state: "+err.Error(),
 		)
-		return
+		return true, diags
 	}
 
 	state.DomainModel = *stateModel
 
 	// Always apply wait_for_ip type conversion — the schema expects it.
 	// During import, waitAttrs is nil so all interfaces get null wait_for_ip.
-	{
-		var diags diag.Diagnostics
-		state.Devices, diags = applyWaitForIPValues(ctx, state.Devices, waitAttrs)
-		resp.Diagnostics.Append(diags...)
-		if resp.Diagnostics.HasError() {
-			return
-		}
+	var waitDiags diag.Diagnostics
+	state.Devices, waitDiags = applyDiskVolumeIDs(ctx, state.Devices, volumeIDs)
+	diags.Append(waitDiags...)
+	if diags.HasError() {
+		return true, diags
+	}
+	state.Devices, waitDiags = applyWaitForIPValues(ctx, state.Devices, waitAttrs, domainInterfaceAddresses(ctx, r.client, domain, parsedDomain, waitForIPUsesAgent(ctx, waitAttrs)))
+	diags.Append(waitDiags...)
+	if diags.HasError() {
+		return true, diags
 	}
 
 	if !originalMetadata.IsNull() && !originalMetadata.IsUnknown() {
@@ -937,11 +1363,11 @@ func (r *DomainResource) Read(ctx context.Context, req resource.ReadRequest, res
 	if isImport || (!state.Autostart.IsNull() && !state.Autostart.IsUnknown()) {
 		autostart, err := r.client.Libvirt().DomainGetAutostart(domain)
 		if err != nil {
-			resp.Diagnostics.AddError(
+			diags.AddError(
 				"Failed to Get Autostart Status",
 				"Failed to read domain autostart setting: "+err.Error(),
 			)
-			return
+			return true, diags
 		}
 		state.Autostart = types.BoolValue(autostart == 1)
 	}
@@ -950,31 +1376,51 @@ func (r *DomainResource) Read(ctx context.Context, req resource.ReadRequest, res
 	if isImport {
 		domainState, _, err := r.client.Libvirt().DomainGetState(domain, 0)
 		if err != nil {
-			resp.Diagnostics.AddError(
+			diags.AddError(
 				"Failed to Get Domain State",
 				"Failed to read domain running state: "+err.Error(),
 			)
-			return
+			return true, diags
 		}
 		state.Running = types.BoolValue(uint32(domainState) == uint32(golibvirt.DomainRunning))
 	}
 
-	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+	return true, diags
 }
 
-// ImportState imports an existing libvirt domain by UUID.
+// ImportState imports an existing libvirt domain by UUID or name.
 //
 // Usage:
 //
 //	terraform import libvirt_domain.myvm <uuid>
+//	terraform import libvirt_domain.myvm <name>
+//
+// Import blocks may also use the structured identity { uuid = "..." }. The imported state
+// leaves out what libvirt generates on its own, such as device addresses and implicit
+// controllers, so that configuration generated from it stays minimal.
 func (r *DomainResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("uuid"), req, resp)
+	if req.ID != "" {
+		uuid, err := resolveDomainImportID(r.client, req.ID)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Domain Not Found",
+				fmt.Sprintf("Cannot import domain: %s", err),
+			)
+			return
+		}
+		req.ID = uuid
+	}
+
+	resource.ImportStatePassthroughWithIdentity(ctx, path.Root("uuid"), path.Root("uuid"), req, resp)
 }
 
-// waitForDomainState waits for a domain to reach the specified state with a timeout
-func waitForDomainState(client *libvirt.Client, domain golibvirt.Domain, targetState uint32, timeout time.Duration) error {
-	deadline := time.Now().Add(timeout)
-	for time.Now().Before(deadline) {
+// waitForDomainState waits for a domain to reach the specified state with a timeout,
+// or until ctx is done
+func waitForDomainState(ctx context.Context, client *libvirt.Client, domain golibvirt.Domain, targetState uint32, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
 		state, _, err := client.Libvirt().DomainGetState(domain, 0)
 		if err != nil {
 			return fmt.Errorf("failed to get domain state: %w", err)
@@ -982,9 +1428,16 @@ func waitForDomainState(client *libvirt.Client, domain golibvirt.Domain, targetS
 		if uint32(state) == targetState {
 			return nil
 		}
-		time.Sleep(1 * time.Second)
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("timeout waiting for domain to reach state %d", targetState)
+			}
+			return fmt.Errorf("stopped waiting for domain to reach state %d: %w", targetState, ctx.Err())
+		case <-time.After(1 * time.Second):
+		}
 	}
-	return fmt.Errorf("timeout waiting for domain to reach state %d", targetState)
 }
 
 // Update updates the domain
@@ -1025,13 +1478,13 @@ func (r *DomainResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
-	updateOptions, updateDiags := domainUpdateOptionsFromUpdate(ctx, plan.Update)
+	updateOptions, updateDiags := domainUpdateOptionsFromUpdate(ctx, plan.Update, state.Update)
 	resp.Diagnostics.Append(updateDiags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	if _, err := r.stopDomainIfRunning(existingDomain, updateOptions); err != nil {
+	if _, err := stopDomainIfRunning(r.client, existingDomain, updateOptions); err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to Stop Domain",
 			"Domain must be stopped before updating: "+err.Error(),
@@ -1048,6 +1501,35 @@ func (r *DomainResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
+	resp.Diagnostics.Append(resolveDiskVolumeIDs(r.client, domainXML, planData.DiskVolumeIDs)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if planData.CreateExtras.CloneFrom != nil {
+		domainXML, err = overlayDefinedDomain(r.client, existingDomain, domainXML)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Failed to Read Domain",
+				"Failed to read the current definition of the cloned domain: "+err.Error(),
+			)
+			return
+		}
+	}
+
+	applyNVRAMTemplate(domainXML, planData.CreateExtras.NVRAMTemplate)
+
+	osDefaults, diags := r.applyOSVariant(ctx, plan.OSVariant, domainXML)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// A reset NVRAM is created again for whatever firmware libvirt selects now
+	if !updateOptions.ResetNVRAM {
+		resp.Diagnostics.Append(keepDomainFirmware(r.client, existingDomain, domainXML)...)
+	}
+
 	xmlString, err := libvirt.MarshalDomainXML(domainXML)
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -1057,6 +1539,17 @@ func (r *DomainResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
+	patchedXML, diags := applyXMLPatches(ctx, xmlString, plan.XMLPatches)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(validateXML(r.client, "domain", patchedXML, generated.DomainXMLPaths)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	libvirtVersion, err := r.client.Libvirt().ConnectGetLibVersion()
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -1066,16 +1559,7 @@ func (r *DomainResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
-	flags := domainUndefineFlagsForUpdate(libvirtVersion)
-	if flags == 0 {
-		if err := r.client.Libvirt().DomainUndefine(existingDomain); err != nil {
-			resp.Diagnostics.AddError(
-				"Domain Undefine Failed",
-				"Failed to undefine existing domain: "+err.Error(),
-			)
-			return
-		}
-	} else if err := r.client.Libvirt().DomainUndefineFlags(existingDomain, flags); err != nil {
+	if err := undefineDomain(r.client, existingDomain, domainUndefineFlagsForUpdate(libvirtVersion, updateOptions.ResetNVRAM)); err != nil {
 		resp.Diagnostics.AddError(
 			"Domain Undefine Failed",
 			"Failed to undefine existing domain: "+err.Error(),
@@ -1083,7 +1567,7 @@ func (r *DomainResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
-	newDomain, err := r.client.Libvirt().DomainDefineXML(xmlString)
+	newDomain, err := defineDomainXML(r.client, patchedXML)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Domain Update Failed",
@@ -1092,24 +1576,20 @@ func (r *DomainResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
-	if !plan.Autostart.IsNull() && !plan.Autostart.IsUnknown() {
-		autostart := int32(0)
-		if plan.Autostart.ValueBool() {
-			autostart = 1
-		}
-		if err := r.client.Libvirt().DomainSetAutostart(newDomain, autostart); err != nil {
-			resp.Diagnostics.AddError(
-				"Failed to Set Autostart",
-				"Domain was updated but failed to set autostart: "+err.Error(),
-			)
-			return
-		}
+	if err := setDomainAutostart(r.client, newDomain, plan.Autostart); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Set Autostart",
+			"Domain was updated but failed to set autostart: "+err.Error(),
+		)
+		return
 	}
 
 	shouldBeRunning := !plan.Running.IsNull() && plan.Running.ValueBool()
 	if shouldBeRunning {
-		flags, startDiags := domainStartFlagsFromCreate(ctx, plan.Create)
+		flags, startDiags := domainStartFlagsFromCreate(ctx, planData.Create)
 		resp.Diagnostics.Append(startDiags...)
+		consoleWait, consoleDiags := consoleWaitConfigFromCreate(ctx, planData.Create)
+		resp.Diagnostics.Append(consoleDiags...)
 		if resp.Diagnostics.HasError() {
 			return
 		}
@@ -1120,6 +1600,15 @@ func (r *DomainResource) Update(ctx context.Context, req resource.UpdateRequest,
 				"Domain was updated but failed to start: "+err.Error(),
 			)
 		} else {
+			if consoleWait != nil {
+				if output, err := waitForConsole(ctx, r.client, newDomain, consoleWait); err != nil {
+					resp.Diagnostics.AddError(
+						"Failed to Wait for Console Output",
+						consoleWaitErrorDetail("Domain was updated but the console did not show the expected output", err, output),
+					)
+					return
+				}
+			}
 			for _, waitCfg := range planData.WaitConfigs {
 				if err := waitForInterfaceIP(ctx, r.client, newDomain, waitCfg.MAC, waitCfg.Timeout, waitCfg.Source); err != nil {
 					resp.Diagnostics.AddError(
@@ -1141,6 +1630,15 @@ func (r *DomainResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
+	xmlDesc, err = restoreXMLPatches(ctx, xmlDesc, plan.XMLPatches, func() (string, error) { return xmlString, nil })
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Read Domain",
+			"Failed to ignore xml_patches in domain XML: "+err.Error(),
+		)
+		return
+	}
+
 	parsedDomain, err := libvirt.UnmarshalDomainXML(xmlDesc)
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -1160,15 +1658,26 @@ func (r *DomainResource) Update(ctx context.Context, req resource.UpdateRequest,
 	}
 
 	newState := DomainResourceModel{
-		DomainModel: *stateModel,
-		Running:     plan.Running,
-		Autostart:   plan.Autostart,
-		Create:      plan.Create,
-		Update:      plan.Update,
-		Destroy:     plan.Destroy,
+		DomainModel:       *stateModel,
+		Running:           plan.Running,
+		Autostart:         plan.Autostart,
+		MACSeed:           plan.MACSeed,
+		XMLPatches:        plan.XMLPatches,
+		ReadbackIgnore:    plan.ReadbackIgnore,
+		OSVariant:         plan.OSVariant,
+		OSVariantDefaults: osDefaults,
+		Create:            plan.Create,
+		Update:            plan.Update,
+		Destroy:           plan.Destroy,
+	}
+
+	newState.Devices, diags = applyDiskVolumeIDs(ctx, newState.Devices, planData.DiskVolumeIDs)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	newState.Devices, diags = applyWaitForIPValues(ctx, newState.Devices, planData.WaitAttributes)
+	newState.Devices, diags = applyWaitForIPValues(ctx, newState.Devices, planData.WaitAttributes, domainInterfaceAddresses(ctx, r.client, newDomain, parsedDomain, waitForIPUsesAgent(ctx, planData.WaitAttributes)))
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
@@ -1195,6 +1704,7 @@ func (r *DomainResource) Update(ctx context.Context, req resource.UpdateRequest,
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &newState)...)
+	resp.Diagnostics.Append(setDomainIdentity(ctx, resp.Identity, newState.UUID)...)
 }
 
 // Delete deletes the domain
@@ -1211,53 +1721,109 @@ func (r *DomainResource) Delete(ctx context.Context, req resource.DeleteRequest,
 		return
 	}
 
-	// Look up the domain
+	// Volumes attached with volume_id usually belong to libvirt_volume resources
+	_, volumeIDs, diags := stripDiskVolumeIDs(ctx, state.Devices)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	for _, volumeID := range volumeIDs {
+		if !volumeID.IsNull() && !volumeID.IsUnknown() {
+			destroyOptions.KeepVolumes = append(destroyOptions.KeepVolumes, volumeID.ValueString())
+		}
+	}
+
+	clonedVolumes, diags := req.Private.GetKey(ctx, domainClonedVolumesKey)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Look up the domain; when it is already gone, only its cloned volumes are left
 	domain, err := r.client.LookupDomainByUUID(state.UUID.ValueString())
-	if err != nil {
-		// Domain already gone - that's OK
+	switch {
+	case golibvirt.IsNotFound(err):
+	case err != nil:
+		resp.Diagnostics.AddError(
+			"Failed to Look Up Domain",
+			fmt.Sprintf("Unable to look up domain %s: %s", state.UUID.ValueString(), err),
+		)
 		return
+	default:
+		resp.Diagnostics.Append(destroyDomain(r.client, domain, destroyOptions)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
 	}
 
-	timedOut, err := r.stopDomainIfRunning(domain, destroyOptions)
+	resp.Diagnostics.Append(removeClonedVolumes(r.client, clonedVolumes, destroyOptions)...)
+}
+
+// destroyDomain stops a domain according to options and undefines it.
+func destroyDomain(client *libvirt.Client, domain golibvirt.Domain, options domainStopOptions) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	timedOut, err := stopDomainIfRunning(client, domain, options)
 	if err != nil {
 		if timedOut {
-			resp.Diagnostics.AddError(
+			diags.AddError(
 				"Timeout Waiting for Domain Shutdown",
-				fmt.Sprintf("Domain did not reach shutoff state within %s: %s", destroyOptions.ShutdownTimeout, err),
+				fmt.Sprintf("Domain did not reach shutoff state within %s: %s", options.ShutdownTimeout, err),
 			)
-			return
+			return diags
 		}
 
-		resp.Diagnostics.AddError(
+		diags.AddError(
 			"Failed to Destroy Domain",
 			"Failed to stop running domain: "+err.Error(),
 		)
-		return
+		return diags
 	}
 
-	libvirtVersion, err := r.client.Libvirt().ConnectGetLibVersion()
+	libvirtVersion, err := client.Libvirt().ConnectGetLibVersion()
 	if err != nil {
-		resp.Diagnostics.AddError(
+		diags.AddError(
 			"Failed to Detect Libvirt Version",
 			"Failed to query libvirt version before domain deletion: "+err.Error(),
 		)
-		return
+		return diags
 	}
 
+	volumes, storageDiags := domainStorageToRemove(client, domain, options)
+	diags.Append(storageDiags...)
+
 	// Undefine the domain using flags supported by the connected libvirt version.
-	flags := domainUndefineFlagsForDelete(libvirtVersion)
-	if flags == 0 {
-		err = r.client.Libvirt().DomainUndefine(domain)
-	} else {
-		err = r.client.Libvirt().DomainUndefineFlags(domain, flags)
-	}
-	if err != nil {
-		resp.Diagnostics.AddError(
+	if err := undefineDomain(client, domain, domainUndefineFlagsForDelete(libvirtVersion)); err != nil {
+		diags.AddError(
 			"Failed to Undefine Domain",
 			"Failed to undefine domain: "+err.Error(),
 		)
-		return
+		return diags
 	}
+
+	diags.Append(removeDomainStorage(client, volumes, options.WipeStorage)...)
+	return diags
+}
+
+// undefineDomain undefines a domain, with flags when the connected libvirt supports any.
+func undefineDomain(client *libvirt.Client, domain golibvirt.Domain, flags golibvirt.DomainUndefineFlagsValues) error {
+	if flags == 0 {
+		return client.Libvirt().DomainUndefine(domain)
+	}
+	return client.Libvirt().DomainUndefineFlags(domain, flags)
+}
+
+// setDomainAutostart applies the autostart setting if it is configured.
+func setDomainAutostart(client *libvirt.Client, domain golibvirt.Domain, autostart types.Bool) error {
+	if autostart.IsNull() || autostart.IsUnknown() {
+		return nil
+	}
+
+	value := int32(0)
+	if autostart.ValueBool() {
+		value = 1
+	}
+	return client.Libvirt().DomainSetAutostart(domain, value)
 }
 
 // waitForInterfaceIP polls for IP addresses on a domain's interfaces
@@ -1334,9 +1900,99 @@ func waitForInterfaceIP(ctx context.Context, client *libvirt.Client, domain goli
 		// Wait before next poll
 		select {
 		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("timeout waiting for IP address: %w", ctx.Err())
+			}
 			return fmt.Errorf("context canceled while waiting for IP")
 		case <-time.After(pollInterval):
 			// Continue polling
 		}
 	}
 }
+
+// domainInterfaceAddresses returns the addresses reported for the interfaces of a
+// running domain, indexed like the interfaces of its definition and matched by MAC
+// address. DHCP leases are listed before addresses reported by the guest agent; sources
+// that fail (for example because the domain is not running or has no agent) are skipped.
+// The guest agent is only queried when useAgent is set, as the query can block for the
+// agent timeout on guests without a running agent.
+func domainInterfaceAddresses(ctx context.Context, client *libvirt.Client, domain golibvirt.Domain, def *libvirtxml.Domain, useAgent bool) [][]attr.Value {
+	type addressSource struct {
+		name   string
+		source golibvirt.DomainInterfaceAddressesSource
+	}
+	sources := []addressSource{{"lease", golibvirt.DomainInterfaceAddressesSrcLease}}
+	if useAgent {
+		sources = append(sources, addressSource{"agent", golibvirt.DomainInterfaceAddressesSrcAgent})
+	}
+
+	result := map[string][]attr.Value{}
+	seen := map[string]bool{}
+	for _, src := range sources {
+		ifaces, err := client.Libvirt().DomainInterfaceAddresses(domain, uint32(src.source), 0)
+		if err != nil {
+			tflog.Debug(ctx, "Skipping interface address source", map[string]any{
+				"source": src.name,
+				"error":  err.Error(),
+			})
+			continue
+		}
+
+		for _, iface := range ifaces {
+			if len(iface.Hwaddr) == 0 {
+				continue
+			}
+			mac := strings.ToLower(iface.Hwaddr[0])
+			for _, addr := range iface.Addrs {
+				if seen[mac+"/"+addr.Addr] {
+					continue
+				}
+				seen[mac+"/"+addr.Addr] = true
+
+				addrType := "ipv4"
+				if addr.Type == int32(golibvirt.IPAddrTypeIpv6) {
+					addrType = "ipv6"
+				}
+				result[mac] = append(result[mac], types.ObjectValueMust(domainInterfaceAddressAttrTypes(), map[string]attr.Value{
+					"type":   types.StringValue(addrType),
+					"addr":   types.StringValue(addr.Addr),
+					"prefix": types.Int64Value(int64(addr.Prefix)),
+					"source": types.StringValue(src.name),
+				}))
+			}
+		}
+	}
+
+	if def == nil || def.Devices == nil {
+		return nil
+	}
+	addresses := make([][]attr.Value, len(def.Devices.Interfaces))
+	for i, iface := range def.Devices.Interfaces {
+		if iface.MAC != nil {
+			addresses[i] = result[strings.ToLower(iface.MAC.Address)]
+		}
+	}
+	return addresses
+}
+
+// waitForIPUsesAgent reports whether any of the wait_for_ip values asks for addresses
+// from the guest agent.
+func waitForIPUsesAgent(ctx context.Context, waitValues []attr.Value) bool {
+	for _, waitVal := range waitValues {
+		waitObj, ok := waitVal.(basetypes.ObjectValue)
+		if !ok || waitObj.IsNull() || waitObj.IsUnknown() {
+			continue
+		}
+		var waitModel DomainInterfaceWaitForIPModel
+		if diags := waitObj.As(ctx, &waitModel, basetypes.ObjectAsOptions{}); diags.HasError() {
+			continue
+		}
+		if waitModel.Source.IsNull() || waitModel.Source.IsUnknown() {
+			return true
+		}
+		if source := waitModel.Source.ValueString(); source == "agent" || source == "any" {
+			return true
+		}
+	}
+	return false
+}
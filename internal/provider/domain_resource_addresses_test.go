@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+func TestDomainResourceInterfaceAddresses(t *testing.T) {
+	client := testMockClient(t)
+
+	state, diags := testCreateResource(t, NewDomainResource(), client, map[string]any{
+		"name":    "test-addresses",
+		"memory":  256,
+		"type":    "kvm",
+		"running": true,
+		"os": map[string]any{
+			"type": "hvm",
+		},
+		"devices": map[string]any{
+			"interfaces": []any{
+				map[string]any{
+					"source": map[string]any{
+						"network": map[string]any{"network": "default"},
+					},
+					"wait_for_ip": map[string]any{
+						"timeout": 5,
+						"source":  "lease",
+					},
+				},
+			},
+		},
+	})
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	iface := path.Root("devices").AtName("interfaces").AtListIndex(0)
+	if addr := testStateValue(t, state, iface.AtName("addresses").AtListIndex(0).AtName("addr")); !strings.HasPrefix(addr, "192.168.122.") {
+		t.Errorf("unexpected address %q", addr)
+	}
+	if source := testStateValue(t, state, iface.AtName("addresses").AtListIndex(0).AtName("source")); source != "lease" {
+		t.Errorf("unexpected address source %q", source)
+	}
+	if family := testStateValue(t, state, iface.AtName("addresses").AtListIndex(0).AtName("type")); family != "ipv4" {
+		t.Errorf("unexpected address type %q", family)
+	}
+	// The guest agent is only queried when a wait_for_ip asks for it
+	if addresses := testStateValue(t, state, iface.AtName("addresses")); strings.Contains(addresses, "agent") {
+		t.Errorf("expected only lease addresses, got %s", addresses)
+	}
+
+	domain, err := client.Libvirt().DomainLookupByName("test-addresses")
+	if err != nil {
+		t.Fatalf("failed to look up domain: %v", err)
+	}
+	if err := client.Libvirt().DomainDestroy(domain); err != nil {
+		t.Fatalf("failed to stop domain: %v", err)
+	}
+
+	state, diags = testReadResource(t, NewDomainResource(), client, state)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if addresses := testStateValue(t, state, iface.AtName("addresses")); addresses != "[]" {
+		t.Errorf("expected no addresses for a stopped domain, got %s", addresses)
+	}
+}
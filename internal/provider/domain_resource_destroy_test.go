@@ -17,8 +17,11 @@ func TestDomainDestroyFlagsFromDestroy(t *testing.T) {
 		"shutdown": types.ObjectType{
 			AttrTypes: map[string]attr.Type{
 				"timeout": types.Int64Type,
+				"mode":    types.ListType{ElemType: types.StringType},
 			},
 		},
+		"remove_storage": types.ListType{ElemType: types.StringType},
+		"wipe_storage":   types.BoolType,
 	}
 
 	tests := []struct {
@@ -40,7 +43,10 @@ func TestDomainDestroyFlagsFromDestroy(t *testing.T) {
 						"graceful": types.BoolValue(true),
 						"shutdown": types.ObjectNull(map[string]attr.Type{
 							"timeout": types.Int64Type,
+							"mode":    types.ListType{ElemType: types.StringType},
 						}),
+						"remove_storage": types.ListNull(types.StringType),
+						"wipe_storage":   types.BoolNull(),
 					},
 				)
 				if diags.HasError() {
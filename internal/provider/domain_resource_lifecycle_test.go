@@ -2,9 +2,11 @@ package provider
 
 import (
 	"context"
+	"slices"
 	"testing"
 	"time"
 
+	golibvirt "github.com/digitalocean/go-libvirt"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
@@ -16,15 +18,26 @@ func TestDomainUpdateOptionsFromUpdate(t *testing.T) {
 		"shutdown": types.ObjectType{
 			AttrTypes: map[string]attr.Type{
 				"timeout": types.Int64Type,
+				"mode":    types.ListType{ElemType: types.StringType},
 			},
 		},
+		"reset_nvram": types.StringType,
+	}
+	resetNVRAM := func(value string) types.Object {
+		return types.ObjectValueMust(updateAttrTypes, map[string]attr.Value{
+			"shutdown":    types.ObjectNull(map[string]attr.Type{"timeout": types.Int64Type, "mode": types.ListType{ElemType: types.StringType}}),
+			"reset_nvram": types.StringValue(value),
+		})
 	}
 
 	tests := []struct {
-		name          string
-		update        types.Object
-		wantTimeout   time.Duration
-		wantForceStop bool
+		name           string
+		update         types.Object
+		prior          types.Object
+		wantTimeout    time.Duration
+		wantForceStop  bool
+		wantModes      []golibvirt.DomainShutdownFlagValues
+		wantResetNVRAM bool
 	}{
 		{
 			name:          "null uses default timeout and force stop",
@@ -41,11 +54,16 @@ func TestDomainUpdateOptionsFromUpdate(t *testing.T) {
 						"shutdown": types.ObjectValueMust(
 							map[string]attr.Type{
 								"timeout": types.Int64Type,
+								"mode":    types.ListType{ElemType: types.StringType},
 							},
 							map[string]attr.Value{
 								"timeout": types.Int64Value(60),
+								"mode": types.ListValueMust(types.StringType, []attr.Value{
+									types.StringValue("agent"), types.StringValue("acpi"),
+								}),
 							},
 						),
+						"reset_nvram": types.StringNull(),
 					},
 				)
 				if diags.HasError() {
@@ -55,6 +73,29 @@ func TestDomainUpdateOptionsFromUpdate(t *testing.T) {
 			}(),
 			wantTimeout:   60 * time.Second,
 			wantForceStop: true,
+			wantModes:     []golibvirt.DomainShutdownFlagValues{golibvirt.DomainShutdownGuestAgent, golibvirt.DomainShutdownAcpiPowerBtn},
+		},
+		{
+			name:           "reset nvram when set",
+			update:         resetNVRAM("1"),
+			wantTimeout:    30 * time.Second,
+			wantForceStop:  true,
+			wantResetNVRAM: true,
+		},
+		{
+			name:          "keep nvram when reset is unchanged",
+			update:        resetNVRAM("1"),
+			prior:         resetNVRAM("1"),
+			wantTimeout:   30 * time.Second,
+			wantForceStop: true,
+		},
+		{
+			name:           "reset nvram when reset changes",
+			update:         resetNVRAM("2"),
+			prior:          resetNVRAM("1"),
+			wantTimeout:    30 * time.Second,
+			wantForceStop:  true,
+			wantResetNVRAM: true,
 		},
 	}
 
@@ -63,7 +104,11 @@ func TestDomainUpdateOptionsFromUpdate(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			options, diags := domainUpdateOptionsFromUpdate(context.Background(), tc.update)
+			prior := tc.prior
+			if prior.IsNull() {
+				prior = types.ObjectNull(updateAttrTypes)
+			}
+			options, diags := domainUpdateOptionsFromUpdate(context.Background(), tc.update, prior)
 			if diags.HasError() {
 				t.Fatalf("unexpected diagnostics: %v", diags)
 			}
@@ -75,6 +120,14 @@ func TestDomainUpdateOptionsFromUpdate(t *testing.T) {
 			if options.ForceOnTimeout != tc.wantForceStop {
 				t.Fatalf("unexpected force-on-timeout: got=%t want=%t", options.ForceOnTimeout, tc.wantForceStop)
 			}
+
+			if !slices.Equal(options.ShutdownModes, tc.wantModes) {
+				t.Fatalf("unexpected shutdown modes: got=%v want=%v", options.ShutdownModes, tc.wantModes)
+			}
+
+			if options.ResetNVRAM != tc.wantResetNVRAM {
+				t.Fatalf("unexpected reset nvram: got=%t want=%t", options.ResetNVRAM, tc.wantResetNVRAM)
+			}
 		})
 	}
 }
@@ -87,8 +140,11 @@ func TestDomainDestroyOptionsFromDestroy(t *testing.T) {
 		"shutdown": types.ObjectType{
 			AttrTypes: map[string]attr.Type{
 				"timeout": types.Int64Type,
+				"mode":    types.ListType{ElemType: types.StringType},
 			},
 		},
+		"remove_storage": types.ListType{ElemType: types.StringType},
+		"wipe_storage":   types.BoolType,
 	}
 
 	destroy, diags := types.ObjectValue(
@@ -98,11 +154,15 @@ func TestDomainDestroyOptionsFromDestroy(t *testing.T) {
 			"shutdown": types.ObjectValueMust(
 				map[string]attr.Type{
 					"timeout": types.Int64Type,
+					"mode":    types.ListType{ElemType: types.StringType},
 				},
 				map[string]attr.Value{
 					"timeout": types.Int64Value(45),
+					"mode":    types.ListNull(types.StringType),
 				},
 			),
+			"remove_storage": types.ListValueMust(types.StringType, []attr.Value{types.StringValue("vda")}),
+			"wipe_storage":   types.BoolValue(true),
 		},
 	)
 	if diags.HasError() {
@@ -125,4 +185,8 @@ func TestDomainDestroyOptionsFromDestroy(t *testing.T) {
 	if options.ForceOnTimeout {
 		t.Fatal("expected destroy shutdown timeout to fail instead of force stopping")
 	}
+
+	if len(options.RemoveStorage) != 1 || options.RemoveStorage[0] != "vda" || !options.WipeStorage {
+		t.Fatalf("unexpected storage removal: got=%v wipe=%t", options.RemoveStorage, options.WipeStorage)
+	}
 }
@@ -10,6 +10,7 @@ func TestDomainUndefineFlagsForUpdate(t *testing.T) {
 	testCases := []struct {
 		name           string
 		libvirtVersion uint64
+		resetNVRAM     bool
 		expected       golibvirt.DomainUndefineFlagsValues
 	}{
 		{
@@ -32,11 +33,23 @@ func TestDomainUndefineFlagsForUpdate(t *testing.T) {
 			libvirtVersion: 8_009_000,
 			expected:       golibvirt.DomainUndefineKeepNvram | golibvirt.DomainUndefineKeepTpm,
 		},
+		{
+			name:           "reset nvram before nvram support",
+			libvirtVersion: 1_002_008,
+			resetNVRAM:     true,
+			expected:       0,
+		},
+		{
+			name:           "reset nvram and keep tpm",
+			libvirtVersion: 8_009_000,
+			resetNVRAM:     true,
+			expected:       golibvirt.DomainUndefineNvram | golibvirt.DomainUndefineKeepTpm,
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			actual := domainUndefineFlagsForUpdate(tc.libvirtVersion)
+			actual := domainUndefineFlagsForUpdate(tc.libvirtVersion, tc.resetNVRAM)
 			if actual != tc.expected {
 				t.Fatalf("expected update flags %v, got %v", tc.expected, actual)
 			}
@@ -0,0 +1,156 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// shutdownModeDestroy ends a shutdown mode chain with a forced stop.
+const shutdownModeDestroy = "destroy"
+
+var domainShutdownModes = map[string]golibvirt.DomainShutdownFlagValues{
+	"acpi":     golibvirt.DomainShutdownAcpiPowerBtn,
+	"agent":    golibvirt.DomainShutdownGuestAgent,
+	"initctl":  golibvirt.DomainShutdownInitctl,
+	"signal":   golibvirt.DomainShutdownSignal,
+	"paravirt": golibvirt.DomainShutdownParavirt,
+}
+
+// domainShutdownModeSchemaAttribute returns the shutdown mode attribute. stopDescription
+// tells what happens when no method stops the domain.
+func domainShutdownModeSchemaAttribute(stopDescription string) schema.ListAttribute {
+	return schema.ListAttribute{
+		Description: "Experimental: shutdown methods to try in order: 'acpi', 'agent', 'initctl', 'signal' or 'paravirt'. " +
+			"Each method is given the shutdown timeout, and the next one is tried when the request fails or the timeout expires. " +
+			stopDescription + " Defaults to the hypervisor's choice.",
+		ElementType: types.StringType,
+		Optional:    true,
+		Validators: []validator.List{
+			listvalidator.SizeAtLeast(1),
+			listvalidator.ValueStringsAre(stringvalidator.OneOf("acpi", "agent", "initctl", "signal", "paravirt", shutdownModeDestroy)),
+			shutdownModeDestroyLastValidator{},
+		},
+	}
+}
+
+// shutdownModeDestroyLastValidator checks that destroy only ends a shutdown mode chain.
+type shutdownModeDestroyLastValidator struct{}
+
+func (v shutdownModeDestroyLastValidator) Description(ctx context.Context) string {
+	return "'destroy' may only be the last shutdown mode"
+}
+
+func (v shutdownModeDestroyLastValidator) MarkdownDescription(ctx context.Context) string {
+	return "`destroy` may only be the last shutdown mode"
+}
+
+func (v shutdownModeDestroyLastValidator) ValidateList(ctx context.Context, req validator.ListRequest, resp *validator.ListResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	elements := req.ConfigValue.Elements()
+	for i := 0; i < len(elements)-1; i++ {
+		element := elements[i]
+		if value, ok := element.(types.String); ok && value.ValueString() == shutdownModeDestroy {
+			resp.Diagnostics.AddAttributeError(req.Path.AtListIndex(i), "Invalid Shutdown Mode",
+				"'destroy' forces the domain to stop, so no shutdown mode can follow it.")
+		}
+	}
+}
+
+// domainShutdownModesFromObject returns the shutdown methods configured in the mode of
+// shutdownVal, and whether the chain ends with a forced stop.
+func domainShutdownModesFromObject(ctx context.Context, shutdownVal types.Object) ([]golibvirt.DomainShutdownFlagValues, bool, diag.Diagnostics) {
+	if shutdownVal.IsNull() || shutdownVal.IsUnknown() {
+		return nil, false, nil
+	}
+
+	var shutdownModel DomainShutdownModel
+	diags := shutdownVal.As(ctx, &shutdownModel, basetypes.ObjectAsOptions{})
+	if diags.HasError() || shutdownModel.Mode.IsNull() || shutdownModel.Mode.IsUnknown() {
+		return nil, false, diags
+	}
+
+	var names []string
+	diags.Append(shutdownModel.Mode.ElementsAs(ctx, &names, false)...)
+	if diags.HasError() {
+		return nil, false, diags
+	}
+
+	var modes []golibvirt.DomainShutdownFlagValues
+	force := false
+	for _, name := range names {
+		if name == shutdownModeDestroy {
+			force = true
+			continue
+		}
+		mode, ok := domainShutdownModes[name]
+		if !ok {
+			diags.AddError("Invalid Shutdown Mode", fmt.Sprintf("Unknown shutdown mode %q.", name))
+			return nil, false, diags
+		}
+		modes = append(modes, mode)
+	}
+	return modes, force, diags
+}
+
+// shutdownDomain requests a guest shutdown with the methods of options in order, waiting
+// up to the shutdown timeout for each. Without configured methods a plain shutdown request
+// is sent and a failure to send it is returned right away. The returned bool reports
+// whether the last method timed out.
+func shutdownDomain(client *libvirt.Client, domain golibvirt.Domain, options domainStopOptions) (bool, error) {
+	if len(options.ShutdownModes) == 0 {
+		if err := client.Libvirt().DomainShutdown(domain); err != nil {
+			return false, fmt.Errorf("request guest shutdown: %w", err)
+		}
+		if err := waitForDomainState(context.Background(), client, domain, uint32(golibvirt.DomainShutoff), options.ShutdownTimeout); err != nil {
+			return true, fmt.Errorf("wait for shutdown: %w", err)
+		}
+		return false, nil
+	}
+
+	var timedOut bool
+	var lastErr error
+	for i, mode := range options.ShutdownModes {
+		if i > 0 {
+			// The guest may have finished a previous request just after its timeout
+			state, _, err := client.Libvirt().DomainGetState(domain, 0)
+			if err != nil {
+				return false, fmt.Errorf("check domain state: %w", err)
+			}
+			if uint32(state) == uint32(golibvirt.DomainShutoff) {
+				return false, nil
+			}
+		}
+		if err := client.Libvirt().DomainShutdownFlags(domain, mode); err != nil {
+			timedOut, lastErr = false, fmt.Errorf("request guest shutdown (%s): %w", domainShutdownModeName(mode), err)
+			continue
+		}
+		if err := waitForDomainState(context.Background(), client, domain, uint32(golibvirt.DomainShutoff), options.ShutdownTimeout); err != nil {
+			timedOut, lastErr = true, fmt.Errorf("wait for shutdown (%s): %w", domainShutdownModeName(mode), err)
+			continue
+		}
+		return false, nil
+	}
+	return timedOut, lastErr
+}
+
+func domainShutdownModeName(mode golibvirt.DomainShutdownFlagValues) string {
+	for name, value := range domainShutdownModes {
+		if value == mode {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%x", int32(mode))
+}
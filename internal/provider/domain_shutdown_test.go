@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+)
+
+func TestStopDomainIfRunningShutdownModes(t *testing.T) {
+	tests := []struct {
+		name        string
+		modes       []golibvirt.DomainShutdownFlagValues
+		force       bool
+		wantErr     bool
+		wantRunning bool
+	}{
+		{
+			name:  "falls back to acpi without guest agent",
+			modes: []golibvirt.DomainShutdownFlagValues{golibvirt.DomainShutdownGuestAgent, golibvirt.DomainShutdownAcpiPowerBtn},
+		},
+		{
+			name:        "fails when no mode works",
+			modes:       []golibvirt.DomainShutdownFlagValues{golibvirt.DomainShutdownGuestAgent, golibvirt.DomainShutdownInitctl},
+			wantErr:     true,
+			wantRunning: true,
+		},
+		{
+			name:  "forces a stop when no mode works",
+			modes: []golibvirt.DomainShutdownFlagValues{golibvirt.DomainShutdownGuestAgent},
+			force: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := testMockClient(t)
+			domain, err := client.Libvirt().DomainDefineXML(`<domain type="kvm">
+  <name>minimal</name>
+  <memory unit="KiB">262144</memory>
+  <os><type>hvm</type></os>
+</domain>`)
+			if err != nil {
+				t.Fatalf("failed to define domain: %v", err)
+			}
+			if err := client.Libvirt().DomainCreate(domain); err != nil {
+				t.Fatalf("failed to start domain: %v", err)
+			}
+
+			timedOut, err := stopDomainIfRunning(client, domain, domainStopOptions{
+				ShutdownEnabled: true,
+				ShutdownTimeout: time.Second,
+				ShutdownModes:   tc.modes,
+				ForceOnTimeout:  tc.force,
+			})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if timedOut {
+				t.Error("expected failed requests not to be reported as a timeout")
+			}
+
+			state, _, err := client.Libvirt().DomainGetState(domain, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if running := state == int32(golibvirt.DomainRunning); running != tc.wantRunning {
+				t.Errorf("expected running=%t, got state %d", tc.wantRunning, state)
+			}
+		})
+	}
+}
@@ -0,0 +1,637 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/xmlpatch"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/identityschema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure the implementation satisfies the expected interfaces
+var (
+	_ resource.Resource                = &DomainXMLResource{}
+	_ resource.ResourceWithConfigure   = &DomainXMLResource{}
+	_ resource.ResourceWithImportState = &DomainXMLResource{}
+	_ resource.ResourceWithIdentity    = &DomainXMLResource{}
+)
+
+// NewDomainXMLResource creates a new raw XML domain resource
+func NewDomainXMLResource() resource.Resource {
+	return &DomainXMLResource{}
+}
+
+// DomainXMLResource manages a domain defined from a raw XML document
+type DomainXMLResource struct {
+	client *libvirt.Client
+}
+
+// DomainXMLResourceModel describes the raw XML domain resource.
+type DomainXMLResourceModel struct {
+	XML       types.String `tfsdk:"xml"`
+	UUID      types.String `tfsdk:"uuid"`
+	Name      types.String `tfsdk:"name"`
+	Running   types.Bool   `tfsdk:"running"`
+	Autostart types.Bool   `tfsdk:"autostart"`
+	Create    types.Object `tfsdk:"create"`
+	Update    types.Object `tfsdk:"update"`
+	Destroy   types.Object `tfsdk:"destroy"`
+}
+
+// Metadata returns the resource type name
+func (r *DomainXMLResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_domain_xml"
+}
+
+// Schema defines the schema for the resource
+func (r *DomainXMLResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	attributes := map[string]schema.Attribute{
+		"xml": schema.StringAttribute{
+			Description: "Domain XML document passed to libvirt as is.",
+			MarkdownDescription: "Domain XML document passed to libvirt as is. " +
+				"Changes that only affect formatting, attribute order or the order of different elements " +
+				"do not cause an update. When reading the domain back, elements and attributes that the document " +
+				"does not set (for example the UUID, device addresses and aliases libvirt assigns) are ignored, and " +
+				"values libvirt normalizes (for example memory units and machine type aliases) are compared with " +
+				"the definition libvirt stored when the XML was applied.",
+			Required: true,
+			PlanModifiers: []planmodifier.String{
+				domainXMLPlanModifier{},
+				stringplanmodifier.RequiresReplaceIf(
+					domainXMLUUIDChanged,
+					"Changing the UUID set in the XML requires replacing the domain.",
+					"Changing the UUID set in the XML requires replacing the domain.",
+				),
+			},
+		},
+		"uuid": schema.StringAttribute{
+			Description: "UUID of the domain, as set in the XML or generated by libvirt.",
+			Computed:    true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		},
+		"name": schema.StringAttribute{
+			Description: "Name of the domain, as set in the XML.",
+			Computed:    true,
+		},
+	}
+	for name, attribute := range domainLifecycleSchemaAttributes() {
+		attributes[name] = attribute
+	}
+
+	resp.Schema = schema.Schema{
+		Description: "Manages a libvirt domain defined from a raw XML document.",
+		MarkdownDescription: `
+Manages a libvirt domain defined from a raw XML document, e.g. one used with ` + "`virsh define`" + `.
+
+Prefer ` + "`libvirt_domain`" + ` for new configurations. This resource is meant for migrating existing
+XML-based tooling, and only detects drift in the parts of the document that it sets.
+`,
+		Attributes: attributes,
+	}
+}
+
+// IdentitySchema defines the identity of the resource
+func (r *DomainXMLResource) IdentitySchema(ctx context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = identityschema.Schema{
+		Attributes: map[string]identityschema.Attribute{
+			"uuid": identityschema.StringAttribute{
+				Description:       "UUID of the domain.",
+				RequiredForImport: true,
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the resource
+func (r *DomainXMLResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*libvirt.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *libvirt.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.client = client
+}
+
+// Create defines the domain from the XML
+func (r *DomainXMLResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan DomainXMLResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if _, err := libvirt.UnmarshalDomainXML(plan.XML.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("xml"),
+			"Invalid Domain XML",
+			"Failed to parse domain XML: "+err.Error(),
+		)
+		return
+	}
+
+	domain, err := defineDomainXML(r.client, plan.XML.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Domain Creation Failed",
+			"Failed to define domain in libvirt: "+err.Error(),
+		)
+		return
+	}
+
+	cleanupOnError := func() {
+		if !plan.Running.IsNull() && plan.Running.ValueBool() {
+			if destroyErr := r.client.Libvirt().DomainDestroy(domain); destroyErr != nil {
+				tflog.Warn(ctx, "Failed to destroy domain during cleanup", map[string]any{"error": destroyErr.Error()})
+			}
+		}
+		if undefErr := r.client.Libvirt().DomainUndefine(domain); undefErr != nil {
+			tflog.Warn(ctx, "Failed to undefine domain during cleanup", map[string]any{"error": undefErr.Error()})
+		}
+	}
+
+	if !plan.Running.IsNull() && plan.Running.ValueBool() {
+		diags := startDomainXML(ctx, r.client, domain, plan.Create, "created")
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			cleanupOnError()
+			return
+		}
+	}
+
+	if err := setDomainAutostart(r.client, domain, plan.Autostart); err != nil {
+		cleanupOnError()
+		resp.Diagnostics.AddError(
+			"Failed to Set Autostart",
+			"Domain was created but failed to set autostart: "+err.Error(),
+		)
+		return
+	}
+
+	state := plan
+	defined, diags := r.readDomain(ctx, &state, domain, "")
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		cleanupOnError()
+		return
+	}
+	state.XML = plan.XML
+
+	definedValue, diags := definedDomainXMLValue(defined)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		cleanupOnError()
+		return
+	}
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, domainXMLDefinedKey, definedValue)...)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+	resp.Diagnostics.Append(setDomainIdentity(ctx, resp.Identity, state.UUID)...)
+}
+
+// startDomainXML starts a defined domain with the create flags and waits for the
+// configured console output. action describes what happened to the domain for errors.
+func startDomainXML(ctx context.Context, client *libvirt.Client, domain golibvirt.Domain, createVal types.Object, action string) diag.Diagnostics {
+	flags, diags := domainStartFlagsFromCreate(ctx, createVal)
+	consoleWait, consoleDiags := consoleWaitConfigFromCreate(ctx, createVal)
+	diags.Append(consoleDiags...)
+	if diags.HasError() {
+		return diags
+	}
+
+	if _, err := client.Libvirt().DomainCreateWithFlags(domain, flags); err != nil {
+		diags.AddError(
+			"Failed to Start Domain",
+			fmt.Sprintf("Domain was %s but failed to start: %s", action, err),
+		)
+		return diags
+	}
+
+	if consoleWait != nil {
+		if output, err := waitForConsole(ctx, client, domain, consoleWait); err != nil {
+			diags.AddError(
+				"Failed to Wait for Console Output",
+				consoleWaitErrorDetail(fmt.Sprintf("Domain was %s and started but the console did not show the expected output", action), err, output),
+			)
+		}
+	}
+	return diags
+}
+
+// Read reads the domain state
+func (r *DomainXMLResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state DomainXMLResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if state.UUID.IsNull() || state.UUID.IsUnknown() {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	domain, err := r.client.LookupDomainByUUID(state.UUID.ValueString())
+	if err != nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	definedValue, diags := req.Private.GetKey(ctx, domainXMLDefinedKey)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	_, diags = r.readDomain(ctx, &state, domain, definedDomainXML(definedValue))
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+	resp.Diagnostics.Append(setDomainIdentity(ctx, resp.Identity, state.UUID)...)
+}
+
+// readDomain refreshes state from the inactive definition of domain. The XML in state is
+// kept unless the definition differs from defined, the definition libvirt stored when the
+// XML was applied, in the parts the XML sets. In that case it is replaced by the canonical
+// form of the definition so the plan shows the differences. When state has no XML
+// (import), the definition is used as is. It returns the definition.
+func (r *DomainXMLResource) readDomain(ctx context.Context, state *DomainXMLResourceModel, domain golibvirt.Domain, defined string) (string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	xmlDesc, err := r.client.Libvirt().DomainGetXMLDesc(domain, golibvirt.DomainXMLSecure|golibvirt.DomainXMLInactive)
+	if err != nil {
+		diags.AddError(
+			"Failed to Read Domain",
+			"Failed to get domain XML: "+err.Error(),
+		)
+		return "", diags
+	}
+
+	parsedDomain, err := libvirt.UnmarshalDomainXML(xmlDesc)
+	if err != nil {
+		diags.AddError(
+			"Failed to Parse Domain XML",
+			"Failed to parse domain XML from libvirt: "+err.Error(),
+		)
+		return "", diags
+	}
+
+	isImport := state.XML.IsNull() || state.XML.IsUnknown()
+	if isImport {
+		state.XML = types.StringValue(xmlDesc)
+	} else {
+		actual, drifted, err := domainXMLDrift(state.XML.ValueString(), defined, xmlDesc)
+		if err != nil {
+			diags.AddError(
+				"Failed to Compare Domain XML",
+				"Failed to compare the domain XML with the definition in libvirt: "+err.Error(),
+			)
+			return "", diags
+		}
+		if drifted {
+			state.XML = types.StringValue(actual)
+		}
+	}
+
+	state.UUID = types.StringValue(parsedDomain.UUID)
+	state.Name = types.StringValue(parsedDomain.Name)
+
+	if isImport || (!state.Autostart.IsNull() && !state.Autostart.IsUnknown()) {
+		autostart, err := r.client.Libvirt().DomainGetAutostart(domain)
+		if err != nil {
+			diags.AddError(
+				"Failed to Get Autostart Status",
+				"Failed to read domain autostart setting: "+err.Error(),
+			)
+			return "", diags
+		}
+		state.Autostart = types.BoolValue(autostart == 1)
+	}
+
+	if isImport {
+		domainState, _, err := r.client.Libvirt().DomainGetState(domain, 0)
+		if err != nil {
+			diags.AddError(
+				"Failed to Get Domain State",
+				"Failed to read domain running state: "+err.Error(),
+			)
+			return "", diags
+		}
+		state.Running = types.BoolValue(uint32(domainState) == uint32(golibvirt.DomainRunning))
+	}
+
+	return xmlDesc, diags
+}
+
+// Update redefines the domain from the new XML, keeping its UUID
+func (r *DomainXMLResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan DomainXMLResourceModel
+	var state DomainXMLResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if state.UUID.IsNull() || state.UUID.IsUnknown() {
+		resp.Diagnostics.AddError(
+			"Missing Domain UUID",
+			"Existing domain is missing UUID in state",
+		)
+		return
+	}
+
+	desired, err := libvirt.UnmarshalDomainXML(plan.XML.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("xml"),
+			"Invalid Domain XML",
+			"Failed to parse domain XML: "+err.Error(),
+		)
+		return
+	}
+
+	// Without a UUID in the document, libvirt would define a new domain
+	domainXML := plan.XML.ValueString()
+	if desired.UUID == "" {
+		domainXML, err = xmlpatch.Apply(domainXML, []xmlpatch.Patch{{
+			Op:    xmlpatch.OpAdd,
+			Path:  "/domain",
+			Value: "<uuid>" + state.UUID.ValueString() + "</uuid>",
+		}})
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Invalid Domain Configuration",
+				"Failed to keep the domain UUID in the XML: "+err.Error(),
+			)
+			return
+		}
+	}
+
+	existingDomain, err := r.client.LookupDomainByUUID(state.UUID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Domain Lookup Failed",
+			"Failed to look up existing domain: "+err.Error(),
+		)
+		return
+	}
+
+	updateOptions, updateDiags := domainUpdateOptionsFromUpdate(ctx, plan.Update, state.Update)
+	resp.Diagnostics.Append(updateDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if _, err := stopDomainIfRunning(r.client, existingDomain, updateOptions); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Stop Domain",
+			"Domain must be stopped before updating: "+err.Error(),
+		)
+		return
+	}
+
+	libvirtVersion, err := r.client.Libvirt().ConnectGetLibVersion()
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Detect Libvirt Version",
+			"Failed to query libvirt version before domain update: "+err.Error(),
+		)
+		return
+	}
+
+	if err := undefineDomain(r.client, existingDomain, domainUndefineFlagsForUpdate(libvirtVersion, updateOptions.ResetNVRAM)); err != nil {
+		resp.Diagnostics.AddError(
+			"Domain Undefine Failed",
+			"Failed to undefine existing domain: "+err.Error(),
+		)
+		return
+	}
+
+	newDomain, err := defineDomainXML(r.client, domainXML)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Domain Update Failed",
+			"Failed to define updated domain in libvirt: "+err.Error(),
+		)
+		return
+	}
+
+	if err := setDomainAutostart(r.client, newDomain, plan.Autostart); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Set Autostart",
+			"Domain was updated but failed to set autostart: "+err.Error(),
+		)
+		return
+	}
+
+	if !plan.Running.IsNull() && plan.Running.ValueBool() {
+		startDiags := startDomainXML(ctx, r.client, newDomain, plan.Create, "updated")
+		resp.Diagnostics.Append(startDiags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	newState := plan
+	defined, diags := r.readDomain(ctx, &newState, newDomain, "")
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	newState.XML = plan.XML
+
+	definedValue, diags := definedDomainXMLValue(defined)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, domainXMLDefinedKey, definedValue)...)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &newState)...)
+	resp.Diagnostics.Append(setDomainIdentity(ctx, resp.Identity, newState.UUID)...)
+}
+
+// Delete stops and undefines the domain
+func (r *DomainXMLResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state DomainXMLResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	destroyOptions, destroyDiags := domainDestroyOptionsFromDestroy(ctx, state.Destroy)
+	resp.Diagnostics.Append(destroyDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	domain, err := r.client.LookupDomainByUUID(state.UUID.ValueString())
+	if err != nil {
+		// Domain already gone - that's OK
+		return
+	}
+
+	resp.Diagnostics.Append(destroyDomain(r.client, domain, destroyOptions)...)
+}
+
+// ImportState imports an existing libvirt domain by UUID or name.
+//
+// Usage:
+//
+//	terraform import libvirt_domain_xml.myvm <uuid>
+//	terraform import libvirt_domain_xml.myvm <name>
+func (r *DomainXMLResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	if req.ID != "" {
+		uuid, err := resolveDomainImportID(r.client, req.ID)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Domain Not Found",
+				fmt.Sprintf("Cannot import domain: %s", err),
+			)
+			return
+		}
+		req.ID = uuid
+	}
+
+	resource.ImportStatePassthroughWithIdentity(ctx, path.Root("uuid"), path.Root("uuid"), req, resp)
+}
+
+// domainXMLDefinedKey is the private state key of the definition libvirt stored when the
+// XML was applied.
+const domainXMLDefinedKey = "defined_xml"
+
+// definedDomainXMLValue returns the private state value recording the definition.
+func definedDomainXMLValue(defined string) ([]byte, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	value, err := json.Marshal(defined)
+	if err != nil {
+		diags.AddError(
+			"Failed to Record Domain XML",
+			fmt.Sprintf("Unable to encode the defined domain XML: %s", err),
+		)
+		return nil, diags
+	}
+	return value, diags
+}
+
+func definedDomainXML(value []byte) string {
+	var defined string
+	if len(value) > 0 {
+		_ = json.Unmarshal(value, &defined)
+	}
+	return defined
+}
+
+// domainXMLDrift compares the definition read from libvirt with the desired domain XML.
+// Both are canonicalized, and the parts of the definition that the desired XML does not
+// set are dropped. libvirt normalizes some values on define (memory units, machine type
+// aliases, default attributes), so when defined, the definition stored right after the
+// XML was applied, is known, the definition is compared with it instead of the desired
+// XML. It returns the canonical definition and whether it differs.
+func domainXMLDrift(desired, defined, actual string) (string, bool, error) {
+	canonicalActual, err := canonicalPrunedXML(actual, desired)
+	if err != nil {
+		return "", false, err
+	}
+
+	var expected string
+	if defined != "" {
+		expected, err = canonicalPrunedXML(defined, desired)
+	} else {
+		expected, err = xmlpatch.Canonicalize(desired)
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return canonicalActual, canonicalActual != expected, nil
+}
+
+// canonicalPrunedXML returns the canonical form of the parts of doc that reference sets.
+func canonicalPrunedXML(doc, reference string) (string, error) {
+	pruned, err := xmlpatch.Prune(doc, reference)
+	if err != nil {
+		return "", err
+	}
+	return xmlpatch.Canonicalize(pruned)
+}
+
+// domainXMLPlanModifier keeps the XML in state when the configured XML only differs in
+// formatting.
+type domainXMLPlanModifier struct{}
+
+func (m domainXMLPlanModifier) Description(ctx context.Context) string {
+	return "Ignores changes to the domain XML that do not change its canonical form"
+}
+
+func (m domainXMLPlanModifier) MarkdownDescription(ctx context.Context) string {
+	return "Ignores changes to the domain XML that do not change its canonical form"
+}
+
+func (m domainXMLPlanModifier) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	if req.PlanValue.IsNull() || req.PlanValue.IsUnknown() || req.StateValue.IsNull() {
+		return
+	}
+	if req.PlanValue.Equal(req.StateValue) {
+		return
+	}
+
+	canonicalPlan, err := xmlpatch.Canonicalize(req.PlanValue.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Domain XML", err.Error())
+		return
+	}
+	canonicalState, err := xmlpatch.Canonicalize(req.StateValue.ValueString())
+	if err != nil {
+		return
+	}
+
+	if canonicalPlan == canonicalState {
+		resp.PlanValue = req.StateValue
+	}
+}
+
+// domainXMLUUIDChanged requires replacement when the configured XML sets a UUID other
+// than the one of the existing domain.
+func domainXMLUUIDChanged(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+	if req.PlanValue.IsNull() || req.PlanValue.IsUnknown() || req.State.Raw.IsNull() {
+		return
+	}
+
+	var uuid types.String
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("uuid"), &uuid)...)
+	if resp.Diagnostics.HasError() || uuid.IsNull() || uuid.IsUnknown() {
+		return
+	}
+
+	desired, err := libvirt.UnmarshalDomainXML(req.PlanValue.ValueString())
+	if err != nil {
+		return
+	}
+	resp.RequiresReplace = desired.UUID != "" && desired.UUID != uuid.ValueString()
+}
@@ -0,0 +1,140 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const testDomainXML = `<domain type='kvm'>
+  <name>test-raw</name>
+  <memory unit='KiB'>262144</memory>
+  <os>
+    <type>hvm</type>
+  </os>
+  <devices>
+    <interface type='network'>
+      <source network='default'/>
+    </interface>
+  </devices>
+</domain>`
+
+func TestDomainXMLResource(t *testing.T) {
+	client := testMockClient(t)
+
+	state, diags := testCreateResource(t, NewDomainXMLResource(), client, map[string]any{
+		"xml": testDomainXML,
+	})
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if name := testStateValue(t, state, path.Root("name")); name != "test-raw" {
+		t.Errorf("expected name test-raw, got %q", name)
+	}
+	uuid := testStateValue(t, state, path.Root("uuid"))
+	if uuid == "" {
+		t.Error("expected the UUID assigned by libvirt")
+	}
+
+	// libvirt adds a UUID and a MAC address and reformats the document, which is no drift
+	state, diags = testReadResource(t, NewDomainXMLResource(), client, state)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if xml := testStateValue(t, state, path.Root("xml")); xml != testDomainXML {
+		t.Errorf("expected the configured XML after read, got\n%s", xml)
+	}
+
+	// A change made outside of Terraform shows up in the XML
+	changed := strings.Replace(testDomainXML, "<name>test-raw</name>", "<name>test-raw</name><uuid>"+uuid+"</uuid>", 1)
+	changed = strings.Replace(changed, "262144", "524288", 1)
+	if _, err := client.Libvirt().DomainDefineXML(changed); err != nil {
+		t.Fatalf("failed to redefine domain: %v", err)
+	}
+
+	state, diags = testReadResource(t, NewDomainXMLResource(), client, state)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	xml := testStateValue(t, state, path.Root("xml"))
+	if !strings.Contains(xml, `<memory unit="KiB">524288</memory>`) {
+		t.Errorf("expected the changed memory in the XML, got\n%s", xml)
+	}
+	if strings.Contains(xml, "<uuid>") || strings.Contains(xml, "<mac") {
+		t.Errorf("expected values assigned by libvirt to be dropped, got\n%s", xml)
+	}
+}
+
+func TestDomainXMLDriftNormalizedValues(t *testing.T) {
+	t.Parallel()
+
+	desired := `<domain type='kvm'>
+  <name>test-raw</name>
+  <memory unit='GiB'>1</memory>
+  <os><type machine='q35'>hvm</type></os>
+</domain>`
+	// libvirt stores the memory in KiB and expands the machine type alias on define
+	defined := `<domain type='kvm'>
+  <name>test-raw</name>
+  <uuid>5f0e3c2a-7f4e-4b8e-9a55-3c1d2e4f6a7b</uuid>
+  <memory unit='KiB'>1048576</memory>
+  <os><type arch='x86_64' machine='pc-q35-8.2'>hvm</type></os>
+</domain>`
+
+	if _, drifted, err := domainXMLDrift(desired, defined, defined); err != nil || drifted {
+		t.Errorf("expected values normalized by libvirt not to drift, got drifted=%t err=%v", drifted, err)
+	}
+
+	changed := strings.Replace(defined, "1048576", "2097152", 1)
+	actual, drifted, err := domainXMLDrift(desired, defined, changed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !drifted || !strings.Contains(actual, `<memory unit="KiB">2097152</memory>`) {
+		t.Errorf("expected the changed memory to drift, got drifted=%t\n%s", drifted, actual)
+	}
+
+	// Without the stored definition the desired XML is the reference
+	if _, drifted, err := domainXMLDrift(desired, "", defined); err != nil || !drifted {
+		t.Errorf("expected a drift against the desired XML, got drifted=%t err=%v", drifted, err)
+	}
+}
+
+func TestDomainXMLPlanModifier(t *testing.T) {
+	t.Parallel()
+
+	reformatted := strings.NewReplacer("'", `"`, "\n  ", "\n\t").Replace(testDomainXML)
+	changed := strings.Replace(testDomainXML, "262144", "524288", 1)
+
+	tests := []struct {
+		name   string
+		plan   string
+		expect string
+	}{
+		{name: "formatting only", plan: reformatted, expect: testDomainXML},
+		{name: "real change", plan: changed, expect: changed},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := planmodifier.StringRequest{
+				PlanValue:  types.StringValue(tc.plan),
+				StateValue: types.StringValue(testDomainXML),
+			}
+			resp := &planmodifier.StringResponse{PlanValue: req.PlanValue}
+			domainXMLPlanModifier{}.PlanModifyString(context.Background(), req, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+			if resp.PlanValue.ValueString() != tc.expect {
+				t.Errorf("unexpected planned XML:\n%s", resp.PlanValue.ValueString())
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+)
+
+// uuidPattern matches the canonical hyphenated UUID form printed by libvirt.
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// resolveDomainImportID accepts a domain UUID or name and returns the domain UUID.
+func resolveDomainImportID(client *libvirt.Client, id string) (string, error) {
+	if uuidPattern.MatchString(id) {
+		if domain, err := client.LookupDomainByUUID(id); err == nil {
+			return libvirt.UUIDString(domain.UUID), nil
+		}
+	}
+
+	domain, err := client.Libvirt().DomainLookupByName(id)
+	if err != nil {
+		return "", fmt.Errorf("no domain with UUID or name '%s': %w", id, err)
+	}
+	return libvirt.UUIDString(domain.UUID), nil
+}
+
+// resolvePoolImportID accepts a storage pool UUID or name and returns the pool UUID.
+func resolvePoolImportID(client *libvirt.Client, id string) (string, error) {
+	if uuidPattern.MatchString(id) {
+		if pool, err := client.LookupPoolByUUID(id); err == nil {
+			return libvirt.UUIDString(pool.UUID), nil
+		}
+	}
+
+	pool, err := client.Libvirt().StoragePoolLookupByName(id)
+	if err != nil {
+		return "", fmt.Errorf("no storage pool with UUID or name '%s': %w", id, err)
+	}
+	return libvirt.UUIDString(pool.UUID), nil
+}
+
+// resolveNetworkImportID accepts a network UUID or name and returns the network UUID.
+func resolveNetworkImportID(client *libvirt.Client, id string) (string, error) {
+	if uuidPattern.MatchString(id) {
+		if network, err := client.LookupNetworkByUUID(id); err == nil {
+			return libvirt.UUIDString(network.UUID), nil
+		}
+	}
+
+	network, err := client.Libvirt().NetworkLookupByName(id)
+	if err != nil {
+		return "", fmt.Errorf("no network with UUID or name '%s': %w", id, err)
+	}
+	return libvirt.UUIDString(network.UUID), nil
+}
+
+// resolveVolumeImportID accepts a volume key, an absolute path or a "pool/volume" pair and
+// returns the volume key.
+func resolveVolumeImportID(client *libvirt.Client, id string) (string, error) {
+	if volume, err := client.Libvirt().StorageVolLookupByKey(id); err == nil {
+		return volume.Key, nil
+	}
+
+	if strings.HasPrefix(id, "/") {
+		volume, err := client.Libvirt().StorageVolLookupByPath(id)
+		if err != nil {
+			return "", fmt.Errorf("no storage volume with key or path '%s': %w", id, err)
+		}
+		return volume.Key, nil
+	}
+
+	poolName, volumeName, ok := splitVolumeImportID(id)
+	if !ok {
+		return "", fmt.Errorf("no storage volume with key '%s'; use a volume key, an absolute path or <pool>/<volume>", id)
+	}
+
+	pool, err := client.Libvirt().StoragePoolLookupByName(poolName)
+	if err != nil {
+		return "", fmt.Errorf("storage pool '%s' not found: %w", poolName, err)
+	}
+
+	volume, err := client.Libvirt().StorageVolLookupByName(pool, volumeName)
+	if err != nil {
+		return "", fmt.Errorf("storage volume '%s' not found in pool '%s': %w", volumeName, poolName, err)
+	}
+	return volume.Key, nil
+}
+
+// splitVolumeImportID splits a "pool/volume" import ID. Volume names may not contain a
+// slash, so the pool is everything before the last one.
+func splitVolumeImportID(id string) (string, string, bool) {
+	idx := strings.LastIndex(id, "/")
+	if idx <= 0 || idx == len(id)-1 {
+		return "", "", false
+	}
+	return id[:idx], id[idx+1:], true
+}
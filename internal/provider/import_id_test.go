@@ -0,0 +1,36 @@
+package provider
+
+import "testing"
+
+func TestSplitVolumeImportID(t *testing.T) {
+	tests := []struct {
+		id     string
+		pool   string
+		volume string
+		ok     bool
+	}{
+		{id: "default/disk.qcow2", pool: "default", volume: "disk.qcow2", ok: true},
+		{id: "disk.qcow2", ok: false},
+		{id: "default/", ok: false},
+		{id: "/disk.qcow2", ok: false},
+	}
+
+	for _, tt := range tests {
+		pool, volume, ok := splitVolumeImportID(tt.id)
+		if ok != tt.ok || pool != tt.pool || volume != tt.volume {
+			t.Fatalf("splitVolumeImportID(%q) = (%q, %q, %v), want (%q, %q, %v)",
+				tt.id, pool, volume, ok, tt.pool, tt.volume, tt.ok)
+		}
+	}
+}
+
+func TestUUIDPattern(t *testing.T) {
+	if !uuidPattern.MatchString("8f1c7e0a-54b4-4a4f-9a52-3c1d2b7e9f01") {
+		t.Fatal("expected canonical UUID to match")
+	}
+	for _, name := range []string{"web-1", "8f1c7e0a54b44a4f9a523c1d2b7e9f01", ""} {
+		if uuidPattern.MatchString(name) {
+			t.Fatalf("expected %q not to match", name)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// resourceSchemaVersion is the schema version of the resources that can be upgraded from the
+// legacy 0.x provider. The legacy SDKv2 resources never set a schema version, so their state
+// is stored as version 0, the same as state written by this provider before the bump.
+// The version 0 upgraders tell both apart by looking for an attribute the legacy schema
+// did not have, and pass state of this provider through unchanged.
+const resourceSchemaVersion = 1
+
+// Ensure the implementations satisfy the expected interfaces
+var (
+	_ resource.ResourceWithUpgradeState = &DomainResource{}
+	_ resource.ResourceWithMoveState    = &DomainResource{}
+	_ resource.ResourceWithUpgradeState = &VolumeResource{}
+	_ resource.ResourceWithMoveState    = &VolumeResource{}
+	_ resource.ResourceWithUpgradeState = &NetworkResource{}
+	_ resource.ResourceWithMoveState    = &NetworkResource{}
+	_ resource.ResourceWithUpgradeState = &CloudInitDiskResource{}
+	_ resource.ResourceWithMoveState    = &CloudInitDiskResource{}
+)
+
+// legacyStateConverter builds state of the current schema from the attributes of a legacy
+// resource. client-backed resources look the object up on the connected host when the
+// resource is configured; otherwise they only seed the identifier and let the next Read
+// populate the rest, exactly as after an import.
+type legacyStateConverter func(ctx context.Context, state *tfsdk.State, attrs map[string]any) diag.Diagnostics
+
+// legacyRawState decodes raw state and reports whether it was written by the legacy provider,
+// which is the case when marker, an attribute only the current schema has, is missing.
+func legacyRawState(raw *tfprotov6.RawState, marker string) (map[string]any, bool, error) {
+	if raw == nil || raw.JSON == nil {
+		return nil, false, fmt.Errorf("state is not stored as JSON; refresh it with the legacy provider first")
+	}
+
+	var attrs map[string]any
+	if err := json.Unmarshal(raw.JSON, &attrs); err != nil {
+		return nil, false, fmt.Errorf("failed to decode state: %w", err)
+	}
+
+	_, current := attrs[marker]
+	return attrs, !current, nil
+}
+
+// legacyString returns a string attribute of legacy state, or "" if unset.
+func legacyString(attrs map[string]any, name string) string {
+	value, _ := attrs[name].(string)
+	return value
+}
+
+// legacyOptionalString returns a string attribute of legacy state, or null if unset.
+func legacyOptionalString(attrs map[string]any, name string) types.String {
+	value, ok := attrs[name].(string)
+	if !ok || value == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(value)
+}
+
+// legacyUpgrader returns the version 0 state upgrader shared by the migrated resources.
+func legacyUpgrader(marker string, convert legacyStateConverter) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: {
+			StateUpgrader: func(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+				attrs, legacy, err := legacyRawState(req.RawState, marker)
+				if err != nil {
+					resp.Diagnostics.AddError(
+						"Unable to Upgrade Resource State",
+						err.Error(),
+					)
+					return
+				}
+
+				if !legacy {
+					// State written by this provider before the schema version bump
+					value, err := req.RawState.UnmarshalWithOpts(
+						resp.State.Schema.Type().TerraformType(ctx),
+						tfprotov6.UnmarshalOpts{ValueFromJSONOpts: tftypes.ValueFromJSONOpts{IgnoreUndefinedAttributes: true}},
+					)
+					if err != nil {
+						resp.Diagnostics.AddError(
+							"Unable to Upgrade Resource State",
+							fmt.Sprintf("Failed to decode state: %s", err),
+						)
+						return
+					}
+					resp.State.Raw = value
+					return
+				}
+
+				tflog.Info(ctx, "Upgrading state written by the legacy provider", map[string]any{
+					"id": legacyString(attrs, "id"),
+				})
+				resp.Diagnostics.Append(convert(ctx, &resp.State, attrs)...)
+			},
+		},
+	}
+}
+
+// legacyMover returns a state mover accepting the legacy resource of the given type name,
+// e.g. when the legacy provider is installed under another source address.
+func legacyMover(typeName string, marker string, convert legacyStateConverter) []resource.StateMover {
+	return []resource.StateMover{
+		{
+			StateMover: func(ctx context.Context, req resource.MoveStateRequest, resp *resource.MoveStateResponse) {
+				if req.SourceTypeName != typeName || req.SourceSchemaVersion != 0 {
+					return
+				}
+
+				attrs, legacy, err := legacyRawState(req.SourceRawState, marker)
+				if err != nil {
+					resp.Diagnostics.AddError(
+						"Unable to Move Resource State",
+						err.Error(),
+					)
+					return
+				}
+				if !legacy {
+					return
+				}
+
+				tflog.Info(ctx, "Moving state written by the legacy provider", map[string]any{
+					"source_provider": req.SourceProviderAddress,
+					"id":              legacyString(attrs, "id"),
+				})
+				resp.Diagnostics.Append(convert(ctx, &resp.TargetState, attrs)...)
+			},
+		},
+	}
+}
+
+// UpgradeState upgrades libvirt_domain state written by the legacy provider
+func (r *DomainResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return legacyUpgrader("uuid", r.stateFromLegacy)
+}
+
+// MoveState moves libvirt_domain state written by the legacy provider
+func (r *DomainResource) MoveState(ctx context.Context) []resource.StateMover {
+	return legacyMover("libvirt_domain", "uuid", r.stateFromLegacy)
+}
+
+// stateFromLegacy rebuilds domain state from the legacy ID, which is the domain UUID.
+func (r *DomainResource) stateFromLegacy(ctx context.Context, state *tfsdk.State, attrs map[string]any) diag.Diagnostics {
+	var model DomainResourceModel
+	diags := seedResourceModel(ctx, state, path.Root("uuid"), types.StringValue(legacyString(attrs, "id")), &model)
+	if diags.HasError() {
+		return diags
+	}
+
+	if r.client != nil {
+		found, readDiags := r.readDomain(ctx, &model)
+		diags.Append(readDiags...)
+		if diags.HasError() {
+			return diags
+		}
+		if !found {
+			tflog.Warn(ctx, "Legacy domain not found on host, leaving it to the next refresh", map[string]any{
+				"uuid": model.UUID.ValueString(),
+			})
+		}
+	}
+
+	diags.Append(state.Set(ctx, &model)...)
+	return diags
+}
+
+// UpgradeState upgrades libvirt_volume state written by the legacy provider
+func (r *VolumeResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return legacyUpgrader("key", r.stateFromLegacy)
+}
+
+// MoveState moves libvirt_volume state written by the legacy provider
+func (r *VolumeResource) MoveState(ctx context.Context) []resource.StateMover {
+	return legacyMover("libvirt_volume", "key", r.stateFromLegacy)
+}
+
+// stateFromLegacy rebuilds volume state from the legacy ID, which is the volume key.
+func (r *VolumeResource) stateFromLegacy(ctx context.Context, state *tfsdk.State, attrs map[string]any) diag.Diagnostics {
+	var model VolumeResourceModel
+	key := legacyString(attrs, "id")
+	diags := seedResourceModel(ctx, state, path.Root("id"), types.StringValue(key), &model)
+	if diags.HasError() {
+		return diags
+	}
+	model.Pool = legacyOptionalString(attrs, "pool")
+
+	if r.client != nil {
+		volume, err := r.client.Libvirt().StorageVolLookupByKey(key)
+		if err == nil {
+			diags.Append(r.readVolume(ctx, &model, volume, nil)...)
+			if diags.HasError() {
+				return diags
+			}
+		} else {
+			tflog.Warn(ctx, "Legacy volume not found on host, leaving it to the next refresh", map[string]any{
+				"key": key,
+			})
+		}
+	}
+
+	diags.Append(state.Set(ctx, &model)...)
+	return diags
+}
+
+// UpgradeState upgrades libvirt_network state written by the legacy provider
+func (r *NetworkResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return legacyUpgrader("uuid", r.stateFromLegacy)
+}
+
+// MoveState moves libvirt_network state written by the legacy provider
+func (r *NetworkResource) MoveState(ctx context.Context) []resource.StateMover {
+	return legacyMover("libvirt_network", "uuid", r.stateFromLegacy)
+}
+
+// stateFromLegacy rebuilds network state from the legacy ID, which is the network UUID.
+func (r *NetworkResource) stateFromLegacy(ctx context.Context, state *tfsdk.State, attrs map[string]any) diag.Diagnostics {
+	var model NetworkResourceModel
+	uuid := legacyString(attrs, "id")
+	diags := seedResourceModel(ctx, state, path.Root("id"), types.StringValue(uuid), &model)
+	if diags.HasError() {
+		return diags
+	}
+
+	if autostart, ok := attrs["autostart"].(bool); ok {
+		model.Autostart = types.BoolValue(autostart)
+	}
+
+	if r.client != nil {
+		network, err := r.client.LookupNetworkByUUID(uuid)
+		if err == nil {
+			if err := r.readNetwork(ctx, &model, network, nil); err != nil {
+				diags.AddError(
+					"Network Read Failed",
+					fmt.Sprintf("Failed to read network: %s", err),
+				)
+				return diags
+			}
+		} else {
+			tflog.Warn(ctx, "Legacy network not found on host, leaving it to the next refresh", map[string]any{
+				"uuid": uuid,
+			})
+		}
+	}
+
+	diags.Append(state.Set(ctx, &model)...)
+	return diags
+}
+
+// UpgradeState upgrades libvirt_cloudinit_disk state written by the legacy provider
+func (r *CloudInitDiskResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return legacyUpgrader("path", r.stateFromLegacy)
+}
+
+// MoveState moves libvirt_cloudinit_disk state written by the legacy provider
+func (r *CloudInitDiskResource) MoveState(ctx context.Context) []resource.StateMover {
+	return legacyMover("libvirt_cloudinit_disk", "path", r.stateFromLegacy)
+}
+
+// stateFromLegacy converts a legacy cloud-init disk, which was a volume uploaded to a pool,
+// into a local ISO with the same content. The ISO path is derived from the content, so if the
+// file does not exist yet the next refresh drops it and Terraform plans to generate it again.
+// The legacy volume is left in its pool.
+func (r *CloudInitDiskResource) stateFromLegacy(ctx context.Context, state *tfsdk.State, attrs map[string]any) diag.Diagnostics {
+	model := CloudInitDiskResourceModel{
+		Name:          types.StringValue(legacyString(attrs, "name")),
+		UserData:      legacyOptionalString(attrs, "user_data"),
+		MetaData:      legacyOptionalString(attrs, "meta_data"),
+		NetworkConfig: legacyOptionalString(attrs, "network_config"),
+		Size:          types.Int64Null(),
+	}
+
+	id := cloudInitDiskID(model.UserData.ValueString(), model.MetaData.ValueString(), model.NetworkConfig)
+	model.ID = types.StringValue(id)
+	model.Path = types.StringValue(cloudInitDiskPath(id))
+
+	tflog.Info(ctx, "Legacy cloud-init disk volume is no longer managed", map[string]any{
+		"volume": legacyString(attrs, "id"),
+	})
+
+	return state.Set(ctx, &model)
+}
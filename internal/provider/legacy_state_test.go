@@ -0,0 +1,123 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestLegacyRawState(t *testing.T) {
+	t.Parallel()
+
+	legacy := &tfprotov6.RawState{JSON: []byte(`{"id":"8f1c7e0a-54b4-4a4f-9a52-3c1d2b7e9f01","name":"vm","vcpu":2}`)}
+	attrs, isLegacy, err := legacyRawState(legacy, "uuid")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !isLegacy {
+		t.Fatal("expected state without uuid to be detected as legacy")
+	}
+	if got := legacyString(attrs, "id"); got != "8f1c7e0a-54b4-4a4f-9a52-3c1d2b7e9f01" {
+		t.Fatalf("unexpected id: %s", got)
+	}
+
+	current := &tfprotov6.RawState{JSON: []byte(`{"uuid":"8f1c7e0a-54b4-4a4f-9a52-3c1d2b7e9f01","name":"vm"}`)}
+	if _, isLegacy, err := legacyRawState(current, "uuid"); err != nil || isLegacy {
+		t.Fatalf("expected current state, got legacy=%v err=%v", isLegacy, err)
+	}
+
+	if _, _, err := legacyRawState(&tfprotov6.RawState{Flatmap: map[string]string{"id": "x"}}, "uuid"); err == nil {
+		t.Fatal("expected error for flatmap state")
+	}
+}
+
+func upgradeLegacyState(t *testing.T, r resource.Resource, upgrader resource.StateUpgrader, rawJSON string) tfsdk.State {
+	t.Helper()
+	ctx := context.Background()
+
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	if schemaResp.Schema.Version != resourceSchemaVersion {
+		t.Fatalf("unexpected schema version: %d", schemaResp.Schema.Version)
+	}
+
+	resp := &resource.UpgradeStateResponse{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+			Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+		},
+	}
+	upgrader.StateUpgrader(ctx, resource.UpgradeStateRequest{RawState: &tfprotov6.RawState{JSON: []byte(rawJSON)}}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+	return resp.State
+}
+
+func TestDomainUpgradeLegacyStateSeedsUUID(t *testing.T) {
+	r := &DomainResource{}
+	state := upgradeLegacyState(t, r, r.UpgradeState(context.Background())[0],
+		`{"id":"8f1c7e0a-54b4-4a4f-9a52-3c1d2b7e9f01","name":"vm","vcpu":2,"network_interface":[]}`)
+
+	var model DomainResourceModel
+	if diags := state.Get(context.Background(), &model); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if model.UUID.ValueString() != "8f1c7e0a-54b4-4a4f-9a52-3c1d2b7e9f01" {
+		t.Fatalf("unexpected uuid: %s", model.UUID)
+	}
+	if !model.Name.IsNull() {
+		t.Fatal("expected name to be left for the next refresh")
+	}
+}
+
+func TestVolumeUpgradeCurrentStatePassesThrough(t *testing.T) {
+	r := &VolumeResource{}
+	state := upgradeLegacyState(t, r, r.UpgradeState(context.Background())[0],
+		`{"id":"/var/lib/libvirt/images/disk.qcow2","key":"/var/lib/libvirt/images/disk.qcow2","name":"disk.qcow2","pool":"default"}`)
+
+	var model VolumeResourceModel
+	if diags := state.Get(context.Background(), &model); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if model.Name.ValueString() != "disk.qcow2" || model.Pool.ValueString() != "default" {
+		t.Fatalf("unexpected model: name=%s pool=%s", model.Name, model.Pool)
+	}
+}
+
+func TestCloudInitDiskUpgradeLegacyState(t *testing.T) {
+	r := &CloudInitDiskResource{}
+	state := upgradeLegacyState(t, r, r.UpgradeState(context.Background())[0],
+		`{"id":"/var/lib/libvirt/images/init.iso;5e2e","name":"init.iso","pool":"default","user_data":"#cloud-config\n","meta_data":"instance-id: vm\n"}`)
+
+	var model CloudInitDiskResourceModel
+	if diags := state.Get(context.Background(), &model); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	wantID := cloudInitDiskID("#cloud-config\n", "instance-id: vm\n", model.NetworkConfig)
+	if model.ID.ValueString() != wantID || model.Path.ValueString() != cloudInitDiskPath(wantID) {
+		t.Fatalf("unexpected id/path: %s %s", model.ID, model.Path)
+	}
+	if model.Name.ValueString() != "init.iso" {
+		t.Fatalf("unexpected name: %s", model.Name)
+	}
+}
+
+func TestLegacyMoverIgnoresOtherTypes(t *testing.T) {
+	r := &NetworkResource{}
+	movers := r.MoveState(context.Background())
+
+	resp := &resource.MoveStateResponse{}
+	movers[0].StateMover(context.Background(), resource.MoveStateRequest{
+		SourceTypeName: "libvirt_domain",
+		SourceRawState: &tfprotov6.RawState{JSON: []byte(`{"id":"x"}`)},
+	}, resp)
+	if resp.Diagnostics.HasError() || resp.TargetState.Raw.Type() != nil {
+		t.Fatal("expected mover to skip other resource types")
+	}
+}
@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// resourceData is implemented by tfsdk.State and tfsdk.Resource.
+type resourceData interface {
+	SetAttribute(ctx context.Context, p path.Path, val interface{}) diag.Diagnostics
+	Get(ctx context.Context, target interface{}) diag.Diagnostics
+}
+
+// seedResourceModel seeds resource data the same way ImportState does, by setting a single
+// identifying attribute, and reads it back into target so every other attribute is a typed
+// null. The resource read helpers then populate target as they would after an import.
+func seedResourceModel(ctx context.Context, data resourceData, idPath path.Path, id types.String, target any) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	diags.Append(data.SetAttribute(ctx, idPath, id)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	diags.Append(data.Get(ctx, target)...)
+	return diags
+}
+
+// listNameMatches reports whether name passes the optional name_prefix filter.
+func listNameMatches(prefix types.String, name string) bool {
+	if prefix.IsNull() || prefix.IsUnknown() {
+		return true
+	}
+	return strings.HasPrefix(name, prefix.ValueString())
+}
+
+// listLimitReached reports whether count results satisfy the limit requested by Terraform.
+func listLimitReached(limit int64, count int64) bool {
+	return limit > 0 && count >= limit
+}
@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestListResourceTypeNames(t *testing.T) {
+	tests := map[string]func() list.ListResource{
+		"libvirt_domain":  NewDomainListResource,
+		"libvirt_pool":    NewPoolListResource,
+		"libvirt_volume":  NewVolumeListResource,
+		"libvirt_network": NewNetworkListResource,
+	}
+
+	for want, factory := range tests {
+		resp := &resource.MetadataResponse{}
+		factory().Metadata(context.Background(), resource.MetadataRequest{ProviderTypeName: "libvirt"}, resp)
+		if resp.TypeName != want {
+			t.Fatalf("unexpected list resource type name: got=%s want=%s", resp.TypeName, want)
+		}
+	}
+}
+
+func TestListNameMatches(t *testing.T) {
+	tests := []struct {
+		prefix types.String
+		name   string
+		want   bool
+	}{
+		{types.StringNull(), "web-1", true},
+		{types.StringValue(""), "web-1", true},
+		{types.StringValue("web-"), "web-1", true},
+		{types.StringValue("db-"), "web-1", false},
+	}
+
+	for _, tt := range tests {
+		if got := listNameMatches(tt.prefix, tt.name); got != tt.want {
+			t.Fatalf("listNameMatches(%s, %q) = %v, want %v", tt.prefix, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestListLimitReached(t *testing.T) {
+	if listLimitReached(0, 100) {
+		t.Fatal("expected no limit when limit is zero")
+	}
+	if listLimitReached(2, 1) {
+		t.Fatal("expected limit not reached")
+	}
+	if !listLimitReached(2, 2) {
+		t.Fatal("expected limit reached")
+	}
+}
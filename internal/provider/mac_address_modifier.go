@@ -0,0 +1,245 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/generated"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// deterministicMACPlanModifier fills in the MAC address of interfaces that do not set one,
+// so it is known at plan time instead of being picked at random by libvirt on define.
+type deterministicMACPlanModifier struct{}
+
+func (m deterministicMACPlanModifier) Description(ctx context.Context) string {
+	return "Generates a stable MAC address from the domain name, interface index and mac_seed when none is configured"
+}
+
+func (m deterministicMACPlanModifier) MarkdownDescription(ctx context.Context) string {
+	return "Generates a stable MAC address from the domain name, interface index and `mac_seed` when none is configured"
+}
+
+func (m deterministicMACPlanModifier) PlanModifyObject(ctx context.Context, req planmodifier.ObjectRequest, resp *planmodifier.ObjectResponse) {
+	// Respect configured MACs, and nothing to plan on destroy
+	if !req.ConfigValue.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	index, ok := interfaceIndexFromPath(req.Path)
+	if !ok {
+		return
+	}
+
+	interfacesPath := path.Root("devices").AtName("interfaces")
+	var configInterfaces, stateInterfaces types.List
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, interfacesPath, &configInterfaces)...)
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, interfacesPath, &stateInterfaces)...)
+	}
+	if resp.Diagnostics.HasError() || configInterfaces.IsUnknown() {
+		return
+	}
+
+	var name, seed types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("name"), &name)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("mac_seed"), &seed)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	macs := planInterfaceMACs(configInterfaces.Elements(), stateInterfaces.Elements(), seed, name)
+	if index < len(macs) && macs[index] != nil {
+		resp.PlanValue = *macs[index]
+	}
+}
+
+// planInterfaceMACs returns the planned MAC of each configured interface without one.
+// Interfaces that already exist keep whatever MAC they have in state, matched by their
+// source and model rather than their position so that removing or reordering interfaces
+// does not move MACs around. For domains created before MACs were generated this is
+// null, leaving libvirt's MAC untouched. New interfaces get a generated MAC that no
+// other interface uses. Entries are nil for configured MACs, and for new interfaces
+// while the name or seed is unknown.
+func planInterfaceMACs(configInterfaces, stateInterfaces []attr.Value, seed, name types.String) []*types.Object {
+	macs := make([]*types.Object, len(configInterfaces))
+	used := map[string]bool{}
+	for _, iface := range configInterfaces {
+		if address := interfaceMACAddress(iface); address != "" {
+			used[address] = true
+		}
+	}
+
+	// Interfaces whose MAC is now configured are not available to others
+	matched := make([]bool, len(stateInterfaces))
+	for j, iface := range stateInterfaces {
+		matched[j] = used[interfaceMACAddress(iface)]
+	}
+
+	var newInterfaces []int
+	for i, iface := range configInterfaces {
+		if !interfaceMAC(iface).IsNull() {
+			continue
+		}
+		j := matchStateInterface(iface, stateInterfaces, matched)
+		if j < 0 {
+			newInterfaces = append(newInterfaces, i)
+			continue
+		}
+		matched[j] = true
+		mac := interfaceMAC(stateInterfaces[j])
+		macs[i] = &mac
+		if address := interfaceMACAddress(stateInterfaces[j]); address != "" {
+			used[address] = true
+		}
+	}
+
+	if name.IsUnknown() || seed.IsUnknown() {
+		return macs
+	}
+	for _, i := range newInterfaces {
+		address := deterministicMAC(seed.ValueString(), name.ValueString(), i)
+		for n := i + 1; used[address]; n++ {
+			address = deterministicMAC(seed.ValueString(), name.ValueString(), n)
+		}
+		used[address] = true
+		mac := types.ObjectValueMust(generated.DomainInterfaceMACAttributeTypes(), map[string]attr.Value{
+			"address": types.StringValue(address),
+			"type":    types.StringNull(),
+			"check":   types.StringNull(),
+		})
+		macs[i] = &mac
+	}
+	return macs
+}
+
+// matchStateInterface returns the index of the first unmatched interface in state with
+// the same source as iface, preferring one with the same model, or -1.
+func matchStateInterface(iface attr.Value, stateInterfaces []attr.Value, matched []bool) int {
+	source, model := interfaceMatchKeys(iface)
+	if source == "" {
+		return -1
+	}
+
+	found := -1
+	for j, stateIface := range stateInterfaces {
+		if matched[j] {
+			continue
+		}
+		stateSource, stateModel := interfaceMatchKeys(stateIface)
+		if stateSource != source {
+			continue
+		}
+		if stateModel == model {
+			return j
+		}
+		if found < 0 {
+			found = j
+		}
+	}
+	return found
+}
+
+// interfaceMatchKeys identifies an interface by the kind and name of its source (network,
+// bridge, device, ...) and by its model. The source key is empty when it is not known.
+func interfaceMatchKeys(iface attr.Value) (string, string) {
+	obj, ok := iface.(types.Object)
+	if !ok || obj.IsNull() || obj.IsUnknown() {
+		return "", ""
+	}
+	attrs := obj.Attributes()
+
+	var source string
+	if sourceObj, ok := attrs["source"].(types.Object); ok && !sourceObj.IsNull() && !sourceObj.IsUnknown() {
+		kinds := sourceObj.Attributes()
+		names := make([]string, 0, len(kinds))
+		for kind := range kinds {
+			names = append(names, kind)
+		}
+		sort.Strings(names)
+		for _, kind := range names {
+			value := kinds[kind]
+			if value.IsNull() {
+				continue
+			}
+			if value.IsUnknown() {
+				return "", ""
+			}
+			source += kind
+			if kindObj, ok := value.(types.Object); ok {
+				for _, field := range []string{"network", "bridge", "dev"} {
+					if str, ok := kindObj.Attributes()[field].(types.String); ok && !str.IsNull() {
+						if str.IsUnknown() {
+							return "", ""
+						}
+						source += "/" + str.ValueString()
+					}
+				}
+			}
+			source += ";"
+		}
+	}
+	if source == "" {
+		return "", ""
+	}
+
+	var model string
+	if modelObj, ok := attrs["model"].(types.Object); ok && !modelObj.IsNull() && !modelObj.IsUnknown() {
+		if modelType, ok := modelObj.Attributes()["type"].(types.String); ok {
+			model = modelType.ValueString()
+		}
+	}
+	return source, model
+}
+
+// interfaceMAC returns the mac attribute of an interface, or a null object.
+func interfaceMAC(iface attr.Value) types.Object {
+	if obj, ok := iface.(types.Object); ok && !obj.IsNull() && !obj.IsUnknown() {
+		if mac, ok := obj.Attributes()["mac"].(types.Object); ok {
+			return mac
+		}
+	}
+	return types.ObjectNull(generated.DomainInterfaceMACAttributeTypes())
+}
+
+// interfaceMACAddress returns the known MAC address of an interface, lowercased.
+func interfaceMACAddress(iface attr.Value) string {
+	mac := interfaceMAC(iface)
+	if mac.IsNull() || mac.IsUnknown() {
+		return ""
+	}
+	if address, ok := mac.Attributes()["address"].(types.String); ok && !address.IsNull() && !address.IsUnknown() {
+		return strings.ToLower(address.ValueString())
+	}
+	return ""
+}
+
+// DeterministicMACPlanModifier returns a plan modifier that generates interface MAC addresses
+func DeterministicMACPlanModifier() planmodifier.Object {
+	return deterministicMACPlanModifier{}
+}
+
+// interfaceIndexFromPath returns the list index of the interface a path points into.
+func interfaceIndexFromPath(p path.Path) (int, bool) {
+	for _, step := range p.Steps() {
+		if key, ok := step.(path.PathStepElementKeyInt); ok {
+			return int(key), true
+		}
+	}
+	return 0, false
+}
+
+// deterministicMAC derives a locally administered unicast MAC address from the seed,
+// domain name and interface index.
+func deterministicMAC(seed, name string, index int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%d", seed, name, index)))
+	// Set the locally administered bit and clear the multicast bit
+	sum[0] = sum[0]&0xfe | 0x02
+	return fmt.Sprintf("%02x:%02x:%02x:%02x:%02x:%02x", sum[0], sum[1], sum[2], sum[3], sum[4], sum[5])
+}
@@ -0,0 +1,170 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestDeterministicMAC(t *testing.T) {
+	t.Parallel()
+
+	mac := deterministicMAC("", "web-1", 0)
+	if !regexp.MustCompile(`^[0-9a-f]{2}(:[0-9a-f]{2}){5}$`).MatchString(mac) {
+		t.Fatalf("malformed MAC %q", mac)
+	}
+
+	var first byte
+	if _, err := fmt.Sscanf(mac[:2], "%02x", &first); err != nil {
+		t.Fatalf("failed to parse MAC %q: %v", mac, err)
+	}
+	if first&0x02 == 0 || first&0x01 != 0 {
+		t.Errorf("expected a locally administered unicast MAC, got %q", mac)
+	}
+
+	if again := deterministicMAC("", "web-1", 0); again != mac {
+		t.Errorf("expected a stable MAC, got %q and %q", mac, again)
+	}
+	for _, other := range []string{
+		deterministicMAC("", "web-1", 1),
+		deterministicMAC("", "web-2", 0),
+		deterministicMAC("site-b", "web-1", 0),
+	} {
+		if other == mac {
+			t.Errorf("expected index, name and seed to change the MAC %q", mac)
+		}
+	}
+}
+
+func TestDeterministicMACPlanModifier(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	schemaResp := testResource(t, NewDomainResource(), nil)
+	objectType := schemaResp.Schema.Type().TerraformType(ctx)
+
+	ifaceOn := func(network string, mac any) map[string]any {
+		return map[string]any{
+			"source": map[string]any{
+				"network": map[string]any{"network": network},
+			},
+			"mac": mac,
+		}
+	}
+	iface := func(mac any) map[string]any {
+		return ifaceOn("default", mac)
+	}
+	address := func(mac string) map[string]any {
+		return map[string]any{"address": mac}
+	}
+	domain := func(seed any, interfaces ...any) map[string]any {
+		return map[string]any{
+			"name":     "web-1",
+			"memory":   256,
+			"mac_seed": seed,
+			"os":       map[string]any{"type": "hvm"},
+			"devices":  map[string]any{"interfaces": interfaces},
+		}
+	}
+
+	plan := func(prior, config map[string]any) tfsdk.State {
+		t.Helper()
+
+		priorValue := tftypes.NewValue(objectType, nil)
+		if prior != nil {
+			priorValue = testValue(t, objectType, prior)
+		}
+		priorState, err := tfprotov6.NewDynamicValue(objectType, priorValue)
+		if err != nil {
+			t.Fatalf("failed to encode prior state: %v", err)
+		}
+		configValue, err := tfprotov6.NewDynamicValue(objectType, testValue(t, objectType, config))
+		if err != nil {
+			t.Fatalf("failed to encode config: %v", err)
+		}
+
+		server := providerserver.NewProtocol6(New("test")())()
+		resp, err := server.PlanResourceChange(ctx, &tfprotov6.PlanResourceChangeRequest{
+			TypeName:         "libvirt_domain",
+			PriorState:       &priorState,
+			ProposedNewState: &configValue,
+			Config:           &configValue,
+		})
+		if err != nil {
+			t.Fatalf("plan failed: %v", err)
+		}
+		for _, d := range resp.Diagnostics {
+			if d.Severity == tfprotov6.DiagnosticSeverityError {
+				t.Fatalf("unexpected diagnostic: %s: %s", d.Summary, d.Detail)
+			}
+		}
+
+		planned, err := resp.PlannedState.Unmarshal(objectType)
+		if err != nil {
+			t.Fatalf("failed to decode plan: %v", err)
+		}
+		return tfsdk.State{Schema: schemaResp.Schema, Raw: planned}
+	}
+
+	macPath := func(i int) path.Path {
+		return path.Root("devices").AtName("interfaces").AtListIndex(i).AtName("mac").AtName("address")
+	}
+
+	created := plan(nil, domain(nil, iface(nil), iface(map[string]any{"address": "52:54:00:aa:bb:cc"})))
+	if got := testStateValue(t, created, macPath(0)); got != deterministicMAC("", "web-1", 0) {
+		t.Errorf("expected a generated MAC, got %q", got)
+	}
+	if got := testStateValue(t, created, macPath(1)); got != "52:54:00:aa:bb:cc" {
+		t.Errorf("expected the configured MAC to be kept, got %q", got)
+	}
+
+	seeded := plan(nil, domain("site-b", iface(nil)))
+	if got := testStateValue(t, seeded, macPath(0)); got != deterministicMAC("site-b", "web-1", 0) {
+		t.Errorf("expected the seed to be used, got %q", got)
+	}
+
+	// An existing interface keeps its MAC, even with a different seed, and a new one gets
+	// a generated MAC.
+	prior := domain(nil, iface(map[string]any{"address": "52:54:00:12:34:56"}))
+	updated := plan(prior, domain("site-b", iface(nil), iface(nil)))
+	if got := testStateValue(t, updated, macPath(0)); got != "52:54:00:12:34:56" {
+		t.Errorf("expected the existing MAC to be kept, got %q", got)
+	}
+	if got := testStateValue(t, updated, macPath(1)); got != deterministicMAC("site-b", "web-1", 1) {
+		t.Errorf("expected a generated MAC for the new interface, got %q", got)
+	}
+
+	// Domains created before MACs were generated keep libvirt's MAC.
+	legacy := plan(domain(nil, iface(nil)), domain(nil, iface(nil)))
+	if got := testStateValue(t, legacy, path.Root("devices").AtName("interfaces").AtListIndex(0).AtName("mac")); got != "<null>" {
+		t.Errorf("expected no MAC for an existing interface without one, got %s", got)
+	}
+
+	// Existing interfaces are matched by source, so removing or reordering interfaces
+	// does not move MACs, and new interfaces do not reuse a MAC that is still in use.
+	prior = domain(nil,
+		ifaceOn("front", address(deterministicMAC("", "web-1", 0))),
+		ifaceOn("back", address(deterministicMAC("", "web-1", 1))),
+	)
+	removed := plan(prior, domain(nil, ifaceOn("back", nil)))
+	if got := testStateValue(t, removed, macPath(0)); got != deterministicMAC("", "web-1", 1) {
+		t.Errorf("expected the remaining interface to keep its MAC, got %q", got)
+	}
+	reordered := plan(prior, domain(nil, ifaceOn("storage", nil), ifaceOn("back", nil), ifaceOn("front", nil)))
+	if got := testStateValue(t, reordered, macPath(1)); got != deterministicMAC("", "web-1", 1) {
+		t.Errorf("expected the back interface to keep its MAC, got %q", got)
+	}
+	if got := testStateValue(t, reordered, macPath(2)); got != deterministicMAC("", "web-1", 0) {
+		t.Errorf("expected the front interface to keep its MAC, got %q", got)
+	}
+	if got := testStateValue(t, reordered, macPath(0)); got != deterministicMAC("", "web-1", 2) {
+		t.Errorf("expected the new interface to skip MACs in use, got %q", got)
+	}
+}
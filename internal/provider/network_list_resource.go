@@ -0,0 +1,161 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/list/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces
+var (
+	_ list.ListResource              = &NetworkListResource{}
+	_ list.ListResourceWithConfigure = &NetworkListResource{}
+)
+
+// NewNetworkListResource creates a new network list resource
+func NewNetworkListResource() list.ListResource {
+	return &NetworkListResource{}
+}
+
+// NetworkListResource enumerates persistent networks for terraform query
+type NetworkListResource struct {
+	client *libvirt.Client
+}
+
+// NetworkListResourceModel describes the list block configuration
+type NetworkListResourceModel struct {
+	NamePrefix types.String `tfsdk:"name_prefix"`
+	State      types.String `tfsdk:"state"`
+}
+
+var networkListStateFlags = map[string]golibvirt.ConnectListAllNetworksFlags{
+	"active":   golibvirt.ConnectListNetworksActive,
+	"inactive": golibvirt.ConnectListNetworksInactive,
+}
+
+// Metadata returns the resource type name being listed
+func (l *NetworkListResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_network"
+}
+
+// ListResourceConfigSchema defines the filters accepted by the list block
+func (l *NetworkListResource) ListResourceConfigSchema(ctx context.Context, req list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Lists persistent libvirt networks (`virConnectListAllNetworks`).",
+		Attributes: map[string]schema.Attribute{
+			"name_prefix": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Only list networks whose name starts with this prefix.",
+			},
+			"state": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Only list networks in this state: `active` or `inactive`.",
+				Validators: []validator.String{
+					stringvalidator.OneOf("active", "inactive"),
+				},
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the list resource
+func (l *NetworkListResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*libvirt.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected List Resource Configure Type",
+			fmt.Sprintf("Expected *libvirt.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	l.client = client
+}
+
+// List streams the networks matching the configured filters
+func (l *NetworkListResource) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	var config NetworkListResourceModel
+	diags := req.Config.Get(ctx, &config)
+	if diags.HasError() {
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	flags := golibvirt.ConnectListNetworksPersistent
+	if !config.State.IsNull() && !config.State.IsUnknown() {
+		flags |= networkListStateFlags[config.State.ValueString()]
+	}
+
+	networks, _, err := l.client.Libvirt().ConnectListAllNetworks(1, flags)
+	if err != nil {
+		diags.AddError(
+			"Failed to List Networks",
+			fmt.Sprintf("Unable to list networks: %s", err),
+		)
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	networkResource := &NetworkResource{client: l.client}
+
+	stream.Results = func(push func(list.ListResult) bool) {
+		var count int64
+		for _, network := range networks {
+			if !listNameMatches(config.NamePrefix, network.Name) {
+				continue
+			}
+			if listLimitReached(req.Limit, count) {
+				return
+			}
+			count++
+
+			result := req.NewListResult(ctx)
+			result.DisplayName = network.Name
+
+			uuid := types.StringValue(libvirt.UUIDString(network.UUID))
+			result.Diagnostics.Append(result.Identity.Set(ctx, NetworkResourceIdentityModel{UUID: uuid})...)
+
+			if req.IncludeResource && !result.Diagnostics.HasError() {
+				result.Diagnostics.Append(networkListResultResource(ctx, networkResource, &result, network, uuid)...)
+			}
+
+			if !push(result) {
+				return
+			}
+		}
+	}
+}
+
+func networkListResultResource(ctx context.Context, r *NetworkResource, result *list.ListResult, network golibvirt.Network, uuid types.String) diag.Diagnostics {
+	var model NetworkResourceModel
+	diags := seedResourceModel(ctx, result.Resource, path.Root("id"), uuid, &model)
+	if diags.HasError() {
+		return diags
+	}
+
+	// No plan: populate every field reported by libvirt
+	if err := r.readNetwork(ctx, &model, network, nil); err != nil {
+		diags.AddError(
+			"Network Read Failed",
+			fmt.Sprintf("Failed to read network %s: %s", network.Name, err),
+		)
+		return diags
+	}
+
+	diags.Append(result.Resource.Set(ctx, &model)...)
+	return diags
+}
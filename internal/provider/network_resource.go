@@ -10,7 +10,9 @@ import (
 	libvirtclient "github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/identityschema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
@@ -27,8 +29,14 @@ type NetworkResource struct {
 // NetworkResourceModel extends generated model with resource-specific fields
 type NetworkResourceModel struct {
 	generated.NetworkModel
-	ID        types.String `tfsdk:"id"`        // Resource identifier (UUID)
-	Autostart types.Bool   `tfsdk:"autostart"` // Provider-specific: whether to autostart
+	ID         types.String `tfsdk:"id"`          // Resource identifier (UUID)
+	Autostart  types.Bool   `tfsdk:"autostart"`   // Provider-specific: whether to autostart
+	XMLPatches types.List   `tfsdk:"xml_patches"` // Provider-specific: patches applied to the generated XML
+}
+
+// NetworkResourceIdentityModel describes the identity of a network
+type NetworkResourceIdentityModel struct {
+	UUID types.String `tfsdk:"uuid"`
 }
 
 func NewNetworkResource() resource.Resource {
@@ -70,7 +78,20 @@ func (r *NetworkResource) Schema(ctx context.Context, req resource.SchemaRequest
 			Optional:    true,
 			Computed:    true,
 		},
+		"xml_patches": xmlPatchesSchemaAttribute(listplanmodifier.RequiresReplace()),
 	})
+	resp.Schema.Version = resourceSchemaVersion
+}
+
+func (r *NetworkResource) IdentitySchema(ctx context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = identityschema.Schema{
+		Attributes: map[string]identityschema.Attribute{
+			"uuid": identityschema.StringAttribute{
+				Description:       "UUID of the network.",
+				RequiredForImport: true,
+			},
+		},
+	}
 }
 
 func (r *NetworkResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
@@ -117,6 +138,17 @@ func (r *NetworkResource) Create(ctx context.Context, req resource.CreateRequest
 		return
 	}
 
+	xmlDoc, diags := applyXMLPatches(ctx, xmlDoc, model.XMLPatches)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(validateXML(r.client, "network", xmlDoc, generated.NetworkXMLPaths)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	tflog.Debug(ctx, "Generated network XML", map[string]any{"xml": xmlDoc})
 
 	// Define the network in libvirt
@@ -180,6 +212,7 @@ func (r *NetworkResource) Create(ctx context.Context, req resource.CreateRequest
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &model)...)
+	resp.Diagnostics.Append(setResourceIdentity(ctx, resp.Identity, NetworkResourceIdentityModel{UUID: model.ID})...)
 }
 
 func (r *NetworkResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
@@ -204,8 +237,13 @@ func (r *NetworkResource) Read(ctx context.Context, req resource.ReadRequest, re
 		return
 	}
 
-	// Read network state (use current state as plan to preserve user intent)
-	if err := r.readNetwork(ctx, &model, net, &model.NetworkModel); err != nil {
+	// Read network state (use current state as plan to preserve user intent).
+	// After ImportState only the ID is set, so populate everything from XML.
+	plan := &model.NetworkModel
+	if model.Name.IsNull() || model.Name.IsUnknown() {
+		plan = nil
+	}
+	if err := r.readNetwork(ctx, &model, net, plan); err != nil {
 		resp.Diagnostics.AddError(
 			"Network Read Failed",
 			fmt.Sprintf("Failed to read network: %s", err),
@@ -214,6 +252,7 @@ func (r *NetworkResource) Read(ctx context.Context, req resource.ReadRequest, re
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &model)...)
+	resp.Diagnostics.Append(setResourceIdentity(ctx, resp.Identity, NetworkResourceIdentityModel{UUID: model.ID})...)
 }
 
 func (r *NetworkResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
@@ -267,6 +306,7 @@ func (r *NetworkResource) Update(ctx context.Context, req resource.UpdateRequest
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &model)...)
+	resp.Diagnostics.Append(setResourceIdentity(ctx, resp.Identity, NetworkResourceIdentityModel{UUID: model.ID})...)
 }
 
 func (r *NetworkResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
@@ -326,7 +366,19 @@ func (r *NetworkResource) Delete(ctx context.Context, req resource.DeleteRequest
 }
 
 func (r *NetworkResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	if req.ID != "" {
+		uuid, err := resolveNetworkImportID(r.client, req.ID)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Network Not Found",
+				fmt.Sprintf("Cannot import network: %s", err),
+			)
+			return
+		}
+		req.ID = uuid
+	}
+
+	resource.ImportStatePassthroughWithIdentity(ctx, path.Root("id"), path.Root("uuid"), req, resp)
 }
 
 // readNetwork reads network state from libvirt and populates the model
@@ -337,6 +389,20 @@ func (r *NetworkResource) readNetwork(ctx context.Context, model *NetworkResourc
 		return fmt.Errorf("failed to get network XML: %w", err)
 	}
 
+	// Reset patched nodes so they do not show up as drift
+	if plan != nil {
+		xmlDoc, err = restoreXMLPatches(ctx, xmlDoc, model.XMLPatches, func() (string, error) {
+			networkXML, err := generated.NetworkToXML(ctx, plan)
+			if err != nil {
+				return "", err
+			}
+			return networkXML.Marshal()
+		})
+		if err != nil {
+			return fmt.Errorf("failed to ignore xml_patches in network XML: %w", err)
+		}
+	}
+
 	// Parse XML
 	var networkXML libvirtxml.Network
 	if err := networkXML.Unmarshal(xmlDoc); err != nil {
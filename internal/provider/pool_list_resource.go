@@ -0,0 +1,158 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/list/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces
+var (
+	_ list.ListResource              = &PoolListResource{}
+	_ list.ListResourceWithConfigure = &PoolListResource{}
+)
+
+// NewPoolListResource creates a new storage pool list resource
+func NewPoolListResource() list.ListResource {
+	return &PoolListResource{}
+}
+
+// PoolListResource enumerates persistent storage pools for terraform query
+type PoolListResource struct {
+	client *libvirt.Client
+}
+
+// PoolListResourceModel describes the list block configuration
+type PoolListResourceModel struct {
+	NamePrefix types.String `tfsdk:"name_prefix"`
+	State      types.String `tfsdk:"state"`
+}
+
+var poolListStateFlags = map[string]golibvirt.ConnectListAllStoragePoolsFlags{
+	"active":   golibvirt.ConnectListStoragePoolsActive,
+	"inactive": golibvirt.ConnectListStoragePoolsInactive,
+}
+
+// Metadata returns the resource type name being listed
+func (l *PoolListResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_pool"
+}
+
+// ListResourceConfigSchema defines the filters accepted by the list block
+func (l *PoolListResource) ListResourceConfigSchema(ctx context.Context, req list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Lists persistent libvirt storage pools (`virConnectListAllStoragePools`).",
+		Attributes: map[string]schema.Attribute{
+			"name_prefix": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Only list pools whose name starts with this prefix.",
+			},
+			"state": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Only list pools in this state: `active` or `inactive`.",
+				Validators: []validator.String{
+					stringvalidator.OneOf("active", "inactive"),
+				},
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the list resource
+func (l *PoolListResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*libvirt.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected List Resource Configure Type",
+			fmt.Sprintf("Expected *libvirt.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	l.client = client
+}
+
+// List streams the storage pools matching the configured filters
+func (l *PoolListResource) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	var config PoolListResourceModel
+	diags := req.Config.Get(ctx, &config)
+	if diags.HasError() {
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	flags := golibvirt.ConnectListStoragePoolsPersistent
+	if !config.State.IsNull() && !config.State.IsUnknown() {
+		flags |= poolListStateFlags[config.State.ValueString()]
+	}
+
+	pools, _, err := l.client.Libvirt().ConnectListAllStoragePools(1, flags)
+	if err != nil {
+		diags.AddError(
+			"Failed to List Storage Pools",
+			fmt.Sprintf("Unable to list storage pools: %s", err),
+		)
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	poolResource := &PoolResource{client: l.client}
+
+	stream.Results = func(push func(list.ListResult) bool) {
+		var count int64
+		for _, pool := range pools {
+			if !listNameMatches(config.NamePrefix, pool.Name) {
+				continue
+			}
+			if listLimitReached(req.Limit, count) {
+				return
+			}
+			count++
+
+			result := req.NewListResult(ctx)
+			result.DisplayName = pool.Name
+
+			uuid := types.StringValue(libvirt.UUIDString(pool.UUID))
+			result.Diagnostics.Append(result.Identity.Set(ctx, PoolResourceIdentityModel{UUID: uuid})...)
+
+			if req.IncludeResource && !result.Diagnostics.HasError() {
+				result.Diagnostics.Append(poolListResultResource(ctx, poolResource, &result, pool, uuid)...)
+			}
+
+			if !push(result) {
+				return
+			}
+		}
+	}
+}
+
+func poolListResultResource(ctx context.Context, r *PoolResource, result *list.ListResult, pool golibvirt.StoragePool, uuid types.String) diag.Diagnostics {
+	var model PoolResourceModel
+	diags := seedResourceModel(ctx, result.Resource, path.Root("id"), uuid, &model)
+	if diags.HasError() {
+		return diags
+	}
+
+	// No plan: populate every field reported by libvirt
+	diags.Append(r.readPoolWithPlan(ctx, &model, pool, nil)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	diags.Append(result.Resource.Set(ctx, &model)...)
+	return diags
+}
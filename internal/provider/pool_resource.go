@@ -10,7 +10,9 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/identityschema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
@@ -22,6 +24,7 @@ import (
 // Ensure the implementation satisfies the resource.Resource interface
 var _ resource.Resource = &PoolResource{}
 var _ resource.ResourceWithImportState = &PoolResource{}
+var _ resource.ResourceWithIdentity = &PoolResource{}
 
 // PoolResource defines the resource implementation
 type PoolResource struct {
@@ -34,6 +37,13 @@ type PoolResourceModel struct {
 	ID      types.String `tfsdk:"id"`      // Resource-specific ID
 	Create  types.Object `tfsdk:"create"`  // Provider-specific lifecycle create controls
 	Destroy types.Object `tfsdk:"destroy"` // Provider-specific lifecycle destroy controls
+
+	XMLPatches types.List `tfsdk:"xml_patches"` // Provider-specific: patches applied to the generated XML
+}
+
+// PoolResourceIdentityModel describes the identity of a storage pool
+type PoolResourceIdentityModel struct {
+	UUID types.String `tfsdk:"uuid"`
 }
 
 // PoolCreateModel describes storage pool creation behavior overrides.
@@ -131,9 +141,22 @@ func (r *PoolResource) Schema(ctx context.Context, req resource.SchemaRequest, r
 				},
 			},
 		},
+		"xml_patches": xmlPatchesSchemaAttribute(listplanmodifier.RequiresReplace()),
 	})
 }
 
+// IdentitySchema defines the identity of the resource
+func (r *PoolResource) IdentitySchema(ctx context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = identityschema.Schema{
+		Attributes: map[string]identityschema.Attribute{
+			"uuid": identityschema.StringAttribute{
+				Description:       "UUID of the storage pool.",
+				RequiredForImport: true,
+			},
+		},
+	}
+}
+
 // Configure configures the resource
 func (r *PoolResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
@@ -205,6 +228,17 @@ func (r *PoolResource) Create(ctx context.Context, req resource.CreateRequest, r
 		return
 	}
 
+	xmlDoc, diags := applyXMLPatches(ctx, xmlDoc, model.XMLPatches)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(validateXML(r.client, "storagepool", xmlDoc, generated.StoragePoolXMLPaths)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	tflog.Debug(ctx, "Generated pool XML", map[string]any{"xml": xmlDoc})
 
 	// Define the pool
@@ -348,6 +382,7 @@ func (r *PoolResource) Create(ctx context.Context, req resource.CreateRequest, r
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &model)...)
+	resp.Diagnostics.Append(setResourceIdentity(ctx, resp.Identity, PoolResourceIdentityModel{UUID: model.ID})...)
 }
 
 // Read reads the storage pool state
@@ -369,13 +404,19 @@ func (r *PoolResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
-	// Read the pool state (use current state as plan to preserve user intent)
-	resp.Diagnostics.Append(r.readPoolWithPlan(ctx, &model, pool, &model.StoragePoolModel)...)
+	// Read the pool state (use current state as plan to preserve user intent).
+	// After ImportState only the ID is set, so populate everything from XML.
+	plan := &model.StoragePoolModel
+	if model.Name.IsNull() || model.Name.IsUnknown() {
+		plan = nil
+	}
+	resp.Diagnostics.Append(r.readPoolWithPlan(ctx, &model, pool, plan)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &model)...)
+	resp.Diagnostics.Append(setResourceIdentity(ctx, resp.Identity, PoolResourceIdentityModel{UUID: model.ID})...)
 }
 
 // readPoolWithPlan reads pool state from libvirt and populates the model
@@ -393,6 +434,24 @@ func (r *PoolResource) readPoolWithPlan(ctx context.Context, model *PoolResource
 		return diags
 	}
 
+	// Reset patched nodes so they do not show up as drift
+	if plan != nil {
+		xmlDoc, err = restoreXMLPatches(ctx, xmlDoc, model.XMLPatches, func() (string, error) {
+			poolDef, err := generated.StoragePoolToXML(ctx, plan)
+			if err != nil {
+				return "", err
+			}
+			return poolDef.Marshal()
+		})
+		if err != nil {
+			diags.AddError(
+				"Failed to Get Pool XML",
+				fmt.Sprintf("Could not ignore xml_patches in storage pool XML: %s", err),
+			)
+			return diags
+		}
+	}
+
 	// Parse XML
 	var poolDef libvirtxml.StoragePool
 	if err := poolDef.Unmarshal(xmlDoc); err != nil {
@@ -524,9 +583,21 @@ func (r *PoolResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 	})
 }
 
-// ImportState imports an existing storage pool
+// ImportState imports an existing storage pool by UUID or name
 func (r *PoolResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	if req.ID != "" {
+		uuid, err := resolvePoolImportID(r.client, req.ID)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Pool Not Found",
+				fmt.Sprintf("Cannot import storage pool: %s", err),
+			)
+			return
+		}
+		req.ID = uuid
+	}
+
+	resource.ImportStatePassthroughWithIdentity(ctx, path.Root("id"), path.Root("uuid"), req, resp)
 }
 
 func poolCreateOptionsFromPlan(ctx context.Context, create types.Object) (poolCreateOptions, diag.Diagnostics) {
@@ -5,7 +5,9 @@ import (
 	"os"
 
 	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework/action"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/list"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -13,8 +15,12 @@ import (
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
-// Ensure the implementation satisfies the provider.Provider interface
-var _ provider.Provider = &LibvirtProvider{}
+// Ensure the implementation satisfies the expected interfaces
+var (
+	_ provider.Provider                  = &LibvirtProvider{}
+	_ provider.ProviderWithActions       = &LibvirtProvider{}
+	_ provider.ProviderWithListResources = &LibvirtProvider{}
+)
 
 // LibvirtProvider defines the provider implementation
 type LibvirtProvider struct {
@@ -23,7 +29,10 @@ type LibvirtProvider struct {
 
 // LibvirtProviderModel describes the provider data model
 type LibvirtProviderModel struct {
-	URI types.String `tfsdk:"uri"`
+	URI           types.String `tfsdk:"uri"`
+	ValidateXML   types.Bool   `tfsdk:"validate_xml"`
+	XMLSchemasDir types.String `tfsdk:"xml_schemas_dir"`
+	OSInfoDir     types.String `tfsdk:"osinfo_dir"`
 }
 
 // New creates a new provider instance
@@ -60,6 +69,29 @@ providing fine-grained control over all libvirt features.
 					"See [libvirt URI documentation](https://libvirt.org/uri.html) for details.",
 				Optional: true,
 			},
+			"validate_xml": schema.BoolAttribute{
+				Description: "Validate the XML generated for domains, networks, storage pools and storage volumes " +
+					"against the libvirt RelaxNG schemas before sending it, and ask libvirt to validate domain XML " +
+					"when defining domains. Errors are reported at the attribute that produced the invalid XML. Defaults to false.",
+				MarkdownDescription: "Validate the XML generated for domains, networks, storage pools and storage volumes " +
+					"against the libvirt RelaxNG schemas before sending it, and ask libvirt to validate domain XML " +
+					"when defining domains. Errors are reported at the attribute that produced the invalid XML. Defaults to `false`.",
+				Optional: true,
+			},
+			"xml_schemas_dir": schema.StringAttribute{
+				Description: "Directory containing the libvirt RelaxNG schemas used by validate_xml. " +
+					"Defaults to " + libvirt.DefaultSchemaDir + ". Validation is skipped with a warning when the schemas are missing.",
+				MarkdownDescription: "Directory containing the libvirt RelaxNG schemas used by `validate_xml`. " +
+					"Defaults to `" + libvirt.DefaultSchemaDir + "`. Validation is skipped with a warning when the schemas are missing.",
+				Optional: true,
+			},
+			"osinfo_dir": schema.StringAttribute{
+				Description: "Directory of the osinfo database used by the os_variant attribute of libvirt_domain. " +
+					"Defaults to the directories libosinfo reads (/usr/share/osinfo, /etc/osinfo and the user configuration directory).",
+				MarkdownDescription: "Directory of the osinfo database used by the `os_variant` attribute of `libvirt_domain`. " +
+					"Defaults to the directories libosinfo reads (`/usr/share/osinfo`, `/etc/osinfo` and the user configuration directory).",
+				Optional: true,
+			},
 		},
 	}
 }
@@ -115,15 +147,23 @@ func (p *LibvirtProvider) Configure(ctx context.Context, req provider.ConfigureR
 		return
 	}
 
+	if config.ValidateXML.ValueBool() {
+		client.EnableSchemaValidation(config.XMLSchemasDir.ValueString())
+	}
+	client.SetOSInfoDir(config.OSInfoDir.ValueString())
+
 	// Make the client available to resources and data sources
 	resp.DataSourceData = client
 	resp.ResourceData = client
+	resp.ActionData = client
+	resp.ListResourceData = client
 }
 
 // Resources returns the list of resources supported by this provider
 func (p *LibvirtProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewDomainResource,
+		NewDomainXMLResource,
 		NewPoolResource,
 		NewVolumeResource,
 		NewNetworkResource,
@@ -140,5 +180,29 @@ func (p *LibvirtProvider) DataSources(ctx context.Context) []func() datasource.D
 		NewNodeDevicesDataSource,
 		NewNodeDeviceInfoDataSource,
 		NewDomainInterfaceAddressesDataSource,
+		NewDomainGuestInfoDataSource,
+		NewDomainStatsDataSource,
+		NewDomainDataSource,
+		NewDomainsDataSource,
+	}
+}
+
+// ListResources returns the list of list resources supported by this provider
+func (p *LibvirtProvider) ListResources(ctx context.Context) []func() list.ListResource {
+	return []func() list.ListResource{
+		NewDomainListResource,
+		NewPoolListResource,
+		NewVolumeListResource,
+		NewNetworkListResource,
+	}
+}
+
+// Actions returns the list of actions supported by this provider
+func (p *LibvirtProvider) Actions(ctx context.Context) []func() action.Action {
+	return []func() action.Action{
+		NewDomainRebootAction,
+		NewDomainResetAction,
+		NewDomainShutdownAction,
+		NewDomainStartAction,
 	}
 }
@@ -1,11 +1,24 @@
 package provider
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"reflect"
+	"sync/atomic"
 	"testing"
 
+	libvirtclient "github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 )
 
@@ -37,3 +50,193 @@ func testAccPreCheck(t *testing.T) {
 	// You can add checks here to verify libvirt is available
 	// For now, we'll let the provider connection handle this
 }
+
+// testMockHosts numbers the mock hosts, since the store of a mock:// URI is shared by
+// the whole process and a test may run more than once.
+var testMockHosts atomic.Int64
+
+// testMockClient connects to a fresh in-process mock libvirt host, so unit tests can
+// exercise resources and data sources without libvirtd.
+func testMockClient(t *testing.T) *libvirtclient.Client {
+	t.Helper()
+
+	uri := fmt.Sprintf("mock:///%s-%d", t.Name(), testMockHosts.Add(1))
+	client, err := libvirtclient.NewClient(context.Background(), uri)
+	if err != nil {
+		t.Fatalf("failed to connect to mock libvirt: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// testReadDataSource runs Read on a configured data source. Top-level attributes missing
+// from config are null.
+func testReadDataSource(t *testing.T, ds datasource.DataSource, client *libvirtclient.Client, config map[string]tftypes.Value) (tfsdk.State, diag.Diagnostics) {
+	t.Helper()
+	ctx := context.Background()
+
+	if configurable, ok := ds.(datasource.DataSourceWithConfigure); ok {
+		configureResp := &datasource.ConfigureResponse{}
+		configurable.Configure(ctx, datasource.ConfigureRequest{ProviderData: client}, configureResp)
+		if configureResp.Diagnostics.HasError() {
+			t.Fatalf("configure failed: %v", configureResp.Diagnostics)
+		}
+	}
+
+	schemaResp := &datasource.SchemaResponse{}
+	ds.Schema(ctx, datasource.SchemaRequest{}, schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("schema failed: %v", schemaResp.Diagnostics)
+	}
+
+	objectType := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	values := make(map[string]tftypes.Value, len(objectType.AttributeTypes))
+	for name, typ := range objectType.AttributeTypes {
+		values[name] = tftypes.NewValue(typ, nil)
+	}
+	for name, value := range config {
+		values[name] = value
+	}
+
+	raw := tftypes.NewValue(objectType, values)
+	resp := &datasource.ReadResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)},
+	}
+	ds.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw}}, resp)
+	return resp.State, resp.Diagnostics
+}
+
+// testValue builds a value of typ from plain Go values: nil, strings, numbers, bools,
+// []any for lists and sets, and map[string]any for objects and maps. Object attributes
+// missing from the map are null.
+func testValue(t *testing.T, typ tftypes.Type, v any) tftypes.Value {
+	t.Helper()
+
+	if v == nil {
+		return tftypes.NewValue(typ, nil)
+	}
+
+	switch typed := typ.(type) {
+	case tftypes.Object:
+		fields, ok := v.(map[string]any)
+		if !ok {
+			t.Fatalf("expected map[string]any for object, got %T", v)
+		}
+		values := make(map[string]tftypes.Value, len(typed.AttributeTypes))
+		for name, attrType := range typed.AttributeTypes {
+			values[name] = testValue(t, attrType, fields[name])
+		}
+		for name := range fields {
+			if _, ok := typed.AttributeTypes[name]; !ok {
+				t.Fatalf("unknown attribute %q", name)
+			}
+		}
+		return tftypes.NewValue(typed, values)
+	case tftypes.List, tftypes.Set:
+		items, ok := v.([]any)
+		if !ok {
+			t.Fatalf("expected []any for %s, got %T", typ, v)
+		}
+		var elemType tftypes.Type
+		if list, ok := typed.(tftypes.List); ok {
+			elemType = list.ElementType
+		} else {
+			elemType = typed.(tftypes.Set).ElementType
+		}
+		values := make([]tftypes.Value, len(items))
+		for i, item := range items {
+			values[i] = testValue(t, elemType, item)
+		}
+		return tftypes.NewValue(typ, values)
+	case tftypes.Map:
+		fields, ok := v.(map[string]any)
+		if !ok {
+			t.Fatalf("expected map[string]any for map, got %T", v)
+		}
+		values := make(map[string]tftypes.Value, len(fields))
+		for name, item := range fields {
+			values[name] = testValue(t, typed.ElementType, item)
+		}
+		return tftypes.NewValue(typ, values)
+	default:
+		return tftypes.NewValue(typ, v)
+	}
+}
+
+// testResource returns the schema of a resource configured with client.
+func testResource(t *testing.T, r fwresource.Resource, client *libvirtclient.Client) fwresource.SchemaResponse {
+	t.Helper()
+	ctx := context.Background()
+
+	if configurable, ok := r.(fwresource.ResourceWithConfigure); ok {
+		configureResp := &fwresource.ConfigureResponse{}
+		configurable.Configure(ctx, fwresource.ConfigureRequest{ProviderData: client}, configureResp)
+		if configureResp.Diagnostics.HasError() {
+			t.Fatalf("configure failed: %v", configureResp.Diagnostics)
+		}
+	}
+
+	schemaResp := fwresource.SchemaResponse{}
+	r.Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("schema failed: %v", schemaResp.Diagnostics)
+	}
+	return schemaResp
+}
+
+// testCreateResource runs Create on a configured resource, using config as plan.
+// Computed attributes missing from config are null in the plan.
+func testCreateResource(t *testing.T, r fwresource.Resource, client *libvirtclient.Client, config map[string]any) (tfsdk.State, diag.Diagnostics) {
+	t.Helper()
+
+	resp := testCreateResourceResponse(t, r, client, config)
+	return resp.State, resp.Diagnostics
+}
+
+// testCreateResourceResponse runs Create like testCreateResource and returns the whole
+// response, including the private state.
+func testCreateResourceResponse(t *testing.T, r fwresource.Resource, client *libvirtclient.Client, config map[string]any) *fwresource.CreateResponse {
+	t.Helper()
+	ctx := context.Background()
+
+	schemaResp := testResource(t, r, client)
+	objectType := schemaResp.Schema.Type().TerraformType(ctx)
+	raw := testValue(t, objectType, config)
+
+	resp := &fwresource.CreateResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)},
+	}
+	// The framework server initializes private state, whose type is internal to it
+	private := reflect.ValueOf(resp).Elem().FieldByName("Private")
+	private.Set(reflect.New(private.Type().Elem()))
+
+	r.Create(ctx, fwresource.CreateRequest{
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw},
+	}, resp)
+	return resp
+}
+
+// testReadResource runs Read on a configured resource with the given prior state.
+func testReadResource(t *testing.T, r fwresource.Resource, client *libvirtclient.Client, state tfsdk.State) (tfsdk.State, diag.Diagnostics) {
+	t.Helper()
+
+	testResource(t, r, client)
+	resp := &fwresource.ReadResponse{State: state}
+	r.Read(context.Background(), fwresource.ReadRequest{State: state}, resp)
+	return resp.State, resp.Diagnostics
+}
+
+// testStateValue returns the attribute at path in state, formatted for comparisons.
+func testStateValue(t *testing.T, state tfsdk.State, p path.Path) string {
+	t.Helper()
+
+	var value attr.Value
+	if diags := state.GetAttribute(context.Background(), p, &value); diags.HasError() {
+		t.Fatalf("failed to read %s: %v", p, diags)
+	}
+	if str, ok := value.(types.String); ok {
+		return str.ValueString()
+	}
+	return fmt.Sprint(value)
+}
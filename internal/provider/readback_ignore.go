@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/generated"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// readbackIgnoreSchemaAttribute returns the readback_ignore attribute of libvirt_domain.
+func readbackIgnoreSchemaAttribute() schema.ListAttribute {
+	return schema.ListAttribute{
+		Description: "Attribute paths that are kept from the prior state instead of being refreshed from " +
+			"the domain XML, for settings changed outside Terraform (e.g. disk I/O tuning adjusted by a backup agent).",
+		MarkdownDescription: "Attribute paths that are kept from the prior state instead of being refreshed from " +
+			"the domain XML, for settings changed outside Terraform (e.g. disk I/O tuning adjusted by a backup agent).\n\n" +
+			"Paths are dot separated attribute names relative to the resource, such as `numa_tune` or " +
+			"`devices.disks[*].io_tune`. List elements are selected with `[n]` or `[*]`; a list without an index " +
+			"applies to all of its elements. Changes to ignored attributes are still applied when Terraform updates the domain.",
+		Optional:    true,
+		ElementType: types.StringType,
+		Validators: []validator.List{
+			readbackIgnorePathValidator{},
+		},
+	}
+}
+
+// readbackIgnorePathValidator checks that every path names a generated domain attribute.
+type readbackIgnorePathValidator struct{}
+
+func (v readbackIgnorePathValidator) Description(ctx context.Context) string {
+	return "values must be paths of domain attributes"
+}
+
+func (v readbackIgnorePathValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v readbackIgnorePathValidator) ValidateList(ctx context.Context, req validator.ListRequest, resp *validator.ListResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	for i, element := range req.ConfigValue.Elements() {
+		value, ok := element.(types.String)
+		if !ok || value.IsNull() || value.IsUnknown() {
+			continue
+		}
+
+		p, err := generated.ParseReadbackPath(value.ValueString())
+		if err == nil && !domainAttributePaths()[strings.Join(p.Names(), ".")] {
+			err = fmt.Errorf("%q is not a domain attribute", value.ValueString())
+		}
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(req.Path.AtListIndex(i), "Invalid Readback Ignore Path", err.Error())
+		}
+	}
+}
+
+// domainAttributePaths returns the paths, without list indexes, of the attributes
+// generated from the domain XML.
+var domainAttributePaths = sync.OnceValue(func() map[string]bool {
+	paths := make(map[string]bool, len(generated.DomainXMLPaths))
+	for _, entry := range generated.DomainXMLPaths {
+		paths[entry.TFPath] = true
+	}
+	return paths
+})
+
+// readbackIgnorePaths parses the readback_ignore attribute.
+func readbackIgnorePaths(ctx context.Context, list types.List) ([]generated.ReadbackPath, diag.Diagnostics) {
+	if list.IsNull() || list.IsUnknown() {
+		return nil, nil
+	}
+
+	var values []string
+	diags := list.ElementsAs(ctx, &values, false)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	paths := make([]generated.ReadbackPath, 0, len(values))
+	for _, value := range values {
+		p, err := generated.ParseReadbackPath(value)
+		if err != nil {
+			diags.AddError("Invalid Readback Ignore Path", err.Error())
+			return nil, diags
+		}
+		paths = append(paths, p)
+	}
+	return paths, diags
+}
@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"libvirt.org/go/libvirtxml"
+)
+
+func TestReadbackIgnorePathValidator(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{path: "numa_tune"},
+		{path: "memory_unit"},
+		{path: "devices.disks[*].io_tune"},
+		{path: "devices.disks[1].io_tune.total_bytes_sec"},
+		{path: "devices.frobnicate", wantErr: true},
+		{path: "devices.disks[0]", wantErr: true},
+		{path: "running", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			t.Parallel()
+
+			req := validator.ListRequest{
+				Path:        path.Root("readback_ignore"),
+				ConfigValue: types.ListValueMust(types.StringType, []attr.Value{types.StringValue(tc.path)}),
+			}
+			resp := &validator.ListResponse{}
+			readbackIgnorePathValidator{}.ValidateList(context.Background(), req, resp)
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Errorf("expected error %t, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestDomainResourceReadbackIgnore(t *testing.T) {
+	client := testMockClient(t)
+
+	state, diags := testCreateResource(t, NewDomainResource(), client, map[string]any{
+		"name":        "test-readback-ignore",
+		"title":       "configured",
+		"description": "configured",
+		"memory":      256,
+		"type":        "kvm",
+		"os": map[string]any{
+			"type": "hvm",
+		},
+		"readback_ignore": []any{"description"},
+	})
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	// Change both settings outside Terraform
+	domain, err := client.Libvirt().DomainLookupByName("test-readback-ignore")
+	if err != nil {
+		t.Fatalf("failed to look up domain: %v", err)
+	}
+	xmlDesc, err := client.Libvirt().DomainGetXMLDesc(domain, golibvirt.DomainXMLSecure)
+	if err != nil {
+		t.Fatalf("failed to get domain XML: %v", err)
+	}
+	var def libvirtxml.Domain
+	if err := def.Unmarshal(xmlDesc); err != nil {
+		t.Fatal(err)
+	}
+	def.Title = "external"
+	def.Description = "external"
+	changed, err := def.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Libvirt().DomainDefineXML(changed); err != nil {
+		t.Fatalf("failed to redefine domain: %v", err)
+	}
+
+	state, diags = testReadResource(t, NewDomainResource(), client, state)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if title := testStateValue(t, state, path.Root("title")); title != "external" {
+		t.Errorf("expected the title to be refreshed, got %q", title)
+	}
+	if description := testStateValue(t, state, path.Root("description")); description != "configured" {
+		t.Errorf("expected the ignored description to be kept, got %q", description)
+	}
+}
@@ -0,0 +1,18 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+)
+
+// setResourceIdentity stores the identity of a managed resource.
+// The framework leaves identity nil when the resource is not served through an
+// identity-aware RPC (e.g. in unit tests), in which case this is a no-op.
+func setResourceIdentity(ctx context.Context, identity *tfsdk.ResourceIdentity, model any) diag.Diagnostics {
+	if identity == nil {
+		return nil
+	}
+	return identity.Set(ctx, model)
+}
@@ -3,6 +3,7 @@ package provider
 import (
 	"fmt"
 
+	dsschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 )
 
@@ -25,3 +26,105 @@ func mustStringAttribute(attr schema.Attribute, name string) schema.StringAttrib
 	}
 	return str
 }
+
+// dataSourceAttributes converts generated resource schema attributes into read-only data
+// source attributes. Every attribute becomes computed; plan modifiers, defaults and
+// validators only apply to resources and are dropped. An attribute type without a data
+// source equivalent is returned as an error.
+func dataSourceAttributes(attrs map[string]schema.Attribute) (map[string]dsschema.Attribute, error) {
+	result := make(map[string]dsschema.Attribute, len(attrs))
+	for name, attr := range attrs {
+		converted, err := dataSourceAttribute(attr, name)
+		if err != nil {
+			return nil, err
+		}
+		result[name] = converted
+	}
+	return result, nil
+}
+
+func dataSourceAttribute(attr schema.Attribute, name string) (dsschema.Attribute, error) {
+	switch a := attr.(type) {
+	case schema.StringAttribute:
+		return dsschema.StringAttribute{
+			Computed:            true,
+			CustomType:          a.CustomType,
+			Sensitive:           a.Sensitive,
+			Description:         a.Description,
+			MarkdownDescription: a.MarkdownDescription,
+		}, nil
+	case schema.BoolAttribute:
+		return dsschema.BoolAttribute{
+			Computed:            true,
+			CustomType:          a.CustomType,
+			Sensitive:           a.Sensitive,
+			Description:         a.Description,
+			MarkdownDescription: a.MarkdownDescription,
+		}, nil
+	case schema.Int64Attribute:
+		return dsschema.Int64Attribute{
+			Computed:            true,
+			CustomType:          a.CustomType,
+			Sensitive:           a.Sensitive,
+			Description:         a.Description,
+			MarkdownDescription: a.MarkdownDescription,
+		}, nil
+	case schema.Float64Attribute:
+		return dsschema.Float64Attribute{
+			Computed:            true,
+			CustomType:          a.CustomType,
+			Sensitive:           a.Sensitive,
+			Description:         a.Description,
+			MarkdownDescription: a.MarkdownDescription,
+		}, nil
+	case schema.ListAttribute:
+		return dsschema.ListAttribute{
+			Computed:            true,
+			ElementType:         a.ElementType,
+			CustomType:          a.CustomType,
+			Sensitive:           a.Sensitive,
+			Description:         a.Description,
+			MarkdownDescription: a.MarkdownDescription,
+		}, nil
+	case schema.MapAttribute:
+		return dsschema.MapAttribute{
+			Computed:            true,
+			ElementType:         a.ElementType,
+			CustomType:          a.CustomType,
+			Sensitive:           a.Sensitive,
+			Description:         a.Description,
+			MarkdownDescription: a.MarkdownDescription,
+		}, nil
+	case schema.SingleNestedAttribute:
+		attributes, err := dataSourceAttributes(a.Attributes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return dsschema.SingleNestedAttribute{
+			Computed:            true,
+			Attributes:          attributes,
+			CustomType:          a.CustomType,
+			Sensitive:           a.Sensitive,
+			Description:         a.Description,
+			MarkdownDescription: a.MarkdownDescription,
+		}, nil
+	case schema.ListNestedAttribute:
+		attributes, err := dataSourceAttributes(a.NestedObject.Attributes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return dsschema.ListNestedAttribute{
+			Computed: true,
+			NestedObject: dsschema.NestedAttributeObject{
+				Attributes: attributes,
+				CustomType: a.NestedObject.CustomType,
+			},
+			CustomType:          a.CustomType,
+			Sensitive:           a.Sensitive,
+			Description:         a.Description,
+			MarkdownDescription: a.MarkdownDescription,
+		}, nil
+	default:
+		return nil, fmt.Errorf("%s schema attribute of type %T has no data source equivalent", name, attr)
+	}
+}
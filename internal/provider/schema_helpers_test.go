@@ -0,0 +1,47 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/generated"
+	dsschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestDataSourceAttributesDomainSchema(t *testing.T) {
+	attrs, err := dataSourceAttributes(generated.DomainSchema(nil).Attributes)
+	if err != nil {
+		t.Fatalf("failed to convert the generated domain schema: %v", err)
+	}
+
+	var walk func(prefix string, attrs map[string]dsschema.Attribute)
+	walk = func(prefix string, attrs map[string]dsschema.Attribute) {
+		for name, attr := range attrs {
+			if !attr.IsComputed() || attr.IsOptional() || attr.IsRequired() {
+				t.Errorf("expected %s%s to be computed only", prefix, name)
+			}
+			switch a := attr.(type) {
+			case dsschema.SingleNestedAttribute:
+				walk(prefix+name+".", a.Attributes)
+			case dsschema.ListNestedAttribute:
+				walk(prefix+name+".", a.NestedObject.Attributes)
+			}
+		}
+	}
+	walk("", attrs)
+}
+
+func TestDataSourceAttributesUnsupported(t *testing.T) {
+	_, err := dataSourceAttributes(map[string]schema.Attribute{
+		"os": schema.SingleNestedAttribute{
+			Attributes: map[string]schema.Attribute{
+				"tags": schema.SetAttribute{ElementType: types.StringType},
+			},
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "os: tags") {
+		t.Fatalf("expected an error naming the unsupported attribute, got %v", err)
+	}
+}
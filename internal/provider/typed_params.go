@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"strings"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// typedParams indexes typed parameters, as returned by virDomainGetGuestInfo or
+// virConnectGetAllDomainStats, by field name.
+type typedParams map[string]any
+
+func newTypedParams(params []golibvirt.TypedParam) typedParams {
+	p := typedParams{}
+	for _, param := range params {
+		p[param.Field] = param.Value.I
+	}
+	return p
+}
+
+func (p typedParams) string(key string) types.String {
+	if v, ok := p[key].(string); ok {
+		return types.StringValue(v)
+	}
+	return types.StringNull()
+}
+
+func (p typedParams) int64(key string) types.Int64 {
+	switch v := p[key].(type) {
+	case int32:
+		return types.Int64Value(int64(v))
+	case uint32:
+		return types.Int64Value(int64(v))
+	case int64:
+		return types.Int64Value(v)
+	case uint64:
+		return types.Int64Value(int64(v))
+	}
+	return types.Int64Null()
+}
+
+func (p typedParams) bool(key string) types.Bool {
+	// booleans are transferred as int32
+	if v, ok := p[key].(int32); ok {
+		return types.BoolValue(v != 0)
+	}
+	return types.BoolNull()
+}
+
+func (p typedParams) count(key string) int {
+	return int(p.int64(key).ValueInt64())
+}
+
+func (p typedParams) has(prefix string) bool {
+	for key := range p {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
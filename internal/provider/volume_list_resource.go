@@ -0,0 +1,173 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/list/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces
+var (
+	_ list.ListResource              = &VolumeListResource{}
+	_ list.ListResourceWithConfigure = &VolumeListResource{}
+)
+
+// NewVolumeListResource creates a new storage volume list resource
+func NewVolumeListResource() list.ListResource {
+	return &VolumeListResource{}
+}
+
+// VolumeListResource enumerates storage volumes for terraform query
+type VolumeListResource struct {
+	client *libvirt.Client
+}
+
+// VolumeListResourceModel describes the list block configuration
+type VolumeListResourceModel struct {
+	Pool       types.String `tfsdk:"pool"`
+	NamePrefix types.String `tfsdk:"name_prefix"`
+}
+
+// Metadata returns the resource type name being listed
+func (l *VolumeListResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_volume"
+}
+
+// ListResourceConfigSchema defines the filters accepted by the list block
+func (l *VolumeListResource) ListResourceConfigSchema(ctx context.Context, req list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Lists libvirt storage volumes (`virStoragePoolListAllVolumes`) of active storage pools.",
+		Attributes: map[string]schema.Attribute{
+			"pool": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Only list volumes of this storage pool. If unset, volumes of all active pools are listed.",
+			},
+			"name_prefix": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Only list volumes whose name starts with this prefix.",
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the list resource
+func (l *VolumeListResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*libvirt.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected List Resource Configure Type",
+			fmt.Sprintf("Expected *libvirt.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	l.client = client
+}
+
+// List streams the storage volumes matching the configured filters
+func (l *VolumeListResource) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	var config VolumeListResourceModel
+	diags := req.Config.Get(ctx, &config)
+	if diags.HasError() {
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	var pools []golibvirt.StoragePool
+	if !config.Pool.IsNull() && !config.Pool.IsUnknown() {
+		pool, err := l.client.Libvirt().StoragePoolLookupByName(config.Pool.ValueString())
+		if err != nil {
+			diags.AddError(
+				"Pool Not Found",
+				fmt.Sprintf("Storage pool '%s' not found: %s", config.Pool.ValueString(), err),
+			)
+			stream.Results = list.ListResultsStreamDiagnostics(diags)
+			return
+		}
+		pools = []golibvirt.StoragePool{pool}
+	} else {
+		// Volumes can only be enumerated on active pools
+		var err error
+		pools, _, err = l.client.Libvirt().ConnectListAllStoragePools(1, golibvirt.ConnectListStoragePoolsActive)
+		if err != nil {
+			diags.AddError(
+				"Failed to List Storage Pools",
+				fmt.Sprintf("Unable to list storage pools: %s", err),
+			)
+			stream.Results = list.ListResultsStreamDiagnostics(diags)
+			return
+		}
+	}
+
+	volumeResource := &VolumeResource{client: l.client}
+
+	stream.Results = func(push func(list.ListResult) bool) {
+		var count int64
+		for _, pool := range pools {
+			volumes, _, err := l.client.Libvirt().StoragePoolListAllVolumes(pool, 1, 0)
+			if err != nil {
+				result := list.ListResult{}
+				result.Diagnostics.AddError(
+					"Failed to List Volumes",
+					fmt.Sprintf("Unable to list volumes of storage pool '%s': %s", pool.Name, err),
+				)
+				push(result)
+				return
+			}
+
+			for _, volume := range volumes {
+				if !listNameMatches(config.NamePrefix, volume.Name) {
+					continue
+				}
+				if listLimitReached(req.Limit, count) {
+					return
+				}
+				count++
+
+				result := req.NewListResult(ctx)
+				result.DisplayName = pool.Name + "/" + volume.Name
+
+				key := types.StringValue(volume.Key)
+				result.Diagnostics.Append(result.Identity.Set(ctx, VolumeResourceIdentityModel{Key: key})...)
+
+				if req.IncludeResource && !result.Diagnostics.HasError() {
+					result.Diagnostics.Append(volumeListResultResource(ctx, volumeResource, &result, pool, volume)...)
+				}
+
+				if !push(result) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func volumeListResultResource(ctx context.Context, r *VolumeResource, result *list.ListResult, pool golibvirt.StoragePool, volume golibvirt.StorageVol) diag.Diagnostics {
+	var model VolumeResourceModel
+	diags := seedResourceModel(ctx, result.Resource, path.Root("id"), types.StringValue(volume.Key), &model)
+	if diags.HasError() {
+		return diags
+	}
+
+	// No plan: populate every field reported by libvirt
+	diags.Append(r.readVolume(ctx, &model, volume, nil)...)
+	if diags.HasError() {
+		return diags
+	}
+	model.Pool = types.StringValue(pool.Name)
+
+	diags.Append(result.Resource.Set(ctx, &model)...)
+	return diags
+}
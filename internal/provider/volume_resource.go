@@ -10,7 +10,9 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/identityschema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
@@ -23,6 +25,7 @@ import (
 // Ensure the implementation satisfies the resource.Resource interface
 var _ resource.Resource = &VolumeResource{}
 var _ resource.ResourceWithImportState = &VolumeResource{}
+var _ resource.ResourceWithIdentity = &VolumeResource{}
 
 // VolumeResource defines the resource implementation
 type VolumeResource struct {
@@ -36,6 +39,13 @@ type VolumeResourceModel struct {
 	Pool   types.String `tfsdk:"pool"`   // Provider-specific: which pool to create in
 	Path   types.String `tfsdk:"path"`   // Computed: convenience field mirroring target.path
 	Create types.Object `tfsdk:"create"` // Provider-specific: upload content on create
+
+	XMLPatches types.List `tfsdk:"xml_patches"` // Provider-specific: patches applied to the generated XML
+}
+
+// VolumeResourceIdentityModel describes the identity of a storage volume
+type VolumeResourceIdentityModel struct {
+	Key types.String `tfsdk:"key"`
 }
 
 // VolumeCreateModel describes the create block for volume initialization
@@ -133,7 +143,21 @@ func (r *VolumeResource) Schema(ctx context.Context, req resource.SchemaRequest,
 				objectplanmodifier.RequiresReplace(),
 			},
 		},
+		"xml_patches": xmlPatchesSchemaAttribute(listplanmodifier.RequiresReplace()),
 	})
+	resp.Schema.Version = resourceSchemaVersion
+}
+
+// IdentitySchema defines the identity of the resource
+func (r *VolumeResource) IdentitySchema(ctx context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = identityschema.Schema{
+		Attributes: map[string]identityschema.Attribute{
+			"key": identityschema.StringAttribute{
+				Description:       "Key of the storage volume (usually its path).",
+				RequiredForImport: true,
+			},
+		},
+	}
 }
 
 // Configure configures the resource
@@ -275,6 +299,17 @@ func (r *VolumeResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
+	xmlDoc, diags := applyXMLPatches(ctx, xmlDoc, model.XMLPatches)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(validateXML(r.client, "storagevol", xmlDoc, generated.StorageVolumeXMLPaths)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	tflog.Debug(ctx, "Generated volume XML", map[string]any{"xml": xmlDoc})
 
 	// Create the volume
@@ -342,6 +377,7 @@ func (r *VolumeResource) Create(ctx context.Context, req resource.CreateRequest,
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &model)...)
+	resp.Diagnostics.Append(setResourceIdentity(ctx, resp.Identity, VolumeResourceIdentityModel{Key: model.ID})...)
 }
 
 // Read reads the storage volume state
@@ -352,8 +388,15 @@ func (r *VolumeResource) Read(ctx context.Context, req resource.ReadRequest, res
 		return
 	}
 
+	// After ImportState only the ID (the volume key) is set
+	isImport := model.Name.IsNull() || model.Name.IsUnknown()
+	key := model.Key.ValueString()
+	if model.Key.IsNull() || model.Key.IsUnknown() {
+		key = model.ID.ValueString()
+	}
+
 	// Look up the volume by key
-	volume, err := r.client.Libvirt().StorageVolLookupByKey(model.Key.ValueString())
+	volume, err := r.client.Libvirt().StorageVolLookupByKey(key)
 	if err != nil {
 		resp.Diagnostics.AddWarning(
 			"Volume Not Found",
@@ -364,12 +407,29 @@ func (r *VolumeResource) Read(ctx context.Context, req resource.ReadRequest, res
 	}
 
 	// Read the volume state (use current state as plan to preserve user intent)
-	resp.Diagnostics.Append(r.readVolume(ctx, &model, volume, &model.StorageVolumeModel)...)
+	plan := &model.StorageVolumeModel
+	if isImport {
+		plan = nil
+	}
+	resp.Diagnostics.Append(r.readVolume(ctx, &model, volume, plan)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	if model.Pool.IsNull() || model.Pool.IsUnknown() {
+		pool, err := r.client.Libvirt().StoragePoolLookupByVolume(volume)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Failed to Look Up Volume Pool",
+				fmt.Sprintf("Could not find the storage pool of volume '%s': %s", volume.Name, err),
+			)
+			return
+		}
+		model.Pool = types.StringValue(pool.Name)
+	}
+
 	resp.Diagnostics.Append(resp.State.Set(ctx, &model)...)
+	resp.Diagnostics.Append(setResourceIdentity(ctx, resp.Identity, VolumeResourceIdentityModel{Key: model.ID})...)
 }
 
 // readVolume reads volume state from libvirt and populates the model
@@ -387,6 +447,24 @@ func (r *VolumeResource) readVolume(ctx context.Context, model *VolumeResourceMo
 		return diags
 	}
 
+	// Reset patched nodes so they do not show up as drift
+	if plan != nil {
+		xmlDoc, err = restoreXMLPatches(ctx, xmlDoc, model.XMLPatches, func() (string, error) {
+			volumeDef, err := generated.StorageVolumeToXML(ctx, plan)
+			if err != nil {
+				return "", err
+			}
+			return volumeDef.Marshal()
+		})
+		if err != nil {
+			diags.AddError(
+				"Failed to Get Volume XML",
+				fmt.Sprintf("Could not ignore xml_patches in storage volume XML: %s", err),
+			)
+			return diags
+		}
+	}
+
 	// Parse XML
 	var volumeDef libvirtxml.StorageVolume
 	if err := volumeDef.Unmarshal(xmlDoc); err != nil {
@@ -490,7 +568,19 @@ func (r *VolumeResource) Delete(ctx context.Context, req resource.DeleteRequest,
 	})
 }
 
-// ImportState imports an existing storage volume
+// ImportState imports an existing storage volume by key, absolute path or <pool>/<volume>
 func (r *VolumeResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	if req.ID != "" {
+		key, err := resolveVolumeImportID(r.client, req.ID)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Volume Not Found",
+				fmt.Sprintf("Cannot import storage volume: %s", err),
+			)
+			return
+		}
+		req.ID = key
+	}
+
+	resource.ImportStatePassthroughWithIdentity(ctx, path.Root("id"), path.Root("key"), req, resp)
 }
@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/xmlpatch"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// XMLPatchModel describes one entry of xml_patches.
+type XMLPatchModel struct {
+	Op    types.String `tfsdk:"op"`
+	Path  types.String `tfsdk:"path"`
+	Value types.String `tfsdk:"value"`
+}
+
+// xmlPatchesSchemaAttribute returns the xml_patches attribute shared by the resources
+// that define objects from generated XML.
+func xmlPatchesSchemaAttribute(planModifiers ...planmodifier.List) schema.ListNestedAttribute {
+	return schema.ListNestedAttribute{
+		Description: "Ordered operations applied to the generated XML before it is passed to libvirt, " +
+			"for settings the schema does not cover (e.g. vendor namespaces). " +
+			"Patched nodes are ignored when reading the object back, so they do not show up as drift.",
+		MarkdownDescription: "Ordered operations applied to the generated XML before it is passed to libvirt, " +
+			"for settings the schema does not cover (e.g. vendor namespaces). " +
+			"Patched nodes are ignored when reading the object back, so they do not show up as drift.\n\n" +
+			"Paths use a subset of XPath: absolute paths of element names (with namespace prefix as written, " +
+			"or `*`), optionally ending in `@attribute` or `text()`, with predicates `[n]`, `[last()]`, " +
+			"`[@attr]`, `[@attr='value']`, `[child='value']` and `[text()='value']`.",
+		Optional:      true,
+		PlanModifiers: planModifiers,
+		NestedObject: schema.NestedAttributeObject{
+			Attributes: map[string]schema.Attribute{
+				"op": schema.StringAttribute{
+					MarkdownDescription: "Operation: `add` appends `value` as children of the matched elements " +
+						"(or sets the attribute or text), `replace` replaces the matched nodes with `value`, " +
+						"`remove` removes them.",
+					Required: true,
+					Validators: []validator.String{
+						stringvalidator.OneOf(string(xmlpatch.OpAdd), string(xmlpatch.OpReplace), string(xmlpatch.OpRemove)),
+					},
+				},
+				"path": schema.StringAttribute{
+					MarkdownDescription: "XPath of the nodes to patch, e.g. `/domain/devices/disk[@device='cdrom']/target/@bus`.",
+					Required:            true,
+					Validators: []validator.String{
+						xmlPatchPathValidator{},
+					},
+				},
+				"value": schema.StringAttribute{
+					MarkdownDescription: "XML fragment for element paths, or the new value for attribute and `text()` paths. " +
+						"Not used by `remove`.",
+					Optional: true,
+				},
+			},
+		},
+	}
+}
+
+// xmlPatchPathValidator checks that a path is in the supported XPath subset.
+type xmlPatchPathValidator struct{}
+
+func (v xmlPatchPathValidator) Description(ctx context.Context) string {
+	return "value must be an absolute path in the supported XPath subset"
+}
+
+func (v xmlPatchPathValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v xmlPatchPathValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, err := xmlpatch.ParsePath(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid XML Patch Path", err.Error())
+	}
+}
+
+// xmlPatchesFromList converts the xml_patches attribute to patches.
+func xmlPatchesFromList(ctx context.Context, list types.List) ([]xmlpatch.Patch, diag.Diagnostics) {
+	if list.IsNull() || list.IsUnknown() {
+		return nil, nil
+	}
+
+	var models []XMLPatchModel
+	diags := list.ElementsAs(ctx, &models, false)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	patches := make([]xmlpatch.Patch, 0, len(models))
+	for _, m := range models {
+		patches = append(patches, xmlpatch.Patch{
+			Op:    xmlpatch.Op(m.Op.ValueString()),
+			Path:  m.Path.ValueString(),
+			Value: m.Value.ValueString(),
+		})
+	}
+	return patches, diags
+}
+
+// applyXMLPatches applies the xml_patches of a resource to its generated XML.
+func applyXMLPatches(ctx context.Context, doc string, list types.List) (string, diag.Diagnostics) {
+	patches, diags := xmlPatchesFromList(ctx, list)
+	if diags.HasError() || len(patches) == 0 {
+		return doc, diags
+	}
+
+	patched, err := xmlpatch.Apply(doc, patches)
+	if err != nil {
+		diags.AddError(
+			"Failed to Apply XML Patches",
+			fmt.Sprintf("Failed to apply xml_patches to the generated XML: %s", err),
+		)
+		return doc, diags
+	}
+	return patched, diags
+}
+
+// restoreXMLPatches resets the nodes touched by xml_patches in XML read back from
+// libvirt to their unpatched values. intended returns the unpatched generated XML and
+// is only called when there are patches.
+func restoreXMLPatches(ctx context.Context, readback string, list types.List, intended func() (string, error)) (string, error) {
+	patches, diags := xmlPatchesFromList(ctx, list)
+	if diags.HasError() {
+		return "", fmt.Errorf("invalid xml_patches: %s", diags.Errors()[0].Detail())
+	}
+	if len(patches) == 0 {
+		return readback, nil
+	}
+
+	unpatched, err := intended()
+	if err != nil {
+		return "", err
+	}
+	return xmlpatch.Restore(readback, unpatched, patches)
+}
@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+func TestDomainResourceXMLPatches(t *testing.T) {
+	client := testMockClient(t)
+
+	state, diags := testCreateResource(t, NewDomainResource(), client, map[string]any{
+		"name":   "test-patches",
+		"title":  "configured",
+		"memory": 256,
+		"type":   "kvm",
+		"os": map[string]any{
+			"type": "hvm",
+		},
+		"xml_patches": []any{
+			map[string]any{
+				"op":    "add",
+				"path":  "/domain/@xmlns:qemu",
+				"value": "http://libvirt.org/schemas/domain/qemu/1.0",
+			},
+			map[string]any{
+				"op":    "add",
+				"path":  "/domain",
+				"value": `<qemu:commandline><qemu:arg value="-no-hpet"/></qemu:commandline>`,
+			},
+			map[string]any{
+				"op":    "replace",
+				"path":  "/domain/title/text()",
+				"value": "patched",
+			},
+		},
+	})
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	domain, err := client.Libvirt().DomainLookupByName("test-patches")
+	if err != nil {
+		t.Fatalf("failed to look up domain: %v", err)
+	}
+	xmlDesc, err := client.Libvirt().DomainGetXMLDesc(domain, golibvirt.DomainXMLSecure)
+	if err != nil {
+		t.Fatalf("failed to get domain XML: %v", err)
+	}
+	if !strings.Contains(xmlDesc, "<title>patched</title>") || !strings.Contains(xmlDesc, `value="-no-hpet"`) {
+		t.Fatalf("expected the patches in the defined XML, got\n%s", xmlDesc)
+	}
+
+	// The patched title must not show up as drift
+	if title := testStateValue(t, state, path.Root("title")); title != "configured" {
+		t.Errorf("expected title from the configuration after create, got %q", title)
+	}
+	state, diags = testReadResource(t, NewDomainResource(), client, state)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if title := testStateValue(t, state, path.Root("title")); title != "configured" {
+		t.Errorf("expected title from the configuration after read, got %q", title)
+	}
+}
+
+func TestDomainResourceXMLPatchesInvalid(t *testing.T) {
+	client := testMockClient(t)
+
+	_, diags := testCreateResource(t, NewDomainResource(), client, map[string]any{
+		"name":   "test-patches-invalid",
+		"memory": 256,
+		"os": map[string]any{
+			"type": "hvm",
+		},
+		"xml_patches": []any{
+			map[string]any{
+				"op":    "replace",
+				"path":  "/domain/devices/hostdev",
+				"value": "<hostdev/>",
+			},
+		},
+	})
+	if !diags.HasError() || diags[0].Summary() != "Failed to Apply XML Patches" {
+		t.Fatalf("expected an error for a patch matching nothing, got %v", diags)
+	}
+	if _, err := client.Libvirt().DomainLookupByName("test-patches-invalid"); err == nil {
+		t.Error("expected the domain not to be defined")
+	}
+}
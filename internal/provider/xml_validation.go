@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/generated"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/relaxng"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// validateXML checks doc against the libvirt RelaxNG schema of the given name when schema
+// validation is enabled in the provider configuration. Errors are reported at the
+// Terraform attribute generated for the offending XML node, found through paths.
+func validateXML(client *libvirt.Client, schema, doc string, paths map[string]generated.XMLPath) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	err := client.ValidateXML(schema, doc)
+	if err == nil {
+		return diags
+	}
+
+	var validationErr *relaxng.ValidationError
+	if !errors.As(err, &validationErr) {
+		// The schemas are advisory: libvirt still validates what it is sent
+		detail := "Failed to validate the generated XML: " + err.Error()
+		if errors.Is(err, fs.ErrNotExist) {
+			detail = "The libvirt RelaxNG schemas are not installed on this machine: " + err.Error()
+		}
+		diags.AddWarning("Skipping XML Schema Validation", detail)
+		return diags
+	}
+
+	detail := fmt.Sprintf("The generated XML does not match the libvirt %s schema at %s: %s",
+		schema, validationErr.XPath(), validationErr.Message)
+	if attrPath := xmlErrorPath(validationErr.Path, paths); len(attrPath.Steps()) > 0 {
+		diags.AddAttributeError(attrPath, "Invalid Libvirt XML", detail)
+	} else {
+		diags.AddError("Invalid Libvirt XML", detail)
+	}
+	return diags
+}
+
+// xmlErrorPath maps the location of an XML node to the path of the Terraform attribute
+// generated for it. Nodes without an attribute of their own, such as attributes added
+// through xml_patches, map to the closest ancestor that has one.
+func xmlErrorPath(steps []relaxng.Step, paths map[string]generated.XMLPath) path.Path {
+	attrPath := path.Empty()
+	if len(steps) == 0 {
+		return attrPath
+	}
+
+	key := steps[0].Name
+	var names []string
+	for _, step := range steps[1:] {
+		if step.Attr {
+			key += ".@" + step.Name
+		} else {
+			key += "." + step.Name
+		}
+
+		entry, ok := paths[key]
+		if !ok {
+			break
+		}
+
+		// Union members and flattened attributes add names that are not nested under the
+		// parent attribute, so such paths are rebuilt without list indexes
+		entryNames := strings.Split(entry.TFPath, ".")
+		if len(entryNames) < len(names) || strings.Join(entryNames[:len(names)], ".") != strings.Join(names, ".") {
+			attrPath = path.Empty()
+			names = nil
+		}
+		for _, name := range entryNames[len(names):] {
+			attrPath = attrPath.AtName(name)
+		}
+		names = entryNames
+
+		if entry.IsList && !step.Attr {
+			attrPath = attrPath.AtListIndex(step.Index)
+		}
+	}
+	return attrPath
+}
+
+// defineDomainXML defines a domain. With schema validation enabled, libvirt is asked to
+// validate the document as well, falling back to a plain define on servers that do not
+// support the procedure or the flag. Other errors, including transport errors, are returned.
+func defineDomainXML(client *libvirt.Client, doc string) (golibvirt.Domain, error) {
+	if !client.SchemaValidationEnabled() {
+		return client.Libvirt().DomainDefineXML(doc)
+	}
+
+	domain, err := client.Libvirt().DomainDefineXMLFlags(doc, golibvirt.DomainDefineValidate)
+	if isUnsupportedError(err) {
+		return client.Libvirt().DomainDefineXML(doc)
+	}
+	return domain, err
+}
+
+// isUnsupportedError reports whether err is libvirt rejecting a procedure or flag that
+// the server does not support.
+func isUnsupportedError(err error) bool {
+	var libvirtErr golibvirt.Error
+	if !errors.As(err, &libvirtErr) {
+		return false
+	}
+	return libvirtErr.Code == uint32(golibvirt.ErrNoSupport) ||
+		(libvirtErr.Code == uint32(golibvirt.ErrInvalidArg) && strings.Contains(libvirtErr.Message, "unsupported flags"))
+}
@@ -0,0 +1,177 @@
+package provider
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/generated"
+	"github.com/dmacvicar/terraform-provider-libvirt/v2/internal/relaxng"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// testDomainSchema accepts any domain of type kvm.
+const testDomainSchema = `<grammar xmlns="http://relaxng.org/ns/structure/1.0">
+  <start>
+    <element name="domain">
+      <attribute name="type">
+        <value>kvm</value>
+      </attribute>
+      <ref name="any"/>
+    </element>
+  </start>
+  <define name="any">
+    <zeroOrMore>
+      <choice>
+        <attribute>
+          <anyName>
+            <except>
+              <name>type</name>
+            </except>
+          </anyName>
+        </attribute>
+        <text/>
+        <element>
+          <anyName/>
+          <zeroOrMore>
+            <choice>
+              <attribute>
+                <anyName/>
+              </attribute>
+              <ref name="any"/>
+            </choice>
+          </zeroOrMore>
+        </element>
+      </choice>
+    </zeroOrMore>
+  </define>
+</grammar>`
+
+func TestXMLErrorPath(t *testing.T) {
+	t.Parallel()
+
+	element := func(name string, index int) relaxng.Step { return relaxng.Step{Name: name, Index: index} }
+	attr := func(name string) relaxng.Step { return relaxng.Step{Name: name, Attr: true} }
+
+	tests := []struct {
+		name   string
+		steps  []relaxng.Step
+		expect path.Path
+	}{
+		{
+			name:   "list element attribute",
+			steps:  []relaxng.Step{element("domain", 0), element("devices", 0), element("disk", 1), element("target", 0), attr("bus")},
+			expect: path.Root("devices").AtName("disks").AtListIndex(1).AtName("target").AtName("bus"),
+		},
+		{
+			name:   "union member",
+			steps:  []relaxng.Step{element("domain", 0), element("devices", 0), element("disk", 2), element("source", 0), attr("file")},
+			expect: path.Root("devices").AtName("disks").AtListIndex(2).AtName("source").AtName("file").AtName("file"),
+		},
+		{
+			name:   "flattened attribute",
+			steps:  []relaxng.Step{element("domain", 0), element("memory", 0), attr("unit")},
+			expect: path.Root("memory_unit"),
+		},
+		{
+			name:   "unknown node maps to its ancestor",
+			steps:  []relaxng.Step{element("domain", 0), element("devices", 0), element("disk", 0), element("frobnicate", 0)},
+			expect: path.Root("devices").AtName("disks").AtListIndex(0),
+		},
+		{
+			name:   "unknown top-level node",
+			steps:  []relaxng.Step{element("domain", 0), attr("frobnicate")},
+			expect: path.Empty(),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := xmlErrorPath(tc.steps, generated.DomainXMLPaths); !got.Equal(tc.expect) {
+				t.Errorf("expected %s, got %s", tc.expect, got)
+			}
+		})
+	}
+}
+
+func TestDomainResourceSchemaValidation(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "domain.rng"), []byte(testDomainSchema), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	client := testMockClient(t)
+	client.EnableSchemaValidation(dir)
+
+	_, diags := testCreateResource(t, NewDomainResource(), client, map[string]any{
+		"name":   "test-rng-invalid",
+		"memory": 256,
+		"type":   "qemu",
+		"os":     map[string]any{"type": "hvm"},
+	})
+	if diags.ErrorsCount() != 1 || diags.Errors()[0].Summary() != "Invalid Libvirt XML" {
+		t.Fatalf("expected an invalid XML error, got %v", diags)
+	}
+	withPath, ok := diags.Errors()[0].(interface{ Path() path.Path })
+	if !ok || !withPath.Path().Equal(path.Root("type")) {
+		t.Errorf("expected the error at type, got %v", diags)
+	}
+	if _, err := client.Libvirt().DomainLookupByName("test-rng-invalid"); err == nil {
+		t.Error("expected the invalid domain not to be defined")
+	}
+
+	_, diags = testCreateResource(t, NewDomainResource(), client, map[string]any{
+		"name":   "test-rng-valid",
+		"memory": 256,
+		"type":   "kvm",
+		"os":     map[string]any{"type": "hvm"},
+	})
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+}
+
+func TestDomainResourceSchemaValidationMissingSchemas(t *testing.T) {
+	client := testMockClient(t)
+	client.EnableSchemaValidation(filepath.Join(t.TempDir(), "missing"))
+
+	_, diags := testCreateResource(t, NewDomainResource(), client, map[string]any{
+		"name":   "test-rng-missing",
+		"memory": 256,
+		"type":   "kvm",
+		"os":     map[string]any{"type": "hvm"},
+	})
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if diags.WarningsCount() != 1 || diags.Warnings()[0].Summary() != "Skipping XML Schema Validation" {
+		t.Errorf("expected a warning about the missing schemas, got %v", diags)
+	}
+}
+
+func TestIsUnsupportedError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"no support", golibvirt.Error{Code: uint32(golibvirt.ErrNoSupport), Message: "unknown procedure: 350"}, true},
+		{"unsupported flags", golibvirt.Error{Code: uint32(golibvirt.ErrInvalidArg), Message: "invalid argument: unsupported flags (0x2)"}, true},
+		{"invalid argument", golibvirt.Error{Code: uint32(golibvirt.ErrInvalidArg), Message: "invalid argument: bad name"}, false},
+		{"rpc", golibvirt.Error{Code: uint32(golibvirt.ErrRPC), Message: "connection reset"}, false},
+		{"xml", golibvirt.Error{Code: uint32(golibvirt.ErrXMLError), Message: "XML error"}, false},
+		{"other", errors.New("unsupported flags"), false},
+	}
+
+	for _, tc := range tests {
+		if got := isUnsupportedError(tc.err); got != tc.want {
+			t.Errorf("%s: expected %t, got %t", tc.name, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package relaxng
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const xsdLibrary = "http://www.w3.org/2001/XMLSchema-datatypes"
+
+// datatype is a datatype of the built-in library or of XML Schema, restricted by the
+// facets given as <param> elements. Types and facets that are not implemented accept
+// every value, so validation errs on the side of letting libvirt decide.
+type datatype struct {
+	library string
+	name    string
+
+	patterns []*regexp.Regexp
+	length   int
+	minLen   int
+	maxLen   int
+
+	minInclusive, maxInclusive *big.Rat
+	minExclusive, maxExclusive *big.Rat
+}
+
+func newDatatype(library, name string, params map[string]string) *datatype {
+	dt := &datatype{library: library, name: name, length: -1, minLen: -1, maxLen: -1}
+
+	for param, value := range params {
+		switch param {
+		case "pattern":
+			// XML Schema regular expressions are implicitly anchored. Those using
+			// constructs RE2 does not support (such as \i or class subtraction) are skipped.
+			if re, err := regexp.Compile("^(?:" + value + ")$"); err == nil {
+				dt.patterns = append(dt.patterns, re)
+			}
+		case "length":
+			dt.length = atoi(value)
+		case "minLength":
+			dt.minLen = atoi(value)
+		case "maxLength":
+			dt.maxLen = atoi(value)
+		case "minInclusive":
+			dt.minInclusive = parseRat(value)
+		case "maxInclusive":
+			dt.maxInclusive = parseRat(value)
+		case "minExclusive":
+			dt.minExclusive = parseRat(value)
+		case "maxExclusive":
+			dt.maxExclusive = parseRat(value)
+		}
+	}
+	return dt
+}
+
+func atoi(s string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+func parseRat(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return nil
+	}
+	return r
+}
+
+// normalize applies the whitespace handling of the datatype to s.
+func (dt *datatype) normalize(s string) string {
+	if dt.name == "string" {
+		return s
+	}
+	if dt.library == xsdLibrary && dt.name == "normalizedString" {
+		return strings.Map(func(r rune) rune {
+			if r == '\t' || r == '\n' || r == '\r' {
+				return ' '
+			}
+			return r
+		}, s)
+	}
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// allows reports whether s is a valid lexical value of the datatype.
+func (dt *datatype) allows(s string) bool {
+	s = dt.normalize(s)
+
+	if dt.library == xsdLibrary && !dt.allowsXSD(s) {
+		return false
+	}
+
+	for _, re := range dt.patterns {
+		if !re.MatchString(s) {
+			return false
+		}
+	}
+
+	if dt.length >= 0 || dt.minLen >= 0 || dt.maxLen >= 0 {
+		n := utf8.RuneCountInString(s)
+		if (dt.length >= 0 && n != dt.length) || (dt.minLen >= 0 && n < dt.minLen) || (dt.maxLen >= 0 && n > dt.maxLen) {
+			return false
+		}
+	}
+
+	if dt.minInclusive != nil || dt.maxInclusive != nil || dt.minExclusive != nil || dt.maxExclusive != nil {
+		v := parseRat(s)
+		if v == nil {
+			return false
+		}
+		if (dt.minInclusive != nil && v.Cmp(dt.minInclusive) < 0) ||
+			(dt.maxInclusive != nil && v.Cmp(dt.maxInclusive) > 0) ||
+			(dt.minExclusive != nil && v.Cmp(dt.minExclusive) <= 0) ||
+			(dt.maxExclusive != nil && v.Cmp(dt.maxExclusive) >= 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// integerRanges are the bounds of the XML Schema integer types, nil meaning unbounded.
+var integerRanges = map[string][2]*big.Int{
+	"integer":            {nil, nil},
+	"long":               {big.NewInt(-1 << 63), big.NewInt(1<<63 - 1)},
+	"int":                {big.NewInt(-1 << 31), big.NewInt(1<<31 - 1)},
+	"short":              {big.NewInt(-1 << 15), big.NewInt(1<<15 - 1)},
+	"byte":               {big.NewInt(-1 << 7), big.NewInt(1<<7 - 1)},
+	"nonNegativeInteger": {big.NewInt(0), nil},
+	"positiveInteger":    {big.NewInt(1), nil},
+	"nonPositiveInteger": {nil, big.NewInt(0)},
+	"negativeInteger":    {nil, big.NewInt(-1)},
+	"unsignedLong":       {big.NewInt(0), new(big.Int).SetUint64(1<<64 - 1)},
+	"unsignedInt":        {big.NewInt(0), big.NewInt(1<<32 - 1)},
+	"unsignedShort":      {big.NewInt(0), big.NewInt(1<<16 - 1)},
+	"unsignedByte":       {big.NewInt(0), big.NewInt(1<<8 - 1)},
+}
+
+func (dt *datatype) allowsXSD(s string) bool {
+	if bounds, ok := integerRanges[dt.name]; ok {
+		v, ok := new(big.Int).SetString(strings.TrimPrefix(s, "+"), 10)
+		if !ok {
+			return false
+		}
+		return (bounds[0] == nil || v.Cmp(bounds[0]) >= 0) && (bounds[1] == nil || v.Cmp(bounds[1]) <= 0)
+	}
+
+	switch dt.name {
+	case "decimal":
+		return parseRat(s) != nil && !strings.ContainsAny(s, "eE/")
+	case "double", "float":
+		if s == "INF" || s == "-INF" || s == "NaN" {
+			return true
+		}
+		_, err := strconv.ParseFloat(s, 64)
+		return err == nil || strings.Contains(err.Error(), "out of range")
+	case "boolean":
+		return s == "true" || s == "false" || s == "1" || s == "0"
+	case "hexBinary":
+		_, err := hex.DecodeString(s)
+		return err == nil
+	case "base64Binary":
+		_, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(s, " ", ""))
+		return err == nil
+	case "NCName", "ID", "IDREF":
+		return s != "" && !strings.ContainsAny(s, ": ")
+	case "Name", "NMTOKEN":
+		return s != "" && !strings.Contains(s, " ")
+	}
+	return true
+}
+
+// equal reports whether s equals the literal value of a value pattern.
+func (dt *datatype) equal(value, s string) bool {
+	value, s = dt.normalize(value), dt.normalize(s)
+	if value == s {
+		return true
+	}
+
+	if dt.library == xsdLibrary {
+		if _, ok := integerRanges[dt.name]; ok || dt.name == "decimal" || dt.name == "double" || dt.name == "float" {
+			a, b := parseRat(value), parseRat(s)
+			return a != nil && b != nil && a.Cmp(b) == 0
+		}
+		if dt.name == "boolean" {
+			return dt.allowsXSD(s) && (value == "true" || value == "1") == (s == "true" || s == "1")
+		}
+	}
+	return false
+}
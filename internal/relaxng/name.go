@@ -0,0 +1,83 @@
+package relaxng
+
+import (
+	"sort"
+	"strings"
+)
+
+// qname is a namespace-qualified XML name.
+type qname struct {
+	ns    string
+	local string
+}
+
+func (n qname) String() string {
+	if n.ns == "" {
+		return n.local
+	}
+	return "{" + n.ns + "}" + n.local
+}
+
+type nameClassKind int
+
+const (
+	nameExact nameClassKind = iota
+	nameAny
+	nameNs
+	nameChoice
+)
+
+// nameClass is the set of names an element or attribute pattern accepts.
+type nameClass struct {
+	kind   nameClassKind
+	name   qname
+	ns     string
+	except *nameClass
+
+	// choices holds the alternatives of a name choice
+	choices []nameClass
+}
+
+func (nc nameClass) contains(n qname) bool {
+	switch nc.kind {
+	case nameExact:
+		return nc.name == n
+	case nameAny:
+		return nc.except == nil || !nc.except.contains(n)
+	case nameNs:
+		return nc.ns == n.ns && (nc.except == nil || !nc.except.contains(n))
+	case nameChoice:
+		for _, c := range nc.choices {
+			if c.contains(n) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (nc nameClass) String() string {
+	switch nc.kind {
+	case nameExact:
+		return nc.name.local
+	case nameNs:
+		return "{" + nc.ns + "}*"
+	case nameChoice:
+		names := make([]string, 0, len(nc.choices))
+		for _, c := range nc.choices {
+			names = append(names, c.String())
+		}
+		return strings.Join(names, "|")
+	}
+	return "*"
+}
+
+// sortedNames returns the keys of names in order.
+func sortedNames(names map[string]bool) []string {
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,493 @@
+package relaxng
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	rngNamespace = "http://relaxng.org/ns/structure/1.0"
+	xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+)
+
+// rngNode is an element of a schema document. Elements from other namespaces, such as
+// annotations, are dropped while parsing.
+type rngNode struct {
+	name     string
+	attrs    map[string]string
+	children []*rngNode
+	text     string
+
+	// prefixes are the namespace prefixes in scope, used to resolve QNames in name
+	// attributes
+	prefixes map[string]string
+}
+
+func (n *rngNode) attr(name string) (string, bool) {
+	v, ok := n.attrs[name]
+	return strings.TrimSpace(v), ok
+}
+
+// parseRNG reads the schema document at file.
+func parseRNG(file string) (*rngNode, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	dec := xml.NewDecoder(f)
+	var stack []*rngNode
+	var root *rngNode
+	skip := 0
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if root != nil && len(stack) == 0 {
+				return root, nil
+			}
+			return nil, fmt.Errorf("parsing %s: %w", file, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if skip > 0 || t.Name.Space != rngNamespace {
+				skip++
+				continue
+			}
+
+			node := &rngNode{name: t.Name.Local, attrs: map[string]string{}, prefixes: map[string]string{"xml": xmlNamespace}}
+			if len(stack) > 0 {
+				for k, v := range stack[len(stack)-1].prefixes {
+					node.prefixes[k] = v
+				}
+			}
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == "xmlns":
+					node.prefixes[a.Name.Local] = a.Value
+				case a.Name.Space == "" && a.Name.Local != "xmlns":
+					node.attrs[a.Name.Local] = a.Value
+				}
+			}
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if skip == 0 && len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+}
+
+// grammar is the scope of define names of a schema and the files it includes.
+type grammar struct {
+	defines map[string]*define
+	start   *define
+}
+
+func (g *grammar) lookup(name string) *define {
+	d, ok := g.defines[name]
+	if !ok {
+		d = &define{name: name}
+		g.defines[name] = d
+	}
+	return d
+}
+
+// context holds the inherited attributes of the schema element being compiled.
+type context struct {
+	dir    string
+	ns     string
+	dtLib  string
+	loaded map[string]bool
+}
+
+type compiler struct {
+	b *builder
+	g *grammar
+}
+
+func (c *compiler) compileGrammarFile(file string, ctx context, overrides map[string]bool) error {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return err
+	}
+	if ctx.loaded[abs] {
+		return fmt.Errorf("schema %s includes itself", file)
+	}
+	ctx.loaded[abs] = true
+	defer delete(ctx.loaded, abs)
+
+	root, err := parseRNG(abs)
+	if err != nil {
+		return err
+	}
+	if root.name != "grammar" {
+		return fmt.Errorf("%s: included schemas must be grammars, found <%s>", file, root.name)
+	}
+
+	ctx.dir = filepath.Dir(abs)
+	return c.compileGrammarContent(root, c.inherit(root, ctx), overrides)
+}
+
+func (c *compiler) inherit(n *rngNode, ctx context) context {
+	if ns, ok := n.attrs["ns"]; ok {
+		ctx.ns = ns
+	}
+	if lib, ok := n.attr("datatypeLibrary"); ok {
+		ctx.dtLib = lib
+	}
+	return ctx
+}
+
+// compileGrammarContent adds the start and define elements of a grammar, skipping
+// those replaced by the including schema.
+func (c *compiler) compileGrammarContent(n *rngNode, ctx context, overrides map[string]bool) error {
+	for _, child := range n.children {
+		childCtx := c.inherit(child, ctx)
+
+		switch child.name {
+		case "start", "define":
+			name, _ := child.attr("name")
+			if child.name == "start" {
+				name = ""
+			}
+			if overrides[child.name+":"+name] {
+				continue
+			}
+			if err := c.compileDefine(child, childCtx, name); err != nil {
+				return err
+			}
+		case "div":
+			if err := c.compileGrammarContent(child, childCtx, overrides); err != nil {
+				return err
+			}
+		case "include":
+			href, _ := child.attr("href")
+			replaced := map[string]bool{}
+			collectOverrides(child, replaced)
+			for k := range overrides {
+				replaced[k] = true
+			}
+			if err := c.compileGrammarFile(filepath.Join(ctx.dir, href), childCtx, replaced); err != nil {
+				return err
+			}
+			if err := c.compileGrammarContent(child, childCtx, overrides); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unexpected <%s> in grammar", child.name)
+		}
+	}
+	return nil
+}
+
+func collectOverrides(n *rngNode, overrides map[string]bool) {
+	for _, child := range n.children {
+		switch child.name {
+		case "start":
+			overrides["start:"] = true
+		case "define":
+			name, _ := child.attr("name")
+			overrides["define:"+name] = true
+		case "div":
+			collectOverrides(child, overrides)
+		}
+	}
+}
+
+func (c *compiler) compileDefine(n *rngNode, ctx context, name string) error {
+	p, err := c.compileGroup(n.children, ctx)
+	if err != nil {
+		return fmt.Errorf("define %q: %w", name, err)
+	}
+
+	d := c.g.start
+	if n.name == "define" {
+		d = c.g.lookup(name)
+	} else if d == nil {
+		d = &define{name: "start"}
+		c.g.start = d
+	}
+
+	combine, _ := n.attr("combine")
+	if d.defined && combine == "" && d.combine == "" {
+		return fmt.Errorf("%s %q is defined more than once without combine", n.name, name)
+	}
+	if combine != "" {
+		d.combine = combine
+	}
+	d.defined = true
+	d.parts = append(d.parts, p)
+	return nil
+}
+
+// finish combines the parts of every define and checks that all references resolve.
+func (c *compiler) finish() (*pattern, error) {
+	defines := make([]*define, 0, len(c.g.defines)+1)
+	for _, d := range c.g.defines {
+		defines = append(defines, d)
+	}
+	if c.g.start == nil {
+		return nil, fmt.Errorf("schema has no start pattern")
+	}
+	defines = append(defines, c.g.start)
+
+	for _, d := range defines {
+		if !d.defined {
+			return nil, fmt.Errorf("reference to undefined pattern %q", d.name)
+		}
+		p := d.parts[0]
+		for _, part := range d.parts[1:] {
+			if d.combine == "interleave" {
+				p = c.b.interleave(p, part)
+			} else {
+				p = c.b.choice(p, part)
+			}
+		}
+		d.pattern = p
+	}
+
+	// A define that only refers to itself would make deref loop forever
+	for _, d := range defines {
+		seen := map[*define]bool{}
+		for p := d.pattern; p.kind == kindRef; p = p.ref.pattern {
+			if seen[p.ref] {
+				return nil, fmt.Errorf("pattern %q refers to itself", d.name)
+			}
+			seen[p.ref] = true
+		}
+	}
+	return c.g.start.pattern, nil
+}
+
+// compileGroup compiles the children of n as a group.
+func (c *compiler) compileGroup(children []*rngNode, ctx context) (*pattern, error) {
+	return c.compileSequence(children, ctx, c.b.group)
+}
+
+func (c *compiler) compileSequence(children []*rngNode, ctx context, combine func(p1, p2 *pattern) *pattern) (*pattern, error) {
+	var result *pattern
+	for _, child := range children {
+		p, err := c.compilePattern(child, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			result = p
+		} else {
+			result = combine(result, p)
+		}
+	}
+	if result == nil {
+		return emptyPattern, nil
+	}
+	return result, nil
+}
+
+func (c *compiler) compilePattern(n *rngNode, ctx context) (*pattern, error) {
+	ctx = c.inherit(n, ctx)
+
+	switch n.name {
+	case "element", "attribute":
+		children := n.children
+		var nc nameClass
+		if name, ok := n.attr("name"); ok {
+			nc = nameClass{kind: nameExact, name: c.resolveName(n, ctx, name, n.name == "attribute")}
+		} else {
+			if len(children) == 0 {
+				return nil, fmt.Errorf("<%s> without a name", n.name)
+			}
+			var err error
+			if nc, err = c.compileNameClass(children[0], ctx, n.name == "attribute"); err != nil {
+				return nil, err
+			}
+			children = children[1:]
+		}
+
+		if n.name == "attribute" {
+			content := textPattern
+			if len(children) > 0 {
+				var err error
+				if content, err = c.compileGroup(children, ctx); err != nil {
+					return nil, err
+				}
+			}
+			return &pattern{kind: kindAttribute, name: nc, p1: content}, nil
+		}
+
+		content, err := c.compileGroup(children, ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &pattern{kind: kindElement, name: nc, p1: content}, nil
+	case "group":
+		return c.compileGroup(n.children, ctx)
+	case "interleave":
+		return c.compileSequence(n.children, ctx, c.b.interleave)
+	case "choice":
+		return c.compileSequence(n.children, ctx, c.b.choice)
+	case "optional":
+		p, err := c.compileGroup(n.children, ctx)
+		if err != nil {
+			return nil, err
+		}
+		return c.b.choice(p, emptyPattern), nil
+	case "zeroOrMore", "oneOrMore":
+		p, err := c.compileGroup(n.children, ctx)
+		if err != nil {
+			return nil, err
+		}
+		p = c.b.oneOrMore(p)
+		if n.name == "zeroOrMore" {
+			p = c.b.choice(p, emptyPattern)
+		}
+		return p, nil
+	case "list":
+		p, err := c.compileGroup(n.children, ctx)
+		if err != nil {
+			return nil, err
+		}
+		return c.b.list(p), nil
+	case "mixed":
+		p, err := c.compileGroup(n.children, ctx)
+		if err != nil {
+			return nil, err
+		}
+		return c.b.interleave(p, textPattern), nil
+	case "ref":
+		name, _ := n.attr("name")
+		return &pattern{kind: kindRef, ref: c.g.lookup(name)}, nil
+	case "externalRef":
+		href, _ := n.attr("href")
+		return c.compileExternal(filepath.Join(ctx.dir, href), ctx)
+	case "empty":
+		return emptyPattern, nil
+	case "notAllowed":
+		return notAllowedPattern, nil
+	case "text":
+		return textPattern, nil
+	case "value":
+		typ, ok := n.attr("type")
+		lib := ctx.dtLib
+		if !ok {
+			typ, lib = "token", ""
+		}
+		return &pattern{kind: kindValue, data: newDatatype(lib, typ, nil), value: n.text}, nil
+	case "data":
+		typ, _ := n.attr("type")
+		params := map[string]string{}
+		var except *pattern
+		for _, child := range n.children {
+			switch child.name {
+			case "param":
+				name, _ := child.attr("name")
+				params[name] = child.text
+			case "except":
+				var err error
+				if except, err = c.compileSequence(child.children, ctx, c.b.choice); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return &pattern{kind: kindData, data: newDatatype(ctx.dtLib, typ, params), except: except}, nil
+	}
+	return nil, fmt.Errorf("unsupported pattern <%s>", n.name)
+}
+
+// compileExternal compiles the schema at file as a pattern with its own grammar.
+func (c *compiler) compileExternal(file string, ctx context) (*pattern, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	root, err := parseRNG(abs)
+	if err != nil {
+		return nil, err
+	}
+	ctx.dir = filepath.Dir(abs)
+	ctx = c.inherit(root, ctx)
+
+	ext := &compiler{b: c.b, g: &grammar{defines: map[string]*define{}}}
+	if root.name == "grammar" {
+		err = ext.compileGrammarContent(root, ctx, nil)
+	} else {
+		// A schema that is a single pattern is a grammar with that pattern as start
+		var p *pattern
+		if p, err = ext.compilePattern(root, ctx); err == nil {
+			ext.g.start = &define{name: "start", parts: []*pattern{p}, defined: true}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ext.finish()
+}
+
+func (c *compiler) resolveName(n *rngNode, ctx context, name string, attribute bool) qname {
+	if prefix, local, ok := strings.Cut(name, ":"); ok {
+		return qname{ns: n.prefixes[prefix], local: local}
+	}
+	// Unprefixed attribute names are in no namespace unless ns is given explicitly
+	if attribute {
+		return qname{ns: n.attrs["ns"], local: name}
+	}
+	return qname{ns: ctx.ns, local: name}
+}
+
+func (c *compiler) compileNameClass(n *rngNode, ctx context, attribute bool) (nameClass, error) {
+	ctx = c.inherit(n, ctx)
+
+	switch n.name {
+	case "name":
+		return nameClass{kind: nameExact, name: c.resolveName(n, ctx, strings.TrimSpace(n.text), attribute && n.attrs["ns"] == "")}, nil
+	case "anyName", "nsName":
+		nc := nameClass{kind: nameAny}
+		if n.name == "nsName" {
+			nc = nameClass{kind: nameNs, ns: ctx.ns}
+		}
+		for _, child := range n.children {
+			if child.name != "except" {
+				continue
+			}
+			except := nameClass{kind: nameChoice}
+			for _, e := range child.children {
+				ec, err := c.compileNameClass(e, ctx, attribute)
+				if err != nil {
+					return nameClass{}, err
+				}
+				except.choices = append(except.choices, ec)
+			}
+			nc.except = &except
+		}
+		return nc, nil
+	case "choice":
+		nc := nameClass{kind: nameChoice}
+		for _, child := range n.children {
+			cc, err := c.compileNameClass(child, ctx, attribute)
+			if err != nil {
+				return nameClass{}, err
+			}
+			nc.choices = append(nc.choices, cc)
+		}
+		return nc, nil
+	}
+	return nameClass{}, fmt.Errorf("unsupported name class <%s>", n.name)
+}
@@ -0,0 +1,390 @@
+package relaxng
+
+import (
+	"strings"
+)
+
+type patternKind int
+
+const (
+	kindEmpty patternKind = iota
+	kindNotAllowed
+	kindText
+	kindChoice
+	kindInterleave
+	kindGroup
+	kindOneOrMore
+	kindList
+	kindData
+	kindValue
+	kindAttribute
+	kindElement
+	kindAfter
+	kindRef
+)
+
+// pattern is a node of a simplified RELAX NG pattern. Apart from the cached nullability,
+// patterns are immutable once built; composite patterns are interned by their builder so
+// that structurally equal patterns share a pointer.
+type pattern struct {
+	kind patternKind
+	p1   *pattern
+	p2   *pattern
+
+	// name is the name class of element and attribute patterns
+	name nameClass
+
+	// data holds the datatype of data and value patterns; except is the pattern excluded
+	// from a data pattern and value the literal of a value pattern
+	data   *datatype
+	except *pattern
+	value  string
+
+	// ref is the definition a ref pattern points to
+	ref *define
+
+	nullable    bool
+	nullableSet bool
+}
+
+// define is a named pattern of a grammar. Refs point to defines, which lets grammars be
+// recursive through elements.
+type define struct {
+	name    string
+	pattern *pattern
+
+	combine string
+	parts   []*pattern
+	defined bool
+}
+
+// deref follows refs to the pattern they name.
+func (p *pattern) deref() *pattern {
+	for p.kind == kindRef {
+		p = p.ref.pattern
+	}
+	return p
+}
+
+var (
+	emptyPattern      = &pattern{kind: kindEmpty}
+	notAllowedPattern = &pattern{kind: kindNotAllowed}
+	textPattern       = &pattern{kind: kindText}
+)
+
+type internKey struct {
+	kind   patternKind
+	p1, p2 *pattern
+}
+
+// builder constructs patterns, applying the simplification rules of the RELAX NG
+// derivative algorithm and interning the results.
+type builder struct {
+	interned map[internKey]*pattern
+}
+
+func newBuilder() *builder {
+	return &builder{interned: make(map[internKey]*pattern)}
+}
+
+func (b *builder) intern(kind patternKind, p1, p2 *pattern) *pattern {
+	key := internKey{kind: kind, p1: p1, p2: p2}
+	if p, ok := b.interned[key]; ok {
+		return p
+	}
+	p := &pattern{kind: kind, p1: p1, p2: p2}
+	b.interned[key] = p
+	return p
+}
+
+func (b *builder) choice(p1, p2 *pattern) *pattern {
+	switch {
+	case p1 == notAllowedPattern:
+		return p2
+	case p2 == notAllowedPattern:
+		return p1
+	case p1 == p2, choiceContains(p1, p2):
+		return p1
+	case choiceContains(p2, p1):
+		return p2
+	}
+	return b.intern(kindChoice, p1, p2)
+}
+
+// choiceContains reports whether p is one of the alternatives of the choice c.
+func choiceContains(c, p *pattern) bool {
+	for c.kind == kindChoice {
+		if c.p2 == p || (c.p2.kind == kindChoice && choiceContains(c.p2, p)) {
+			return true
+		}
+		c = c.p1
+	}
+	return c == p
+}
+
+func (b *builder) group(p1, p2 *pattern) *pattern {
+	switch {
+	case p1 == notAllowedPattern, p2 == notAllowedPattern:
+		return notAllowedPattern
+	case p1 == emptyPattern:
+		return p2
+	case p2 == emptyPattern:
+		return p1
+	}
+	return b.intern(kindGroup, p1, p2)
+}
+
+func (b *builder) interleave(p1, p2 *pattern) *pattern {
+	switch {
+	case p1 == notAllowedPattern, p2 == notAllowedPattern:
+		return notAllowedPattern
+	case p1 == emptyPattern:
+		return p2
+	case p2 == emptyPattern:
+		return p1
+	}
+	return b.intern(kindInterleave, p1, p2)
+}
+
+func (b *builder) after(p1, p2 *pattern) *pattern {
+	if p1 == notAllowedPattern || p2 == notAllowedPattern {
+		return notAllowedPattern
+	}
+	return b.intern(kindAfter, p1, p2)
+}
+
+func (b *builder) oneOrMore(p *pattern) *pattern {
+	if p == notAllowedPattern || p == emptyPattern {
+		return p
+	}
+	return b.intern(kindOneOrMore, p, nil)
+}
+
+func (b *builder) list(p *pattern) *pattern {
+	if p == notAllowedPattern {
+		return p
+	}
+	return b.intern(kindList, p, nil)
+}
+
+// nullable reports whether p matches the empty sequence.
+func nullable(p *pattern) bool {
+	p = p.deref()
+	switch p.kind {
+	case kindEmpty, kindText:
+		return true
+	case kindNotAllowed:
+		return false
+	}
+	if p.nullableSet {
+		return p.nullable
+	}
+
+	var n bool
+	switch p.kind {
+	case kindChoice:
+		n = nullable(p.p1) || nullable(p.p2)
+	case kindGroup, kindInterleave:
+		n = nullable(p.p1) && nullable(p.p2)
+	case kindOneOrMore:
+		n = nullable(p.p1)
+	}
+	p.nullable, p.nullableSet = n, true
+	return n
+}
+
+// The derivative functions below follow "An algorithm for RELAX NG validation" by
+// James Clark. Each returns the pattern that matches what remains of the document after
+// the given event; notAllowed means the event is invalid at that point.
+
+func (b *builder) textDeriv(p *pattern, s string) *pattern {
+	p = p.deref()
+	switch p.kind {
+	case kindChoice:
+		return b.choice(b.textDeriv(p.p1, s), b.textDeriv(p.p2, s))
+	case kindInterleave:
+		return b.choice(b.interleave(b.textDeriv(p.p1, s), p.p2), b.interleave(p.p1, b.textDeriv(p.p2, s)))
+	case kindGroup:
+		g := b.group(b.textDeriv(p.p1, s), p.p2)
+		if nullable(p.p1) {
+			return b.choice(g, b.textDeriv(p.p2, s))
+		}
+		return g
+	case kindAfter:
+		return b.after(b.textDeriv(p.p1, s), p.p2)
+	case kindOneOrMore:
+		return b.group(b.textDeriv(p.p1, s), b.choice(p, emptyPattern))
+	case kindText:
+		return p
+	case kindValue:
+		if p.data.equal(p.value, s) {
+			return emptyPattern
+		}
+	case kindData:
+		if p.data.allows(s) && (p.except == nil || !nullable(b.textDeriv(p.except, s))) {
+			return emptyPattern
+		}
+	case kindList:
+		q := p.p1
+		for _, word := range strings.Fields(s) {
+			q = b.textDeriv(q, word)
+		}
+		if nullable(q) {
+			return emptyPattern
+		}
+	}
+	return notAllowedPattern
+}
+
+// applyAfter applies f to the second pattern of every after pattern in p.
+func (b *builder) applyAfter(f func(*pattern) *pattern, p *pattern) *pattern {
+	switch p.kind {
+	case kindAfter:
+		return b.after(p.p1, f(p.p2))
+	case kindChoice:
+		return b.choice(b.applyAfter(f, p.p1), b.applyAfter(f, p.p2))
+	}
+	return notAllowedPattern
+}
+
+func (b *builder) startTagOpenDeriv(p *pattern, n qname) *pattern {
+	p = p.deref()
+	switch p.kind {
+	case kindChoice:
+		return b.choice(b.startTagOpenDeriv(p.p1, n), b.startTagOpenDeriv(p.p2, n))
+	case kindElement:
+		if p.name.contains(n) {
+			return b.after(p.p1, emptyPattern)
+		}
+	case kindInterleave:
+		return b.choice(
+			b.applyAfter(func(q *pattern) *pattern { return b.interleave(q, p.p2) }, b.startTagOpenDeriv(p.p1, n)),
+			b.applyAfter(func(q *pattern) *pattern { return b.interleave(p.p1, q) }, b.startTagOpenDeriv(p.p2, n)),
+		)
+	case kindOneOrMore:
+		return b.applyAfter(func(q *pattern) *pattern {
+			return b.group(q, b.choice(p, emptyPattern))
+		}, b.startTagOpenDeriv(p.p1, n))
+	case kindGroup:
+		x := b.applyAfter(func(q *pattern) *pattern { return b.group(q, p.p2) }, b.startTagOpenDeriv(p.p1, n))
+		if nullable(p.p1) {
+			return b.choice(x, b.startTagOpenDeriv(p.p2, n))
+		}
+		return x
+	case kindAfter:
+		return b.applyAfter(func(q *pattern) *pattern { return b.after(q, p.p2) }, b.startTagOpenDeriv(p.p1, n))
+	}
+	return notAllowedPattern
+}
+
+func (b *builder) attDeriv(p *pattern, n qname, value string) *pattern {
+	p = p.deref()
+	switch p.kind {
+	case kindAfter:
+		return b.after(b.attDeriv(p.p1, n, value), p.p2)
+	case kindChoice:
+		return b.choice(b.attDeriv(p.p1, n, value), b.attDeriv(p.p2, n, value))
+	case kindGroup:
+		return b.choice(b.group(b.attDeriv(p.p1, n, value), p.p2), b.group(p.p1, b.attDeriv(p.p2, n, value)))
+	case kindInterleave:
+		return b.choice(b.interleave(b.attDeriv(p.p1, n, value), p.p2), b.interleave(p.p1, b.attDeriv(p.p2, n, value)))
+	case kindOneOrMore:
+		return b.group(b.attDeriv(p.p1, n, value), b.choice(p, emptyPattern))
+	case kindAttribute:
+		if p.name.contains(n) && b.valueMatch(p.p1, value) {
+			return emptyPattern
+		}
+	}
+	return notAllowedPattern
+}
+
+func (b *builder) valueMatch(p *pattern, s string) bool {
+	return (nullable(p) && strings.TrimSpace(s) == "") || nullable(b.textDeriv(p, s))
+}
+
+func (b *builder) startTagCloseDeriv(p *pattern) *pattern {
+	p = p.deref()
+	switch p.kind {
+	case kindAfter:
+		return b.after(b.startTagCloseDeriv(p.p1), p.p2)
+	case kindChoice:
+		return b.choice(b.startTagCloseDeriv(p.p1), b.startTagCloseDeriv(p.p2))
+	case kindGroup:
+		return b.group(b.startTagCloseDeriv(p.p1), b.startTagCloseDeriv(p.p2))
+	case kindInterleave:
+		return b.interleave(b.startTagCloseDeriv(p.p1), b.startTagCloseDeriv(p.p2))
+	case kindOneOrMore:
+		return b.oneOrMore(b.startTagCloseDeriv(p.p1))
+	case kindAttribute:
+		return notAllowedPattern
+	}
+	return p
+}
+
+func (b *builder) endTagDeriv(p *pattern) *pattern {
+	switch p.kind {
+	case kindChoice:
+		return b.choice(b.endTagDeriv(p.p1), b.endTagDeriv(p.p2))
+	case kindAfter:
+		if nullable(p.p1) {
+			return p.p2
+		}
+	}
+	return notAllowedPattern
+}
+
+// expected collects the names of the elements (or, with attributes set, the attributes)
+// that p accepts next. It is used for error messages only.
+func expected(p *pattern, attributes bool, names map[string]bool, seen map[*pattern]bool) {
+	p = p.deref()
+	if seen[p] {
+		return
+	}
+	seen[p] = true
+
+	switch p.kind {
+	case kindChoice, kindInterleave:
+		expected(p.p1, attributes, names, seen)
+		expected(p.p2, attributes, names, seen)
+	case kindGroup:
+		expected(p.p1, attributes, names, seen)
+		if attributes || nullable(p.p1) {
+			expected(p.p2, attributes, names, seen)
+		}
+	case kindOneOrMore, kindAfter:
+		expected(p.p1, attributes, names, seen)
+	case kindElement:
+		if !attributes {
+			names[p.name.String()] = true
+		}
+	case kindAttribute:
+		if attributes {
+			names[p.name.String()] = true
+		}
+	}
+}
+
+// requiredAttributes collects the attributes p cannot do without. It is used for error
+// messages only.
+func requiredAttributes(p *pattern, names map[string]bool) {
+	p = p.deref()
+	switch p.kind {
+	case kindAfter, kindOneOrMore:
+		requiredAttributes(p.p1, names)
+	case kindGroup, kindInterleave:
+		requiredAttributes(p.p1, names)
+		requiredAttributes(p.p2, names)
+	case kindChoice:
+		// Only attributes required by both alternatives are certainly missing
+		left, right := map[string]bool{}, map[string]bool{}
+		requiredAttributes(p.p1, left)
+		requiredAttributes(p.p2, right)
+		for name := range left {
+			if right[name] {
+				names[name] = true
+			}
+		}
+	case kindAttribute:
+		names[p.name.String()] = true
+	}
+}
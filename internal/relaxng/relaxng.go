@@ -0,0 +1,259 @@
+// Package relaxng validates XML documents against RELAX NG schemas such as the ones
+// libvirt installs in /usr/share/libvirt/schemas.
+//
+// Schemas use the XML syntax. Grammars with include, externalRef, div and combined
+// defines are supported, as are the built-in and XML Schema datatype libraries; nested
+// grammars (parentRef) are not. Validation uses the derivative algorithm described by
+// James Clark and stops at the first error, which is reported with the location of the
+// offending element or attribute.
+package relaxng
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Schema is a compiled RELAX NG schema. It is safe for concurrent use.
+type Schema struct {
+	mu    sync.Mutex
+	start *pattern
+}
+
+// LoadFile compiles the schema at file together with the files it includes.
+func LoadFile(file string) (*Schema, error) {
+	c := &compiler{b: newBuilder()}
+	start, err := c.compileExternal(file, context{loaded: map[string]bool{}})
+	if err != nil {
+		return nil, fmt.Errorf("loading schema %s: %w", file, err)
+	}
+	return &Schema{start: start}, nil
+}
+
+// Step is one element or attribute of the location of a validation error.
+type Step struct {
+	// Name is the local name of the element or attribute
+	Name string
+
+	// Index is the position of the element among its siblings of the same name,
+	// starting at 0
+	Index int
+
+	// Attr indicates the step is an attribute
+	Attr bool
+}
+
+// ValidationError describes where and why a document does not match a schema.
+type ValidationError struct {
+	// Path is the location of the offending node, starting at the document element
+	Path []Step
+
+	// Message explains the error
+	Message string
+}
+
+// XPath returns the location of the error as an XPath expression.
+func (e *ValidationError) XPath() string {
+	var sb strings.Builder
+	for _, step := range e.Path {
+		sb.WriteString("/")
+		if step.Attr {
+			sb.WriteString("@" + step.Name)
+			continue
+		}
+		sb.WriteString(step.Name)
+		if step.Index > 0 {
+			fmt.Fprintf(&sb, "[%d]", step.Index+1)
+		}
+	}
+	return sb.String()
+}
+
+func (e *ValidationError) Error() string {
+	return e.XPath() + ": " + e.Message
+}
+
+// node is an element of the document being validated.
+type node struct {
+	name     qname
+	attrs    []xml.Attr
+	children []any // *node or string
+}
+
+func parseDocument(doc []byte) (*node, error) {
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	var stack []*node
+	var root *node
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			if root == nil {
+				return nil, fmt.Errorf("document has no root element")
+			}
+			return root, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &node{name: qname{ns: t.Name.Space, local: t.Name.Local}}
+			for _, a := range t.Attr {
+				if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") {
+					continue
+				}
+				n.attrs = append(n.attrs, a)
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			} else {
+				root = n
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) == 0 {
+				continue
+			}
+			parent := stack[len(stack)-1]
+			// Text split by comments or processing instructions is one text node
+			if last := len(parent.children) - 1; last >= 0 {
+				if s, ok := parent.children[last].(string); ok {
+					parent.children[last] = s + string(t)
+					continue
+				}
+			}
+			parent.children = append(parent.children, string(t))
+		}
+	}
+}
+
+// Validate checks doc against the schema. A document that does not match is reported
+// as a *ValidationError; a document that is not well-formed XML as a plain error.
+func (s *Schema) Validate(doc []byte) error {
+	root, err := parseDocument(doc)
+	if err != nil {
+		return fmt.Errorf("parsing XML: %w", err)
+	}
+
+	// Nullability is cached on the schema patterns, so validations are serialized
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v := &validator{b: newBuilder()}
+	p, verr := v.element(s.start, root, nil, 0)
+	if verr != nil {
+		return verr
+	}
+	if !nullable(p) {
+		return &ValidationError{Path: []Step{{Name: root.name.local}}, Message: "document is incomplete"}
+	}
+	return nil
+}
+
+type validator struct {
+	b *builder
+}
+
+// element returns the derivative of p with respect to the element n, or the first error
+// found inside it. parent is the location of the parent element.
+func (v *validator) element(p *pattern, n *node, parent []Step, index int) (*pattern, *ValidationError) {
+	loc := append(append([]Step(nil), parent...), Step{Name: n.name.local, Index: index})
+	fail := func(loc []Step, format string, args ...any) (*pattern, *ValidationError) {
+		return nil, &ValidationError{Path: loc, Message: fmt.Sprintf(format, args...)}
+	}
+
+	q := v.b.startTagOpenDeriv(p, n.name)
+	if q == notAllowedPattern {
+		if names := expectedNames(p, false); len(names) > 0 {
+			return fail(loc, "element %q is not allowed here; expected one of: %s", n.name.local, strings.Join(names, ", "))
+		}
+		return fail(loc, "element %q is not allowed here", n.name.local)
+	}
+
+	for _, a := range n.attrs {
+		next := v.b.attDeriv(q, qname{ns: a.Name.Space, local: a.Name.Local}, a.Value)
+		if next == notAllowedPattern {
+			attrLoc := append(append([]Step(nil), loc...), Step{Name: a.Name.Local, Attr: true})
+			if !slices.Contains(expectedNames(q, true), a.Name.Local) {
+				return fail(attrLoc, "attribute %q is not allowed on element %q", a.Name.Local, n.name.local)
+			}
+			return fail(attrLoc, "invalid value %q for attribute %q", a.Value, a.Name.Local)
+		}
+		q = next
+	}
+
+	closed := v.b.startTagCloseDeriv(q)
+	if closed == notAllowedPattern {
+		missing := map[string]bool{}
+		requiredAttributes(q, missing)
+		if len(missing) > 0 {
+			return fail(loc, "element %q is missing required attributes: %s", n.name.local, strings.Join(sortedNames(missing), ", "))
+		}
+		return fail(loc, "element %q is missing required attributes", n.name.local)
+	}
+	q = closed
+
+	hasElements := false
+	for _, child := range n.children {
+		if _, ok := child.(*node); ok {
+			hasElements = true
+			break
+		}
+	}
+
+	counts := map[string]int{}
+	for _, child := range n.children {
+		switch c := child.(type) {
+		case *node:
+			var err *ValidationError
+			if q, err = v.element(q, c, loc, counts[c.name.local]); err != nil {
+				return nil, err
+			}
+			counts[c.name.local]++
+		case string:
+			whitespace := strings.TrimSpace(c) == ""
+			if whitespace && hasElements {
+				continue
+			}
+			next := v.b.textDeriv(q, c)
+			if whitespace {
+				next = v.b.choice(q, next)
+			}
+			if next == notAllowedPattern {
+				if hasElements {
+					return fail(loc, "text %q is not allowed in element %q", strings.TrimSpace(c), n.name.local)
+				}
+				return fail(loc, "invalid value %q for element %q", strings.TrimSpace(c), n.name.local)
+			}
+			q = next
+		}
+	}
+	if len(n.children) == 0 {
+		q = v.b.choice(q, v.b.textDeriv(q, ""))
+	}
+
+	ended := v.b.endTagDeriv(q)
+	if ended == notAllowedPattern {
+		if names := expectedNames(q, false); len(names) > 0 {
+			return fail(loc, "element %q is incomplete; expected one of: %s", n.name.local, strings.Join(names, ", "))
+		}
+		return fail(loc, "element %q is incomplete", n.name.local)
+	}
+	return ended, nil
+}
+
+func expectedNames(p *pattern, attributes bool) []string {
+	names := map[string]bool{}
+	expected(p, attributes, names, map[*pattern]bool{})
+	return sortedNames(names)
+}
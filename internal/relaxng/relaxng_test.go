@@ -0,0 +1,205 @@
+package relaxng
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+const testDomain = `<domain type="kvm" xmlns:qemu="http://libvirt.org/schemas/domain/qemu/1.0">
+  <name>test</name>
+  <uuid>5c1f3bd4-2d5c-4a43-9c3e-0e9a6d0f8a1b</uuid>
+  <memory unit="MiB">512</memory>
+  <features>
+    <pae/>
+    <acpi/>
+  </features>
+  <devices>
+    <disk type="file">
+      <source file="/var/lib/libvirt/images/test.qcow2"/>
+      <target dev="vda" bus="virtio"/>
+    </disk>
+    <interface type="network">
+      <source network="default"/>
+      <mtu size="1500"/>
+    </interface>
+    <disk type="block" readonly="yes">
+      <target dev="sda" bus="sata"/>
+    </disk>
+  </devices>
+  <metadata>
+    <app:info xmlns:app="https://example.com/app" version="1">
+      <owner>ops</owner>
+    </app:info>
+  </metadata>
+  <qemu:commandline>
+    <qemu:arg value="-no-hpet"/>
+  </qemu:commandline>
+</domain>`
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	schema, err := LoadFile("testdata/domain.rng")
+	if err != nil {
+		t.Fatalf("failed to load schema: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		old     string
+		new     string
+		xpath   string
+		message string
+	}{
+		{name: "valid document"},
+		{
+			name:    "invalid attribute value",
+			old:     `<domain type="kvm"`,
+			new:     `<domain type="xen"`,
+			xpath:   "/domain/@type",
+			message: `invalid value "xen" for attribute "type"`,
+		},
+		{
+			name:    "unknown attribute",
+			old:     `<disk type="file">`,
+			new:     `<disk type="file" cache="none">`,
+			xpath:   "/domain/devices/disk/@cache",
+			message: `attribute "cache" is not allowed on element "disk"`,
+		},
+		{
+			name:    "value from an overridden define",
+			old:     `bus="sata"`,
+			new:     `bus="ide"`,
+			xpath:   "/domain/devices/disk[2]/target/@bus",
+			message: `invalid value "ide"`,
+		},
+		{
+			name:    "datatype facet",
+			old:     `dev="sda"`,
+			new:     `dev="verylongname0"`,
+			xpath:   "/domain/devices/disk[2]/target/@dev",
+			message: `invalid value "verylongname0"`,
+		},
+		{
+			name:    "XML Schema datatype",
+			old:     `<mtu size="1500"/>`,
+			new:     `<mtu size="0"/>`,
+			xpath:   "/domain/devices/interface/mtu/@size",
+			message: `invalid value "0"`,
+		},
+		{
+			name:    "invalid element value",
+			old:     `>512<`,
+			new:     `>lots<`,
+			xpath:   "/domain/memory",
+			message: `invalid value "lots" for element "memory"`,
+		},
+		{
+			name:    "unexpected element",
+			old:     `<mtu size="1500"/>`,
+			new:     `<mtu size="1500"/><model type="virtio"/>`,
+			xpath:   "/domain/devices/interface/model",
+			message: `element "model" is not allowed here`,
+		},
+		{
+			name:    "missing required element",
+			old:     `<name>test</name>`,
+			xpath:   "/domain",
+			message: `element "domain" is incomplete; expected one of:`,
+		},
+		{
+			name:    "missing required attribute",
+			old:     `<interface type="network">`,
+			new:     `<interface>`,
+			xpath:   "/domain/devices/interface",
+			message: `missing required attributes: type`,
+		},
+		{
+			name:    "element in the wrong namespace",
+			old:     `<qemu:commandline>`,
+			new:     `<qemu:commandline xmlns:qemu="https://example.com/other">`,
+			xpath:   "/domain/commandline",
+			message: `element "commandline" is not allowed here`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := testDomain
+			if tc.old != "" {
+				doc = strings.Replace(doc, tc.old, tc.new, 1)
+			}
+
+			err := schema.Validate([]byte(doc))
+			if tc.xpath == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("expected a validation error, got %v", err)
+			}
+			if verr.XPath() != tc.xpath {
+				t.Errorf("expected error at %s, got %v", tc.xpath, verr)
+			}
+			if !strings.Contains(verr.Message, tc.message) {
+				t.Errorf("expected message containing %q, got %q", tc.message, verr.Message)
+			}
+		})
+	}
+}
+
+func TestValidateMalformed(t *testing.T) {
+	t.Parallel()
+
+	schema, err := LoadFile("testdata/domain.rng")
+	if err != nil {
+		t.Fatalf("failed to load schema: %v", err)
+	}
+
+	err = schema.Validate([]byte(`<domain type="kvm"><name>test</domain>`))
+	var verr *ValidationError
+	if err == nil || errors.As(err, &verr) {
+		t.Fatalf("expected a parse error, got %v", err)
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	tests := map[string]string{
+		"undefined reference": `<grammar xmlns="http://relaxng.org/ns/structure/1.0">
+  <start><ref name="missing"/></start>
+</grammar>`,
+		"duplicate define": `<grammar xmlns="http://relaxng.org/ns/structure/1.0">
+  <start><ref name="a"/></start>
+  <define name="a"><element name="a"><empty/></element></define>
+  <define name="a"><element name="b"><empty/></element></define>
+</grammar>`,
+		"nested grammar": `<grammar xmlns="http://relaxng.org/ns/structure/1.0">
+  <start><grammar><start><parentRef name="a"/></start></grammar></start>
+</grammar>`,
+	}
+
+	for name, schema := range tests {
+		file := dir + "/" + strings.ReplaceAll(name, " ", "_") + ".rng"
+		if err := os.WriteFile(file, []byte(schema), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFile(file); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if _, err := LoadFile(dir + "/missing.rng"); err == nil {
+		t.Error("expected an error for a missing schema")
+	}
+}
@@ -0,0 +1,112 @@
+package xmlpatch
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+)
+
+// Canonicalize returns doc in a canonical form for comparison. Comments, processing
+// instructions and whitespace between elements are dropped, text is trimmed, attributes
+// are sorted by name and sibling elements are sorted by name, keeping the order of
+// elements with the same name (e.g. disks). The result is indented by two spaces so that
+// differences stay readable in a plan.
+func Canonicalize(doc string) (string, error) {
+	tree, err := parse(doc)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	for _, elem := range tree.elements() {
+		writeCanonical(&b, elem, 0)
+	}
+	return b.String(), nil
+}
+
+func writeCanonical(b *bytes.Buffer, n *node, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	attrs := append([]attribute{}, n.attrs...)
+	sort.SliceStable(attrs, func(i, j int) bool { return attrs[i].name < attrs[j].name })
+
+	b.WriteString(indent + "<" + n.name)
+	for _, a := range attrs {
+		b.WriteString(" " + a.name + `="`)
+		escape(b, a.value, true)
+		b.WriteString(`"`)
+	}
+
+	text := strings.TrimSpace(n.text())
+	elems := n.elements()
+	sort.SliceStable(elems, func(i, j int) bool { return elems[i].name < elems[j].name })
+
+	switch {
+	case text == "" && len(elems) == 0:
+		b.WriteString("/>\n")
+	case len(elems) == 0:
+		b.WriteString(">")
+		escape(b, text, false)
+		b.WriteString("</" + n.name + ">\n")
+	default:
+		b.WriteString(">\n")
+		if text != "" {
+			b.WriteString(indent + "  ")
+			escape(b, text, false)
+			b.WriteString("\n")
+		}
+		for _, elem := range elems {
+			writeCanonical(b, elem, depth+1)
+		}
+		b.WriteString(indent + "</" + n.name + ">\n")
+	}
+}
+
+// Prune removes the attributes, text and child elements of doc that reference does not
+// have, pairing child elements by name and position among the siblings with that name.
+// Values that libvirt fills in, such as a generated UUID or device addresses, are dropped
+// unless the reference sets them, while values the reference sets but doc lacks or
+// changes are kept as differences.
+func Prune(doc, reference string) (string, error) {
+	tree, err := parse(doc)
+	if err != nil {
+		return "", err
+	}
+	refTree, err := parse(reference)
+	if err != nil {
+		return "", err
+	}
+
+	prune(tree.elements()[0], refTree.elements()[0])
+	return tree.String(), nil
+}
+
+func prune(n, ref *node) {
+	kept := n.attrs[:0]
+	for _, a := range n.attrs {
+		if _, ok := ref.attr(a.name); ok {
+			kept = append(kept, a)
+		}
+	}
+	n.attrs = kept
+
+	if strings.TrimSpace(ref.text()) == "" {
+		n.setText("")
+	}
+
+	refChildren := map[string][]*node{}
+	for _, child := range ref.elements() {
+		refChildren[child.name] = append(refChildren[child.name], child)
+	}
+
+	seen := map[string]int{}
+	for _, child := range n.elements() {
+		i := seen[child.name]
+		seen[child.name]++
+		if i >= len(refChildren[child.name]) {
+			child.remove()
+			continue
+		}
+		prune(child, refChildren[child.name][i])
+	}
+}
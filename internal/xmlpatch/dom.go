@@ -0,0 +1,291 @@
+package xmlpatch
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type nodeKind int
+
+const (
+	documentNode nodeKind = iota
+	elementNode
+	textNode
+	// otherNode holds comments, processing instructions and directives verbatim.
+	otherNode
+)
+
+type attribute struct {
+	name  string
+	value string
+}
+
+// node is a minimal XML tree. Names keep their namespace prefix as written, so vendor
+// elements such as qemu:commandline round-trip unchanged.
+type node struct {
+	kind     nodeKind
+	name     string
+	attrs    []attribute
+	children []*node
+	parent   *node
+	data     string
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// parse reads an XML document into a tree.
+func parse(doc string) (*node, error) {
+	root := &node{kind: documentNode}
+	if err := parseInto(root, doc); err != nil {
+		return nil, err
+	}
+
+	elements := 0
+	for _, child := range root.children {
+		if child.kind == elementNode {
+			elements++
+		}
+	}
+	if elements != 1 {
+		return nil, fmt.Errorf("expected exactly one root element, found %d", elements)
+	}
+	return root, nil
+}
+
+// parseFragment reads a sequence of elements and text, as used for patch values.
+func parseFragment(fragment string) ([]*node, error) {
+	holder := &node{kind: documentNode}
+	if err := parseInto(holder, fragment); err != nil {
+		return nil, err
+	}
+	return holder.children, nil
+}
+
+func parseInto(container *node, doc string) error {
+	decoder := xml.NewDecoder(strings.NewReader(doc))
+	current := container
+
+	for {
+		// RawToken leaves namespace prefixes unresolved, which keeps names as written
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("invalid XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			elem := &node{kind: elementNode, name: qualifiedName(t.Name)}
+			for _, a := range t.Attr {
+				elem.attrs = append(elem.attrs, attribute{name: qualifiedName(a.Name), value: a.Value})
+			}
+			current.append(elem)
+			current = elem
+		case xml.EndElement:
+			if current.kind != elementNode || current.name != qualifiedName(t.Name) {
+				return fmt.Errorf("invalid XML: unexpected end element </%s>", qualifiedName(t.Name))
+			}
+			current = current.parent
+		case xml.CharData:
+			current.append(&node{kind: textNode, data: string(t)})
+		case xml.Comment:
+			current.append(&node{kind: otherNode, data: "<!--" + string(t) + "-->"})
+		case xml.ProcInst:
+			current.append(&node{kind: otherNode, data: "<?" + t.Target + " " + string(t.Inst) + "?>"})
+		case xml.Directive:
+			current.append(&node{kind: otherNode, data: "<!" + string(t) + ">"})
+		}
+	}
+
+	if current != container {
+		return fmt.Errorf("invalid XML: element <%s> is not closed", current.name)
+	}
+	return nil
+}
+
+func (n *node) append(child *node) {
+	child.parent = n
+	n.children = append(n.children, child)
+}
+
+// insertAfter inserts child into n right after the existing child ref, or at the end if
+// ref is nil.
+func (n *node) insertAfter(ref, child *node) {
+	child.parent = n
+	if ref == nil {
+		n.children = append(n.children, child)
+		return
+	}
+	for i, c := range n.children {
+		if c == ref {
+			n.children = append(n.children[:i+1], append([]*node{child}, n.children[i+1:]...)...)
+			return
+		}
+	}
+	n.children = append(n.children, child)
+}
+
+// replaceWith replaces n in its parent by the given nodes.
+func (n *node) replaceWith(nodes ...*node) {
+	parent := n.parent
+	for i, c := range parent.children {
+		if c != n {
+			continue
+		}
+		rest := append([]*node{}, parent.children[i+1:]...)
+		parent.children = parent.children[:i]
+		for _, repl := range nodes {
+			parent.append(repl)
+		}
+		parent.children = append(parent.children, rest...)
+		n.parent = nil
+		return
+	}
+}
+
+func (n *node) remove() {
+	n.replaceWith()
+}
+
+func (n *node) clone() *node {
+	c := &node{kind: n.kind, name: n.name, data: n.data}
+	c.attrs = append(c.attrs, n.attrs...)
+	for _, child := range n.children {
+		c.append(child.clone())
+	}
+	return c
+}
+
+func (n *node) attr(name string) (string, bool) {
+	for _, a := range n.attrs {
+		if a.name == name {
+			return a.value, true
+		}
+	}
+	return "", false
+}
+
+func (n *node) setAttr(name, value string) {
+	for i, a := range n.attrs {
+		if a.name == name {
+			n.attrs[i].value = value
+			return
+		}
+	}
+	n.attrs = append(n.attrs, attribute{name: name, value: value})
+}
+
+func (n *node) removeAttr(name string) {
+	for i, a := range n.attrs {
+		if a.name == name {
+			n.attrs = append(n.attrs[:i], n.attrs[i+1:]...)
+			return
+		}
+	}
+}
+
+// text returns the concatenated text children of an element.
+func (n *node) text() string {
+	var b strings.Builder
+	for _, child := range n.children {
+		if child.kind == textNode {
+			b.WriteString(child.data)
+		}
+	}
+	return b.String()
+}
+
+// setText replaces the text children of an element, keeping child elements.
+func (n *node) setText(value string) {
+	kept := n.children[:0]
+	for _, child := range n.children {
+		if child.kind != textNode {
+			kept = append(kept, child)
+		}
+	}
+	n.children = kept
+	if value != "" {
+		n.append(&node{kind: textNode, data: value})
+	}
+}
+
+func (n *node) elements() []*node {
+	var elems []*node
+	for _, child := range n.children {
+		if child.kind == elementNode {
+			elems = append(elems, child)
+		}
+	}
+	return elems
+}
+
+// String serializes the tree back to XML.
+func (n *node) String() string {
+	var b bytes.Buffer
+	n.write(&b)
+	return b.String()
+}
+
+func (n *node) write(b *bytes.Buffer) {
+	switch n.kind {
+	case documentNode:
+		for _, child := range n.children {
+			child.write(b)
+		}
+	case textNode:
+		escape(b, n.data, false)
+	case otherNode:
+		b.WriteString(n.data)
+	case elementNode:
+		b.WriteString("<" + n.name)
+		for _, a := range n.attrs {
+			b.WriteString(" " + a.name + `="`)
+			escape(b, a.value, true)
+			b.WriteString(`"`)
+		}
+		if len(n.children) == 0 {
+			b.WriteString("/>")
+			return
+		}
+		b.WriteString(">")
+		for _, child := range n.children {
+			child.write(b)
+		}
+		b.WriteString("</" + n.name + ">")
+	}
+}
+
+// escape writes s with markup characters escaped. Unlike xml.EscapeText, newlines in
+// text are kept as is, so indentation survives a round trip.
+func escape(b *bytes.Buffer, s string, inAttr bool) {
+	for _, r := range s {
+		switch {
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>':
+			b.WriteString("&gt;")
+		case inAttr && r == '"':
+			b.WriteString("&quot;")
+		case inAttr && r == '\n':
+			b.WriteString("&#xA;")
+		case inAttr && r == '\t':
+			b.WriteString("&#x9;")
+		case r == '\r':
+			b.WriteString("&#xD;")
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
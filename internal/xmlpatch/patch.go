@@ -0,0 +1,257 @@
+// Package xmlpatch applies ordered add, replace and remove operations, addressed by a
+// subset of XPath, to XML documents. It lets users reach libvirt XML that the generated
+// schema does not model, such as vendor namespaces. It also brings documents into a
+// canonical form, so that XML read back from libvirt can be compared with the XML that
+// was defined.
+package xmlpatch
+
+import (
+	"fmt"
+)
+
+// Op is a patch operation.
+type Op string
+
+const (
+	// OpAdd appends the value as children of the matched elements, or sets the matched
+	// attribute or text.
+	OpAdd Op = "add"
+	// OpReplace replaces the matched elements with the value, or sets the matched
+	// attribute or text.
+	OpReplace Op = "replace"
+	// OpRemove removes the matched elements, attributes or text.
+	OpRemove Op = "remove"
+)
+
+// Patch is a single operation on a document.
+type Patch struct {
+	Op    Op
+	Path  string
+	Value string
+}
+
+// Validate checks the operation and path of the patch, and that its value is well-formed.
+func (p Patch) Validate() error {
+	path, err := ParsePath(p.Path)
+	if err != nil {
+		return err
+	}
+
+	switch p.Op {
+	case OpAdd, OpReplace:
+		if path.last().kind == elementStep {
+			if _, err := parseFragment(p.Value); err != nil {
+				return fmt.Errorf("value of %s %s: %w", p.Op, p.Path, err)
+			}
+		}
+	case OpRemove:
+	default:
+		return fmt.Errorf("unknown operation %q", p.Op)
+	}
+	return nil
+}
+
+// Apply applies the patches in order to doc and returns the patched document. Add and
+// replace fail if their path matches nothing; remove does not.
+func Apply(doc string, patches []Patch) (string, error) {
+	if len(patches) == 0 {
+		return doc, nil
+	}
+
+	tree, err := parse(doc)
+	if err != nil {
+		return "", err
+	}
+	for i, patch := range patches {
+		if err := apply(tree, patch); err != nil {
+			return "", fmt.Errorf("patch %d (%s %s): %w", i, patch.Op, patch.Path, err)
+		}
+	}
+	return tree.String(), nil
+}
+
+func apply(tree *node, patch Patch) error {
+	if err := patch.Validate(); err != nil {
+		return err
+	}
+	path, _ := ParsePath(patch.Path)
+	last := path.last()
+
+	if last.kind != elementStep {
+		elems := selectElements(tree, path.parent().steps)
+		matched := 0
+		for _, elem := range elems {
+			switch {
+			case last.kind == attributeStep && patch.Op == OpRemove:
+				elem.removeAttr(last.name)
+			case last.kind == attributeStep:
+				if _, ok := elem.attr(last.name); !ok && patch.Op == OpReplace {
+					continue
+				}
+				elem.setAttr(last.name, patch.Value)
+			case patch.Op == OpRemove:
+				elem.setText("")
+			default:
+				elem.setText(patch.Value)
+			}
+			matched++
+		}
+		if matched == 0 && patch.Op != OpRemove {
+			return fmt.Errorf("path matched no nodes")
+		}
+		return nil
+	}
+
+	elems := selectElements(tree, path.steps)
+	if len(elems) == 0 && patch.Op != OpRemove {
+		return fmt.Errorf("path matched no nodes")
+	}
+
+	for _, elem := range elems {
+		switch patch.Op {
+		case OpAdd:
+			fragment, _ := parseFragment(patch.Value)
+			for _, n := range fragment {
+				elem.append(n)
+			}
+		case OpReplace:
+			fragment, _ := parseFragment(patch.Value)
+			if elem.parent.kind == documentNode && countElements(fragment) != 1 {
+				return fmt.Errorf("the root element must be replaced by exactly one element")
+			}
+			elem.replaceWith(fragment...)
+		case OpRemove:
+			if elem.parent.kind == documentNode {
+				return fmt.Errorf("the root element cannot be removed")
+			}
+			elem.remove()
+		}
+	}
+	return nil
+}
+
+func countElements(nodes []*node) int {
+	count := 0
+	for _, n := range nodes {
+		if n.kind == elementNode {
+			count++
+		}
+	}
+	return count
+}
+
+// Restore undoes the effect of patches on a document read back from libvirt, so that
+// converting it to state does not report the patched parts as drift. Every node a patch
+// touches is reset to its value in intended, the unpatched document that was generated
+// from the configuration.
+func Restore(readback, intended string, patches []Patch) (string, error) {
+	if len(patches) == 0 {
+		return readback, nil
+	}
+
+	readTree, err := parse(readback)
+	if err != nil {
+		return "", err
+	}
+	intendedTree, err := parse(intended)
+	if err != nil {
+		return "", err
+	}
+
+	for _, patch := range patches {
+		paths, err := patchedPaths(patch)
+		if err != nil {
+			return "", err
+		}
+		for _, path := range paths {
+			restore(readTree, intendedTree, path)
+		}
+	}
+	return readTree.String(), nil
+}
+
+// patchedPaths returns the paths whose content a patch may change. For elements added
+// to a parent, these are the parent's children with the added names.
+func patchedPaths(patch Patch) ([]Path, error) {
+	if err := patch.Validate(); err != nil {
+		return nil, err
+	}
+	path, _ := ParsePath(patch.Path)
+	if patch.Op != OpAdd || path.last().kind != elementStep {
+		return []Path{path}, nil
+	}
+
+	fragment, _ := parseFragment(patch.Value)
+	var paths []Path
+	seen := map[string]bool{}
+	for _, n := range fragment {
+		switch {
+		case n.kind == elementNode && !seen[n.name]:
+			seen[n.name] = true
+			paths = append(paths, path.child(n.name))
+		case n.kind == textNode && !seen["text()"]:
+			seen["text()"] = true
+			paths = append(paths, Path{raw: path.raw + "/text()", steps: append(append([]step{}, path.steps...), step{kind: textStep})})
+		}
+	}
+	return paths, nil
+}
+
+// restore makes the nodes matched by path in readTree match those in intendedTree,
+// pairing the parents matched in both documents by position.
+func restore(readTree, intendedTree *node, path Path) {
+	last := path.last()
+	readParents := selectElements(readTree, path.parent().steps)
+	intendedParents := selectElements(intendedTree, path.parent().steps)
+
+	for i, parent := range readParents {
+		var source *node
+		if i < len(intendedParents) {
+			source = intendedParents[i]
+		}
+
+		switch last.kind {
+		case attributeStep:
+			if value, ok := attrOf(source, last.name); ok {
+				parent.setAttr(last.name, value)
+			} else {
+				parent.removeAttr(last.name)
+			}
+		case textStep:
+			if source == nil {
+				parent.setText("")
+			} else {
+				parent.setText(source.text())
+			}
+		case elementStep:
+			current := selectChildren(parent, last)
+			var wanted []*node
+			if source != nil {
+				wanted = selectChildren(source, last)
+			}
+
+			var anchor *node
+			for j, elem := range current {
+				if j < len(wanted) {
+					replacement := wanted[j].clone()
+					elem.replaceWith(replacement)
+					anchor = replacement
+				} else {
+					elem.remove()
+				}
+			}
+			for j := len(current); j < len(wanted); j++ {
+				replacement := wanted[j].clone()
+				parent.insertAfter(anchor, replacement)
+				anchor = replacement
+			}
+		}
+	}
+}
+
+func attrOf(n *node, name string) (string, bool) {
+	if n == nil {
+		return "", false
+	}
+	return n.attr(name)
+}
@@ -0,0 +1,293 @@
+package xmlpatch
+
+import (
+	"strings"
+	"testing"
+)
+
+const testDomain = `<domain type="kvm" xmlns:qemu="http://libvirt.org/schemas/domain/qemu/1.0">
+  <name>test</name>
+  <memory unit="KiB">524288</memory>
+  <devices>
+    <disk type="file" device="disk"><target dev="vda" bus="virtio"/></disk>
+    <disk type="file" device="cdrom"><target dev="sda" bus="sata"/><readonly/></disk>
+    <interface type="network"><source network="default"/></interface>
+  </devices>
+</domain>`
+
+func TestParsePath(t *testing.T) {
+	t.Parallel()
+
+	valid := []string{
+		"/domain",
+		"/domain/devices/disk[2]/target/@bus",
+		"/domain/devices/disk[@device='cdrom'][last()]",
+		"/domain/devices/*[@type=\"network\"]/source",
+		"/domain/qemu:commandline/qemu:arg[@value='a/b']",
+		"/domain/name/text()",
+		"/domain/devices/interface[source='x']",
+		"/domain/name[text()='test']",
+	}
+	for _, path := range valid {
+		if _, err := ParsePath(path); err != nil {
+			t.Errorf("ParsePath(%q) failed: %v", path, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"domain/name",
+		"/domain//disk",
+		"/@type",
+		"/domain/@type/name",
+		"/domain/devices/disk[0]",
+		"/domain/devices/disk[@device=cdrom]",
+		"/domain/devices/disk[position() > 1]",
+		"/domain/devices/disk[@device='cdrom'",
+		"/domain/name/@type[1]",
+	}
+	for _, path := range invalid {
+		if _, err := ParsePath(path); err == nil {
+			t.Errorf("expected ParsePath(%q) to fail", path)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		patches  []Patch
+		contains []string
+		excludes []string
+	}{
+		{
+			name: "add element to root",
+			patches: []Patch{{
+				Op:    OpAdd,
+				Path:  "/domain",
+				Value: `<qemu:commandline><qemu:arg value="-no-hpet"/></qemu:commandline>`,
+			}},
+			contains: []string{`<qemu:commandline><qemu:arg value="-no-hpet"/></qemu:commandline></domain>`},
+		},
+		{
+			name:     "set attribute by predicate",
+			patches:  []Patch{{Op: OpAdd, Path: "/domain/devices/disk[@device='cdrom']/target/@tray", Value: "open"}},
+			contains: []string{`<target dev="sda" bus="sata" tray="open"/>`},
+		},
+		{
+			name:     "replace attribute",
+			patches:  []Patch{{Op: OpReplace, Path: "/domain/devices/disk[1]/target/@bus", Value: "scsi"}},
+			contains: []string{`<target dev="vda" bus="scsi"/>`},
+		},
+		{
+			name:     "replace text",
+			patches:  []Patch{{Op: OpReplace, Path: "/domain/memory/text()", Value: "1048576"}},
+			contains: []string{`<memory unit="KiB">1048576</memory>`},
+		},
+		{
+			name: "replace element by child",
+			patches: []Patch{{
+				Op:    OpReplace,
+				Path:  "/domain/devices/interface[@type='network']",
+				Value: `<interface type="bridge"><source bridge="br0"/></interface>`,
+			}},
+			contains: []string{`<interface type="bridge"><source bridge="br0"/></interface>`},
+			excludes: []string{`network="default"`},
+		},
+		{
+			name: "remove element and attribute in order",
+			patches: []Patch{
+				{Op: OpRemove, Path: "/domain/devices/disk[2]/readonly"},
+				{Op: OpRemove, Path: "/domain/devices/disk[last()]/@type"},
+				{Op: OpRemove, Path: "/domain/devices/hostdev"},
+			},
+			contains: []string{`<disk device="cdrom"><target dev="sda" bus="sata"/></disk>`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(testDomain, tt.patches)
+			if err != nil {
+				t.Fatalf("Apply failed: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("expected %q in\n%s", want, got)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(got, unwanted) {
+					t.Errorf("did not expect %q in\n%s", unwanted, got)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, patch := range []Patch{
+		{Op: OpReplace, Path: "/domain/devices/hostdev", Value: "<hostdev/>"},
+		{Op: OpAdd, Path: "/domain/os", Value: "<type>hvm</type>"},
+		{Op: OpReplace, Path: "/domain/@missing", Value: "x"},
+		{Op: OpAdd, Path: "/domain", Value: "<unclosed>"},
+		{Op: OpRemove, Path: "/domain"},
+		{Op: OpRemove, Path: "/domain/devices/interface[source/@network]"},
+		{Op: "move", Path: "/domain"},
+	} {
+		if _, err := Apply(testDomain, []Patch{patch}); err == nil {
+			t.Errorf("expected %s %s to fail", patch.Op, patch.Path)
+		}
+	}
+}
+
+func TestApplyRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	got, err := Apply(testDomain, []Patch{{Op: OpRemove, Path: "/domain/devices/hostdev"}})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if got != testDomain {
+		t.Fatalf("expected an unchanged document, got\n%s", got)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	t.Parallel()
+
+	patches := []Patch{
+		{Op: OpAdd, Path: "/domain", Value: `<qemu:commandline><qemu:arg value="-no-hpet"/></qemu:commandline>`},
+		{Op: OpReplace, Path: "/domain/memory/text()", Value: "1048576"},
+		{Op: OpRemove, Path: "/domain/devices/disk[@device='cdrom']/readonly"},
+		{Op: OpAdd, Path: "/domain/devices/disk[1]/target/@tray", Value: "closed"},
+	}
+	patched, err := Apply(testDomain, patches)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	// libvirt adds defaults of its own next to the patched nodes, which must survive
+	readback := strings.Replace(patched, `<name>test</name>`, `<name>test</name><uuid>1234</uuid>`, 1)
+
+	restored, err := Restore(readback, testDomain, patches)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	for _, want := range []string{
+		`<uuid>1234</uuid>`,
+		`<memory unit="KiB">524288</memory>`,
+		`<readonly/>`,
+		`<target dev="vda" bus="virtio"/>`,
+	} {
+		if !strings.Contains(restored, want) {
+			t.Errorf("expected %q in\n%s", want, restored)
+		}
+	}
+	if strings.Contains(restored, "qemu:commandline") {
+		t.Errorf("expected the added element to be dropped:\n%s", restored)
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	t.Parallel()
+
+	a := `<?xml version="1.0"?>
+<domain type="kvm"><!-- test -->
+  <name>test</name>
+  <devices><interface type="network"/><disk device="disk" type="file"/><disk type="file" device="cdrom"/></devices>
+</domain>`
+	b := `<domain type='kvm'>
+    <devices>
+        <disk type="file" device="disk"></disk>
+        <interface type="network"></interface>
+        <disk device="cdrom" type="file"/>
+    </devices>
+    <name>
+        test
+    </name>
+</domain>`
+
+	canonicalA, err := Canonicalize(a)
+	if err != nil {
+		t.Fatalf("Canonicalize failed: %v", err)
+	}
+	canonicalB, err := Canonicalize(b)
+	if err != nil {
+		t.Fatalf("Canonicalize failed: %v", err)
+	}
+	if canonicalA != canonicalB {
+		t.Fatalf("expected equal canonical forms, got\n%s\nand\n%s", canonicalA, canonicalB)
+	}
+
+	want := `<domain type="kvm">
+  <devices>
+    <disk device="disk" type="file"/>
+    <disk device="cdrom" type="file"/>
+    <interface type="network"/>
+  </devices>
+  <name>test</name>
+</domain>
+`
+	if canonicalA != want {
+		t.Fatalf("unexpected canonical form:\n%s", canonicalA)
+	}
+
+	swapped := strings.Replace(a, `device="disk" type="file"/><disk type="file" device="cdrom"/>`, `type="file" device="cdrom"/><disk device="disk" type="file"/>`, 1)
+	canonicalSwapped, err := Canonicalize(swapped)
+	if err != nil {
+		t.Fatalf("Canonicalize failed: %v", err)
+	}
+	if canonicalSwapped == canonicalA {
+		t.Fatal("expected the order of disks to matter")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	t.Parallel()
+
+	reference := `<domain type="kvm">
+  <name>test</name>
+  <memory>524288</memory>
+  <devices>
+    <disk type="file" device="disk"><target dev="vda"/></disk>
+  </devices>
+</domain>`
+	actual := `<domain type="kvm" id="1">
+  <name>test</name>
+  <uuid>1234</uuid>
+  <memory unit="KiB">1048576</memory>
+  <devices>
+    <disk type="file" device="disk"><target dev="vda" bus="virtio"/><address type="pci" slot="0x04"/></disk>
+    <controller type="usb"/>
+  </devices>
+</domain>`
+
+	pruned, err := Prune(actual, reference)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	canonical, err := Canonicalize(pruned)
+	if err != nil {
+		t.Fatalf("Canonicalize failed: %v", err)
+	}
+
+	want := `<domain type="kvm">
+  <devices>
+    <disk device="disk" type="file">
+      <target dev="vda"/>
+    </disk>
+  </devices>
+  <memory>1048576</memory>
+  <name>test</name>
+</domain>
+`
+	if canonical != want {
+		t.Fatalf("unexpected pruned document:\n%s", canonical)
+	}
+}
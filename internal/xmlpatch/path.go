@@ -0,0 +1,311 @@
+package xmlpatch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type stepKind int
+
+const (
+	elementStep stepKind = iota
+	attributeStep
+	textStep
+)
+
+type predicateKind int
+
+const (
+	positionPredicate predicateKind = iota
+	lastPredicate
+	attributeExistsPredicate
+	attributeEqualsPredicate
+	childEqualsPredicate
+	textEqualsPredicate
+)
+
+type predicate struct {
+	kind     predicateKind
+	position int
+	name     string
+	value    string
+}
+
+type step struct {
+	kind       stepKind
+	name       string
+	predicates []predicate
+}
+
+// Path is a parsed location path. The supported subset of XPath is absolute paths of
+// child steps, e.g. /domain/devices/disk[@device='cdrom'][1]/target/@bus:
+//
+//   - element steps by name (with namespace prefix as written, e.g. qemu:commandline) or *
+//   - a final @name step selecting an attribute, or text() selecting the element text
+//   - predicates [n], [last()], [@name], [@name='value'], [child='value'] and
+//     [text()='value'], applied in order
+type Path struct {
+	raw   string
+	steps []step
+}
+
+func (p Path) String() string {
+	return p.raw
+}
+
+// last returns the final step, which may select an attribute or text.
+func (p Path) last() step {
+	return p.steps[len(p.steps)-1]
+}
+
+// parent returns the path without its final step.
+func (p Path) parent() Path {
+	return Path{raw: p.raw, steps: p.steps[:len(p.steps)-1]}
+}
+
+// child returns the path extended by an element step without predicates.
+func (p Path) child(name string) Path {
+	steps := append(append([]step{}, p.steps...), step{kind: elementStep, name: name})
+	return Path{raw: p.raw + "/" + name, steps: steps}
+}
+
+// ParsePath parses a location path in the supported XPath subset.
+func ParsePath(raw string) (Path, error) {
+	if !strings.HasPrefix(raw, "/") {
+		return Path{}, fmt.Errorf("path %q must be absolute", raw)
+	}
+	parts, err := splitSteps(raw[1:])
+	if err != nil {
+		return Path{}, fmt.Errorf("path %q: %w", raw, err)
+	}
+
+	path := Path{raw: raw}
+	for i, part := range parts {
+		s, err := parseStep(part)
+		if err != nil {
+			return Path{}, fmt.Errorf("path %q: %w", raw, err)
+		}
+		if s.kind != elementStep && i != len(parts)-1 {
+			return Path{}, fmt.Errorf("path %q: %s must be the last step", raw, part)
+		}
+		if s.kind != elementStep && i == 0 {
+			return Path{}, fmt.Errorf("path %q: the first step must select the root element", raw)
+		}
+		path.steps = append(path.steps, s)
+	}
+	return path, nil
+}
+
+// splitSteps splits a path on slashes outside predicates and quotes.
+func splitSteps(raw string) ([]string, error) {
+	var parts []string
+	depth := 0
+	var quote rune
+	start := 0
+
+	for i, r := range raw {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '[':
+			depth++
+		case r == ']':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced ]")
+			}
+		case r == '/' && depth == 0:
+			parts = append(parts, raw[start:i])
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated string literal")
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced [")
+	}
+	parts = append(parts, raw[start:])
+
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("empty step (the descendant axis // is not supported)")
+		}
+	}
+	return parts, nil
+}
+
+func parseStep(part string) (step, error) {
+	name := part
+	rest := ""
+	if i := strings.IndexByte(part, '['); i >= 0 {
+		name, rest = part[:i], part[i:]
+	}
+
+	var s step
+	switch {
+	case name == "text()":
+		s = step{kind: textStep}
+	case strings.HasPrefix(name, "@"):
+		s = step{kind: attributeStep, name: name[1:]}
+		if !validName(s.name) {
+			return step{}, fmt.Errorf("invalid attribute name %q", s.name)
+		}
+	default:
+		s = step{kind: elementStep, name: name}
+		if name != "*" && !validName(name) {
+			return step{}, fmt.Errorf("invalid element name %q", name)
+		}
+	}
+
+	for rest != "" {
+		end := predicateEnd(rest)
+		if !strings.HasPrefix(rest, "[") || end < 0 {
+			return step{}, fmt.Errorf("invalid predicate in %q", part)
+		}
+		pred, err := parsePredicate(strings.TrimSpace(rest[1:end]))
+		if err != nil {
+			return step{}, err
+		}
+		s.predicates = append(s.predicates, pred)
+		rest = rest[end+1:]
+	}
+
+	if s.kind != elementStep && len(s.predicates) > 0 {
+		return step{}, fmt.Errorf("predicates are only supported on element steps")
+	}
+	return s, nil
+}
+
+// predicateEnd returns the index of the ] closing the predicate at the start of s.
+func predicateEnd(s string) int {
+	var quote rune
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+func parsePredicate(expr string) (predicate, error) {
+	if expr == "last()" {
+		return predicate{kind: lastPredicate}, nil
+	}
+	if n, err := strconv.Atoi(expr); err == nil {
+		if n < 1 {
+			return predicate{}, fmt.Errorf("position %d must be at least 1", n)
+		}
+		return predicate{kind: positionPredicate, position: n}, nil
+	}
+
+	lhs, rhs, hasValue := strings.Cut(expr, "=")
+	lhs = strings.TrimSpace(lhs)
+
+	var value string
+	if hasValue {
+		rhs = strings.TrimSpace(rhs)
+		if len(rhs) < 2 || (rhs[0] != '\'' && rhs[0] != '"') || rhs[len(rhs)-1] != rhs[0] {
+			return predicate{}, fmt.Errorf("predicate [%s]: value must be a quoted string", expr)
+		}
+		value = rhs[1 : len(rhs)-1]
+	}
+
+	switch {
+	case strings.HasPrefix(lhs, "@") && validName(lhs[1:]):
+		if !hasValue {
+			return predicate{kind: attributeExistsPredicate, name: lhs[1:]}, nil
+		}
+		return predicate{kind: attributeEqualsPredicate, name: lhs[1:], value: value}, nil
+	case lhs == "text()" && hasValue:
+		return predicate{kind: textEqualsPredicate, value: value}, nil
+	case validName(lhs) && hasValue:
+		return predicate{kind: childEqualsPredicate, name: lhs, value: value}, nil
+	}
+	return predicate{}, fmt.Errorf("unsupported predicate [%s]", expr)
+}
+
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+		case i > 0 && (r >= '0' && r <= '9' || r == '-' || r == '.' || r == ':'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// selectElements returns the elements matched by a sequence of element steps.
+func selectElements(doc *node, steps []step) []*node {
+	current := []*node{doc}
+	for _, s := range steps {
+		var next []*node
+		for _, parent := range current {
+			next = append(next, selectChildren(parent, s)...)
+		}
+		current = next
+	}
+	return current
+}
+
+// selectChildren returns the child elements of parent matched by an element step.
+func selectChildren(parent *node, s step) []*node {
+	var matched []*node
+	for _, child := range parent.elements() {
+		if s.name == "*" || child.name == s.name {
+			matched = append(matched, child)
+		}
+	}
+
+	for _, pred := range s.predicates {
+		var filtered []*node
+		for i, elem := range matched {
+			if pred.matches(elem, i, len(matched)) {
+				filtered = append(filtered, elem)
+			}
+		}
+		matched = filtered
+	}
+	return matched
+}
+
+func (p predicate) matches(elem *node, index, count int) bool {
+	switch p.kind {
+	case positionPredicate:
+		return index+1 == p.position
+	case lastPredicate:
+		return index+1 == count
+	case attributeExistsPredicate:
+		_, ok := elem.attr(p.name)
+		return ok
+	case attributeEqualsPredicate:
+		value, ok := elem.attr(p.name)
+		return ok && value == p.value
+	case textEqualsPredicate:
+		return elem.text() == p.value
+	case childEqualsPredicate:
+		for _, child := range elem.elements() {
+			if child.name == p.name && child.text() == p.value {
+				return true
+			}
+		}
+	}
+	return false
+}